@@ -1,14 +1,24 @@
 package main
 
 import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/fiscal"
 	"beauty-salon/internal/handlers"
 	"beauty-salon/internal/middleware"
 	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/notify"
+	"beauty-salon/internal/payments"
 	"beauty-salon/internal/repository"
 	"beauty-salon/internal/service"
+	"beauty-salon/internal/sms"
+	"beauty-salon/internal/telegram"
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,49 +39,366 @@ func main() {
 		log.Fatal(err)
 	}
 
-	db.AutoMigrate(&models.User{}, &models.Service{}, &models.Staff{}, &models.Booking{})
+	db.AutoMigrate(&models.User{}, &models.Service{}, &models.Staff{}, &models.Booking{}, &models.UserIdentity{}, &models.APIKey{}, &models.DataExport{},
+		&models.ClientProfile{}, &models.ClientNote{}, &models.Notification{}, &models.NotificationPreference{}, &models.Consent{},
+		&models.OutboxEvent{}, &models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.CalendarFeed{},
+		&models.PushDevice{}, &models.Payment{}, &models.PaymentRefund{}, &models.DepositRule{},
+		&models.Receipt{}, &models.ReceiptItem{}, &models.ReceiptTender{}, &models.TaxRate{},
+		&models.GiftCard{}, &models.GiftCardEntry{}, &models.DiscountRule{}, &models.DiscountRedemption{},
+		&models.LoyaltyEntry{}, &models.Package{}, &models.PackageItem{}, &models.ClientPackage{}, &models.ClientPackageSession{},
+		&models.MembershipPlan{}, &models.MembershipBenefit{}, &models.Subscription{},
+		&models.CommissionScheme{}, &models.CommissionTier{})
+	// Старые цены и суммы чеков велись в валюте салона.
+	if err := repository.MigrateMoney(db, money.DefaultCurrency); err != nil {
+		log.Fatal(err)
+	}
 
 	// Redis
 	rdb := redis.NewClient(&redis.Options{Addr: os.Getenv("REDIS_HOST")})
 
+	// JWT
+	keys, err := auth.LoadKeysFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	tokens, err := auth.NewTokenManager(os.Getenv("JWT_ISSUER"), time.Hour*72, keys...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Dependency Injection
 	repo := repository.NewPostgresRepository(db)
-	svc := service.NewSalonService(repo)
+	svc := service.NewSalonService(repo, tokens)
 	h := handlers.NewHandler(svc)
 
+	oidcProviders, err := auth.LoadOIDCProvidersFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var oidcClients []service.OIDCClient
+	for _, p := range oidcProviders {
+		oidcClients = append(oidcClients, p)
+	}
+	oh := handlers.NewOIDCHandler(service.NewOIDCService(repo, rdb, tokens, oidcClients...))
+
+	keySvc := service.NewAPIKeyService(repo)
+	akh := handlers.NewAPIKeyHandler(keySvc)
+
+	otpSecret := os.Getenv("OTP_SECRET")
+	if otpSecret == "" {
+		log.Fatal("OTP_SECRET is not set")
+	}
+	ph := handlers.NewPhoneAuthHandler(service.NewPhoneAuthService(repo, rdb, sms.LogSender{}, tokens, []byte(otpSecret)))
+
+	exportSvc := service.NewExportService(repo)
+	eh := handlers.NewExportHandler(exportSvc)
+
+	clientSvc := service.NewClientCardService(repo)
+	clientSvc.RegisterExportSections(exportSvc)
+	ch := handlers.NewClientCardHandler(clientSvc)
+
+	reminderOffsets, err := service.ParseReminderOffsets(os.Getenv("REMINDER_OFFSETS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Push пока пишется в лог: настоящая доставка подключается реализацией notify.PushSender.
+	pushSvc := service.NewPushDeviceService(repo)
+	pushh := handlers.NewPushHandler(pushSvc)
+	channels := []notify.Channel{notify.SMSChannel{Sender: sms.LogSender{}}, notify.EmailChannel{Sender: notify.LogEmailSender{}},
+		notify.PushChannel{Sender: notify.LogPushSender{}, Devices: pushSvc}}
+
+	// Telegram-бот включается, если задан токен. TELEGRAM_API_URL позволяет
+	// направить бота на локальную заглушку Bot API.
+	var tgh *handlers.TelegramHandler
+	var tgBot *telegram.Bot
+	if token := os.Getenv("TELEGRAM_BOT_TOKEN"); token != "" {
+		tg := telegram.NewClient(os.Getenv("TELEGRAM_API_URL"), token, nil)
+		tgLinks := service.NewTelegramLinkService(repo, rdb, os.Getenv("TELEGRAM_BOT_USERNAME"))
+		channels = append(channels, notify.TelegramChannel{Sender: tg, Chats: tgLinks})
+		tgBot = telegram.NewBot(tg, svc, tgLinks) // запускается, когда готовы платежи
+		tgh = handlers.NewTelegramHandler(tgLinks)
+	}
+
+	notifySvc := service.NewNotificationService(repo, reminderOffsets, channels...)
+	notifySvc.RegisterExportSections(exportSvc)
+	// Ссылки на ICS-подписки строятся от публичного адреса API.
+	calendarSvc := service.NewCalendarService(repo, strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")+"/api/v1/calendar/")
+	notifySvc.SetAttachments(calendarSvc)
+	calh := handlers.NewCalendarHandler(calendarSvc)
+	svc.SetNotifier(notifySvc)
+	go notifySvc.Run(context.Background(), 30*time.Second)
+	nh := handlers.NewNotificationHandler(notifySvc)
+
+	// Доменные события: outbox -> внутренняя шина, вебхуки и живая лента.
+	bus := events.NewBus(10000)
+	webhookSvc := service.NewWebhookService(repo, nil)
+	liveFeed := service.NewLiveFeed(rdb)
+	go liveFeed.Run(context.Background())
+	lfh := handlers.NewLiveFeedHandler(liveFeed)
+	go service.NewOutboxRelay(repo, bus, webhookSvc, liveFeed).Run(context.Background(), 2*time.Second)
+	go webhookSvc.Run(context.Background(), 5*time.Second)
+	wh := handlers.NewWebhookHandler(webhookSvc)
+
+	// Платежи. Настоящий эквайринг подключается реализацией payments.Provider;
+	// пока используется фейковый провайдер с подписью уведомлений общим секретом.
+	// Без секрета подпись может посчитать кто угодно, поэтому он обязателен.
+	webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if webhookSecret == "" {
+		log.Fatal("PAYMENT_WEBHOOK_SECRET is not set")
+	}
+	paymentProvider := payments.NewFakeProvider(webhookSecret)
+	paymentSvc := service.NewPaymentService(repo, paymentProvider, svc)
+	paymentSvc.RegisterExportSections(exportSvc)
+	paymentSvc.Subscribe(bus)
+	payh := handlers.NewPaymentHandler(paymentSvc)
+	if tgBot != nil {
+		tgBot.SetPayments(paymentSvc)
+		go tgBot.Run(context.Background())
+	}
+
+	// Предоплата: запись ждёт оплату DEPOSIT_PAYMENT_TTL, поздняя отмена
+	// (ближе DEPOSIT_FREE_CANCELLATION до визита) оставляет предоплату салону.
+	depositTTL := durationEnv("DEPOSIT_PAYMENT_TTL", service.DefaultDepositPaymentTTL)
+	freeCancellation := durationEnv("DEPOSIT_FREE_CANCELLATION", service.DefaultFreeCancellation)
+	depositSvc := service.NewDepositService(repo, svc, depositTTL, freeCancellation)
+	svc.SetDeposits(depositSvc)
+	paymentSvc.SetCancellationPolicy(depositSvc)
+	go depositSvc.Run(context.Background(), time.Minute)
+	dh := handlers.NewDepositHandler(depositSvc)
+
+	packageSvc := service.NewPackageService(repo)
+	svc.SetPackages(packageSvc)
+	pkh := handlers.NewPackageHandler(packageSvc)
+
+	discountSvc := service.NewDiscountService(repo)
+	svc.SetDiscounts(discountSvc)
+	dch := handlers.NewDiscountHandler(discountSvc)
+
+	// Абонементы продлеваются счётом у того же провайдера; неоплаченный
+	// действует ещё MEMBERSHIP_GRACE_PERIOD после конца периода.
+	membershipSvc := service.NewMembershipService(repo, paymentProvider, durationEnv("MEMBERSHIP_GRACE_PERIOD", service.DefaultMembershipGrace))
+	svc.SetMemberships(membershipSvc)
+	paymentSvc.SetMemberships(membershipSvc)
+	go membershipSvc.Run(context.Background(), time.Hour)
+	mh := handlers.NewMembershipHandler(membershipSvc)
+
+	// НДС: TAX_MODE=inclusive — цены с НДС, exclusive — НДС сверху.
+	taxMode, err := service.ParseTaxMode(os.Getenv("TAX_MODE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	taxSvc := service.NewTaxService(repo, taxMode)
+	th := handlers.NewTaxHandler(taxSvc)
+
+	// Выплаты мастерам считаются по строкам чеков, засчитанным мастеру.
+	prh := handlers.NewPayrollHandler(service.NewPayrollService(repo))
+
+	// Баллы лояльности сгорают через LOYALTY_POINTS_EXPIRY после начисления.
+	loyaltyCfg := service.DefaultLoyaltyConfig
+	loyaltyCfg.Expiry = durationEnv("LOYALTY_POINTS_EXPIRY", loyaltyCfg.Expiry)
+	loyaltySvc := service.NewLoyaltyService(repo, loyaltyCfg)
+	loyaltySvc.Subscribe(bus)
+	go loyaltySvc.Run(context.Background(), time.Hour)
+	lh := handlers.NewLoyaltyHandler(loyaltySvc)
+
+	giftSvc := service.NewGiftCardService(repo, durationEnv("GIFT_CARD_VALIDITY", service.DefaultGiftCardValidity))
+	gch := handlers.NewGiftCardHandler(giftSvc)
+
+	checkoutSvc := service.NewCheckoutService(repo)
+	checkoutSvc.SetTaxes(taxSvc)
+	checkoutSvc.SetGiftCards(giftSvc)
+	checkoutSvc.SetDiscounts(discountSvc)
+	checkoutSvc.SetLoyalty(loyaltySvc)
+	checkoutSvc.SetPackages(packageSvc)
+	checkoutSvc.SetMemberships(membershipSvc)
+	checkoutSvc.SetRefunds(paymentSvc)
+	checkoutSvc.RegisterExportSections(exportSvc)
+	coh := handlers.NewCheckoutHandler(checkoutSvc)
+
+	// Фискализация чеков. Настоящий регистратор подключается реализацией
+	// service.FiscalRegistrar; пока чеки пишутся файлами в FISCAL_DIR.
+	fiscalDir := os.Getenv("FISCAL_DIR")
+	if fiscalDir == "" {
+		fiscalDir = "fiscal"
+	}
+	registrar, err := fiscal.NewFileRegistrar(fiscalDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fiscalSvc := service.NewFiscalService(repo, registrar)
+	fiscalSvc.Subscribe(bus)
+	fh := handlers.NewFiscalHandler(fiscalSvc)
+
+	// Выгрузки собираются, когда все модули уже добавили свои разделы.
+	go exportSvc.Run(context.Background(), 5*time.Second)
+
 	// Router
 	r := gin.Default()
+	r.Use(middleware.Locale(nil))                        // Язык ответа по Accept-Language
 	r.Use(middleware.RateLimiter(rdb, 100, time.Minute)) // Анти-спам: 100 req/min
 
+	r.GET("/.well-known/jwks.json", handlers.JWKS(tokens))
+
 	api := r.Group("/api/v1")
 	{
 		api.POST("/register", h.Register)
 		api.POST("/login", h.Login)
+		api.GET("/auth/oidc/:provider/login", oh.Login)
+		api.GET("/auth/oidc/:provider/callback", oh.Callback)
+		api.POST("/auth/phone/code", ph.RequestCode)
+		api.POST("/auth/phone/verify", ph.VerifyCode)
+		api.GET("/exports/download/:token", eh.Download)
+		api.GET("/calendar/:token", calh.Feed)
+		api.POST("/payments/webhook", payh.Webhook)
+		api.GET("/gift-cards/:code", gch.Balance)
 
 		auth := api.Group("/")
-		auth.Use(middleware.AuthMiddleware())
+		auth.Use(middleware.AuthMiddleware(tokens, nil), middleware.Locale(svc))
 		{
 			auth.POST("/logout", h.Logout)
+			auth.POST("/auth/oidc/:provider/link", oh.Link)
 			auth.GET("/users/me", h.GetMe)
+			auth.PATCH("/users/me", h.PatchMe)
+			auth.POST("/users/me/password", h.ChangePassword)
+			auth.DELETE("/users/me", h.DeleteMe)
+			auth.GET("/users/me/export", eh.RequestMine)
+			auth.GET("/users/me/exports/:id", eh.GetMine)
+			auth.GET("/users/me/visits", ch.GetMyVisits)
+			auth.GET("/users/me/notifications", nh.List)
+			auth.GET("/users/me/notification-preferences", nh.GetPreferences)
+			auth.PUT("/users/me/notification-preferences", nh.SavePreferences)
+			auth.PUT("/users/me/push-device", pushh.Register)
+			auth.DELETE("/users/me/push-device", pushh.Unregister)
+			auth.POST("/users/me/calendar", calh.CreateMine)
+			auth.DELETE("/users/me/calendar", calh.DeleteMine)
+			auth.GET("/bookings/:id/ics", calh.BookingICS)
+			auth.POST("/bookings/:id/payments", payh.Create)
+			auth.GET("/users/me/payments", payh.ListMine)
+			auth.POST("/quote", dch.Quote)
+			auth.GET("/users/me/loyalty", lh.Mine)
+			auth.GET("/users/me/packages", pkh.Mine)
+			auth.GET("/membership-plans", mh.ListPlans)
+			auth.GET("/users/me/membership", mh.Mine)
+			auth.POST("/users/me/membership", mh.Subscribe)
+			auth.POST("/users/me/membership/payments", mh.Pay)
+			auth.DELETE("/users/me/membership", mh.Cancel)
+			if tgh != nil {
+				auth.POST("/users/me/telegram/link", tgh.Link)
+				auth.DELETE("/users/me/telegram", tgh.Unlink)
+			}
 			auth.GET("/users", h.GetAllUsers)
 			auth.DELETE("/users/:id", h.DeleteUser)
 
 			auth.POST("/services", h.AddService)
-			auth.GET("/services", h.GetServices)
-			auth.GET("/services/:id", h.GetServiceByID)
 			auth.DELETE("/services/:id", h.DeleteService)
 
 			auth.POST("/staff", h.AddStaff)
-			auth.GET("/staff", h.GetStaff)
-			auth.GET("/staff/:id", h.GetStaffByID)
 			auth.DELETE("/staff/:id", h.DeleteStaff)
+		}
+
+		// Маршруты, доступные и по API-ключу (заголовок X-API-Key) с нужной областью.
+		keyed := api.Group("/")
+		keyed.Use(middleware.AuthMiddleware(tokens, keySvc), middleware.Locale(svc), middleware.RateLimiter(rdb, 100, time.Minute))
+		{
+			keyed.GET("/services", middleware.RequireScope(models.ScopeReadCatalog), h.GetServices)
+			keyed.GET("/services/:id", middleware.RequireScope(models.ScopeReadCatalog), h.GetServiceByID)
+			keyed.GET("/staff", middleware.RequireScope(models.ScopeReadCatalog), h.GetStaff)
+			keyed.GET("/staff/:id", middleware.RequireScope(models.ScopeReadCatalog), h.GetStaffByID)
+			keyed.GET("/slots", middleware.RequireScope(models.ScopeReadCatalog), h.GetSlots)
 
-			auth.POST("/bookings", h.CreateBooking)
-			auth.GET("/bookings", h.GetBookings)
-			auth.GET("/bookings/:id", h.GetBookingByID)
-			auth.PATCH("/bookings/:id", h.PatchBooking)
-			auth.DELETE("/bookings/:id", h.DeleteBooking)
+			keyed.POST("/bookings", middleware.RequireScope(models.ScopeWriteBookings), h.CreateBooking)
+			keyed.GET("/bookings", middleware.RequireScope(models.ScopeReadBookings), h.GetBookings)
+			keyed.GET("/bookings/:id", middleware.RequireScope(models.ScopeReadBookings), h.GetBookingByID)
+			keyed.PATCH("/bookings/:id", middleware.RequireScope(models.ScopeWriteBookings), h.PatchBooking)
+			keyed.DELETE("/bookings/:id", middleware.RequireScope(models.ScopeWriteBookings), h.DeleteBooking)
+		}
+
+		// Карточки клиентов и касса — только для мастеров и администраторов.
+		staff := api.Group("/")
+		staff.Use(middleware.AuthMiddleware(tokens, nil), middleware.Locale(svc), middleware.RequireRole(svc, "staff", "admin"))
+		{
+			staff.GET("/clients/:id/card", ch.GetCard)
+			staff.PUT("/clients/:id/profile", ch.SaveProfile)
+			staff.POST("/clients/:id/notes", ch.AddNote)
+			staff.DELETE("/clients/:id/notes/:noteId", ch.DeleteNote)
+			staff.GET("/clients/:id/visits", ch.GetVisits)
+			staff.GET("/bookings/:id/client", ch.GetBookingCard)
+			staff.GET("/clients/:id/packages", pkh.ForClient)
+			staff.POST("/bookings/:id/checkout", coh.Checkout)
+			staff.GET("/bookings/:id/receipt", coh.Receipt)
+			staff.POST("/bookings/live/ticket", lfh.Ticket)
+		}
+
+		// Живое расписание для ресепшена. EventSource не передаёт заголовки,
+		// поэтому вход — по одноразовому билету из POST /bookings/live/ticket в ?ticket=.
+		live := api.Group("/")
+		live.Use(middleware.StreamTicket(liveFeed, "ticket"), middleware.Locale(svc), middleware.RequireRole(svc, "staff", "admin"))
+		live.GET("/bookings/live", lfh.Stream)
+
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(tokens, nil), middleware.Locale(svc), middleware.RequireRole(svc, "admin"))
+		{
+			admin.POST("/api-keys", akh.Create)
+			admin.GET("/api-keys", akh.List)
+			admin.DELETE("/api-keys/:id", akh.Revoke)
+
+			admin.POST("/webhooks", wh.Create)
+			admin.GET("/webhooks", wh.List)
+			admin.DELETE("/webhooks/:id", wh.Delete)
+			admin.GET("/webhooks/:id/deliveries", wh.Deliveries)
+			admin.POST("/webhook-deliveries/:id/redeliver", wh.Redeliver)
+
+			admin.GET("/bookings/:id/payments", payh.ListForBooking)
+			admin.POST("/payments/:id/refunds", payh.Refund)
+			admin.GET("/bookings/:id/receipt/fiscal", fh.Receipt)
+
+			admin.POST("/gift-cards", gch.Issue)
+			admin.GET("/gift-cards/:code/ledger", gch.Ledger)
+
+			admin.PUT("/tax-rates", th.Save)
+			admin.GET("/tax-rates", th.List)
+			admin.DELETE("/tax-rates/:id", th.Delete)
+
+			admin.PUT("/staff/:id/commission", prh.SaveScheme)
+			admin.DELETE("/staff/:id/commission", prh.DeleteScheme)
+			admin.GET("/commission-schemes", prh.ListSchemes)
+			admin.GET("/payroll", prh.Report)
+			admin.GET("/payroll.csv", prh.ReportCSV)
+
+			admin.POST("/membership-plans", mh.CreatePlan)
+			admin.DELETE("/membership-plans/:id", mh.DeletePlan)
+
+			admin.POST("/packages", pkh.Create)
+			admin.GET("/packages", pkh.List)
+			admin.DELETE("/packages/:id", pkh.Delete)
+
+			admin.POST("/discount-rules", dch.Create)
+			admin.GET("/discount-rules", dch.List)
+			admin.DELETE("/discount-rules/:id", dch.Delete)
+
+			admin.POST("/deposit-rules", dh.Create)
+			admin.GET("/deposit-rules", dh.List)
+			admin.DELETE("/deposit-rules/:id", dh.Delete)
+
+			admin.POST("/staff/:id/calendar", calh.CreateForStaff)
+			admin.DELETE("/staff/:id/calendar", calh.DeleteForStaff)
+
+			admin.POST("/users/:id/export", eh.RequestForUser)
+			admin.POST("/users/:id/loyalty/bonus", lh.Bonus)
+			admin.GET("/exports/:id", eh.Get)
 		}
 	}
 	r.Run(":" + os.Getenv("PORT"))
 }
+
+func durationEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("invalid %s: %q", name, v)
+	}
+	return d
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrEmptySecret    = errors.New("jwt secret is empty")
+	ErrUnsupportedAlg = errors.New("unsupported jwt algorithm")
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	ErrNoSigningKey   = errors.New("no signing key configured")
+	ErrDuplicateKeyID = errors.New("duplicate key id")
+)
+
+// Key — ключ подписи или проверки токенов. Для асимметричных алгоритмов
+// Private может быть nil: такой ключ только проверяет старые токены после ротации.
+type Key struct {
+	ID      string
+	Alg     string
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+	Secret  []byte
+}
+
+func NewHMACKey(id string, secret []byte) (*Key, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	return &Key{ID: id, Alg: AlgHS256, Secret: secret}, nil
+}
+
+// NewKey определяет алгоритм по типу ключа: *rsa.PrivateKey/*rsa.PublicKey → RS256,
+// ed25519 → EdDSA.
+func NewKey(id string, k interface{}) (*Key, error) {
+	switch key := k.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Alg: AlgRS256, Private: key, Public: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: id, Alg: AlgRS256, Public: key}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Alg: AlgEdDSA, Private: key, Public: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Alg: AlgEdDSA, Public: key}, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	switch k.Alg {
+	case AlgHS256:
+		return jwt.SigningMethodHS256
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	}
+	return nil
+}
+
+func (k *Key) signingKey() interface{} {
+	if k.Alg == AlgHS256 {
+		return k.Secret
+	}
+	return k.Private
+}
+
+func (k *Key) verificationKey() interface{} {
+	if k.Alg == AlgHS256 {
+		return k.Secret
+	}
+	return k.Public
+}
+
+func (k *Key) canSign() bool {
+	return k.signingKey() != nil
+}
+
+// ParsePEMKey читает PEM (PKCS#1, PKCS#8 или PKIX) и возвращает Key.
+func ParsePEMKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+	var (
+		parsed interface{}
+		err    error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewKey(id, parsed)
+}
+
+// LoadKeysFromEnv собирает ключи из переменных окружения:
+//
+//	JWT_ALG            — HS256 (по умолчанию), RS256 или EdDSA
+//	JWT_SECRET         — секрет для HS256
+//	JWT_KEY_ID         — kid активного ключа
+//	JWT_PRIVATE_KEY    — путь к PEM-файлу приватного ключа (RS256/EdDSA)
+//	JWT_VERIFY_KEYS    — выведенные из ротации публичные ключи: "kid1=path1,kid2=path2"
+//
+// Первый ключ в результате — активный ключ подписи.
+func LoadKeysFromEnv() ([]*Key, error) {
+	alg := os.Getenv("JWT_ALG")
+	if alg == "" {
+		alg = AlgHS256
+	}
+	kid := os.Getenv("JWT_KEY_ID")
+	if kid == "" {
+		kid = "default"
+	}
+
+	var keys []*Key
+	switch alg {
+	case AlgHS256:
+		k, err := NewHMACKey(kid, []byte(os.Getenv("JWT_SECRET")))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	case AlgRS256, AlgEdDSA:
+		path := os.Getenv("JWT_PRIVATE_KEY")
+		if path == "" {
+			return nil, ErrNoSigningKey
+		}
+		k, err := loadPEMFile(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		if k.Alg != alg || !k.canSign() {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY is not a %s private key", alg)
+		}
+		keys = append(keys, k)
+	default:
+		return nil, ErrUnsupportedAlg
+	}
+
+	if extra := os.Getenv("JWT_VERIFY_KEYS"); extra != "" {
+		for _, entry := range strings.Split(extra, ",") {
+			id, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok || id == "" || path == "" {
+				return nil, fmt.Errorf("invalid JWT_VERIFY_KEYS entry %q", entry)
+			}
+			k, err := loadPEMFile(id, path)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func loadPEMFile(id, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePEMKey(id, data)
+}
+
+// JWK — публичный ключ в формате RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k *Key) jwk() (JWK, bool) {
+	enc := base64.RawURLEncoding
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA", Kid: k.ID, Use: "sig", Alg: k.Alg,
+			N: enc.EncodeToString(pub.N.Bytes()),
+			E: enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Kid: k.ID, Use: "sig", Alg: k.Alg, Crv: "Ed25519", X: enc.EncodeToString(pub)}, true
+	}
+	// Симметричные ключи никогда не публикуются.
+	return JWK{}, false
+}
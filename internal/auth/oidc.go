@@ -0,0 +1,322 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrOIDCDiscovery = errors.New("oidc discovery failed")
+	ErrOIDCExchange  = errors.New("oidc code exchange failed")
+	ErrOIDCIDToken   = errors.New("invalid oidc id token")
+)
+
+type OIDCConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// OIDCIdentity — проверенные данные пользователя из ID-токена провайдера.
+type OIDCIdentity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcClaims struct {
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// OIDCProvider реализует authorization code flow с PKCE (RFC 7636).
+// Discovery-документ и ключи провайдера загружаются лениво и кэшируются.
+type OIDCProvider struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]interface{}
+	keysAt    time.Time
+}
+
+func NewOIDCProvider(cfg OIDCConfig, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	return &OIDCProvider{cfg: cfg, client: client}
+}
+
+func (p *OIDCProvider) Name() string { return p.cfg.Name }
+
+// NewPKCEVerifier возвращает случайный code_verifier (43 символа base64url).
+func NewPKCEVerifier() string {
+	return RandomToken(32)
+}
+
+// RandomToken — криптостойкая случайная строка в base64url из n байт.
+func RandomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {pkceChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange меняет code на токены и проверяет ID-токен: подпись ключом
+// провайдера, iss, aud, exp и nonce.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*OIDCIdentity, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCExchange, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrOIDCExchange, resp.StatusCode)
+	}
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil || tok.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrOIDCExchange)
+	}
+	return p.verifyIDToken(ctx, d, tok.IDToken, nonce)
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, d *oidcDiscovery, raw, nonce string) (*OIDCIdentity, error) {
+	claims := &oidcClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, d, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256", AlgEdDSA}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCIDToken, err)
+	}
+	if claims.Nonce != nonce || claims.Subject == "" {
+		return nil, ErrOIDCIDToken
+	}
+	return &OIDCIdentity{
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	var d oidcDiscovery
+	wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &d); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscovery, err)
+	}
+	if d.Issuer != p.cfg.Issuer || d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete or mismatched discovery document", ErrOIDCDiscovery)
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// key ищет ключ провайдера по kid. При неизвестном kid набор ключей
+// перечитывается (провайдер мог их ротировать), но не чаще раза в минуту.
+func (p *OIDCProvider) key(ctx context.Context, d *oidcDiscovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	if time.Since(p.keysAt) < time.Minute && p.keys != nil {
+		return nil, ErrUnknownKeyID
+	}
+	var set JWKSet
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if k, err := jwk.PublicKey(); err == nil {
+			keys[jwk.Kid] = k
+		}
+	}
+	p.keys, p.keysAt = keys, time.Now()
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, ErrUnknownKeyID
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// PublicKey восстанавливает публичный ключ из JWK (RSA, EC P-256, Ed25519).
+func (j JWK) PublicKey() (interface{}, error) {
+	dec := base64.RawURLEncoding
+	switch j.Kty {
+	case "RSA":
+		n, err := dec.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, ErrUnsupportedKey
+		}
+		x, err := dec.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := dec.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, ErrUnsupportedKey
+		}
+		x, err := dec.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// LoadOIDCProvidersFromEnv читает OIDC_PROVIDERS="google,yandex" и для каждого
+// провайдера OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL.
+func LoadOIDCProvidersFromEnv() ([]*OIDCProvider, error) {
+	var providers []*OIDCProvider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := OIDCConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			return nil, fmt.Errorf("oidc provider %q is not fully configured", name)
+		}
+		providers = append(providers, NewOIDCProvider(cfg, nil))
+	}
+	return providers, nil
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"beauty-salon/internal/auth/oidctest"
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockOIDC(t *testing.T) (*oidctest.Provider, *OIDCProvider) {
+	mock := oidctest.NewProvider("salon-app")
+	t.Cleanup(mock.Close)
+	p := NewOIDCProvider(OIDCConfig{
+		Name:        "mock",
+		Issuer:      mock.Issuer(),
+		ClientID:    "salon-app",
+		RedirectURL: "http://localhost/callback",
+	}, nil)
+	return mock, p
+}
+
+func TestOIDCAuthCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	mock, p := newMockOIDC(t)
+
+	verifier := NewPKCEVerifier()
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+
+	u, _ := url.Parse(authURL)
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, pkceChallenge(verifier), u.Query().Get("code_challenge"))
+	assert.NotContains(t, authURL, verifier)
+
+	t.Run("Success", func(t *testing.T) {
+		code, state, err := mock.Login(authURL, oidctest.User{Subject: "sub-1", Email: "a@b.c", EmailVerified: true})
+		require.NoError(t, err)
+		assert.Equal(t, "state-1", state)
+
+		id, err := p.Exchange(ctx, code, verifier, "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, "sub-1", id.Subject)
+		assert.Equal(t, "a@b.c", id.Email)
+		assert.True(t, id.EmailVerified)
+	})
+
+	t.Run("Wrong Verifier", func(t *testing.T) {
+		code, _, _ := mock.Login(authURL, oidctest.User{Subject: "sub-1"})
+		_, err := p.Exchange(ctx, code, NewPKCEVerifier(), "nonce-1")
+		assert.ErrorIs(t, err, ErrOIDCExchange)
+	})
+
+	t.Run("Nonce Mismatch", func(t *testing.T) {
+		code, _, _ := mock.Login(authURL, oidctest.User{Subject: "sub-1"})
+		_, err := p.Exchange(ctx, code, verifier, "other-nonce")
+		assert.ErrorIs(t, err, ErrOIDCIDToken)
+	})
+
+	t.Run("Code Reuse", func(t *testing.T) {
+		code, _, _ := mock.Login(authURL, oidctest.User{Subject: "sub-1"})
+		_, err := p.Exchange(ctx, code, verifier, "nonce-1")
+		require.NoError(t, err)
+		_, err = p.Exchange(ctx, code, verifier, "nonce-1")
+		assert.ErrorIs(t, err, ErrOIDCExchange)
+	})
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	mock := oidctest.NewProvider("salon-app")
+	defer mock.Close()
+	p := NewOIDCProvider(OIDCConfig{Issuer: mock.Issuer() + "/evil", ClientID: "salon-app"}, nil)
+	_, err := p.AuthCodeURL(context.Background(), "s", "n", "v")
+	assert.ErrorIs(t, err, ErrOIDCDiscovery)
+}
+
+func TestLoadOIDCProvidersFromEnv(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "Google")
+	t.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "id")
+	t.Setenv("OIDC_GOOGLE_REDIRECT_URL", "https://salon.example/cb")
+
+	providers, err := LoadOIDCProvidersFromEnv()
+	require.NoError(t, err)
+	require.Len(t, providers, 1)
+	assert.Equal(t, "google", providers[0].Name())
+
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "")
+	_, err = LoadOIDCProvidersFromEnv()
+	assert.Error(t, err)
+}
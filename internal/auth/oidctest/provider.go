@@ -0,0 +1,192 @@
+// Package oidctest — локальный OIDC-провайдер для тестов: discovery, JWKS,
+// authorize с автоматическим входом и token endpoint с проверкой PKCE.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type User struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+type grant struct {
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	user        User
+}
+
+type Provider struct {
+	Server   *httptest.Server
+	ClientID string
+	// User — учётная запись, под которой «входит» пользователь на /authorize.
+	User User
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+func NewProvider(clientID string) *Provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	p := &Provider{
+		ClientID: clientID,
+		User:     User{Subject: "mock-subject", Email: "client@example.com", EmailVerified: true, Name: "Mock Client"},
+		key:      key,
+		codes:    map[string]grant{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/jwks", p.jwks)
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+func (p *Provider) Issuer() string { return p.Server.URL }
+
+func (p *Provider) Close() { p.Server.Close() }
+
+// Login имитирует вход пользователя по ссылке authURL и возвращает code и state,
+// с которыми провайдер перенаправил бы браузер на redirect_uri.
+func (p *Provider) Login(authURL string, user User) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	if q.Get("client_id") != p.ClientID || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		return "", "", errors.New("oidctest: invalid authorization request")
+	}
+	code = base64.RawURLEncoding.EncodeToString(randomBytes(16))
+	p.mu.Lock()
+	p.codes[code] = grant{
+		clientID:    q.Get("client_id"),
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		user:        user,
+	}
+	p.mu.Unlock()
+	return code, q.Get("state"), nil
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.Issuer(),
+		"authorization_endpoint": p.Issuer() + "/authorize",
+		"token_endpoint":         p.Issuer() + "/token",
+		"jwks_uri":               p.Issuer() + "/jwks",
+	})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	code, state, err := p.Login(p.Issuer()+r.URL.RequestURI(), p.User)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	redirect, _ := url.Parse(r.URL.Query().Get("redirect_uri"))
+	q := redirect.Query()
+	q.Set("code", code)
+	q.Set("state", state)
+	redirect.RawQuery = q.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	p.mu.Lock()
+	g, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != g.clientID ||
+		r.PostForm.Get("redirect_uri") != g.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                p.Issuer(),
+		"sub":                g.user.Subject,
+		"aud":                g.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              g.nonce,
+		"email":              g.user.Email,
+		"email_verified":     g.user.EmailVerified,
+		"name":               g.user.Name,
+		"preferred_username": g.user.PreferredUsername,
+	})
+	idToken.Header["kid"] = "mock"
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	enc := base64.RawURLEncoding
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "mock",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   enc.EncodeToString(p.key.N.Bytes()),
+			"e":   enc.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnknownKeyID = errors.New("unknown key id")
+)
+
+// TokenIssuer выпускает токены доступа. Используется сервисом при логине.
+type TokenIssuer interface {
+	Issue(userID uint) (string, error)
+}
+
+// TokenVerifier проверяет подпись, алгоритм и срок действия токена.
+type TokenVerifier interface {
+	Verify(token string) (*Claims, error)
+}
+
+type Claims struct {
+	UserID uint `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager подписывает токены активным ключом и принимает токены,
+// подписанные любым из известных ключей (kid в заголовке) — так ключи
+// можно ротировать без разлогина пользователей.
+type TokenManager struct {
+	signing *Key
+	keys    map[string]*Key
+	ordered []*Key
+	ttl     time.Duration
+	issuer  string
+	now     func() time.Time
+}
+
+// NewTokenManager принимает ключи, первый из которых — активный ключ подписи.
+func NewTokenManager(issuer string, ttl time.Duration, keys ...*Key) (*TokenManager, error) {
+	if len(keys) == 0 || !keys[0].canSign() {
+		return nil, ErrNoSigningKey
+	}
+	byID := make(map[string]*Key, len(keys))
+	for _, k := range keys {
+		if k.method() == nil {
+			return nil, ErrUnsupportedAlg
+		}
+		if _, dup := byID[k.ID]; dup {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateKeyID, k.ID)
+		}
+		byID[k.ID] = k
+	}
+	return &TokenManager{signing: keys[0], keys: byID, ordered: keys, ttl: ttl, issuer: issuer, now: time.Now}, nil
+}
+
+func (m *TokenManager) Issue(userID uint) (string, error) {
+	now := m.now()
+	claims := Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+		},
+	}
+	token := jwt.NewWithClaims(m.signing.method(), claims)
+	token.Header["kid"] = m.signing.ID
+	return token.SignedString(m.signing.signingKey())
+}
+
+func (m *TokenManager) Verify(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	opts := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now),
+		// Допустимые алгоритмы — только те, для которых у нас есть ключи.
+		jwt.WithValidMethods(m.algorithms()),
+	}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, m.keyFunc, opts...)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (m *TokenManager) keyFunc(t *jwt.Token) (interface{}, error) {
+	var key *Key
+	if kid, ok := t.Header["kid"].(string); ok {
+		key = m.keys[kid]
+	} else if m.signing.Alg == AlgHS256 {
+		// Токены, выпущенные до появления kid, подписаны общим секретом.
+		key = m.signing
+	}
+	if key == nil {
+		return nil, ErrUnknownKeyID
+	}
+	// Алгоритм из заголовка обязан совпадать с алгоритмом ключа,
+	// иначе возможна подмена (например, RS256 → HS256 с публичным ключом).
+	if t.Method.Alg() != key.Alg {
+		return nil, ErrInvalidToken
+	}
+	return key.verificationKey(), nil
+}
+
+func (m *TokenManager) algorithms() []string {
+	seen := map[string]bool{}
+	var algs []string
+	for _, k := range m.ordered {
+		if !seen[k.Alg] {
+			seen[k.Alg] = true
+			algs = append(algs, k.Alg)
+		}
+	}
+	return algs
+}
+
+// JWKS возвращает публичные части асимметричных ключей, начиная с активного.
+func (m *TokenManager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range m.ordered {
+		if jwk, ok := k.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
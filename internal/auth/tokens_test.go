@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaKey(t *testing.T, id string) *Key {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	k, err := NewKey(id, priv)
+	require.NoError(t, err)
+	return k
+}
+
+func edKey(t *testing.T, id string) *Key {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	k, err := NewKey(id, priv)
+	require.NoError(t, err)
+	return k
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	hmac, _ := NewHMACKey("h1", []byte("secret"))
+	for _, key := range []*Key{hmac, rsaKey(t, "r1"), edKey(t, "e1")} {
+		t.Run(key.Alg, func(t *testing.T) {
+			tm, err := NewTokenManager("salon", time.Hour, key)
+			require.NoError(t, err)
+
+			token, err := tm.Issue(42)
+			require.NoError(t, err)
+
+			claims, err := tm.Verify(token)
+			assert.NoError(t, err)
+			assert.Equal(t, uint(42), claims.UserID)
+			assert.Equal(t, "salon", claims.Issuer)
+		})
+	}
+}
+
+func TestEmptySecret(t *testing.T) {
+	_, err := NewHMACKey("h1", nil)
+	assert.ErrorIs(t, err, ErrEmptySecret)
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := edKey(t, "old")
+	newKey := edKey(t, "new")
+
+	before, _ := NewTokenManager("", time.Hour, oldKey)
+	token, _ := before.Issue(7)
+
+	// После ротации старый ключ остаётся только для проверки.
+	retired, _ := NewKey("old", oldKey.Public)
+	after, err := NewTokenManager("", time.Hour, newKey, retired)
+	require.NoError(t, err)
+
+	claims, err := after.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+
+	fresh, _ := after.Issue(7)
+	parsed, _, _ := jwt.NewParser().ParseUnverified(fresh, &Claims{})
+	assert.Equal(t, "new", parsed.Header["kid"])
+
+	// Без старого ключа токен не принимается.
+	only, _ := NewTokenManager("", time.Hour, newKey)
+	_, err = only.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifyRejects(t *testing.T) {
+	rsaSigner := rsaKey(t, "r1")
+	tm, _ := NewTokenManager("", time.Hour, rsaSigner)
+
+	t.Run("Algorithm Confusion", func(t *testing.T) {
+		// HS256, подписанный публичным ключом RSA, с тем же kid.
+		pubDER := x509.MarshalPKCS1PublicKey(rsaSigner.Public.(*rsa.PublicKey))
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+			UserID:           1,
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		})
+		token.Header["kid"] = "r1"
+		s, _ := token.SignedString(pubDER)
+
+		_, err := tm.Verify(s)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Unknown Kid", func(t *testing.T) {
+		other, _ := NewTokenManager("", time.Hour, rsaKey(t, "r2"))
+		s, _ := other.Issue(1)
+		_, err := tm.Verify(s)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		expired, _ := NewTokenManager("", -time.Minute, rsaSigner)
+		s, _ := expired.Issue(1)
+		_, err := tm.Verify(s)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Missing Exp", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{UserID: 1})
+		token.Header["kid"] = "r1"
+		s, _ := token.SignedString(rsaSigner.Private)
+		_, err := tm.Verify(s)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestNewTokenManagerErrors(t *testing.T) {
+	_, err := NewTokenManager("", time.Hour)
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	pubOnly, _ := NewKey("p", rsaKey(t, "x").Public)
+	_, err = NewTokenManager("", time.Hour, pubOnly)
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	k := edKey(t, "dup")
+	_, err = NewTokenManager("", time.Hour, k, k)
+	assert.ErrorIs(t, err, ErrDuplicateKeyID)
+}
+
+func TestJWKS(t *testing.T) {
+	hmac, _ := NewHMACKey("h1", []byte("secret"))
+	tm, _ := NewTokenManager("", time.Hour, hmac)
+	assert.Empty(t, tm.JWKS().Keys)
+
+	active := rsaKey(t, "r1")
+	retired := edKey(t, "e0")
+	tm, _ = NewTokenManager("", time.Hour, active, retired)
+	set := tm.JWKS()
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "r1", set.Keys[0].Kid)
+	assert.Equal(t, "RSA", set.Keys[0].Kty)
+	assert.Equal(t, "AQAB", set.Keys[0].E)
+	assert.Equal(t, "Ed25519", set.Keys[1].Crv)
+}
+
+func TestLoadKeysFromEnv(t *testing.T) {
+	t.Run("HS256 Empty Secret", func(t *testing.T) {
+		t.Setenv("JWT_ALG", "")
+		t.Setenv("JWT_SECRET", "")
+		_, err := LoadKeysFromEnv()
+		assert.ErrorIs(t, err, ErrEmptySecret)
+	})
+
+	t.Run("Unsupported Alg", func(t *testing.T) {
+		t.Setenv("JWT_ALG", "none")
+		_, err := LoadKeysFromEnv()
+		assert.ErrorIs(t, err, ErrUnsupportedAlg)
+	})
+
+	t.Run("EdDSA With Rotation", func(t *testing.T) {
+		dir := t.TempDir()
+		pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+		privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+		_, oldPub := mustEd(t)
+		pubDER, _ := x509.MarshalPKIXPublicKey(oldPub)
+
+		privPath := filepath.Join(dir, "current.pem")
+		oldPath := filepath.Join(dir, "old.pem")
+		require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600))
+		require.NoError(t, os.WriteFile(oldPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0600))
+
+		t.Setenv("JWT_ALG", "EdDSA")
+		t.Setenv("JWT_KEY_ID", "2025-01")
+		t.Setenv("JWT_PRIVATE_KEY", privPath)
+		t.Setenv("JWT_VERIFY_KEYS", "2024-12="+oldPath)
+
+		keys, err := LoadKeysFromEnv()
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, "2025-01", keys[0].ID)
+		assert.Equal(t, pub, keys[0].Public)
+		assert.Equal(t, "2024-12", keys[1].ID)
+		assert.Nil(t, keys[1].Private)
+	})
+}
+
+func mustEd(t *testing.T) (ed25519.PrivateKey, ed25519.PublicKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return priv, pub
+}
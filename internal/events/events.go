@@ -0,0 +1,167 @@
+// Package events — доменные события и их доставка подписчикам.
+// События пишутся в таблицу outbox в той же транзакции, что и изменение
+// состояния, а затем публикуются в Sink'и. Доставка «как минимум один раз»:
+// получатели отбрасывают повторы по Event.ID.
+package events
+
+import (
+	"beauty-salon/internal/money"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	UserRegistered       = "user.registered"
+	BookingCreated       = "booking.created"
+	BookingStatusChanged = "booking.status_changed"
+	BookingRescheduled   = "booking.rescheduled"
+	BookingCompleted     = "booking.completed" // визит состоялся
+	PaymentSucceeded     = "payment.succeeded"
+	PaymentRefunded      = "payment.refunded"
+	ReceiptCreated       = "receipt.created" // визит закрыт на кассе
+)
+
+var Types = []string{UserRegistered, BookingCreated, BookingStatusChanged, BookingRescheduled, BookingCompleted,
+	PaymentSucceeded, PaymentRefunded, ReceiptCreated}
+
+type Event struct {
+	ID          string          `json:"id"` // ключ дедупликации
+	Type        string          `json:"type"`
+	AggregateID uint            `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+type UserRegisteredPayload struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+type BookingPayload struct {
+	BookingID uint   `json:"booking_id"`
+	UserID    uint   `json:"user_id"`
+	ServiceID uint   `json:"service_id"`
+	StaffID   uint   `json:"staff_id"`
+	Date      string `json:"date"`
+	Status    string `json:"status"`
+}
+
+type BookingStatusChangedPayload struct {
+	BookingID uint   `json:"booking_id"`
+	UserID    uint   `json:"user_id"`
+	StaffID   uint   `json:"staff_id"`
+	Date      string `json:"date"` // время визита, YYYY-MM-DD HH:MM
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+type BookingRescheduledPayload struct {
+	BookingID uint   `json:"booking_id"`
+	UserID    uint   `json:"user_id"`
+	StaffID   uint   `json:"staff_id"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+type PaymentPayload struct {
+	PaymentID      uint        `json:"payment_id"`
+	BookingID      uint        `json:"booking_id"`
+	UserID         uint        `json:"user_id"`
+	Amount         money.Money `json:"amount"`
+	RefundedAmount money.Money `json:"refunded_amount"`
+	Status         string      `json:"status"`
+	SubscriptionID *uint       `json:"subscription_id,omitempty"` // оплата абонемента
+}
+
+type PaymentRefundedPayload struct {
+	PaymentPayload
+	RefundID     uint        `json:"refund_id"`
+	RefundAmount money.Money `json:"refund_amount"`
+}
+
+type ReceiptPayload struct {
+	ReceiptID uint        `json:"receipt_id"`
+	BookingID uint        `json:"booking_id"`
+	UserID    uint        `json:"user_id"`
+	StaffID   uint        `json:"staff_id"`
+	Subtotal  money.Money `json:"subtotal"`
+	Discount  money.Money `json:"discount"`
+	Tips      money.Money `json:"tips"`
+	Total     money.Money `json:"total"`
+	Tax       money.Money `json:"tax"`
+}
+
+// NewID возвращает случайный идентификатор события.
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Sink получает опубликованные события. Ошибка означает, что событие
+// будет доставлено повторно.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e Event) error
+}
+
+type Handler func(ctx context.Context, e Event) error
+
+// Bus — внутрипроцессная шина. Повторно доставленные события (с уже
+// виденным ID) подписчикам не передаются.
+type Bus struct {
+	mu       sync.Mutex
+	handlers map[string][]Handler
+	seen     map[string]bool
+	order    []string
+	memory   int
+}
+
+// NewBus создаёт шину, которая помнит последние memory идентификаторов событий.
+func NewBus(memory int) *Bus {
+	return &Bus{handlers: map[string][]Handler{}, seen: map[string]bool{}, memory: memory}
+}
+
+func (b *Bus) Name() string { return "bus" }
+
+// Subscribe подписывает обработчик на тип события; "*" — на все события.
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], h)
+}
+
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	b.mu.Lock()
+	if b.seen[e.ID] {
+		b.mu.Unlock()
+		return nil
+	}
+	handlers := append(append([]Handler(nil), b.handlers[e.Type]...), b.handlers["*"]...)
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		if err := h(ctx, e); err != nil {
+			return err
+		}
+	}
+	b.remember(e.ID)
+	return nil
+}
+
+func (b *Bus) remember(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seen[id] = true
+	b.order = append(b.order, id)
+	if len(b.order) > b.memory {
+		delete(b.seen, b.order[0])
+		b.order = b.order[1:]
+	}
+}
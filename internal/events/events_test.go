@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusDispatchAndDedup(t *testing.T) {
+	bus := NewBus(2)
+	var got []string
+	bus.Subscribe(BookingCreated, func(_ context.Context, e Event) error {
+		got = append(got, "created:"+e.ID)
+		return nil
+	})
+	bus.Subscribe("*", func(_ context.Context, e Event) error {
+		got = append(got, "all:"+e.ID)
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, bus.Publish(ctx, Event{ID: "1", Type: BookingCreated}))
+	assert.NoError(t, bus.Publish(ctx, Event{ID: "1", Type: BookingCreated})) // повтор
+	assert.NoError(t, bus.Publish(ctx, Event{ID: "2", Type: UserRegistered}))
+	assert.Equal(t, []string{"created:1", "all:1", "all:2"}, got)
+
+	// Память ограничена: самый старый ID забывается.
+	assert.NoError(t, bus.Publish(ctx, Event{ID: "3", Type: UserRegistered}))
+	assert.NoError(t, bus.Publish(ctx, Event{ID: "1", Type: UserRegistered}))
+	assert.Equal(t, []string{"created:1", "all:1", "all:2", "all:3", "all:1"}, got)
+}
+
+func TestBusHandlerErrorAllowsRedelivery(t *testing.T) {
+	bus := NewBus(10)
+	calls := 0
+	bus.Subscribe(BookingCreated, func(context.Context, Event) error {
+		calls++
+		if calls == 1 {
+			return errors.New("busy")
+		}
+		return nil
+	})
+
+	e := Event{ID: "1", Type: BookingCreated}
+	assert.Error(t, bus.Publish(context.Background(), e))
+	assert.NoError(t, bus.Publish(context.Background(), e))
+	assert.Equal(t, 2, calls)
+}
+
+func TestNewID(t *testing.T) {
+	assert.Len(t, NewID(), 32)
+	assert.NotEqual(t, NewID(), NewID())
+}
@@ -0,0 +1,65 @@
+package fiscal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileRegistrar вместо регистратора пишет чеки JSON-файлами в каталог —
+// для разработки и сверки с бухгалтерией. Повторная регистрация того же
+// номера возвращает уже выданный фискальный номер.
+type FileRegistrar struct {
+	dir string
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+type fileRecord struct {
+	Registration
+	Receipt *Receipt `json:"receipt"`
+}
+
+func NewFileRegistrar(dir string) (*FileRegistrar, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileRegistrar{dir: dir, now: time.Now}, nil
+}
+
+func (f *FileRegistrar) Register(_ context.Context, r *Receipt) (*Registration, error) {
+	if r.Number == "" {
+		return nil, errors.New("receipt number is required")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := filepath.Join(f.dir, "receipt-"+r.Number+".json")
+	if data, err := os.ReadFile(path); err == nil {
+		var rec fileRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, err
+		}
+		return &rec.Registration, nil
+	}
+
+	rec := fileRecord{Registration: Registration{FiscalID: "FILE-" + r.Number, RegisteredAt: f.now()}, Receipt: r}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// Через временный файл, чтобы не оставить половину чека при сбое.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, fmt.Errorf("save receipt %s: %w", r.Number, err)
+	}
+	return &rec.Registration, nil
+}
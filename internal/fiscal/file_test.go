@@ -0,0 +1,43 @@
+package fiscal
+
+import (
+	"beauty-salon/internal/money"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRegistrar(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	f, err := NewFileRegistrar(dir)
+	require.NoError(t, err)
+
+	r := &Receipt{Number: "7", BookingID: 1, TaxMode: "inclusive", Total: money.New(560000, "KZT"), Tax: money.New(60000, "KZT"),
+		Items: []Item{{Name: "Стрижка", Kind: "service", Quantity: 1, Price: money.New(560000, "KZT"), Amount: money.New(560000, "KZT"),
+			TaxRate: 1200, Tax: money.New(60000, "KZT")}}}
+	reg, err := f.Register(ctx, r)
+	require.NoError(t, err)
+	assert.Equal(t, "FILE-7", reg.FiscalID)
+
+	data, err := os.ReadFile(filepath.Join(dir, "receipt-7.json"))
+	require.NoError(t, err)
+	var saved fileRecord
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, money.New(60000, "KZT"), saved.Receipt.Items[0].Tax)
+	assert.Contains(t, string(data), `"amount": "5600.00"`)
+
+	// Повтор возвращает ту же регистрацию и не переписывает файл.
+	again, err := f.Register(ctx, &Receipt{Number: "7"})
+	require.NoError(t, err)
+	assert.Equal(t, reg.FiscalID, again.FiscalID)
+	assert.True(t, reg.RegisteredAt.Equal(again.RegisteredAt))
+
+	_, err = f.Register(ctx, &Receipt{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+// Package fiscal — данные чека для фискального регистратора (онлайн-ККМ)
+// и регистраторы. Суммы — money.Money, в JSON десятичными строками.
+package fiscal
+
+import (
+	"beauty-salon/internal/money"
+	"time"
+)
+
+// Receipt — чек в том виде, в каком его принимает регистратор: скидки уже
+// разнесены по позициям, НДС посчитан по каждой позиции.
+type Receipt struct {
+	Number    string      `json:"number"` // номер чека в салоне
+	BookingID uint        `json:"booking_id"`
+	IssuedAt  time.Time   `json:"issued_at"`
+	CashierID uint        `json:"cashier_id"`
+	TaxMode   string      `json:"tax_mode"` // inclusive — цены с НДС, exclusive — НДС сверху
+	Items     []Item      `json:"items"`
+	Payments  []Payment   `json:"payments"`
+	Total     money.Money `json:"total"`
+	Tax       money.Money `json:"tax"`
+	Change    money.Money `json:"change"`
+}
+
+type Item struct {
+	Name     string      `json:"name"`
+	Kind     string      `json:"kind"` // service, addon, product, tip
+	Quantity int         `json:"quantity"`
+	Price    money.Money `json:"price"`
+	Discount money.Money `json:"discount"`
+	Amount   money.Money `json:"amount"`   // к оплате по позиции, со скидкой и НДС
+	TaxRate  int         `json:"tax_rate"` // в сотых долях процента; 0 — без НДС
+	Tax      money.Money `json:"tax"`
+}
+
+type Payment struct {
+	Method string      `json:"method"` // cash, card, gift_card, online
+	Amount money.Money `json:"amount"`
+}
+
+// Registration — ответ регистратора о принятом чеке.
+type Registration struct {
+	FiscalID     string    `json:"fiscal_id"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	svc service.APIKeys
+}
+
+func NewAPIKeyHandler(svc service.APIKeys) *APIKeyHandler {
+	return &APIKeyHandler{svc: svc}
+}
+
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var i struct {
+		Name      string     `json:"name" binding:"required"`
+		Scopes    []string   `json:"scopes" binding:"required"`
+		ExpiresAt *time.Time `json:"expires_at"`
+		RateLimit int        `json:"rate_limit"`
+	}
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	raw, key, err := h.svc.CreateAPIKey(i.Name, i.Scopes, i.ExpiresAt, i.RateLimit, c.MustGet("userID").(uint))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidScope), errors.Is(err, service.ErrInvalidAPIKey):
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+		default:
+			c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		}
+		return
+	}
+	// Ключ в открытом виде показывается только один раз.
+	c.JSON(201, gin.H{"key": raw, "api_key": key})
+}
+
+func (h *APIKeyHandler) List(c *gin.Context) {
+	k, _ := h.svc.GetAPIKeys()
+	c.JSON(200, k)
+}
+
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	if err := h.svc.RevokeAPIKey(c.Param("id")); err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
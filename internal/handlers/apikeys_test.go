@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeys struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeys) CreateAPIKey(name string, scopes []string, expiresAt *time.Time, rateLimit int, createdBy uint) (string, *models.APIKey, error) {
+	args := m.Called(name, scopes, expiresAt, rateLimit, createdBy)
+	if args.Get(1) == nil {
+		return "", nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).(*models.APIKey), args.Error(2)
+}
+
+func (m *MockAPIKeys) GetAPIKeys() ([]models.APIKey, error) {
+	args := m.Called()
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeys) RevokeAPIKey(id string) error { return m.Called(id).Error(0) }
+
+func (m *MockAPIKeys) AuthenticateAPIKey(raw string) (*models.APIKey, error) {
+	args := m.Called(raw)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func setupAPIKeys() (*gin.Engine, *MockAPIKeys) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockAPIKeys)
+	h := NewAPIKeyHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)) })
+	r.POST("/admin/api-keys", h.Create)
+	r.GET("/admin/api-keys", h.List)
+	r.DELETE("/admin/api-keys/:id", h.Revoke)
+	return r, m
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	r, m := setupAPIKeys()
+
+	t.Run("Success", func(t *testing.T) {
+		m.On("CreateAPIKey", "kiosk", []string{"read:bookings"}, (*time.Time)(nil), 60, uint(1)).
+			Return("sk_abcd1234_secret", &models.APIKey{Name: "kiosk", Prefix: "abcd1234"}, nil).Once()
+		body, _ := json.Marshal(map[string]interface{}{"name": "kiosk", "scopes": []string{"read:bookings"}, "rate_limit": 60})
+		req, _ := http.NewRequest("POST", "/admin/api-keys", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 201, w.Code)
+		assert.Contains(t, w.Body.String(), "sk_abcd1234_secret")
+		assert.NotContains(t, w.Body.String(), "key_hash")
+	})
+
+	t.Run("Invalid Scope", func(t *testing.T) {
+		m.On("CreateAPIKey", "kiosk", []string{"root"}, (*time.Time)(nil), 0, uint(1)).
+			Return("", nil, service.ErrInvalidScope).Once()
+		body, _ := json.Marshal(map[string]interface{}{"name": "kiosk", "scopes": []string{"root"}})
+		req, _ := http.NewRequest("POST", "/admin/api-keys", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+	})
+
+	t.Run("Missing Name", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/admin/api-keys", bytes.NewBuffer([]byte(`{"scopes":["read:bookings"]}`)))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+	})
+
+	t.Run("Blank Name", func(t *testing.T) {
+		m.On("CreateAPIKey", " ", []string{"read:bookings"}, (*time.Time)(nil), 0, uint(1)).
+			Return("", nil, fmt.Errorf("%w: name is required", service.ErrInvalidAPIKey)).Once()
+		body, _ := json.Marshal(map[string]interface{}{"name": " ", "scopes": []string{"read:bookings"}})
+		req, _ := http.NewRequest("POST", "/admin/api-keys", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+		assert.Contains(t, w.Body.String(), "name is required")
+	})
+}
+
+func TestListAPIKeys(t *testing.T) {
+	r, m := setupAPIKeys()
+	m.On("GetAPIKeys").Return([]models.APIKey{{Name: "kiosk"}}, nil).Once()
+	req, _ := http.NewRequest("GET", "/admin/api-keys", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "kiosk")
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	r, m := setupAPIKeys()
+
+	t.Run("Success", func(t *testing.T) {
+		m.On("RevokeAPIKey", "3").Return(nil).Once()
+		req, _ := http.NewRequest("DELETE", "/admin/api-keys/3", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 204, w.Code)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		m.On("RevokeAPIKey", "9").Return(errors.New("db error")).Once()
+		req, _ := http.NewRequest("DELETE", "/admin/api-keys/9", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 500, w.Code)
+	})
+}
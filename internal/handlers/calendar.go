@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+type CalendarHandler struct {
+	svc service.Calendars
+}
+
+func NewCalendarHandler(svc service.Calendars) *CalendarHandler {
+	return &CalendarHandler{svc: svc}
+}
+
+// CreateMine выпускает ссылку на подписку; прежняя ссылка перестаёт работать.
+func (h *CalendarHandler) CreateMine(c *gin.Context) {
+	url, err := h.svc.CreateUserFeed(c.MustGet("userID").(uint))
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, gin.H{"url": url})
+}
+
+func (h *CalendarHandler) DeleteMine(c *gin.Context) {
+	if err := h.svc.DeleteUserFeed(c.MustGet("userID").(uint)); err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
+
+func (h *CalendarHandler) CreateForStaff(c *gin.Context) {
+	url, err := h.svc.CreateStaffFeed(c.Param("id"))
+	if err != nil {
+		h.staffFail(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"url": url})
+}
+
+func (h *CalendarHandler) DeleteForStaff(c *gin.Context) {
+	if err := h.svc.DeleteStaffFeed(c.Param("id")); err != nil {
+		h.staffFail(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+func (h *CalendarHandler) staffFail(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": tr(c, "Staff not found")})
+		return
+	}
+	c.JSON(500, gin.H{"error": tr(c, "Failed")})
+}
+
+// Feed отдаёт подписку по секретной ссылке — без авторизации, её запрашивает календарь.
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	out, err := h.svc.Feed(strings.TrimSuffix(c.Param("token"), ".ics"))
+	if err != nil {
+		if errors.Is(err, service.ErrFeedNotFound) {
+			c.JSON(404, gin.H{"error": tr(c, "Calendar feed not found")})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(200, calendarContentType, out)
+}
+
+// BookingICS отдаёт одну запись файлом для импорта в календарь.
+func (h *CalendarHandler) BookingICS(c *gin.Context) {
+	out, err := h.svc.BookingICS(c.Param("id"), c.MustGet("userID").(uint))
+	if err != nil {
+		if errors.Is(err, service.ErrBookingNotFound) {
+			c.JSON(404, gin.H{"error": tr(c, "Booking not found")})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="booking-`+c.Param("id")+`.ics"`)
+	c.Data(200, calendarContentType, out)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockCalendars struct {
+	mock.Mock
+}
+
+func (m *MockCalendars) CreateUserFeed(userID uint) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+func (m *MockCalendars) DeleteUserFeed(userID uint) error { return m.Called(userID).Error(0) }
+func (m *MockCalendars) CreateStaffFeed(staffID string) (string, error) {
+	args := m.Called(staffID)
+	return args.String(0), args.Error(1)
+}
+func (m *MockCalendars) DeleteStaffFeed(staffID string) error { return m.Called(staffID).Error(0) }
+func (m *MockCalendars) Feed(token string) ([]byte, error) {
+	args := m.Called(token)
+	out, _ := args.Get(0).([]byte)
+	return out, args.Error(1)
+}
+func (m *MockCalendars) BookingICS(bookingID string, userID uint) ([]byte, error) {
+	args := m.Called(bookingID, userID)
+	out, _ := args.Get(0).([]byte)
+	return out, args.Error(1)
+}
+
+func setupCalendar() (*gin.Engine, *MockCalendars) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockCalendars)
+	h := NewCalendarHandler(m)
+	r := gin.New()
+	r.GET("/calendar/:token", h.Feed)
+	authed := r.Group("/", func(c *gin.Context) { c.Set("userID", uint(4)) })
+	authed.POST("/users/me/calendar", h.CreateMine)
+	authed.DELETE("/users/me/calendar", h.DeleteMine)
+	authed.GET("/bookings/:id/ics", h.BookingICS)
+	authed.POST("/admin/staff/:id/calendar", h.CreateForStaff)
+	authed.DELETE("/admin/staff/:id/calendar", h.DeleteForStaff)
+	return r, m
+}
+
+func TestCalendarFeedHandler(t *testing.T) {
+	r, m := setupCalendar()
+
+	m.On("Feed", "abc").Return([]byte("BEGIN:VCALENDAR\r\n"), nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/calendar/abc.ics", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n", w.Body.String())
+
+	m.On("Feed", "old").Return(nil, service.ErrFeedNotFound).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/calendar/old.ics", nil))
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestCalendarFeedLinks(t *testing.T) {
+	r, m := setupCalendar()
+
+	m.On("CreateUserFeed", uint(4)).Return("https://salon.example/api/v1/calendar/abc.ics", nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/me/calendar", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "calendar/abc.ics")
+
+	m.On("DeleteUserFeed", uint(4)).Return(nil).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/me/calendar", nil))
+	assert.Equal(t, 204, w.Code)
+
+	m.On("CreateStaffFeed", "3").Return("https://salon.example/api/v1/calendar/def.ics", nil).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/staff/3/calendar", nil))
+	assert.Equal(t, 200, w.Code)
+
+	m.On("DeleteStaffFeed", "99").Return(gorm.ErrRecordNotFound).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/staff/99/calendar", nil))
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestBookingICSHandler(t *testing.T) {
+	r, m := setupCalendar()
+
+	m.On("BookingICS", "7", uint(4)).Return([]byte("BEGIN:VCALENDAR\r\n"), nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/bookings/7/ics", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `attachment; filename="booking-7.ics"`, w.Header().Get("Content-Disposition"))
+
+	m.On("BookingICS", "8", uint(4)).Return(nil, service.ErrBookingNotFound).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/bookings/8/ics", nil))
+	assert.Equal(t, 404, w.Code)
+
+	m.On("BookingICS", "9", uint(4)).Return(nil, errors.New("db down")).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/bookings/9/ics", nil))
+	assert.Equal(t, 500, w.Code)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CheckoutHandler struct {
+	svc service.Checkout
+}
+
+func NewCheckoutHandler(svc service.Checkout) *CheckoutHandler {
+	return &CheckoutHandler{svc: svc}
+}
+
+// Checkout закрывает визит: пробивает чек и отмечает запись состоявшейся.
+func (h *CheckoutHandler) Checkout(c *gin.Context) {
+	var i service.CheckoutInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	rec, err := h.svc.Checkout(c.Param("id"), i, c.MustGet("userID").(uint))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(201, rec)
+}
+
+func (h *CheckoutHandler) Receipt(c *gin.Context) {
+	rec, err := h.svc.GetReceipt(c.Param("id"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, rec)
+}
+
+func (h *CheckoutHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrBookingNotFound), errors.Is(err, service.ErrReceiptNotFound):
+		c.JSON(404, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrInvalidCheckout), errors.Is(err, service.ErrInsufficientTender),
+		errors.Is(err, service.ErrInvalidGiftCard), errors.Is(err, service.ErrGiftCardNotFound),
+		errors.Is(err, service.ErrGiftCardExpired), errors.Is(err, service.ErrGiftCardInsufficient),
+		errors.Is(err, service.ErrLoyaltyBalance), errors.Is(err, service.ErrLoyaltyPointsInvalid),
+		errors.Is(err, service.ErrPackageNotFound),
+		isPromoError(err):
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrAlreadyCheckedOut):
+		c.JSON(409, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+	}
+}
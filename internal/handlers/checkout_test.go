@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/service"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCheckout struct {
+	mock.Mock
+}
+
+func (m *MockCheckout) Checkout(bookingID string, in service.CheckoutInput, by uint) (*models.Receipt, error) {
+	args := m.Called(bookingID, in, by)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Receipt), args.Error(1)
+}
+
+func (m *MockCheckout) GetReceipt(bookingID string) (*models.Receipt, error) {
+	args := m.Called(bookingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Receipt), args.Error(1)
+}
+
+func setupCheckout() (*gin.Engine, *MockCheckout) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockCheckout)
+	h := NewCheckoutHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(9)) })
+	r.POST("/bookings/:id/checkout", h.Checkout)
+	r.GET("/bookings/:id/receipt", h.Receipt)
+	return r, m
+}
+
+func TestCheckoutHandler(t *testing.T) {
+	r, m := setupCheckout()
+	in := service.CheckoutInput{Tip: money.New(50000, "KZT"), Tenders: []service.CheckoutTender{{Method: "cash", Amount: money.New(600000, "KZT")}}}
+	m.On("Checkout", "1", in, uint(9)).Return(&models.Receipt{BookingID: 1, Total: money.New(550050, "KZT"), Change: money.New(49950, "KZT")}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/bookings/1/checkout",
+		bytes.NewBufferString(`{"tip":"500.00","tenders":[{"method":"cash","amount":{"amount":"6000","currency":"KZT"}}]}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"change":{"amount":"499.50","currency":"KZT"}`)
+}
+
+func TestCheckoutHandlerErrors(t *testing.T) {
+	r, m := setupCheckout()
+	for id, err := range map[string]error{
+		"2": service.ErrBookingNotFound,
+		"3": service.ErrInsufficientTender,
+		"4": service.ErrAlreadyCheckedOut,
+	} {
+		m.On("Checkout", id, mock.Anything, uint(9)).Return(nil, err).Once()
+	}
+	for id, code := range map[string]int{"2": 404, "3": 400, "4": 409} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/bookings/"+id+"/checkout", bytes.NewBufferString(`{}`)))
+		assert.Equal(t, code, w.Code, id)
+	}
+
+	m.On("GetReceipt", "5").Return(nil, service.ErrReceiptNotFound).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/bookings/5/receipt", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientCardHandler — карточка клиента для мастеров и администраторов.
+type ClientCardHandler struct {
+	svc service.ClientCards
+}
+
+func NewClientCardHandler(svc service.ClientCards) *ClientCardHandler {
+	return &ClientCardHandler{svc: svc}
+}
+
+func (h *ClientCardHandler) GetCard(c *gin.Context) {
+	id, ok := clientID(c)
+	if !ok {
+		return
+	}
+	card, err := h.svc.GetCard(id)
+	if err != nil {
+		clientCardError(c, err)
+		return
+	}
+	c.JSON(200, card)
+}
+
+// GetBookingCard показывает карточку клиента при открытии записи.
+func (h *ClientCardHandler) GetBookingCard(c *gin.Context) {
+	card, err := h.svc.GetCardForBooking(c.Param("id"))
+	if err != nil {
+		clientCardError(c, err)
+		return
+	}
+	c.JSON(200, card)
+}
+
+func (h *ClientCardHandler) SaveProfile(c *gin.Context) {
+	id, ok := clientID(c)
+	if !ok {
+		return
+	}
+	var in service.ClientProfileInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	p, err := h.svc.SaveProfile(id, in)
+	if err != nil {
+		clientCardError(c, err)
+		return
+	}
+	c.JSON(200, p)
+}
+
+func (h *ClientCardHandler) AddNote(c *gin.Context) {
+	id, ok := clientID(c)
+	if !ok {
+		return
+	}
+	var i struct {
+		Text string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	n, err := h.svc.AddNote(id, c.MustGet("userID").(uint), i.Text)
+	if err != nil {
+		clientCardError(c, err)
+		return
+	}
+	c.JSON(201, n)
+}
+
+func (h *ClientCardHandler) DeleteNote(c *gin.Context) {
+	id, ok := clientID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteNote(id, c.Param("noteId")); err != nil {
+		clientCardError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+func (h *ClientCardHandler) GetVisits(c *gin.Context) {
+	id, ok := clientID(c)
+	if !ok {
+		return
+	}
+	h.visits(c, id)
+}
+
+// GetMyVisits — история визитов для самого клиента (без заметок персонала).
+func (h *ClientCardHandler) GetMyVisits(c *gin.Context) {
+	h.visits(c, c.MustGet("userID").(uint))
+}
+
+func (h *ClientCardHandler) visits(c *gin.Context, id uint) {
+	v, err := h.svc.GetVisitHistory(id)
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, v)
+}
+
+func clientID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid client id")})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func clientCardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrClientNotFound), errors.Is(err, service.ErrNoteNotFound):
+		c.JSON(404, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrInvalidClientCard):
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/service"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockClientCards struct {
+	mock.Mock
+}
+
+func (m *MockClientCards) GetCard(userID uint) (*service.ClientCard, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ClientCard), args.Error(1)
+}
+
+func (m *MockClientCards) GetCardForBooking(bookingID string) (*service.ClientCard, error) {
+	args := m.Called(bookingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ClientCard), args.Error(1)
+}
+
+func (m *MockClientCards) SaveProfile(userID uint, in service.ClientProfileInput) (*models.ClientProfile, error) {
+	args := m.Called(userID, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientProfile), args.Error(1)
+}
+
+func (m *MockClientCards) AddNote(userID, authorID uint, text string) (*models.ClientNote, error) {
+	args := m.Called(userID, authorID, text)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientNote), args.Error(1)
+}
+
+func (m *MockClientCards) DeleteNote(userID uint, noteID string) error {
+	return m.Called(userID, noteID).Error(0)
+}
+
+func (m *MockClientCards) GetVisitHistory(userID uint) (*models.VisitHistory, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitHistory), args.Error(1)
+}
+
+func setupClientCards() (*gin.Engine, *MockClientCards) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockClientCards)
+	h := NewClientCardHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)) })
+	r.GET("/clients/:id/card", h.GetCard)
+	r.PUT("/clients/:id/profile", h.SaveProfile)
+	r.POST("/clients/:id/notes", h.AddNote)
+	r.DELETE("/clients/:id/notes/:noteId", h.DeleteNote)
+	r.GET("/clients/:id/visits", h.GetVisits)
+	r.GET("/bookings/:id/client", h.GetBookingCard)
+	r.GET("/users/me/visits", h.GetMyVisits)
+	return r, m
+}
+
+func TestGetClientCardHandler(t *testing.T) {
+	r, m := setupClientCards()
+
+	t.Run("Success", func(t *testing.T) {
+		card := &service.ClientCard{
+			Client:  &models.User{Username: "anna"},
+			Profile: &models.ClientProfile{Allergies: "латекс"},
+			History: &models.VisitHistory{TotalVisits: 2, TotalSpend: money.New(750000, "KZT")},
+		}
+		m.On("GetCard", uint(3)).Return(card, nil).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/clients/3/card", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"allergies":"латекс"`)
+		assert.Contains(t, w.Body.String(), `"total_spend":{"amount":"7500.00","currency":"KZT"}`)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/clients/abc/card", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("From Booking", func(t *testing.T) {
+		m.On("GetCardForBooking", "12").Return(nil, service.ErrClientNotFound).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/bookings/12/client", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSaveClientProfileHandler(t *testing.T) {
+	r, m := setupClientCards()
+	staffID := uint(2)
+	in := service.ClientProfileInput{Allergies: []string{"латекс"}, PreferredStaffID: &staffID}
+
+	m.On("SaveProfile", uint(3), in).Return(&models.ClientProfile{UserID: 3}, nil).Once()
+	body, _ := json.Marshal(in)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/clients/3/profile", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	m.On("SaveProfile", uint(4), mock.Anything).Return(nil, service.ErrInvalidClientCard).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/clients/4/profile", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestClientNotesHandler(t *testing.T) {
+	r, m := setupClientCards()
+
+	m.On("AddNote", uint(3), uint(1), "любит чай").Return(&models.ClientNote{Text: "любит чай"}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/clients/3/notes", bytes.NewBufferString(`{"text":"любит чай"}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/clients/3/notes", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	m.On("DeleteNote", uint(3), "5").Return(service.ErrNoteNotFound).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/clients/3/notes/5", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestVisitsHandler(t *testing.T) {
+	r, m := setupClientCards()
+
+	m.On("GetVisitHistory", uint(1)).Return(&models.VisitHistory{TotalVisits: 1, LastVisit: "2025-04-20 12:00"}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/visits", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"last_visit":"2025-04-20 12:00"`)
+
+	m.On("GetVisitHistory", uint(3)).Return(nil, errors.New("db error")).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/clients/3/visits", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DepositHandler struct {
+	svc service.Deposits
+}
+
+func NewDepositHandler(svc service.Deposits) *DepositHandler {
+	return &DepositHandler{svc: svc}
+}
+
+func (h *DepositHandler) Create(c *gin.Context) {
+	var i service.DepositRuleInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	rule, err := h.svc.CreateRule(i)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDepositRule) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(201, rule)
+}
+
+func (h *DepositHandler) List(c *gin.Context) {
+	rules, err := h.svc.GetRules()
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, rules)
+}
+
+func (h *DepositHandler) Delete(c *gin.Context) {
+	if err := h.svc.DeleteRule(c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrDepositRuleNotFound) {
+			c.JSON(404, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDeposits struct {
+	mock.Mock
+}
+
+func (m *MockDeposits) CreateRule(in service.DepositRuleInput) (*models.DepositRule, error) {
+	args := m.Called(in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DepositRule), args.Error(1)
+}
+
+func (m *MockDeposits) GetRules() ([]models.DepositRule, error) {
+	args := m.Called()
+	return args.Get(0).([]models.DepositRule), args.Error(1)
+}
+
+func (m *MockDeposits) DeleteRule(id string) error { return m.Called(id).Error(0) }
+
+func setupDeposits() (*gin.Engine, *MockDeposits) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockDeposits)
+	h := NewDepositHandler(m)
+	r := gin.New()
+	r.POST("/admin/deposit-rules", h.Create)
+	r.GET("/admin/deposit-rules", h.List)
+	r.DELETE("/admin/deposit-rules/:id", h.Delete)
+	return r, m
+}
+
+func TestCreateDepositRuleHandler(t *testing.T) {
+	r, m := setupDeposits()
+
+	m.On("CreateRule", service.DepositRuleInput{Kind: "percent", Percent: 30, RiskLevel: "high"}).
+		Return(&models.DepositRule{Kind: "percent", Percent: 30, RiskLevel: "high"}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/deposit-rules",
+		bytes.NewBufferString(`{"kind":"percent","percent":30,"risk_level":"high"}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"percent":30`)
+
+	m.On("CreateRule", service.DepositRuleInput{Kind: "fixed"}).Return(nil, service.ErrInvalidDepositRule).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/deposit-rules", bytes.NewBufferString(`{"kind":"fixed"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeleteDepositRuleHandler(t *testing.T) {
+	r, m := setupDeposits()
+	m.On("DeleteRule", "3").Return(nil).Once()
+	m.On("DeleteRule", "4").Return(service.ErrDepositRuleNotFound).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/deposit-rules/3", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/deposit-rules/4", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DiscountHandler struct {
+	svc service.Discounts
+}
+
+func NewDiscountHandler(svc service.Discounts) *DiscountHandler {
+	return &DiscountHandler{svc: svc}
+}
+
+func (h *DiscountHandler) Create(c *gin.Context) {
+	var i service.DiscountRuleInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	rule, err := h.svc.CreateRule(i)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDiscountRule) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(201, rule)
+}
+
+func (h *DiscountHandler) List(c *gin.Context) {
+	rules, err := h.svc.GetRules()
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, rules)
+}
+
+func (h *DiscountHandler) Delete(c *gin.Context) {
+	if err := h.svc.DeleteRule(c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrDiscountRuleNotFound) {
+			c.JSON(404, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
+
+// Quote считает цену услуги со скидками и поясняет, какие правила сработали.
+func (h *DiscountHandler) Quote(c *gin.Context) {
+	var i service.QuoteInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	q, err := h.svc.Quote(i, c.MustGet("userID").(uint))
+	if err != nil {
+		if isPromoError(err) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, q)
+}
+
+func isPromoError(err error) bool {
+	return errors.Is(err, service.ErrInvalidQuote) || errors.Is(err, service.ErrInvalidPromoCode) ||
+		errors.Is(err, service.ErrPromoCodeNotApplicable) || errors.Is(err, service.ErrPromoCodeLimit)
+}
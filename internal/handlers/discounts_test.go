@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/service"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDiscounts struct {
+	mock.Mock
+}
+
+func (m *MockDiscounts) CreateRule(in service.DiscountRuleInput) (*models.DiscountRule, error) {
+	args := m.Called(in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DiscountRule), args.Error(1)
+}
+
+func (m *MockDiscounts) GetRules() ([]models.DiscountRule, error) {
+	args := m.Called()
+	return args.Get(0).([]models.DiscountRule), args.Error(1)
+}
+
+func (m *MockDiscounts) DeleteRule(id string) error { return m.Called(id).Error(0) }
+
+func (m *MockDiscounts) Quote(in service.QuoteInput, userID uint) (*service.Quote, error) {
+	args := m.Called(in, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.Quote), args.Error(1)
+}
+
+func setupDiscounts() (*gin.Engine, *MockDiscounts) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockDiscounts)
+	h := NewDiscountHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(5)); c.Next() })
+	r.POST("/admin/discount-rules", h.Create)
+	r.DELETE("/admin/discount-rules/:id", h.Delete)
+	r.POST("/quote", h.Quote)
+	return r, m
+}
+
+func TestCreateDiscountRuleHandler(t *testing.T) {
+	r, m := setupDiscounts()
+	m.On("CreateRule", service.DiscountRuleInput{Name: "Весна", Code: "SPRING", Kind: "percent", Percent: 10, Weekdays: []int{1, 2}}).
+		Return(&models.DiscountRule{Name: "Весна", Code: "SPRING"}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/discount-rules",
+		bytes.NewBufferString(`{"name":"Весна","code":"SPRING","kind":"percent","percent":10,"weekdays":[1,2]}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	m.On("CreateRule", service.DiscountRuleInput{Kind: "bogus"}).Return(nil, service.ErrInvalidDiscountRule).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/discount-rules", bytes.NewBufferString(`{"kind":"bogus"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	m.On("DeleteRule", "4").Return(service.ErrDiscountRuleNotFound).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/discount-rules/4", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestQuoteHandler(t *testing.T) {
+	r, m := setupDiscounts()
+	price := money.New(500000, "KZT")
+	m.On("Quote", service.QuoteInput{ServiceID: 1, Date: "2026-03-02 10:00", PromoCode: "SPRING"}, uint(5)).
+		Return(&service.Quote{Price: price, Total: price, Discount: money.Zero("KZT"),
+			Discounts: []service.AppliedDiscount{{RuleID: 7, Name: "Весна", Reasons: []string{service.ReasonPromoCode}}}}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/quote",
+		bytes.NewBufferString(`{"service_id":1,"date":"2026-03-02 10:00","promo_code":"SPRING"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"reasons":["promo_code"]`)
+
+	m.On("Quote", service.QuoteInput{ServiceID: 1, PromoCode: "NOPE"}, uint(5)).Return(nil, service.ErrInvalidPromoCode).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/quote", bytes.NewBufferString(`{"service_id":1,"promo_code":"NOPE"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	svc service.DataExports
+}
+
+func NewExportHandler(svc service.DataExports) *ExportHandler {
+	return &ExportHandler{svc: svc}
+}
+
+func (h *ExportHandler) RequestMine(c *gin.Context) {
+	uid := c.MustGet("userID").(uint)
+	h.request(c, uid, uid)
+}
+
+func (h *ExportHandler) RequestForUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid user id")})
+		return
+	}
+	h.request(c, uint(id), c.MustGet("userID").(uint))
+}
+
+func (h *ExportHandler) request(c *gin.Context, userID, requestedBy uint) {
+	e, err := h.svc.RequestExport(userID, requestedBy)
+	if err != nil {
+		c.JSON(404, gin.H{"error": tr(c, "User not found")})
+		return
+	}
+	c.JSON(202, exportView(e))
+}
+
+func (h *ExportHandler) GetMine(c *gin.Context) {
+	e, err := h.svc.GetExport(c.Param("id"))
+	if err != nil || e.UserID != c.MustGet("userID").(uint) {
+		c.JSON(404, gin.H{"error": tr(c, "Export not found")})
+		return
+	}
+	c.JSON(200, exportView(e))
+}
+
+func (h *ExportHandler) Get(c *gin.Context) {
+	e, err := h.svc.GetExport(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": tr(c, "Export not found")})
+		return
+	}
+	c.JSON(200, exportView(e))
+}
+
+// Download отдаёт архив по секретной ссылке, авторизация не требуется. Ссылку
+// можно открывать повторно, пока она не истекла (24 часа после сборки).
+func (h *ExportHandler) Download(c *gin.Context) {
+	data, err := h.svc.DownloadExport(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrExportExpired) {
+			c.JSON(410, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(404, gin.H{"error": tr(c, "Export not found")})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="personal-data.zip"`)
+	c.Data(200, "application/zip", data)
+}
+
+func exportView(e *models.DataExport) gin.H {
+	v := gin.H{"export": e}
+	if e.Status == "ready" && e.Token != "" {
+		v["download_url"] = "/api/v1/exports/download/" + e.Token
+	}
+	return v
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockExports struct {
+	mock.Mock
+}
+
+func (m *MockExports) RequestExport(userID, requestedBy uint) (*models.DataExport, error) {
+	args := m.Called(userID, requestedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+
+func (m *MockExports) GetExport(id string) (*models.DataExport, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+
+func (m *MockExports) DownloadExport(token string) ([]byte, error) {
+	args := m.Called(token)
+	data, _ := args.Get(0).([]byte)
+	return data, args.Error(1)
+}
+
+func setupExports() (*gin.Engine, *MockExports) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockExports)
+	h := NewExportHandler(m)
+	r := gin.New()
+	r.GET("/exports/download/:token", h.Download)
+	authed := r.Group("/")
+	authed.Use(func(c *gin.Context) { c.Set("userID", uint(1)) })
+	authed.GET("/users/me/export", h.RequestMine)
+	authed.GET("/users/me/exports/:id", h.GetMine)
+	authed.POST("/admin/users/:id/export", h.RequestForUser)
+	authed.GET("/admin/exports/:id", h.Get)
+	return r, m
+}
+
+func TestRequestExportHandler(t *testing.T) {
+	r, m := setupExports()
+
+	t.Run("Self", func(t *testing.T) {
+		m.On("RequestExport", uint(1), uint(1)).Return(&models.DataExport{UserID: 1, Status: "pending"}, nil).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/export", nil))
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("Admin For User", func(t *testing.T) {
+		m.On("RequestExport", uint(5), uint(1)).Return(&models.DataExport{UserID: 5, RequestedBy: 1}, nil).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/users/5/export", nil))
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("Unknown User", func(t *testing.T) {
+		m.On("RequestExport", uint(9), uint(1)).Return(nil, errors.New("record not found")).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/users/9/export", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGetExportHandler(t *testing.T) {
+	r, m := setupExports()
+
+	t.Run("Ready Has Download URL", func(t *testing.T) {
+		m.On("GetExport", "2").Return(&models.DataExport{UserID: 1, Status: "ready", Token: "tok"}, nil).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/exports/2", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, "/api/v1/exports/download/tok", resp["download_url"])
+		assert.NotContains(t, w.Body.String(), `"token"`)
+	})
+
+	t.Run("Pending Has No URL", func(t *testing.T) {
+		m.On("GetExport", "3").Return(&models.DataExport{UserID: 1, Status: "pending"}, nil).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/exports/3", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "download_url")
+	})
+
+	t.Run("Foreign Export Hidden", func(t *testing.T) {
+		m.On("GetExport", "4").Return(&models.DataExport{UserID: 2, Status: "ready", Token: "tok"}, nil).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/exports/4", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Admin Sees Any", func(t *testing.T) {
+		m.On("GetExport", "4").Return(&models.DataExport{UserID: 2, Status: "ready", Token: "tok"}, nil).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/exports/4", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestDownloadExportHandler(t *testing.T) {
+	r, m := setupExports()
+
+	t.Run("Success", func(t *testing.T) {
+		m.On("DownloadExport", "good").Return([]byte("PK"), nil).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/exports/download/good", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+		assert.Equal(t, "PK", w.Body.String())
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		m.On("DownloadExport", "old").Return(nil, service.ErrExportExpired).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/exports/download/old", nil))
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		m.On("DownloadExport", "nope").Return(nil, service.ErrExportNotFound).Once()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/exports/download/nope", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FiscalHandler struct {
+	svc service.Fiscal
+}
+
+func NewFiscalHandler(svc service.Fiscal) *FiscalHandler {
+	return &FiscalHandler{svc: svc}
+}
+
+// Receipt отдаёт чек записи в формате фискального регистратора.
+func (h *FiscalHandler) Receipt(c *gin.Context) {
+	r, err := h.svc.GetFiscalReceipt(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrReceiptNotFound) {
+			c.JSON(404, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, r)
+}
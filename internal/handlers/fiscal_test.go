@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"beauty-salon/internal/fiscal"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFiscal struct {
+	mock.Mock
+}
+
+func (m *MockFiscal) GetFiscalReceipt(bookingID string) (*fiscal.Receipt, error) {
+	args := m.Called(bookingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*fiscal.Receipt), args.Error(1)
+}
+
+func TestFiscalReceiptHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockFiscal)
+	r := gin.New()
+	r.GET("/admin/bookings/:id/receipt/fiscal", NewFiscalHandler(m).Receipt)
+
+	m.On("GetFiscalReceipt", "1").Return(&fiscal.Receipt{Number: "7", Tax: money.New(48219, "KZT")}, nil).Once()
+	m.On("GetFiscalReceipt", "2").Return(nil, service.ErrReceiptNotFound).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/bookings/1/receipt/fiscal", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"tax":{"amount":"482.19","currency":"KZT"}`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/bookings/2/receipt/fiscal", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
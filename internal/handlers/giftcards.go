@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GiftCardHandler struct {
+	svc service.GiftCards
+}
+
+func NewGiftCardHandler(svc service.GiftCards) *GiftCardHandler {
+	return &GiftCardHandler{svc: svc}
+}
+
+// Issue выпускает карту без продажи — например, в подарок от салона.
+func (h *GiftCardHandler) Issue(c *gin.Context) {
+	var i service.GiftCardInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	card, err := h.svc.Issue(i, c.MustGet("userID").(uint))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidGiftCard) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(201, card)
+}
+
+// Balance — публичная проверка остатка по коду карты.
+func (h *GiftCardHandler) Balance(c *gin.Context) {
+	b, err := h.svc.Balance(c.Param("code"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, b)
+}
+
+// Ledger отдаёт журнал операций по карте для сверки.
+func (h *GiftCardHandler) Ledger(c *gin.Context) {
+	entries, err := h.svc.Ledger(c.Param("code"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, entries)
+}
+
+func (h *GiftCardHandler) fail(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrGiftCardNotFound) {
+		c.JSON(404, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	c.JSON(500, gin.H{"error": tr(c, "Failed")})
+}
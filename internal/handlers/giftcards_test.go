@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/service"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockGiftCards struct {
+	mock.Mock
+}
+
+func (m *MockGiftCards) Issue(in service.GiftCardInput, by uint) (*models.GiftCard, error) {
+	args := m.Called(in, by)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GiftCard), args.Error(1)
+}
+
+func (m *MockGiftCards) Balance(code string) (*service.GiftCardBalance, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.GiftCardBalance), args.Error(1)
+}
+
+func (m *MockGiftCards) Ledger(code string) ([]models.GiftCardEntry, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.GiftCardEntry), args.Error(1)
+}
+
+func setupGiftCards() (*gin.Engine, *MockGiftCards) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockGiftCards)
+	h := NewGiftCardHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)); c.Next() })
+	r.POST("/admin/gift-cards", h.Issue)
+	r.GET("/admin/gift-cards/:code/ledger", h.Ledger)
+	r.GET("/gift-cards/:code", h.Balance)
+	return r, m
+}
+
+func TestIssueGiftCardHandler(t *testing.T) {
+	r, m := setupGiftCards()
+	m.On("Issue", service.GiftCardInput{Amount: money.New(1000000, "KZT")}, uint(1)).
+		Return(&models.GiftCard{Code: "ABCD", Balance: money.New(1000000, "KZT")}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/gift-cards", bytes.NewBufferString(`{"amount":"10000"}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"ABCD"`)
+
+	m.On("Issue", service.GiftCardInput{Amount: money.New(0, "KZT")}, uint(1)).Return(nil, service.ErrInvalidGiftCard).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/gift-cards", bytes.NewBufferString(`{"amount":"0"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGiftCardBalanceHandler(t *testing.T) {
+	r, m := setupGiftCards()
+	m.On("Balance", "ABCD").Return(&service.GiftCardBalance{Code: "ABCD", Balance: money.New(250000, "KZT")}, nil).Once()
+	m.On("Balance", "NONE").Return(nil, service.ErrGiftCardNotFound).Once()
+	m.On("Ledger", "ABCD").Return([]models.GiftCardEntry{{Kind: models.GiftCardIssue}}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/gift-cards/ABCD", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"amount":"2500.00"`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/gift-cards/NONE", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/gift-cards/ABCD/ledger", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"kind":"issue"`)
+}
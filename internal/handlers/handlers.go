@@ -3,6 +3,8 @@ package handlers
 import (
 	"beauty-salon/internal/models"
 	"beauty-salon/internal/service"
+	"errors"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,42 +20,108 @@ func NewHandler(svc service.Service) *Handler {
 func (h *Handler) Register(c *gin.Context) {
 	var i struct{ Username, Password string }
 	if err := c.ShouldBindJSON(&i); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
 		return
 	}
 	if err := h.svc.Register(i.Username, i.Password); err != nil {
-		c.JSON(500, gin.H{"error": "Failed"})
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
 		return
 	}
-	c.JSON(201, gin.H{"message": "Registered"})
+	c.JSON(201, gin.H{"message": tr(c, "Registered")})
 }
 
 func (h *Handler) Login(c *gin.Context) {
 	var i struct{ Username, Password string }
 	if err := c.ShouldBindJSON(&i); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input"})
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
 		return
 	}
 	t, err := h.svc.Login(i.Username, i.Password)
 	if err != nil {
-		c.JSON(401, gin.H{"error": err.Error()})
+		c.JSON(401, gin.H{"error": tr(c, err.Error())})
 		return
 	}
 	c.JSON(200, gin.H{"token": t})
 }
 
-func (h *Handler) Logout(c *gin.Context) { c.JSON(200, gin.H{"message": "Logged out"}) }
+func (h *Handler) Logout(c *gin.Context) { c.JSON(200, gin.H{"message": tr(c, "Logged out")}) }
 
 // Users
 func (h *Handler) GetMe(c *gin.Context) {
 	u, err := h.svc.GetUserByID(c.MustGet("userID").(uint))
 	if err != nil {
-		c.JSON(404, gin.H{"error": "User not found"})
+		c.JSON(404, gin.H{"error": tr(c, "User not found")})
+		return
+	}
+	c.JSON(200, u)
+}
+
+func (h *Handler) PatchMe(c *gin.Context) {
+	var p service.ProfileUpdate
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	u, err := h.svc.UpdateProfile(c.MustGet("userID").(uint), p)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidProfile):
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+		case errors.Is(err, service.ErrProfileConflict):
+			c.JSON(409, gin.H{"error": tr(c, err.Error())})
+		default:
+			c.JSON(500, gin.H{"error": tr(c, "Update failed")})
+		}
 		return
 	}
 	c.JSON(200, u)
 }
 
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var i struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	if err := h.svc.ChangePassword(c.MustGet("userID").(uint), i.CurrentPassword, i.NewPassword, c.GetTime("authTime")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidProfile):
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+		case errors.Is(err, service.ErrReauthRequired):
+			c.JSON(401, gin.H{"error": tr(c, err.Error())})
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(403, gin.H{"error": tr(c, err.Error())})
+		default:
+			c.JSON(500, gin.H{"error": tr(c, "Update failed")})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"message": tr(c, "Password changed")})
+}
+
+func (h *Handler) DeleteMe(c *gin.Context) {
+	var i struct {
+		Password string `json:"password"`
+	}
+	// Тело необязательно: у пользователей без пароля его нет.
+	_ = c.ShouldBindJSON(&i)
+	if err := h.svc.DeleteAccount(c.MustGet("userID").(uint), i.Password, c.GetTime("authTime")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrReauthRequired):
+			c.JSON(401, gin.H{"error": tr(c, err.Error())})
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(403, gin.H{"error": tr(c, err.Error())})
+		default:
+			c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		}
+		return
+	}
+	c.Status(204)
+}
+
 func (h *Handler) GetAllUsers(c *gin.Context) {
 	u, _ := h.svc.GetAllUsers()
 	c.JSON(200, u)
@@ -61,7 +129,7 @@ func (h *Handler) GetAllUsers(c *gin.Context) {
 
 func (h *Handler) DeleteUser(c *gin.Context) {
 	if err := h.svc.DeleteUser(c.Param("id")); err != nil {
-		c.JSON(500, gin.H{"error": "Failed"})
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
 		return
 	}
 	c.Status(204)
@@ -71,11 +139,11 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 func (h *Handler) AddService(c *gin.Context) {
 	var s models.Service
 	if err := c.ShouldBindJSON(&s); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
 		return
 	}
 	if err := h.svc.AddService(&s); err != nil {
-		c.JSON(500, gin.H{"error": "Failed"})
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
 		return
 	}
 	c.JSON(201, s)
@@ -83,16 +151,19 @@ func (h *Handler) AddService(c *gin.Context) {
 
 func (h *Handler) GetServices(c *gin.Context) {
 	s, _ := h.svc.GetServices()
+	for i := range s {
+		s[i] = s[i].Localized(c.GetString("locale"))
+	}
 	c.JSON(200, s)
 }
 
 func (h *Handler) GetServiceByID(c *gin.Context) {
 	s, err := h.svc.GetService(c.Param("id"))
 	if err != nil {
-		c.JSON(404, gin.H{"error": "Service not found"})
+		c.JSON(404, gin.H{"error": tr(c, "Service not found")})
 		return
 	}
-	c.JSON(200, s)
+	c.JSON(200, s.Localized(c.GetString("locale")))
 }
 
 func (h *Handler) DeleteService(c *gin.Context) {
@@ -104,7 +175,7 @@ func (h *Handler) DeleteService(c *gin.Context) {
 func (h *Handler) AddStaff(c *gin.Context) {
 	var s models.Staff
 	if err := c.ShouldBindJSON(&s); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
 		return
 	}
 	h.svc.AddStaff(&s)
@@ -119,7 +190,7 @@ func (h *Handler) GetStaff(c *gin.Context) {
 func (h *Handler) GetStaffByID(c *gin.Context) {
 	s, err := h.svc.GetStaff(c.Param("id"))
 	if err != nil {
-		c.JSON(404, gin.H{"error": "Staff not found"})
+		c.JSON(404, gin.H{"error": tr(c, "Staff not found")})
 		return
 	}
 	c.JSON(200, s)
@@ -130,16 +201,54 @@ func (h *Handler) DeleteStaff(c *gin.Context) {
 	c.Status(204)
 }
 
+// Slots
+func (h *Handler) GetSlots(c *gin.Context) {
+	var q struct {
+		ServiceID uint   `form:"service_id" binding:"required"`
+		StaffID   uint   `form:"staff_id"`
+		Date      string `form:"date" binding:"required"` // YYYY-MM-DD
+	}
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	slots, err := h.svc.GetAvailableSlots(q.ServiceID, q.StaffID, q.Date)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDate) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(404, gin.H{"error": tr(c, "Service or staff not found")})
+		return
+	}
+	c.JSON(200, slots)
+}
+
 // Bookings
 func (h *Handler) CreateBooking(c *gin.Context) {
 	var b models.Booking
 	if err := c.ShouldBindJSON(&b); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	if id, ok := c.Get("userID"); ok {
+		b.UserID = id.(uint)
+	} else if b.UserID == 0 {
+		// Запрос по API-ключу (киоск): клиент указывается явно.
+		c.JSON(400, gin.H{"error": tr(c, "user_id is required")})
 		return
 	}
-	b.UserID = c.MustGet("userID").(uint)
 	if err := h.svc.CreateBooking(&b); err != nil {
-		c.JSON(500, gin.H{"error": "Failed"})
+		if isPromoError(err) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		if errors.Is(err, service.ErrPackageExhausted) || errors.Is(err, service.ErrMembershipLimit) ||
+			errors.Is(err, service.ErrSlotUnavailable) {
+			c.JSON(409, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
 		return
 	}
 	c.JSON(201, b)
@@ -147,30 +256,43 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 
 func (h *Handler) GetBookings(c *gin.Context) {
 	b, _ := h.svc.GetBookings()
+	for i := range b {
+		b[i].Service = b[i].Service.Localized(c.GetString("locale"))
+	}
 	c.JSON(200, b)
 }
 
 func (h *Handler) GetBookingByID(c *gin.Context) {
 	b, err := h.svc.GetBooking(c.Param("id"))
 	if err != nil {
-		c.JSON(404, gin.H{"error": "Booking not found"})
+		c.JSON(404, gin.H{"error": tr(c, "Booking not found")})
 		return
 	}
+	b.Service = b.Service.Localized(c.GetString("locale"))
 	c.JSON(200, b)
 }
 
 func (h *Handler) PatchBooking(c *gin.Context) {
-	var u map[string]interface{}
-	if err := c.ShouldBindJSON(&u); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input"})
+	var in service.BookingPatch
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
 		return
 	}
-	b, err := h.svc.UpdateBooking(c.Param("id"), u)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Update failed"})
-		return
+	b, err := h.svc.PatchBooking(c.Param("id"), in, c.GetUint("userID"))
+	switch {
+	case err == nil:
+		c.JSON(200, b)
+	case errors.Is(err, service.ErrBookingNotFound):
+		c.JSON(404, gin.H{"error": tr(c, "Booking not found")})
+	case errors.Is(err, service.ErrBookingForbidden):
+		c.JSON(403, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrInvalidDate), errors.Is(err, service.ErrInvalidBookingUpdate):
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrSlotUnavailable):
+		c.JSON(409, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(500, gin.H{"error": tr(c, "Update failed")})
 	}
-	c.JSON(200, b)
 }
 
 func (h *Handler) DeleteBooking(c *gin.Context) {
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"beauty-salon/internal/middleware"
 	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -39,6 +42,19 @@ func (m *MockService) GetAllUsers() ([]models.User, error) {
 	args := m.Called()
 	return args.Get(0).([]models.User), args.Error(1)
 }
+func (m *MockService) UpdateProfile(id uint, p service.ProfileUpdate) (*models.User, error) {
+	args := m.Called(id, p)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockService) ChangePassword(id uint, current, next string, authTime time.Time) error {
+	return m.Called(id, current, next, authTime).Error(0)
+}
+func (m *MockService) DeleteAccount(id uint, password string, authTime time.Time) error {
+	return m.Called(id, password, authTime).Error(0)
+}
 func (m *MockService) DeleteUser(id string) error { return m.Called(id).Error(0) }
 
 func (m *MockService) AddService(s *models.Service) error { return m.Called(s).Error(0) }
@@ -90,8 +106,8 @@ func (m *MockService) GetBooking(id string) (*models.Booking, error) {
 	return args.Get(0).(*models.Booking), args.Error(1)
 }
 
-func (m *MockService) UpdateBooking(id string, u map[string]interface{}) (*models.Booking, error) {
-	args := m.Called(id, u)
+func (m *MockService) PatchBooking(id string, in service.BookingPatch, userID uint) (*models.Booking, error) {
+	args := m.Called(id, in, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -100,6 +116,28 @@ func (m *MockService) UpdateBooking(id string, u map[string]interface{}) (*model
 
 func (m *MockService) CancelBooking(id string) error { return m.Called(id).Error(0) }
 
+func (m *MockService) GetAvailableSlots(serviceID, staffID uint, day string) ([]service.Slot, error) {
+	args := m.Called(serviceID, staffID, day)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.Slot), args.Error(1)
+}
+func (m *MockService) BookSlot(userID, serviceID, staffID uint, date string) (*models.Booking, error) {
+	args := m.Called(userID, serviceID, staffID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+func (m *MockService) GetUserUpcomingBookings(userID uint) ([]models.Booking, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
+func (m *MockService) CancelUserBooking(userID uint, id string) error {
+	return m.Called(userID, id).Error(0)
+}
+
 func setup() (*gin.Engine, *MockService, *Handler) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := new(MockService)
@@ -229,6 +267,132 @@ func TestGetMe(t *testing.T) {
 	})
 }
 
+func TestPatchMe(t *testing.T) {
+	r, mockSvc, h := setup()
+	r.PATCH("/users/me", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		h.PatchMe(c)
+	})
+
+	cases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"Success", nil, 200},
+		{"Invalid", service.ErrInvalidProfile, 400},
+		{"Conflict", service.ErrProfileConflict, 409},
+		{"Failure", errors.New("db error"), 500},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			name := "Anna"
+			var ret interface{}
+			if tc.err == nil {
+				ret = &models.User{FullName: name}
+			}
+			mockSvc.On("UpdateProfile", uint(1), service.ProfileUpdate{FullName: &name}).Return(ret, tc.err).Once()
+
+			req, _ := http.NewRequest("PATCH", "/users/me", bytes.NewBufferString(`{"full_name":"Anna"}`))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	r, mockSvc, h := setup()
+	authTime := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	r.POST("/users/me/password", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		c.Set("authTime", authTime)
+		h.ChangePassword(c)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockSvc.On("ChangePassword", uint(1), "old", "new-password", authTime).Return(nil).Once()
+		body, _ := json.Marshal(map[string]string{"current_password": "old", "new_password": "new-password"})
+		req, _ := http.NewRequest("POST", "/users/me/password", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+	})
+
+	t.Run("Wrong Current Password", func(t *testing.T) {
+		mockSvc.On("ChangePassword", uint(1), "bad", "new-password", authTime).Return(service.ErrInvalidCredentials).Once()
+		body, _ := json.Marshal(map[string]string{"current_password": "bad", "new_password": "new-password"})
+		req, _ := http.NewRequest("POST", "/users/me/password", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 403, w.Code)
+	})
+
+	t.Run("Stale Login", func(t *testing.T) {
+		mockSvc.On("ChangePassword", uint(1), "", "new-password", authTime).Return(service.ErrReauthRequired).Once()
+		req, _ := http.NewRequest("POST", "/users/me/password", bytes.NewBufferString(`{"new_password":"new-password"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 401, w.Code)
+	})
+
+	t.Run("Storage Error", func(t *testing.T) {
+		mockSvc.On("ChangePassword", uint(1), "old", "other-password", authTime).Return(errors.New("db down")).Once()
+		body, _ := json.Marshal(map[string]string{"current_password": "old", "new_password": "other-password"})
+		req, _ := http.NewRequest("POST", "/users/me/password", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 500, w.Code)
+	})
+
+	t.Run("Missing New Password", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/users/me/password", bytes.NewBufferString(`{"current_password":"old"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+	})
+}
+
+func TestDeleteMe(t *testing.T) {
+	r, mockSvc, h := setup()
+	r.DELETE("/users/me", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		h.DeleteMe(c)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockSvc.On("DeleteAccount", uint(1), "secret", time.Time{}).Return(nil).Once()
+		req, _ := http.NewRequest("DELETE", "/users/me", bytes.NewBufferString(`{"password":"secret"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 204, w.Code)
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		mockSvc.On("DeleteAccount", uint(1), "", time.Time{}).Return(service.ErrInvalidCredentials).Once()
+		req, _ := http.NewRequest("DELETE", "/users/me", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 403, w.Code)
+	})
+
+	t.Run("Stale Login", func(t *testing.T) {
+		mockSvc.On("DeleteAccount", uint(1), "", time.Time{}).Return(service.ErrReauthRequired).Once()
+		req, _ := http.NewRequest("DELETE", "/users/me", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 401, w.Code)
+	})
+
+	t.Run("Storage Error", func(t *testing.T) {
+		mockSvc.On("DeleteAccount", uint(1), "", time.Time{}).Return(errors.New("db error")).Once()
+		req, _ := http.NewRequest("DELETE", "/users/me", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 500, w.Code)
+	})
+}
+
 func TestGetAllUsers(t *testing.T) {
 	r, mockSvc, h := setup()
 	r.GET("/users", h.GetAllUsers)
@@ -336,6 +500,65 @@ func TestGetServiceByID(t *testing.T) {
 	})
 }
 
+func TestLocalizedService(t *testing.T) {
+	r, mockSvc, h := setup()
+	r.Use(middleware.Locale(nil))
+	r.GET("/services/:id", h.GetServiceByID)
+
+	srv := &models.Service{Title: "Стрижка", Description: "Женская стрижка",
+		Translations: map[string]models.ServiceText{"en": {Title: "Haircut"}, "kk": {Title: "Шаш қию", Description: "Әйелдер шаш үлгісі"}}}
+	mockSvc.On("GetService", "1").Return(srv, nil)
+	mockSvc.On("GetService", "99").Return(nil, errors.New("not found"))
+
+	for _, tc := range []struct{ lang, title, description string }{
+		{"", "Стрижка", "Женская стрижка"},
+		{"en-GB,en;q=0.9", "Haircut", "Женская стрижка"},
+		{"kk", "Шаш қию", "Әйелдер шаш үлгісі"},
+	} {
+		req, _ := http.NewRequest("GET", "/services/1", nil)
+		req.Header.Set("Accept-Language", tc.lang)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var got models.Service
+		json.Unmarshal(w.Body.Bytes(), &got)
+		assert.Equal(t, tc.title, got.Title, tc.lang)
+		assert.Equal(t, tc.description, got.Description, tc.lang)
+	}
+
+	req, _ := http.NewRequest("GET", "/services/99", nil)
+	req.Header.Set("Accept-Language", "kk")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+	assert.Contains(t, w.Body.String(), "Қызмет табылмады")
+}
+
+func TestGetSlots(t *testing.T) {
+	r, mockSvc, h := setup()
+	r.GET("/slots", h.GetSlots)
+
+	mockSvc.On("GetAvailableSlots", uint(1), uint(0), "2025-05-02").
+		Return([]service.Slot{{StaffID: 2, StaffName: "Анна", Date: "2025-05-02 10:00"}}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/slots?service_id=1&date=2025-05-02", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "2025-05-02 10:00")
+
+	mockSvc.On("GetAvailableSlots", uint(1), uint(0), "tomorrow").Return(nil, service.ErrInvalidDate).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/slots?service_id=1&date=tomorrow", nil))
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/slots?date=2025-05-02", nil))
+	assert.Equal(t, 400, w.Code)
+
+	mockSvc.On("GetAvailableSlots", uint(9), uint(0), "2025-05-02").Return(nil, errors.New("record not found")).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/slots?service_id=9&date=2025-05-02", nil))
+	assert.Equal(t, 404, w.Code)
+}
+
 func TestDeleteService(t *testing.T) {
 	r, mockSvc, h := setup()
 	r.DELETE("/services/:id", h.DeleteService)
@@ -458,6 +681,43 @@ func TestCreateBooking(t *testing.T) {
 		assert.Equal(t, 500, w.Code)
 		assert.Contains(t, w.Body.String(), "Failed")
 	})
+
+	t.Run("Promo Code Does Not Apply", func(t *testing.T) {
+		mockSvc.On("CreateBooking", mock.Anything).Return(service.ErrPromoCodeNotApplicable).Once()
+
+		body, _ := json.Marshal(models.Booking{ServiceID: 1, StaffID: 1, PromoCode: "HELLO"})
+		req, _ := http.NewRequest("POST", "/bookings", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 400, w.Code)
+	})
+}
+
+func TestCreateBookingWithAPIKey(t *testing.T) {
+	r, mockSvc, h := setup()
+	r.POST("/bookings", h.CreateBooking) // без userID, как при входе по API-ключу
+
+	t.Run("User From Body", func(t *testing.T) {
+		mockSvc.On("CreateBooking", mock.MatchedBy(func(b *models.Booking) bool {
+			return b.UserID == 5
+		})).Return(nil).Once()
+
+		body, _ := json.Marshal(models.Booking{UserID: 5, ServiceID: 1, StaffID: 1})
+		req, _ := http.NewRequest("POST", "/bookings", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 201, w.Code)
+	})
+
+	t.Run("Missing User", func(t *testing.T) {
+		body, _ := json.Marshal(models.Booking{ServiceID: 1, StaffID: 1})
+		req, _ := http.NewRequest("POST", "/bookings", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+		assert.Contains(t, w.Body.String(), "user_id is required")
+	})
 }
 
 func TestGetBookings(t *testing.T) {
@@ -499,12 +759,13 @@ func TestGetBookingByID(t *testing.T) {
 
 func TestPatchBooking(t *testing.T) {
 	r, mockSvc, h := setup()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(7)) })
 	r.PATCH("/bookings/:id", h.PatchBooking)
 
 	t.Run("Success", func(t *testing.T) {
-		mockSvc.On("UpdateBooking", "1", mock.MatchedBy(func(u map[string]interface{}) bool {
-			return u["status"] == "confirmed"
-		})).Return(&models.Booking{Status: "confirmed"}, nil).Once()
+		mockSvc.On("PatchBooking", "1", mock.MatchedBy(func(in service.BookingPatch) bool {
+			return in.Status != nil && *in.Status == "confirmed"
+		}), uint(7)).Return(&models.Booking{Status: "confirmed"}, nil).Once()
 
 		body, _ := json.Marshal(map[string]interface{}{"status": "confirmed"})
 		req, _ := http.NewRequest("PATCH", "/bookings/1", bytes.NewBuffer(body))
@@ -524,8 +785,30 @@ func TestPatchBooking(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "Invalid input")
 	})
 
+	t.Run("Client Changes Status (403)", func(t *testing.T) {
+		mockSvc.On("PatchBooking", "2", mock.Anything, uint(7)).Return(nil, service.ErrBookingForbidden).Once()
+
+		body, _ := json.Marshal(map[string]interface{}{"status": "completed"})
+		req, _ := http.NewRequest("PATCH", "/bookings/2", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 403, w.Code)
+	})
+
+	t.Run("Slot Taken (409)", func(t *testing.T) {
+		mockSvc.On("PatchBooking", "3", mock.Anything, uint(7)).Return(nil, service.ErrSlotUnavailable).Once()
+
+		body, _ := json.Marshal(map[string]interface{}{"date": "2026-01-20 11:00"})
+		req, _ := http.NewRequest("PATCH", "/bookings/3", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 409, w.Code)
+	})
+
 	t.Run("Update Failed (500)", func(t *testing.T) {
-		mockSvc.On("UpdateBooking", "99", mock.Anything).Return(nil, errors.New("db error")).Once()
+		mockSvc.On("PatchBooking", "99", mock.Anything, uint(7)).Return(nil, errors.New("db error")).Once()
 
 		body, _ := json.Marshal(map[string]interface{}{"status": "cancelled"})
 		req, _ := http.NewRequest("PATCH", "/bookings/99", bytes.NewBuffer(body))
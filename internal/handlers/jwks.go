@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"beauty-salon/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+type KeySetProvider interface {
+	JWKS() auth.JWKSet
+}
+
+// JWKS отдаёт публичные ключи подписи, чтобы другие сервисы могли
+// проверять наши токены без общего секрета.
+func JWKS(keys KeySetProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(200, keys.JWKS())
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"beauty-salon/internal/auth"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := auth.NewKey("k1", priv)
+	tokens, _ := auth.NewTokenManager("", time.Hour, key)
+
+	r := gin.New()
+	r.GET("/.well-known/jwks.json", JWKS(tokens))
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var set auth.JWKSet
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	assert.Len(t, set.Keys, 1)
+	assert.Equal(t, "k1", set.Keys[0].Kid)
+	assert.Equal(t, "OKP", set.Keys[0].Kty)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LiveFeedHandler struct {
+	feed      service.LiveFeeds
+	heartbeat time.Duration
+}
+
+func NewLiveFeedHandler(feed service.LiveFeeds) *LiveFeedHandler {
+	return &LiveFeedHandler{feed: feed, heartbeat: 25 * time.Second}
+}
+
+// Ticket выдаёт одноразовый билет для подключения к Stream: /bookings/live?ticket=...
+func (h *LiveFeedHandler) Ticket(c *gin.Context) {
+	t, err := h.feed.IssueTicket(c.Request.Context(), c.MustGet("userID").(uint))
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(201, gin.H{"ticket": t, "expires_in": int(service.StreamTicketTTL.Seconds())})
+}
+
+// Stream отдаёт изменения записей как Server-Sent Events. Комментарий-пинг
+// не даёт прокси закрыть простаивающее соединение.
+func (h *LiveFeedHandler) Stream(c *gin.Context) {
+	var q struct {
+		StaffID uint `form:"staff_id"`
+	}
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	feed, cancel := h.feed.Subscribe(service.FeedFilter{StaffID: q.StaffID})
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // nginx не должен буферизовать поток
+	c.Status(200)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-feed:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Kind, data)
+		case <-ticker.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
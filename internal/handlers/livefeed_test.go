@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeFeed struct {
+	filter service.FeedFilter
+	ch     chan service.FeedEvent
+}
+
+func (f *fakeFeed) Subscribe(filter service.FeedFilter) (<-chan service.FeedEvent, func()) {
+	f.filter = filter
+	return f.ch, func() {}
+}
+
+func (f *fakeFeed) IssueTicket(_ context.Context, userID uint) (string, error) {
+	return fmt.Sprintf("ticket-%d", userID), nil
+}
+
+func (f *fakeFeed) RedeemTicket(_ context.Context, ticket string) (uint, error) {
+	return 0, service.ErrInvalidStreamTicket
+}
+
+func TestLiveFeedTicket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(4)) })
+	r.POST("/bookings/live/ticket", NewLiveFeedHandler(&fakeFeed{}).Ticket)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/bookings/live/ticket", nil))
+	assert.Equal(t, 201, w.Code)
+	assert.JSONEq(t, `{"ticket":"ticket-4","expires_in":30}`, w.Body.String())
+}
+
+func TestLiveFeedStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := &fakeFeed{ch: make(chan service.FeedEvent, 2)}
+	h := NewLiveFeedHandler(feed)
+	h.heartbeat = time.Hour
+	r := gin.New()
+	r.GET("/bookings/live", h.Stream)
+
+	feed.ch <- service.FeedEvent{ID: "ev-1", Kind: service.FeedCreated, BookingID: 12, StaffID: 3}
+	feed.ch <- service.FeedEvent{ID: "ev-2", Kind: service.FeedCancelled, BookingID: 12, StaffID: 3}
+	close(feed.ch)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/bookings/live?staff_id=3", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, uint(3), feed.filter.StaffID)
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "retry: 3000\n\n"))
+	assert.Contains(t, body, "id: ev-1\nevent: created\ndata: {\"id\":\"ev-1\"")
+	assert.Contains(t, body, "id: ev-2\nevent: cancelled\n")
+	assert.Contains(t, body, `"booking_id":12`)
+}
+
+func TestLiveFeedBadFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/bookings/live", NewLiveFeedHandler(&fakeFeed{}).Stream)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/bookings/live?staff_id=olga", nil))
+	assert.Equal(t, 400, w.Code)
+}
@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"beauty-salon/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tr переводит сообщение на язык запроса, выбранный middleware.Locale.
+func tr(c *gin.Context, msg string) string {
+	return i18n.T(c.GetString("locale"), msg)
+}
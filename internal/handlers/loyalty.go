@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoyaltyHandler struct {
+	svc service.Loyalty
+}
+
+func NewLoyaltyHandler(svc service.Loyalty) *LoyaltyHandler {
+	return &LoyaltyHandler{svc: svc}
+}
+
+// Mine отдаёт баланс, уровень и историю баллов текущего пользователя.
+func (h *LoyaltyHandler) Mine(c *gin.Context) {
+	sum, err := h.svc.Summary(c.MustGet("userID").(uint))
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, sum)
+}
+
+// Bonus начисляет клиенту бонусные баллы.
+func (h *LoyaltyHandler) Bonus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid user id")})
+		return
+	}
+	var i service.LoyaltyBonusInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	e, err := h.svc.AwardBonus(uint(id), i, c.MustGet("userID").(uint))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLoyaltyBonus) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(201, e)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLoyalty struct {
+	mock.Mock
+}
+
+func (m *MockLoyalty) Summary(userID uint) (*service.LoyaltySummary, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.LoyaltySummary), args.Error(1)
+}
+
+func (m *MockLoyalty) AwardBonus(userID uint, in service.LoyaltyBonusInput, by uint) (*models.LoyaltyEntry, error) {
+	args := m.Called(userID, in, by)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LoyaltyEntry), args.Error(1)
+}
+
+func setupLoyalty() (*gin.Engine, *MockLoyalty) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockLoyalty)
+	h := NewLoyaltyHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)); c.Next() })
+	r.GET("/users/me/loyalty", h.Mine)
+	r.POST("/admin/users/:id/loyalty/bonus", h.Bonus)
+	return r, m
+}
+
+func TestMyLoyaltyHandler(t *testing.T) {
+	r, m := setupLoyalty()
+	m.On("Summary", uint(1)).Return(&service.LoyaltySummary{Balance: 640, Tier: "silver",
+		History: []models.LoyaltyEntry{{Kind: models.LoyaltyEarn, Points: 72}}}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/loyalty", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"balance":640`)
+	assert.Contains(t, w.Body.String(), `"tier":"silver"`)
+}
+
+func TestLoyaltyBonusHandler(t *testing.T) {
+	r, m := setupLoyalty()
+	m.On("AwardBonus", uint(4), service.LoyaltyBonusInput{Reason: "review"}, uint(1)).
+		Return(&models.LoyaltyEntry{Kind: models.LoyaltyBonus, Points: 50}, nil).Once()
+	m.On("AwardBonus", uint(4), service.LoyaltyBonusInput{Reason: "birthday"}, uint(1)).
+		Return(nil, service.ErrInvalidLoyaltyBonus).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/users/4/loyalty/bonus", bytes.NewBufferString(`{"reason":"review"}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/users/4/loyalty/bonus", bytes.NewBufferString(`{"reason":"birthday"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/users/x/loyalty/bonus", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MembershipHandler struct {
+	svc service.Memberships
+}
+
+func NewMembershipHandler(svc service.Memberships) *MembershipHandler {
+	return &MembershipHandler{svc: svc}
+}
+
+func (h *MembershipHandler) CreatePlan(c *gin.Context) {
+	var i service.MembershipPlanInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	p, err := h.svc.CreatePlan(i)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(201, p)
+}
+
+func (h *MembershipHandler) ListPlans(c *gin.Context) {
+	list, err := h.svc.GetPlans()
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, list)
+}
+
+func (h *MembershipHandler) DeletePlan(c *gin.Context) {
+	if err := h.svc.DeletePlan(c.Param("id")); err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// Subscribe оформляет абонемент и возвращает счёт за первый период со
+// ссылкой на оплату.
+func (h *MembershipHandler) Subscribe(c *gin.Context) {
+	var i struct {
+		PlanID uint `json:"plan_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	sub, p, err := h.svc.Subscribe(c.Request.Context(), c.MustGet("userID").(uint), i.PlanID)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(201, gin.H{"subscription": sub, "payment": p})
+}
+
+func (h *MembershipHandler) Mine(c *gin.Context) {
+	sub, err := h.svc.Current(c.MustGet("userID").(uint))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, sub)
+}
+
+// Pay выставляет счёт по неоплаченному абонементу.
+func (h *MembershipHandler) Pay(c *gin.Context) {
+	p, err := h.svc.Pay(c.Request.Context(), c.MustGet("userID").(uint))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(201, p)
+}
+
+func (h *MembershipHandler) Cancel(c *gin.Context) {
+	sub, err := h.svc.Cancel(c.MustGet("userID").(uint))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, sub)
+}
+
+func (h *MembershipHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMembershipPlan):
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrMembershipPlanNotFound), errors.Is(err, service.ErrMembershipNotFound):
+		c.JSON(404, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrAlreadySubscribed), errors.Is(err, service.ErrMembershipNotPayable):
+		c.JSON(409, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrPaymentProvider):
+		c.JSON(502, gin.H{"error": tr(c, "Payment provider unavailable")})
+	default:
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMemberships struct {
+	mock.Mock
+}
+
+func (m *MockMemberships) CreatePlan(in service.MembershipPlanInput) (*models.MembershipPlan, error) {
+	args := m.Called(in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MembershipPlan), args.Error(1)
+}
+
+func (m *MockMemberships) GetPlans() ([]models.MembershipPlan, error) {
+	args := m.Called()
+	return args.Get(0).([]models.MembershipPlan), args.Error(1)
+}
+
+func (m *MockMemberships) DeletePlan(id string) error { return m.Called(id).Error(0) }
+
+func (m *MockMemberships) Subscribe(_ context.Context, userID, planID uint) (*models.Subscription, *models.Payment, error) {
+	args := m.Called(userID, planID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.Subscription), args.Get(1).(*models.Payment), args.Error(2)
+}
+
+func (m *MockMemberships) Current(userID uint) (*models.Subscription, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockMemberships) Pay(_ context.Context, userID uint) (*models.Payment, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *MockMemberships) Cancel(userID uint) (*models.Subscription, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func setupMemberships() (*gin.Engine, *MockMemberships) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockMemberships)
+	h := NewMembershipHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)); c.Next() })
+	r.POST("/admin/membership-plans", h.CreatePlan)
+	r.GET("/users/me/membership", h.Mine)
+	r.POST("/users/me/membership", h.Subscribe)
+	r.POST("/users/me/membership/payments", h.Pay)
+	r.DELETE("/users/me/membership", h.Cancel)
+	return r, m
+}
+
+func TestCreateMembershipPlanHandler(t *testing.T) {
+	r, m := setupMemberships()
+	m.On("CreatePlan", mock.Anything).Return(nil, service.ErrInvalidMembershipPlan).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/membership-plans", bytes.NewBufferString(`{"name":"Укладки"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSubscribeHandler(t *testing.T) {
+	r, m := setupMemberships()
+	m.On("Subscribe", uint(1), uint(2)).Return(&models.Subscription{Status: models.SubscriptionPending},
+		&models.Payment{ConfirmationURL: "https://pay.example/checkout/fake_pay_1"}, nil).Once()
+	m.On("Subscribe", uint(1), uint(3)).Return(nil, nil, service.ErrAlreadySubscribed).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/me/membership", bytes.NewBufferString(`{"plan_id":2}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"confirmation_url":"https://pay.example/checkout/fake_pay_1"`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/me/membership", bytes.NewBufferString(`{"plan_id":3}`)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/me/membership", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMyMembershipHandler(t *testing.T) {
+	r, m := setupMemberships()
+	m.On("Current", uint(1)).Return(nil, service.ErrMembershipNotFound).Once()
+	m.On("Pay", uint(1)).Return(nil, service.ErrPaymentProvider).Once()
+	m.On("Cancel", uint(1)).Return(&models.Subscription{Status: models.SubscriptionActive, CancelAtPeriodEnd: true}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/membership", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/me/membership/payments", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/me/membership", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"cancel_at_period_end":true`)
+}
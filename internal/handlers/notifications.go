@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	svc service.Notifications
+}
+
+func NewNotificationHandler(svc service.Notifications) *NotificationHandler {
+	return &NotificationHandler{svc: svc}
+}
+
+func (h *NotificationHandler) List(c *gin.Context) {
+	n, err := h.svc.GetNotifications(c.MustGet("userID").(uint))
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, n)
+}
+
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	p, err := h.svc.GetPreferences(c.MustGet("userID").(uint))
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, p)
+}
+
+func (h *NotificationHandler) SavePreferences(c *gin.Context) {
+	var in service.NotificationPreferencesInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	p, err := h.svc.SavePreferences(c.MustGet("userID").(uint), in)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPreferences) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, p)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNotifications struct {
+	mock.Mock
+}
+
+func (m *MockNotifications) GetNotifications(userID uint) ([]models.Notification, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Notification), args.Error(1)
+}
+
+func (m *MockNotifications) GetPreferences(userID uint) (*models.NotificationPreference, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.NotificationPreference), args.Error(1)
+}
+
+func (m *MockNotifications) SavePreferences(userID uint, in service.NotificationPreferencesInput) (*models.NotificationPreference, error) {
+	args := m.Called(userID, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.NotificationPreference), args.Error(1)
+}
+
+func setupNotifications() (*gin.Engine, *MockNotifications) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockNotifications)
+	h := NewNotificationHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)) })
+	r.GET("/users/me/notifications", h.List)
+	r.GET("/users/me/notification-preferences", h.GetPreferences)
+	r.PUT("/users/me/notification-preferences", h.SavePreferences)
+	return r, m
+}
+
+func TestNotificationsHandler(t *testing.T) {
+	r, m := setupNotifications()
+
+	m.On("GetNotifications", uint(1)).Return([]models.Notification{{Event: "booking_created", To: "+77010000000"}}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/notifications", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "booking_created")
+	assert.NotContains(t, w.Body.String(), "+77010000000")
+
+	m.On("GetPreferences", uint(1)).Return(&models.NotificationPreference{DisabledChannels: "sms"}, nil).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/notification-preferences", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"disabled_channels":"sms"`)
+}
+
+func TestSaveNotificationPreferencesHandler(t *testing.T) {
+	r, m := setupNotifications()
+
+	in := service.NotificationPreferencesInput{DisabledEvents: []string{"booking_reminder"}}
+	m.On("SavePreferences", uint(1), in).Return(&models.NotificationPreference{DisabledEvents: "booking_reminder"}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/users/me/notification-preferences", bytes.NewBufferString(`{"disabled_events":["booking_reminder"]}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	m.On("SavePreferences", uint(1), mock.Anything).Return(nil, fmt.Errorf("%w: unknown value", service.ErrInvalidPreferences)).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/users/me/notification-preferences", bytes.NewBufferString(`{"disabled_channels":["pigeon"]}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcBindingCookie привязывает вход к браузеру, который его начал.
+const (
+	oidcBindingCookie = "oidc_binding"
+	oidcBindingPath   = "/api/v1/auth/oidc"
+	oidcBindingMaxAge = 10 * 60 // как TTL state в сервисе
+)
+
+type OIDCHandler struct {
+	svc service.OIDCLogin
+}
+
+func NewOIDCHandler(svc service.OIDCLogin) *OIDCHandler {
+	return &OIDCHandler{svc: svc}
+}
+
+// Login перенаправляет браузер на страницу входа провайдера.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	u, binding, err := h.svc.BeginLogin(c.Request.Context(), c.Param("provider"), 0)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	setBindingCookie(c, binding, oidcBindingMaxAge)
+	c.Redirect(302, u)
+}
+
+// Link возвращает ссылку для привязки внешней учётки к текущему пользователю.
+func (h *OIDCHandler) Link(c *gin.Context) {
+	u, binding, err := h.svc.BeginLogin(c.Request.Context(), c.Param("provider"), c.MustGet("userID").(uint))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	setBindingCookie(c, binding, oidcBindingMaxAge)
+	c.JSON(200, gin.H{"url": u})
+}
+
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if e := c.Query("error"); e != "" {
+		c.JSON(401, gin.H{"error": e})
+		return
+	}
+	binding, _ := c.Cookie(oidcBindingCookie)
+	setBindingCookie(c, "", -1)
+	t, err := h.svc.CompleteLogin(c.Request.Context(), c.Param("provider"), c.Query("state"), binding, c.Query("code"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"token": t})
+}
+
+// setBindingCookie ставит cookie с SameSite=Lax: она нужна в callback, куда
+// браузер приходит редиректом с сайта провайдера.
+func setBindingCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBindingCookie, value, maxAge, oidcBindingPath, "", secure, true)
+}
+
+func (h *OIDCHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnknownProvider):
+		c.JSON(404, gin.H{"error": tr(c, "Provider not found")})
+	case errors.Is(err, service.ErrIdentityTaken):
+		c.JSON(409, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrInvalidState):
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(401, gin.H{"error": tr(c, "Login failed")})
+	}
+}
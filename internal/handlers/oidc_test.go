@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOIDC struct {
+	mock.Mock
+}
+
+func (m *MockOIDC) BeginLogin(ctx context.Context, provider string, linkUserID uint) (string, string, error) {
+	args := m.Called(provider, linkUserID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockOIDC) CompleteLogin(ctx context.Context, provider, state, binding, code string) (string, error) {
+	args := m.Called(provider, state, binding, code)
+	return args.String(0), args.Error(1)
+}
+
+func setupOIDC() (*gin.Engine, *MockOIDC) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockOIDC)
+	h := NewOIDCHandler(m)
+	r := gin.New()
+	r.GET("/auth/oidc/:provider/login", h.Login)
+	r.GET("/auth/oidc/:provider/callback", h.Callback)
+	r.POST("/auth/oidc/:provider/link", func(c *gin.Context) {
+		c.Set("userID", uint(3))
+		h.Link(c)
+	})
+	return r, m
+}
+
+func TestOIDCLogin(t *testing.T) {
+	r, m := setupOIDC()
+
+	t.Run("Redirect", func(t *testing.T) {
+		m.On("BeginLogin", "google", uint(0)).Return("https://idp/authorize?x=1", "bnd", nil).Once()
+		req, _ := http.NewRequest("GET", "/auth/oidc/google/login", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "https://idp/authorize?x=1", w.Header().Get("Location"))
+		cookie := w.Result().Cookies()[0]
+		assert.Equal(t, "oidc_binding", cookie.Name)
+		assert.Equal(t, "bnd", cookie.Value)
+		assert.True(t, cookie.HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	})
+
+	t.Run("Unknown Provider", func(t *testing.T) {
+		m.On("BeginLogin", "nope", uint(0)).Return("", "", service.ErrUnknownProvider).Once()
+		req, _ := http.NewRequest("GET", "/auth/oidc/nope/login", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 404, w.Code)
+	})
+}
+
+func TestOIDCLink(t *testing.T) {
+	r, m := setupOIDC()
+	m.On("BeginLogin", "google", uint(3)).Return("https://idp/authorize", "bnd", nil).Once()
+	req, _ := http.NewRequest("POST", "/auth/oidc/google/link", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "https://idp/authorize")
+	assert.Equal(t, "bnd", w.Result().Cookies()[0].Value)
+}
+
+func TestOIDCCallback(t *testing.T) {
+	r, m := setupOIDC()
+
+	t.Run("Success", func(t *testing.T) {
+		m.On("CompleteLogin", "google", "st", "bnd", "cd").Return("token123", nil).Once()
+		req, _ := http.NewRequest("GET", "/auth/oidc/google/callback?state=st&code=cd", nil)
+		req.AddCookie(&http.Cookie{Name: "oidc_binding", Value: "bnd"})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+		assert.Contains(t, w.Body.String(), "token123")
+		// Cookie больше не нужна и удаляется.
+		assert.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+	})
+
+	t.Run("Provider Error", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/auth/oidc/google/callback?error=access_denied", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 401, w.Code)
+		assert.Contains(t, w.Body.String(), "access_denied")
+	})
+
+	t.Run("Invalid State", func(t *testing.T) {
+		m.On("CompleteLogin", "google", "bad", "", "cd").Return("", service.ErrInvalidState).Once()
+		req, _ := http.NewRequest("GET", "/auth/oidc/google/callback?state=bad&code=cd", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+	})
+
+	t.Run("Exchange Failed", func(t *testing.T) {
+		m.On("CompleteLogin", "google", "st", "", "bad").Return("", errors.New("invalid_grant")).Once()
+		req, _ := http.NewRequest("GET", "/auth/oidc/google/callback?state=st&code=bad", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 401, w.Code)
+		assert.Contains(t, w.Body.String(), "Login failed")
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PackageHandler struct {
+	svc service.Packages
+}
+
+func NewPackageHandler(svc service.Packages) *PackageHandler {
+	return &PackageHandler{svc: svc}
+}
+
+func (h *PackageHandler) Create(c *gin.Context) {
+	var i service.PackageInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	p, err := h.svc.CreatePackage(i)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPackage) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(201, p)
+}
+
+func (h *PackageHandler) List(c *gin.Context) {
+	list, err := h.svc.GetPackages()
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, list)
+}
+
+func (h *PackageHandler) Delete(c *gin.Context) {
+	if err := h.svc.DeletePackage(c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrPackageNotFound) {
+			c.JSON(404, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
+
+// Mine — купленные пакеты текущего пользователя с остатком сеансов.
+func (h *PackageHandler) Mine(c *gin.Context) {
+	h.clientPackages(c, c.MustGet("userID").(uint))
+}
+
+// ForClient — купленные пакеты клиента для ресепшена.
+func (h *PackageHandler) ForClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid user id")})
+		return
+	}
+	h.clientPackages(c, uint(id))
+}
+
+func (h *PackageHandler) clientPackages(c *gin.Context, userID uint) {
+	list, err := h.svc.GetClientPackages(userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, list)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPackages struct {
+	mock.Mock
+}
+
+func (m *MockPackages) CreatePackage(in service.PackageInput) (*models.Package, error) {
+	args := m.Called(in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Package), args.Error(1)
+}
+
+func (m *MockPackages) GetPackages() ([]models.Package, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Package), args.Error(1)
+}
+
+func (m *MockPackages) DeletePackage(id string) error { return m.Called(id).Error(0) }
+
+func (m *MockPackages) GetClientPackages(userID uint) ([]models.ClientPackage, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.ClientPackage), args.Error(1)
+}
+
+func setupPackages() (*gin.Engine, *MockPackages) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockPackages)
+	h := NewPackageHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)); c.Next() })
+	r.POST("/admin/packages", h.Create)
+	r.DELETE("/admin/packages/:id", h.Delete)
+	r.GET("/users/me/packages", h.Mine)
+	r.GET("/clients/:id/packages", h.ForClient)
+	return r, m
+}
+
+func TestCreatePackageHandler(t *testing.T) {
+	r, m := setupPackages()
+	m.On("CreatePackage", mock.MatchedBy(func(in service.PackageInput) bool { return in.Name == "Лазер" })).
+		Return(&models.Package{Name: "Лазер"}, nil).Once()
+	m.On("CreatePackage", mock.Anything).Return(nil, service.ErrInvalidPackage).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/packages", bytes.NewBufferString(`{"name":"Лазер","items":[{"service_id":2,"quantity":5}]}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/packages", bytes.NewBufferString(`{"name":""}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeletePackageHandler(t *testing.T) {
+	r, m := setupPackages()
+	m.On("DeletePackage", "3").Return(service.ErrPackageNotFound).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/packages/3", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestClientPackagesHandler(t *testing.T) {
+	r, m := setupPackages()
+	list := []models.ClientPackage{{Name: "Лазер", Sessions: []models.ClientPackageSession{{ServiceID: 2, Total: 5, Remaining: 3}}}}
+	m.On("GetClientPackages", uint(1)).Return(list, nil).Once()
+	m.On("GetClientPackages", uint(4)).Return(list, nil).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/packages", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"remaining":3`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/clients/4/packages", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/clients/x/packages", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	m.AssertExpectations(t)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/payments"
+	"beauty-salon/internal/service"
+	"errors"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader — повтор запроса с тем же ключом не создаёт второй платёж.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type PaymentHandler struct {
+	svc service.Payments
+}
+
+func NewPaymentHandler(svc service.Payments) *PaymentHandler {
+	return &PaymentHandler{svc: svc}
+}
+
+// Create выставляет счёт на оплату своей записи и возвращает ссылку на оплату.
+func (h *PaymentHandler) Create(c *gin.Context) {
+	p, err := h.svc.CreatePayment(c.Request.Context(), c.MustGet("userID").(uint), c.Param("id"), c.GetHeader(IdempotencyKeyHeader))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(201, p)
+}
+
+func (h *PaymentHandler) ListMine(c *gin.Context) {
+	p, err := h.svc.GetUserPayments(c.MustGet("userID").(uint))
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, p)
+}
+
+func (h *PaymentHandler) ListForBooking(c *gin.Context) {
+	p, err := h.svc.GetBookingPayments(c.Param("id"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, p)
+}
+
+// Refund возвращает сумму ("1500.00"); без суммы возвращается весь остаток.
+func (h *PaymentHandler) Refund(c *gin.Context) {
+	var i struct {
+		Amount money.Money `json:"amount"`
+		Reason string      `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&i); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	r, err := h.svc.Refund(c.Request.Context(), c.Param("id"), i.Amount, i.Reason, c.MustGet("userID").(uint))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(201, r)
+}
+
+// Webhook принимает уведомления провайдера о статусе платежа. Подпись
+// проверяет провайдер; ответ не 2xx заставит его повторить уведомление.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	if err := h.svc.HandleNotification(c.Request.Context(), c.Request.Header, body); err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Status(200)
+}
+
+func (h *PaymentHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrBookingNotFound):
+		c.JSON(404, gin.H{"error": tr(c, "Booking not found")})
+	case errors.Is(err, service.ErrPaymentNotFound):
+		c.JSON(404, gin.H{"error": tr(c, "Payment not found")})
+	case errors.Is(err, payments.ErrInvalidSignature):
+		c.JSON(401, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrInvalidRefundAmount), errors.Is(err, service.ErrRefundTooLarge):
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrAlreadyPaid), errors.Is(err, service.ErrBookingNotPayable),
+		errors.Is(err, service.ErrIdempotencyConflict), errors.Is(err, service.ErrPaymentNotRefundable):
+		c.JSON(409, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrPaymentProvider):
+		c.JSON(502, gin.H{"error": tr(c, "Payment provider unavailable")})
+	default:
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/payments"
+	"beauty-salon/internal/service"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPayments struct {
+	mock.Mock
+}
+
+func (m *MockPayments) CreatePayment(ctx context.Context, userID uint, bookingID, idempotencyKey string) (*models.Payment, error) {
+	args := m.Called(userID, bookingID, idempotencyKey)
+	p, _ := args.Get(0).(*models.Payment)
+	return p, args.Error(1)
+}
+func (m *MockPayments) GetUserPayments(userID uint) ([]models.Payment, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Payment), args.Error(1)
+}
+func (m *MockPayments) GetBookingPayments(bookingID string) ([]models.Payment, error) {
+	args := m.Called(bookingID)
+	p, _ := args.Get(0).([]models.Payment)
+	return p, args.Error(1)
+}
+func (m *MockPayments) HandleNotification(ctx context.Context, header http.Header, body []byte) error {
+	return m.Called(string(body)).Error(0)
+}
+func (m *MockPayments) Refund(ctx context.Context, paymentID string, amount money.Money, reason string, by uint) (*models.PaymentRefund, error) {
+	args := m.Called(paymentID, amount, reason, by)
+	r, _ := args.Get(0).(*models.PaymentRefund)
+	return r, args.Error(1)
+}
+
+func setupPayments() (*gin.Engine, *MockPayments) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockPayments)
+	h := NewPaymentHandler(m)
+	r := gin.New()
+	r.POST("/payments/webhook", h.Webhook)
+	authed := r.Group("/", func(c *gin.Context) { c.Set("userID", uint(4)) })
+	authed.POST("/bookings/:id/payments", h.Create)
+	authed.GET("/users/me/payments", h.ListMine)
+	authed.GET("/admin/bookings/:id/payments", h.ListForBooking)
+	authed.POST("/admin/payments/:id/refunds", h.Refund)
+	return r, m
+}
+
+func TestCreatePaymentHandler(t *testing.T) {
+	r, m := setupPayments()
+	for _, tc := range []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"Created", nil, 201},
+		{"Not Found", service.ErrBookingNotFound, 404},
+		{"Already Paid", service.ErrAlreadyPaid, 409},
+		{"Key Conflict", service.ErrIdempotencyConflict, 409},
+		{"Provider Down", service.ErrPaymentProvider, 502},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var p *models.Payment
+			if tc.err == nil {
+				p = &models.Payment{Amount: money.New(500000, "KZT"), Status: "pending", ConfirmationURL: "https://pay.example/checkout/1"}
+			}
+			m.On("CreatePayment", uint(4), "1", "key-1").Return(p, tc.err).Once()
+			req := httptest.NewRequest("POST", "/bookings/1/payments", nil)
+			req.Header.Set(IdempotencyKeyHeader, "key-1")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+}
+
+func TestRefundHandler(t *testing.T) {
+	r, m := setupPayments()
+
+	m.On("Refund", "9", money.New(100000, "KZT"), "скидка", uint(4)).Return(&models.PaymentRefund{Amount: money.New(100000, "KZT"), Status: "succeeded"}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/payments/9/refunds", strings.NewReader(`{"amount":"1000.00","reason":"скидка"}`)))
+	assert.Equal(t, 201, w.Code)
+
+	// Без тела — полный возврат.
+	m.On("Refund", "9", money.Money{}, "", uint(4)).Return(nil, service.ErrRefundTooLarge).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/payments/9/refunds", nil))
+	assert.Equal(t, 400, w.Code)
+
+	m.On("Refund", "10", money.Money{}, "", uint(4)).Return(nil, service.ErrPaymentNotRefundable).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/payments/10/refunds", nil))
+	assert.Equal(t, 409, w.Code)
+}
+
+func TestPaymentWebhookHandler(t *testing.T) {
+	r, m := setupPayments()
+
+	m.On("HandleNotification", `{"ok":1}`).Return(nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/payments/webhook", strings.NewReader(`{"ok":1}`)))
+	assert.Equal(t, 200, w.Code)
+
+	m.On("HandleNotification", `{"forged":1}`).Return(payments.ErrInvalidSignature).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/payments/webhook", strings.NewReader(`{"forged":1}`)))
+	assert.Equal(t, 401, w.Code)
+}
+
+func TestListPaymentsHandler(t *testing.T) {
+	r, m := setupPayments()
+
+	m.On("GetUserPayments", uint(4)).Return([]models.Payment{{Amount: money.New(500000, "KZT")}}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/me/payments", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"amount":{"amount":"5000.00","currency":"KZT"}`)
+
+	m.On("GetBookingPayments", "99").Return(nil, service.ErrBookingNotFound).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/bookings/99/payments", nil))
+	assert.Equal(t, 404, w.Code)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayrollHandler struct {
+	svc service.Payroll
+}
+
+func NewPayrollHandler(svc service.Payroll) *PayrollHandler {
+	return &PayrollHandler{svc: svc}
+}
+
+// SaveScheme задаёт схему оплаты мастера; прежняя схема заменяется.
+func (h *PayrollHandler) SaveScheme(c *gin.Context) {
+	var i service.CommissionSchemeInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	scheme, err := h.svc.SaveScheme(c.Param("id"), i)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, scheme)
+}
+
+func (h *PayrollHandler) ListSchemes(c *gin.Context) {
+	list, err := h.svc.GetSchemes()
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, list)
+}
+
+func (h *PayrollHandler) DeleteScheme(c *gin.Context) {
+	if err := h.svc.DeleteScheme(c.Param("id")); err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// Report — выплаты мастерам за дни from–to (YYYY-MM-DD, включительно).
+func (h *PayrollHandler) Report(c *gin.Context) {
+	report, err := h.svc.Report(c.Query("from"), c.Query("to"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(200, report)
+}
+
+// ReportCSV — тот же отчёт файлом для бухгалтерии.
+func (h *PayrollHandler) ReportCSV(c *gin.Context) {
+	report, err := h.svc.Report(c.Query("from"), c.Query("to"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	out, err := service.PayrollCSV(report)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="payroll-`+report.From+`-`+report.To+`.csv"`)
+	c.Data(200, "text/csv; charset=utf-8", out)
+}
+
+func (h *PayrollHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCommission), errors.Is(err, service.ErrInvalidDate):
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+	case errors.Is(err, service.ErrStaffNotFound), errors.Is(err, service.ErrCommissionNotFound):
+		c.JSON(404, gin.H{"error": tr(c, err.Error())})
+	default:
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+	}
+}
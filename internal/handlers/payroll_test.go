@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/service"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPayroll struct {
+	mock.Mock
+}
+
+func (m *MockPayroll) SaveScheme(staffID string, in service.CommissionSchemeInput) (*models.CommissionScheme, error) {
+	args := m.Called(staffID, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CommissionScheme), args.Error(1)
+}
+
+func (m *MockPayroll) GetSchemes() ([]models.CommissionScheme, error) {
+	args := m.Called()
+	return args.Get(0).([]models.CommissionScheme), args.Error(1)
+}
+
+func (m *MockPayroll) DeleteScheme(staffID string) error { return m.Called(staffID).Error(0) }
+
+func (m *MockPayroll) Report(from, to string) (*service.PayrollReport, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.PayrollReport), args.Error(1)
+}
+
+func setupPayroll() (*gin.Engine, *MockPayroll) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockPayroll)
+	h := NewPayrollHandler(m)
+	r := gin.New()
+	r.PUT("/admin/staff/:id/commission", h.SaveScheme)
+	r.DELETE("/admin/staff/:id/commission", h.DeleteScheme)
+	r.GET("/admin/commission-schemes", h.ListSchemes)
+	r.GET("/admin/payroll", h.Report)
+	r.GET("/admin/payroll.csv", h.ReportCSV)
+	return r, m
+}
+
+func TestSaveCommissionSchemeHandler(t *testing.T) {
+	r, m := setupPayroll()
+
+	m.On("SaveScheme", "2", service.CommissionSchemeInput{Kind: "percent", Percent: 40}).
+		Return(&models.CommissionScheme{ID: 1, StaffID: 2, Kind: "percent", Percent: 40}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/admin/staff/2/commission", bytes.NewBufferString(`{"kind":"percent","percent":40}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"percent":40`)
+
+	m.On("SaveScheme", "2", service.CommissionSchemeInput{Kind: "salary"}).Return(nil, service.ErrInvalidCommission).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/admin/staff/2/commission", bytes.NewBufferString(`{"kind":"salary"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	m.On("SaveScheme", "9", service.CommissionSchemeInput{Kind: "percent", Percent: 40}).Return(nil, service.ErrStaffNotFound).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/admin/staff/9/commission", bytes.NewBufferString(`{"kind":"percent","percent":40}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeleteCommissionSchemeHandler(t *testing.T) {
+	r, m := setupPayroll()
+
+	m.On("DeleteScheme", "2").Return(nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/staff/2/commission", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	m.On("DeleteScheme", "9").Return(service.ErrCommissionNotFound).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/staff/9/commission", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPayrollReportHandler(t *testing.T) {
+	r, m := setupPayroll()
+	kzt := func(minor int64) money.Money { return money.New(minor, "KZT") }
+	report := &service.PayrollReport{From: "2026-03-01", To: "2026-03-31", Totals: []money.Money{kzt(2100000)}, Rows: []service.PayrollRow{
+		{StaffID: 2, StaffName: "Ольга", Scheme: "percent", Services: 4, Revenue: kzt(5000000),
+			Commission: kzt(2000000), Tips: kzt(100000), Total: kzt(2100000)},
+	}}
+	m.On("Report", "2026-03-01", "2026-03-31").Return(report, nil).Twice()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/payroll?from=2026-03-01&to=2026-03-31", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"commission":{"amount":"20000.00","currency":"KZT"}`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/payroll.csv?from=2026-03-01&to=2026-03-31", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="payroll-2026-03-01-2026-03-31.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "2;Ольга;4;50000.00;20000.00;1000.00;21000.00;KZT")
+
+	m.On("Report", "", "").Return(nil, service.ErrInvalidDate).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/payroll.csv", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
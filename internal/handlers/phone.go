@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PhoneAuthHandler struct {
+	svc service.PhoneAuth
+}
+
+func NewPhoneAuthHandler(svc service.PhoneAuth) *PhoneAuthHandler {
+	return &PhoneAuthHandler{svc: svc}
+}
+
+func (h *PhoneAuthHandler) RequestCode(c *gin.Context) {
+	var i struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	if err := h.svc.RequestCode(c.Request.Context(), i.Phone); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPhone):
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+		case errors.Is(err, service.ErrOTPCooldown):
+			c.JSON(429, gin.H{"error": tr(c, err.Error())})
+		default:
+			c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		}
+		return
+	}
+	c.JSON(202, gin.H{"message": tr(c, "Code sent")})
+}
+
+func (h *PhoneAuthHandler) VerifyCode(c *gin.Context) {
+	var i struct {
+		Phone string `json:"phone" binding:"required"`
+		Code  string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	t, err := h.svc.VerifyCode(c.Request.Context(), i.Phone, i.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPhone):
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+		case errors.Is(err, service.ErrOTPInvalid):
+			c.JSON(401, gin.H{"error": tr(c, err.Error())})
+		case errors.Is(err, service.ErrOTPTooManyTries):
+			c.JSON(429, gin.H{"error": tr(c, err.Error())})
+		default:
+			c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"token": t})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPhoneAuth struct {
+	mock.Mock
+}
+
+func (m *MockPhoneAuth) RequestCode(ctx context.Context, phone string) error {
+	return m.Called(phone).Error(0)
+}
+
+func (m *MockPhoneAuth) VerifyCode(ctx context.Context, phone, code string) (string, error) {
+	args := m.Called(phone, code)
+	return args.String(0), args.Error(1)
+}
+
+func setupPhone() (*gin.Engine, *MockPhoneAuth) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockPhoneAuth)
+	h := NewPhoneAuthHandler(m)
+	r := gin.New()
+	r.POST("/auth/phone/code", h.RequestCode)
+	r.POST("/auth/phone/verify", h.VerifyCode)
+	return r, m
+}
+
+func TestPhoneRequestCode(t *testing.T) {
+	r, m := setupPhone()
+
+	cases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"Sent", nil, 202},
+		{"Invalid Phone", service.ErrInvalidPhone, 400},
+		{"Cooldown", service.ErrOTPCooldown, 429},
+		{"Gateway Error", errors.New("sms down"), 500},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m.On("RequestCode", "+77011234567").Return(tc.err).Once()
+			body, _ := json.Marshal(map[string]string{"phone": "+77011234567"})
+			req, _ := http.NewRequest("POST", "/auth/phone/code", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+
+	t.Run("Missing Phone", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/auth/phone/code", bytes.NewBuffer([]byte(`{}`)))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+	})
+}
+
+func TestPhoneVerifyCode(t *testing.T) {
+	r, m := setupPhone()
+
+	t.Run("Success", func(t *testing.T) {
+		m.On("VerifyCode", "+77011234567", "123456").Return("token123", nil).Once()
+		body, _ := json.Marshal(map[string]string{"phone": "+77011234567", "code": "123456"})
+		req, _ := http.NewRequest("POST", "/auth/phone/verify", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+		assert.Contains(t, w.Body.String(), "token123")
+	})
+
+	t.Run("Invalid Code", func(t *testing.T) {
+		m.On("VerifyCode", "+77011234567", "000000").Return("", service.ErrOTPInvalid).Once()
+		body, _ := json.Marshal(map[string]string{"phone": "+77011234567", "code": "000000"})
+		req, _ := http.NewRequest("POST", "/auth/phone/verify", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 401, w.Code)
+	})
+
+	t.Run("Too Many Attempts", func(t *testing.T) {
+		m.On("VerifyCode", "+77011234567", "999999").Return("", service.ErrOTPTooManyTries).Once()
+		body, _ := json.Marshal(map[string]string{"phone": "+77011234567", "code": "999999"})
+		req, _ := http.NewRequest("POST", "/auth/phone/verify", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 429, w.Code)
+	})
+}
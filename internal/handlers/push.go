@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PushHandler struct {
+	svc service.PushDevices
+}
+
+func NewPushHandler(svc service.PushDevices) *PushHandler {
+	return &PushHandler{svc: svc}
+}
+
+// Register привязывает устройство для push-уведомлений; прежнее отвязывается.
+func (h *PushHandler) Register(c *gin.Context) {
+	var i struct {
+		Platform string `json:"platform" binding:"required"`
+		Token    string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	if err := h.svc.RegisterDevice(c.MustGet("userID").(uint), i.Platform, i.Token); err != nil {
+		if errors.Is(err, service.ErrInvalidPushDevice) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
+
+func (h *PushHandler) Unregister(c *gin.Context) {
+	if err := h.svc.UnregisterDevice(c.MustGet("userID").(uint)); err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPushDevices struct {
+	mock.Mock
+}
+
+func (m *MockPushDevices) RegisterDevice(userID uint, platform, token string) error {
+	return m.Called(userID, platform, token).Error(0)
+}
+func (m *MockPushDevices) UnregisterDevice(userID uint) error { return m.Called(userID).Error(0) }
+
+func setupPush() (*gin.Engine, *MockPushDevices) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockPushDevices)
+	h := NewPushHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(4)) })
+	r.PUT("/users/me/push-device", h.Register)
+	r.DELETE("/users/me/push-device", h.Unregister)
+	return r, m
+}
+
+func TestRegisterPushDevice(t *testing.T) {
+	r, m := setupPush()
+
+	m.On("RegisterDevice", uint(4), "ios", "apns-token").Return(nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/users/me/push-device", bytes.NewBufferString(`{"platform":"ios","token":"apns-token"}`)))
+	assert.Equal(t, 204, w.Code)
+
+	m.On("RegisterDevice", uint(4), "symbian", "t").Return(service.ErrInvalidPushDevice).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/users/me/push-device", bytes.NewBufferString(`{"platform":"symbian","token":"t"}`)))
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/users/me/push-device", bytes.NewBufferString(`{"platform":"ios"}`)))
+	assert.Equal(t, 400, w.Code)
+	m.AssertExpectations(t)
+}
+
+func TestUnregisterPushDevice(t *testing.T) {
+	r, m := setupPush()
+
+	m.On("UnregisterDevice", uint(4)).Return(nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/me/push-device", nil))
+	assert.Equal(t, 204, w.Code)
+
+	m.On("UnregisterDevice", uint(4)).Return(errors.New("db error")).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/me/push-device", nil))
+	assert.Equal(t, 500, w.Code)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxHandler struct {
+	svc service.Taxes
+}
+
+func NewTaxHandler(svc service.Taxes) *TaxHandler {
+	return &TaxHandler{svc: svc}
+}
+
+// Save задаёт ставку НДС категории; ставка той же категории заменяется.
+func (h *TaxHandler) Save(c *gin.Context) {
+	var i service.TaxRateInput
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, "Invalid input")})
+		return
+	}
+	rate, err := h.svc.SaveRate(i)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTaxRate) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, rate)
+}
+
+func (h *TaxHandler) List(c *gin.Context) {
+	rates, err := h.svc.GetRates()
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, rates)
+}
+
+func (h *TaxHandler) Delete(c *gin.Context) {
+	if err := h.svc.DeleteRate(c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrTaxRateNotFound) {
+			c.JSON(404, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
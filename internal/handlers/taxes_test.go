@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTaxes struct {
+	mock.Mock
+}
+
+func (m *MockTaxes) SaveRate(in service.TaxRateInput) (*models.TaxRate, error) {
+	args := m.Called(in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaxRate), args.Error(1)
+}
+
+func (m *MockTaxes) GetRates() ([]models.TaxRate, error) {
+	args := m.Called()
+	return args.Get(0).([]models.TaxRate), args.Error(1)
+}
+
+func (m *MockTaxes) DeleteRate(id string) error { return m.Called(id).Error(0) }
+
+func setupTaxes() (*gin.Engine, *MockTaxes) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockTaxes)
+	h := NewTaxHandler(m)
+	r := gin.New()
+	r.PUT("/admin/tax-rates", h.Save)
+	r.GET("/admin/tax-rates", h.List)
+	r.DELETE("/admin/tax-rates/:id", h.Delete)
+	return r, m
+}
+
+func TestSaveTaxRateHandler(t *testing.T) {
+	r, m := setupTaxes()
+
+	m.On("SaveRate", service.TaxRateInput{Category: "cosmetics", Name: "НДС 12%", Rate: 1200}).
+		Return(&models.TaxRate{ID: 1, Category: "cosmetics", Rate: 1200}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/admin/tax-rates",
+		bytes.NewBufferString(`{"category":"cosmetics","name":"НДС 12%","rate":1200}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"rate":1200`)
+
+	m.On("SaveRate", service.TaxRateInput{Rate: -5}).Return(nil, service.ErrInvalidTaxRate).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/admin/tax-rates", bytes.NewBufferString(`{"rate":-5}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeleteTaxRateHandler(t *testing.T) {
+	r, m := setupTaxes()
+	m.On("DeleteRate", "3").Return(nil).Once()
+	m.On("DeleteRate", "4").Return(service.ErrTaxRateNotFound).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/tax-rates/3", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/tax-rates/4", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TelegramHandler struct {
+	svc service.TelegramLinks
+}
+
+func NewTelegramHandler(svc service.TelegramLinks) *TelegramHandler {
+	return &TelegramHandler{svc: svc}
+}
+
+// Link выдаёт deep link на бота; ссылка одноразовая и действует 15 минут.
+func (h *TelegramHandler) Link(c *gin.Context) {
+	url, err := h.svc.CreateLink(c.Request.Context(), c.MustGet("userID").(uint))
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, gin.H{"url": url})
+}
+
+func (h *TelegramHandler) Unlink(c *gin.Context) {
+	if err := h.svc.Unlink(c.MustGet("userID").(uint)); err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
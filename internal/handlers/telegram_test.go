@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTelegramLinks struct {
+	mock.Mock
+}
+
+func (m *MockTelegramLinks) CreateLink(ctx context.Context, userID uint) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+func (m *MockTelegramLinks) CompleteLink(ctx context.Context, token string, telegramUserID int64) (*models.User, error) {
+	args := m.Called(token, telegramUserID)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockTelegramLinks) UserByTelegramID(telegramUserID int64) (*models.User, error) {
+	args := m.Called(telegramUserID)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockTelegramLinks) Unlink(userID uint) error { return m.Called(userID).Error(0) }
+
+func setupTelegram() (*gin.Engine, *MockTelegramLinks) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockTelegramLinks)
+	h := NewTelegramHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(4)) })
+	r.POST("/users/me/telegram/link", h.Link)
+	r.DELETE("/users/me/telegram", h.Unlink)
+	return r, m
+}
+
+func TestTelegramLink(t *testing.T) {
+	r, m := setupTelegram()
+
+	m.On("CreateLink", uint(4)).Return("https://t.me/salon_bot?start=abc", nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/me/telegram/link", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "https://t.me/salon_bot?start=abc")
+
+	m.On("CreateLink", uint(4)).Return("", errors.New("redis down")).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/me/telegram/link", nil))
+	assert.Equal(t, 500, w.Code)
+
+	m.On("Unlink", uint(4)).Return(nil).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/me/telegram", nil))
+	assert.Equal(t, 204, w.Code)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"beauty-salon/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	svc service.Webhooks
+}
+
+func NewWebhookHandler(svc service.Webhooks) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var i struct {
+		URL        string   `json:"url" binding:"required"`
+		EventTypes []string `json:"event_types" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&i); err != nil {
+		c.JSON(400, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	secret, w, err := h.svc.CreateWebhook(i.URL, i.EventTypes, c.MustGet("userID").(uint))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhook) {
+			c.JSON(400, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	// Секрет для проверки подписи показывается только один раз.
+	c.JSON(201, gin.H{"secret": secret, "webhook": w})
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	w, _ := h.svc.GetWebhooks()
+	c.JSON(200, w)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	if err := h.svc.DeleteWebhook(c.Param("id")); err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.Status(204)
+}
+
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	d, err := h.svc.GetDeliveries(c.Param("id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(200, d)
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	d, err := h.svc.Redeliver(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrDeliveryNotFound) {
+			c.JSON(404, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		c.JSON(500, gin.H{"error": tr(c, "Failed")})
+		return
+	}
+	c.JSON(202, d)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhooks struct {
+	mock.Mock
+}
+
+func (m *MockWebhooks) CreateWebhook(rawURL string, eventTypes []string, createdBy uint) (string, *models.WebhookSubscription, error) {
+	args := m.Called(rawURL, eventTypes, createdBy)
+	if args.Get(1) == nil {
+		return "", nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).(*models.WebhookSubscription), args.Error(2)
+}
+
+func (m *MockWebhooks) GetWebhooks() ([]models.WebhookSubscription, error) {
+	args := m.Called()
+	return args.Get(0).([]models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhooks) DeleteWebhook(id string) error { return m.Called(id).Error(0) }
+
+func (m *MockWebhooks) GetDeliveries(subscriptionID string) ([]models.WebhookDelivery, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhooks) Redeliver(deliveryID string) (*models.WebhookDelivery, error) {
+	args := m.Called(deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func setupWebhooks() (*gin.Engine, *MockWebhooks) {
+	gin.SetMode(gin.TestMode)
+	m := new(MockWebhooks)
+	h := NewWebhookHandler(m)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)) })
+	r.POST("/admin/webhooks", h.Create)
+	r.GET("/admin/webhooks", h.List)
+	r.DELETE("/admin/webhooks/:id", h.Delete)
+	r.GET("/admin/webhooks/:id/deliveries", h.Deliveries)
+	r.POST("/admin/webhook-deliveries/:id/redeliver", h.Redeliver)
+	return r, m
+}
+
+func TestCreateWebhookHandler(t *testing.T) {
+	r, m := setupWebhooks()
+
+	m.On("CreateWebhook", "https://crm.example.com/hook", []string{"user.registered"}, uint(1)).
+		Return("whsec_abc", &models.WebhookSubscription{URL: "https://crm.example.com/hook", Secret: "whsec_abc"}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/webhooks",
+		bytes.NewBufferString(`{"url":"https://crm.example.com/hook","event_types":["user.registered"]}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":"whsec_abc"`)
+
+	m.On("CreateWebhook", "ftp://x", []string{"*"}, uint(1)).Return("", nil, service.ErrInvalidWebhook).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/webhooks", bytes.NewBufferString(`{"url":"ftp://x","event_types":["*"]}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListWebhooksHidesSecret(t *testing.T) {
+	r, m := setupWebhooks()
+	m.On("GetWebhooks").Return([]models.WebhookSubscription{{URL: "https://crm.example.com/hook", Secret: "whsec_abc"}}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/webhooks", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "whsec_abc")
+}
+
+func TestWebhookDeliveriesHandler(t *testing.T) {
+	r, m := setupWebhooks()
+
+	m.On("GetDeliveries", "1").Return([]models.WebhookDelivery{{EventID: "ev-1", Status: "failed"}}, nil).Once()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/webhooks/1/deliveries", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "ev-1")
+
+	m.On("Redeliver", "7").Return(&models.WebhookDelivery{Status: "pending"}, nil).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/webhook-deliveries/7/redeliver", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	m.On("Redeliver", "8").Return(nil, service.ErrDeliveryNotFound).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/webhook-deliveries/8/redeliver", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	m.On("DeleteWebhook", "1").Return(nil).Once()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/webhooks/1", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
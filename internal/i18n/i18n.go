@@ -0,0 +1,89 @@
+// Package i18n — каталог сообщений API и выбор языка ответа.
+//
+// Исходные сообщения в коде написаны по-английски и служат ключами каталога,
+// поэтому для "en" и неизвестных строк возвращается исходный текст.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	RU = "ru"
+	EN = "en"
+	KK = "kk"
+
+	// DefaultLocale — язык по умолчанию: большинство клиентов и мастеров говорят по-русски.
+	DefaultLocale = RU
+)
+
+var Locales = []string{RU, EN, KK}
+
+// Normalize приводит тег языка ("ru-RU", "kk_KZ", "KZ") к поддерживаемому коду.
+// Для неподдерживаемых языков возвращается пустая строка.
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag == "kz" {
+		// Частая ошибка: код страны вместо кода языка.
+		tag = KK
+	}
+	for _, l := range Locales {
+		if l == tag {
+			return l
+		}
+	}
+	return ""
+}
+
+// Negotiate выбирает язык по заголовку Accept-Language с учётом весов q.
+// Если ни один язык не поддерживается, возвращается пустая строка.
+func Negotiate(header string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		c := candidate{tag: strings.TrimSpace(fields[0]), q: 1}
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					c.q = q
+				}
+			}
+		}
+		if c.tag != "" && c.q > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	for _, c := range candidates {
+		if l := Normalize(c.tag); l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+// T переводит сообщение на язык locale. Обёрнутые ошибки вида
+// "invalid profile: invalid email" переводятся по частям; непереведённые
+// части остаются как есть.
+func T(locale, msg string) string {
+	messages, ok := catalog[locale]
+	if !ok {
+		return msg
+	}
+	if s, ok := messages[msg]; ok {
+		return s
+	}
+	if i := strings.Index(msg, ": "); i > 0 {
+		return T(locale, msg[:i]) + ": " + T(locale, msg[i+2:])
+	}
+	return msg
+}
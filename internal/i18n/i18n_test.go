@@ -0,0 +1,43 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, RU, Normalize("ru-RU"))
+	assert.Equal(t, KK, Normalize("kk_KZ"))
+	assert.Equal(t, KK, Normalize("KZ"))
+	assert.Equal(t, EN, Normalize(" en "))
+	assert.Equal(t, "", Normalize("de"))
+	assert.Equal(t, "", Normalize(""))
+}
+
+func TestNegotiate(t *testing.T) {
+	assert.Equal(t, KK, Negotiate("kk-KZ,kk;q=0.9,ru;q=0.8"))
+	assert.Equal(t, EN, Negotiate("de-DE, ru;q=0.5, en;q=0.8"))
+	assert.Equal(t, RU, Negotiate("ru"))
+	assert.Equal(t, "", Negotiate("de, fr;q=0.5"))
+	assert.Equal(t, "", Negotiate("en;q=0, *"))
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Пользователь не найден", T(RU, "User not found"))
+	assert.Equal(t, "Пайдаланушы табылмады", T(KK, "User not found"))
+	assert.Equal(t, "User not found", T(EN, "User not found"))
+	assert.Equal(t, "User not found", T("", "User not found"))
+
+	// Обёрнутые ошибки переводятся по частям, неизвестные части не меняются.
+	assert.Equal(t, "некорректные данные профиля: некорректный email", T(RU, "invalid profile: invalid email"))
+	assert.Equal(t, "недопустимая область доступа: write:all", T(RU, "invalid scope: write:all"))
+	assert.Equal(t, "something odd", T(RU, "something odd"))
+}
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for key := range catalog[RU] {
+		assert.Contains(t, catalog[KK], key)
+	}
+	assert.Len(t, catalog[KK], len(catalog[RU]))
+}
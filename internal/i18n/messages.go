@@ -0,0 +1,285 @@
+package i18n
+
+// catalog содержит переводы исходных (английских) сообщений.
+var catalog = map[string]map[string]string{
+	RU: {
+		// Ответы API
+		"Failed":                       "Не удалось выполнить запрос",
+		"Invalid input":                "Некорректные данные",
+		"Update failed":                "Не удалось обновить",
+		"Login failed":                 "Не удалось войти",
+		"User not found":               "Пользователь не найден",
+		"Service not found":            "Услуга не найдена",
+		"Service or staff not found":   "Услуга или мастер не найдены",
+		"Staff not found":              "Мастер не найден",
+		"Booking not found":            "Запись не найдена",
+		"Export not found":             "Выгрузка не найдена",
+		"Provider not found":           "Провайдер не найден",
+		"Invalid user id":              "Некорректный идентификатор пользователя",
+		"Invalid client id":            "Некорректный идентификатор клиента",
+		"user_id is required":          "Укажите user_id",
+		"Authorization required":       "Требуется авторизация",
+		"Invalid token":                "Недействительный токен",
+		"Invalid API key":              "Недействительный API-ключ",
+		"Forbidden":                    "Доступ запрещён",
+		"Insufficient scope":           "Недостаточно прав у ключа",
+		"Slow down, too many requests": "Слишком много запросов, повторите позже",
+		"Registered":                   "Регистрация завершена",
+		"Logged out":                   "Вы вышли из системы",
+		"Password changed":             "Пароль изменён",
+		"Code sent":                    "Код отправлен",
+
+		// Ошибки сервисов
+		"user not found":                       "пользователь не найден",
+		"invalid credentials":                  "неверный логин или пароль",
+		"sign in again to confirm this action": "войдите заново, чтобы подтвердить действие",
+		"invalid profile":                      "некорректные данные профиля",
+		"username or email is already taken":   "логин или email уже заняты",
+		"username must be 3-32 characters of letters, digits, '.', '_' or '-'": "логин должен содержать 3-32 символа: буквы, цифры, '.', '_' или '-'",
+		"full_name is too long":                   "слишком длинное имя",
+		"invalid email":                           "некорректный email",
+		"password must be 8-72 bytes long":        "пароль должен быть длиной 8-72 байта",
+		"invalid phone number":                    "некорректный номер телефона",
+		"code was sent recently, try again later": "код уже отправлен, повторите позже",
+		"invalid or expired code":                 "неверный или просроченный код",
+		"too many attempts, try again later":      "слишком много попыток, попробуйте позже",
+		"unknown identity provider":               "неизвестный провайдер входа",
+		"invalid or expired login state":          "сессия входа недействительна или истекла",
+		"identity is linked to another user":      "этот аккаунт привязан к другому пользователю",
+		"invalid api key":                         "недействительный API-ключ",
+		"invalid scope":                           "недопустимая область доступа",
+		"name is required":                        "укажите название",
+		"rate_limit must not be negative":         "rate_limit не может быть отрицательным",
+		"export not found":                        "выгрузка не найдена",
+		"export link has expired":                 "срок действия ссылки на выгрузку истёк",
+		"client not found":                        "клиент не найден",
+		"note not found":                          "заметка не найдена",
+		"invalid client card":                     "некорректные данные карточки клиента",
+		"invalid notification preferences":        "некорректные настройки уведомлений",
+		"invalid webhook subscription":            "некорректная подписка на вебхук",
+		"delivery not found":                      "доставка не найдена",
+		"invalid date":                            "некорректная дата",
+		"slot is not available":                   "это время уже занято",
+		"only staff can change booking status":    "менять статус записи может только мастер или администратор",
+		"invalid booking update":                  "запись нельзя изменить",
+		"invalid push device":                     "некорректное устройство для push-уведомлений",
+		"%s (package)":                            "%s (по пакету)",
+		"%s (membership)":                         "%s (по абонементу)",
+		"%s tier discount":                        "Скидка уровня %s",
+		"Paid with points":                        "Оплата баллами",
+		"%s membership discount":                  "Скидка по абонементу «%s»",
+		"Gift card":                               "Подарочный сертификат",
+		"%s membership":                           "Абонемент «%s»",
+		"token is required":                       "нужен токен",
+		"platform must be ios, android or web":    "платформа должна быть ios, android или web",
+		"booking not found":                       "запись не найдена",
+		"invalid locale":                          "неподдерживаемый язык",
+		// Telegram-бот
+		"Account linked. Use /book to book a visit and /bookings to see your bookings.":    "Аккаунт привязан. Записаться: /book, ваши записи: /bookings.",
+		"To use the bot, link your account: open the Telegram link in your salon profile.": "Чтобы пользоваться ботом, привяжите аккаунт: откройте ссылку на Telegram в профиле салона.",
+		"Commands: /book — book a visit, /bookings — my bookings":                          "Команды: /book — записаться, /bookings — мои записи",
+		"Choose a service:": "Выберите услугу:",
+		"Choose a day:":     "Выберите день:",
+		"Choose a time:":    "Выберите время:",
+		"No free time on this day. Choose another day: /book": "На этот день свободного времени нет. Выберите другой день: /book",
+		"Done! See your bookings: /bookings":                  "Готово! Ваши записи: /bookings",
+		"Prepayment is required to confirm the booking":       "Запись подтвердится после предоплаты",
+		"Pay before": "Оплатите до",
+		"Pay":        "Оплатить",
+		"Pay in the app, otherwise the booking will be cancelled": "Оплатите в приложении, иначе запись отменится",
+		"You have no upcoming bookings. Book a visit: /book":      "Предстоящих записей нет. Записаться: /book",
+		"Your upcoming bookings:":                                 "Ваши предстоящие записи:",
+		"Cancel":                                                  "Отменить",
+		"Booking cancelled":                                       "Запись отменена",
+		"Something went wrong, try again later":                   "Что-то пошло не так, попробуйте позже",
+		"invalid or expired link token":                           "ссылка недействительна или устарела, получите новую в приложении",
+		// Календарь
+		"Salon bookings":          "Записи в салон",
+		"Staff":                   "Мастер",
+		"Client":                  "Клиент",
+		"Calendar feed not found": "Календарь не найден",
+		// Платежи
+		"Payment not found":                                   "Платёж не найден",
+		"Payment provider unavailable":                        "Платёжный сервис недоступен, попробуйте позже",
+		"invalid signature":                                   "неверная подпись",
+		"booking is already paid":                             "запись уже оплачена",
+		"booking cannot be paid":                              "эту запись нельзя оплатить",
+		"idempotency key is already used for another booking": "ключ идемпотентности уже использован для другой записи",
+		"payment cannot be refunded":                          "по этому платежу нельзя сделать возврат",
+		"invalid refund amount":                               "некорректная сумма возврата",
+		"refund exceeds paid amount":                          "сумма возврата больше оплаченной",
+		"invalid deposit rule":                                "некорректное правило предоплаты",
+		"deposit rule not found":                              "правило предоплаты не найдено",
+		"invalid checkout":                                    "некорректные данные чека",
+		"booking is already checked out":                      "по записи уже пробит чек",
+		"tenders do not cover the total":                      "оплата не покрывает сумму чека",
+		"receipt not found":                                   "чек не найден",
+		"invalid commission scheme":                           "некорректная схема оплаты мастера",
+		"commission scheme not found":                         "схема оплаты мастера не найдена",
+		"staff not found":                                     "мастер не найден",
+		"invalid membership plan":                             "неверный абонемент",
+		"membership plan not found":                           "абонемент не найден",
+		"client already has a membership":                     "у клиента уже есть абонемент",
+		"membership not found":                                "у вас нет абонемента",
+		"membership has nothing to pay":                       "по абонементу нечего оплачивать",
+		"membership visit limit reached":                      "лимит визитов по абонементу исчерпан",
+		"invalid package":                                     "неверный пакет услуг",
+		"package not found":                                   "пакет услуг не найден",
+		"package has no sessions left":                        "в пакете не осталось сеансов",
+		"invalid loyalty bonus":                               "неверный бонус",
+		"loyalty points must be positive":                     "количество баллов должно быть положительным",
+		"not enough loyalty points":                           "недостаточно баллов",
+		"invalid discount rule":                               "неверное правило скидки",
+		"discount rule not found":                             "правило скидки не найдено",
+		"invalid quote request":                               "неверный запрос расчёта цены",
+		"promo code is not valid":                             "промокод недействителен",
+		"promo code does not apply":                           "промокод не подходит для этой записи",
+		"promo code usage limit reached":                      "лимит использования промокода исчерпан",
+		"invalid gift card":                                   "неверные данные подарочной карты",
+		"gift card not found":                                 "подарочная карта не найдена",
+		"gift card has expired":                               "срок действия подарочной карты истёк",
+		"gift card balance is too low":                        "на подарочной карте недостаточно средств",
+		"invalid tax rate":                                    "некорректная ставка НДС",
+		"tax rate not found":                                  "ставка НДС не найдена",
+	},
+	KK: {
+		// Ответы API
+		"Failed":                       "Сұрауды орындау мүмкін болмады",
+		"Invalid input":                "Деректер қате",
+		"Update failed":                "Жаңарту мүмкін болмады",
+		"Login failed":                 "Кіру мүмкін болмады",
+		"User not found":               "Пайдаланушы табылмады",
+		"Service not found":            "Қызмет табылмады",
+		"Service or staff not found":   "Қызмет немесе шебер табылмады",
+		"Staff not found":              "Шебер табылмады",
+		"Booking not found":            "Жазба табылмады",
+		"Export not found":             "Экспорт табылмады",
+		"Provider not found":           "Провайдер табылмады",
+		"Invalid user id":              "Пайдаланушы идентификаторы қате",
+		"Invalid client id":            "Клиент идентификаторы қате",
+		"user_id is required":          "user_id көрсетіңіз",
+		"Authorization required":       "Авторизация қажет",
+		"Invalid token":                "Токен жарамсыз",
+		"Invalid API key":              "API кілті жарамсыз",
+		"Forbidden":                    "Қол жеткізуге тыйым салынған",
+		"Insufficient scope":           "Кілттің құқықтары жеткіліксіз",
+		"Slow down, too many requests": "Сұраулар тым көп, кейінірек қайталаңыз",
+		"Registered":                   "Тіркелу аяқталды",
+		"Logged out":                   "Жүйеден шықтыңыз",
+		"Password changed":             "Құпиясөз өзгертілді",
+		"Code sent":                    "Код жіберілді",
+
+		// Ошибки сервисов
+		"user not found":                       "пайдаланушы табылмады",
+		"invalid credentials":                  "логин немесе құпиясөз қате",
+		"sign in again to confirm this action": "әрекетті растау үшін қайта кіріңіз",
+		"invalid profile":                      "профиль деректері қате",
+		"username or email is already taken":   "логин немесе email бос емес",
+		"username must be 3-32 characters of letters, digits, '.', '_' or '-'": "логин 3-32 таңбадан тұруы керек: әріптер, сандар, '.', '_' немесе '-'",
+		"full_name is too long":                   "аты тым ұзын",
+		"invalid email":                           "email қате",
+		"password must be 8-72 bytes long":        "құпиясөз ұзындығы 8-72 байт болуы керек",
+		"invalid phone number":                    "телефон нөмірі қате",
+		"code was sent recently, try again later": "код жақында жіберілді, кейінірек қайталаңыз",
+		"invalid or expired code":                 "код қате немесе оның мерзімі өтті",
+		"too many attempts, try again later":      "әрекеттер тым көп, кейінірек қайталаңыз",
+		"unknown identity provider":               "белгісіз кіру провайдері",
+		"invalid or expired login state":          "кіру сессиясы жарамсыз немесе мерзімі өтті",
+		"identity is linked to another user":      "бұл аккаунт басқа пайдаланушыға байланған",
+		"invalid api key":                         "API кілті жарамсыз",
+		"invalid scope":                           "қол жеткізу аясы қате",
+		"name is required":                        "атауын көрсетіңіз",
+		"rate_limit must not be negative":         "rate_limit теріс болмауы керек",
+		"export not found":                        "экспорт табылмады",
+		"export link has expired":                 "экспорт сілтемесінің мерзімі өтті",
+		"client not found":                        "клиент табылмады",
+		"note not found":                          "ескертпе табылмады",
+		"invalid client card":                     "клиент картасының деректері қате",
+		"invalid notification preferences":        "хабарландыру баптаулары қате",
+		"invalid webhook subscription":            "вебхук жазылымы қате",
+		"delivery not found":                      "жеткізу табылмады",
+		"invalid date":                            "күні қате",
+		"slot is not available":                   "бұл уақыт бос емес",
+		"only staff can change booking status":    "жазба мәртебесін тек шебер немесе әкімші өзгерте алады",
+		"invalid booking update":                  "жазбаны өзгертуге болмайды",
+		"invalid push device":                     "push-хабарламаларға арналған құрылғы қате",
+		"%s (package)":                            "%s (пакет бойынша)",
+		"%s (membership)":                         "%s (абонемент бойынша)",
+		"%s tier discount":                        "%s деңгейінің жеңілдігі",
+		"Paid with points":                        "Ұпайлармен төлем",
+		"%s membership discount":                  "«%s» абонементі бойынша жеңілдік",
+		"Gift card":                               "Сыйлық сертификаты",
+		"%s membership":                           "«%s» абонементі",
+		"token is required":                       "токен қажет",
+		"platform must be ios, android or web":    "платформа ios, android немесе web болуы керек",
+		"booking not found":                       "жазба табылмады",
+		"invalid locale":                          "тіл қолдау көрсетілмейді",
+		// Telegram-бот
+		"Account linked. Use /book to book a visit and /bookings to see your bookings.":    "Аккаунт байланыстырылды. Жазылу: /book, жазбаларыңыз: /bookings.",
+		"To use the bot, link your account: open the Telegram link in your salon profile.": "Ботты пайдалану үшін аккаунтты байланыстырыңыз: салон профиліндегі Telegram сілтемесін ашыңыз.",
+		"Commands: /book — book a visit, /bookings — my bookings":                          "Командалар: /book — жазылу, /bookings — менің жазбаларым",
+		"Choose a service:": "Қызметті таңдаңыз:",
+		"Choose a day:":     "Күнді таңдаңыз:",
+		"Choose a time:":    "Уақытты таңдаңыз:",
+		"No free time on this day. Choose another day: /book": "Бұл күні бос уақыт жоқ. Басқа күнді таңдаңыз: /book",
+		"Done! See your bookings: /bookings":                  "Дайын! Жазбаларыңыз: /bookings",
+		"Prepayment is required to confirm the booking":       "Жазба алдын ала төлемнен кейін расталады",
+		"Pay before": "Төлеу мерзімі",
+		"Pay":        "Төлеу",
+		"Pay in the app, otherwise the booking will be cancelled": "Қосымшада төлеңіз, әйтпесе жазба күшін жояды",
+		"You have no upcoming bookings. Book a visit: /book":      "Алдағы жазбалар жоқ. Жазылу: /book",
+		"Your upcoming bookings:":                                 "Алдағы жазбаларыңыз:",
+		"Cancel":                                                  "Болдырмау",
+		"Booking cancelled":                                       "Жазба болдырылмады",
+		"Something went wrong, try again later":                   "Бірдеңе дұрыс болмады, кейінірек қайталаңыз",
+		"invalid or expired link token":                           "сілтеме жарамсыз немесе ескірген, қосымшадан жаңасын алыңыз",
+		// Күнтізбе
+		"Salon bookings":          "Салондағы жазбалар",
+		"Staff":                   "Шебер",
+		"Client":                  "Клиент",
+		"Calendar feed not found": "Күнтізбе табылмады",
+		// Төлемдер
+		"Payment not found":                                   "Төлем табылмады",
+		"Payment provider unavailable":                        "Төлем қызметі қолжетімсіз, кейінірек қайталаңыз",
+		"invalid signature":                                   "қолтаңба жарамсыз",
+		"booking is already paid":                             "жазба төленіп қойған",
+		"booking cannot be paid":                              "бұл жазбаны төлеуге болмайды",
+		"idempotency key is already used for another booking": "идемпотенттілік кілті басқа жазба үшін қолданылған",
+		"payment cannot be refunded":                          "бұл төлем бойынша қайтару мүмкін емес",
+		"invalid refund amount":                               "қайтару сомасы қате",
+		"refund exceeds paid amount":                          "қайтару сомасы төленген сомадан асады",
+		"invalid deposit rule":                                "алдын ала төлем ережесі қате",
+		"deposit rule not found":                              "алдын ала төлем ережесі табылмады",
+		"invalid checkout":                                    "чек деректері қате",
+		"booking is already checked out":                      "жазба бойынша чек бұрын шығарылған",
+		"tenders do not cover the total":                      "төлем чек сомасын жаппайды",
+		"receipt not found":                                   "чек табылмады",
+		"invalid commission scheme":                           "шебер төлемінің схемасы қате",
+		"commission scheme not found":                         "шебер төлемінің схемасы табылмады",
+		"staff not found":                                     "шебер табылмады",
+		"invalid membership plan":                             "абонемент қате",
+		"membership plan not found":                           "абонемент табылмады",
+		"client already has a membership":                     "клиентте абонемент бар",
+		"membership not found":                                "сізде абонемент жоқ",
+		"membership has nothing to pay":                       "абонемент бойынша төлейтін ештеңе жоқ",
+		"membership visit limit reached":                      "абонемент бойынша келу лимиті таусылды",
+		"invalid package":                                     "қызметтер пакеті қате",
+		"package not found":                                   "қызметтер пакеті табылмады",
+		"package has no sessions left":                        "пакетте сеанс қалмады",
+		"invalid loyalty bonus":                               "бонус қате",
+		"loyalty points must be positive":                     "ұпай саны оң болуы керек",
+		"not enough loyalty points":                           "ұпай жеткіліксіз",
+		"invalid discount rule":                               "жеңілдік ережесі қате",
+		"discount rule not found":                             "жеңілдік ережесі табылмады",
+		"invalid quote request":                               "баға есептеу сұрауы қате",
+		"promo code is not valid":                             "промокод жарамсыз",
+		"promo code does not apply":                           "промокод бұл жазылуға қолданылмайды",
+		"promo code usage limit reached":                      "промокодты пайдалану шегі таусылды",
+		"invalid gift card":                                   "сыйлық картасының деректері қате",
+		"gift card not found":                                 "сыйлық картасы табылмады",
+		"gift card has expired":                               "сыйлық картасының мерзімі өтті",
+		"gift card balance is too low":                        "сыйлық картасында қаражат жеткіліксіз",
+		"invalid tax rate":                                    "ҚҚС мөлшерлемесі дұрыс емес",
+		"tax rate not found":                                  "ҚҚС мөлшерлемесі табылмады",
+	},
+}
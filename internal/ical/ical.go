@@ -0,0 +1,110 @@
+// Package ical формирует календари iCalendar (RFC 5545) из записей салона.
+package ical
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	prodID     = "-//Beauty Salon//Bookings//RU"
+	utcLayout  = "20060102T150405Z"
+	lineLength = 75 // октетов без CRLF
+)
+
+// Статусы VEVENT.
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// Event — одно событие календаря. UID должен быть стабильным: по нему
+// клиенты календаря узнают событие при обновлении и отмене.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Status      string
+	// Sequence растёт с каждым изменением события.
+	Sequence     int
+	LastModified time.Time
+}
+
+// Calendar — VCALENDAR с набором событий. Method задаётся для вложений
+// в письма (PUBLISH, CANCEL); для подписок он не нужен.
+type Calendar struct {
+	Name   string
+	Method string
+	Events []Event
+}
+
+// Encode сериализует календарь; stamp попадает в DTSTAMP всех событий.
+func (c Calendar) Encode(stamp time.Time) []byte {
+	var b bytes.Buffer
+	w := func(name, value string) { writeLine(&b, name+":"+value) }
+	w("BEGIN", "VCALENDAR")
+	w("VERSION", "2.0")
+	w("PRODID", prodID)
+	w("CALSCALE", "GREGORIAN")
+	if c.Method != "" {
+		w("METHOD", c.Method)
+	}
+	if c.Name != "" {
+		w("X-WR-CALNAME", escape(c.Name))
+	}
+	for _, e := range c.Events {
+		w("BEGIN", "VEVENT")
+		w("UID", e.UID)
+		w("DTSTAMP", formatTime(stamp))
+		w("DTSTART", formatTime(e.Start))
+		w("DTEND", formatTime(e.End))
+		w("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			w("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			w("LOCATION", escape(e.Location))
+		}
+		if e.Status != "" {
+			w("STATUS", e.Status)
+		}
+		w("SEQUENCE", strconv.Itoa(e.Sequence))
+		if !e.LastModified.IsZero() {
+			w("LAST-MODIFIED", formatTime(e.LastModified))
+		}
+		w("END", "VEVENT")
+	}
+	w("END", "VCALENDAR")
+	return b.Bytes()
+}
+
+func formatTime(t time.Time) string { return t.UTC().Format(utcLayout) }
+
+// escape экранирует значение типа TEXT (RFC 5545, 3.3.11).
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeLine пишет строку с переносом по 75 октетов, не разрывая символы UTF-8.
+func writeLine(b *bytes.Buffer, line string) {
+	limit := lineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = lineLength - 1 // продолжение начинается с пробела
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,58 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	start := time.Date(2026, 3, 10, 14, 0, 0, 0, time.FixedZone("ALMT", 5*3600))
+	cal := Calendar{
+		Name: "Записи",
+		Events: []Event{{
+			UID:          "booking-7@beauty-salon",
+			Summary:      "Стрижка, укладка",
+			Description:  "Мастер: Анна\nКаб. 2; 2 этаж",
+			Start:        start,
+			End:          start.Add(time.Hour),
+			Status:       StatusCancelled,
+			Sequence:     3,
+			LastModified: start,
+		}},
+	}
+	out := string(cal.Encode(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)))
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, out, "UID:booking-7@beauty-salon\r\n")
+	assert.Contains(t, out, "DTSTAMP:20260301T000000Z\r\n")
+	assert.Contains(t, out, "DTSTART:20260310T090000Z\r\n")
+	assert.Contains(t, out, "DTEND:20260310T100000Z\r\n")
+	assert.Contains(t, out, `SUMMARY:Стрижка\, укладка`)
+	assert.Contains(t, out, `DESCRIPTION:Мастер: Анна\nКаб. 2\; 2 этаж`)
+	assert.Contains(t, out, "STATUS:CANCELLED\r\n")
+	assert.Contains(t, out, "SEQUENCE:3\r\n")
+	assert.NotContains(t, out, "METHOD:")
+}
+
+func TestWriteLineFolding(t *testing.T) {
+	var b bytes.Buffer
+	writeLine(&b, "SUMMARY:"+strings.Repeat("я", 100))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	var joined string
+	for i, l := range lines {
+		assert.LessOrEqual(t, len(l), lineLength, "line %d", i)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(l, " "))
+			l = l[1:]
+		}
+		joined += l
+	}
+	assert.Equal(t, "SUMMARY:"+strings.Repeat("я", 100), joined)
+}
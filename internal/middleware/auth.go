@@ -1,33 +1,113 @@
 package middleware
 
 import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/i18n"
+	"beauty-salon/internal/models"
+	"context"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"net/http"
-	"os"
 	"strings"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+const APIKeyHeader = "X-API-Key"
+
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(raw string) (*models.APIKey, error)
+}
+
+type UserLookup interface {
+	GetUserByID(id uint) (*models.User, error)
+}
+
+// AuthMiddleware принимает Bearer JWT, а если keys != nil — ещё и API-ключ
+// в заголовке X-API-Key. Запрос по ключу не имеет userID: доступ к маршруту
+// ему даёт только RequireScope.
+func AuthMiddleware(tokens auth.TokenVerifier, keys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if raw := c.GetHeader(APIKeyHeader); raw != "" && keys != nil {
+			key, err := keys.AuthenticateAPIKey(raw)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c.GetString("locale"), "Invalid API key")})
+				return
+			}
+			c.Set("apiKey", key)
+			c.Next()
+			return
+		}
+
 		tokenStr := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
 		if tokenStr == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c.GetString("locale"), "Authorization required")})
 			return
 		}
 
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		claims, err := tokens.Verify(tokenStr)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c.GetString("locale"), "Invalid token")})
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		if claims.IssuedAt != nil {
+			c.Set("authTime", claims.IssuedAt.Time) // для действий, требующих свежего входа
+		}
+		c.Next()
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+// RequireScope пропускает пользователей с JWT и API-ключи с нужной областью.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v, ok := c.Get("apiKey"); ok {
+			if !v.(*models.APIKey).HasScope(scope) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": i18n.T(c.GetString("locale"), "Insufficient scope")})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireRole пропускает только пользователей с одной из указанных ролей.
+func RequireRole(users UserLookup, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := c.Get("userID")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": i18n.T(c.GetString("locale"), "Forbidden")})
+			return
+		}
+		u, err := users.GetUserByID(id.(uint))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": i18n.T(c.GetString("locale"), "Forbidden")})
 			return
 		}
+		for _, role := range roles {
+			if u.Role == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": i18n.T(c.GetString("locale"), "Forbidden")})
+	}
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("userID", uint(claims["user_id"].(float64)))
+// TicketRedeemer гасит одноразовый билет на поток — это service.LiveFeed.
+type TicketRedeemer interface {
+	RedeemTicket(ctx context.Context, ticket string) (uint, error)
+}
+
+// StreamTicket авторизует по одноразовому билету из параметра запроса:
+// браузерный EventSource не умеет передавать заголовки, а JWT в URL осел бы
+// в логах доступа.
+func StreamTicket(tickets TicketRedeemer, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := tickets.RedeemTicket(c.Request.Context(), c.Query(param))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c.GetString("locale"), "Invalid token")})
+			return
 		}
+		c.Set("userID", id)
 		c.Next()
 	}
 }
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/models"
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 
@@ -14,14 +17,15 @@ import (
 
 func TestAuthMiddleware(t *testing.T) {
 	secret := "test_secret"
-	os.Setenv("JWT_SECRET", secret)
+	key, _ := auth.NewHMACKey("test", []byte(secret))
+	tokens, _ := auth.NewTokenManager("", time.Hour, key)
 	gin.SetMode(gin.TestMode)
 
 	t.Run("Missing Authorization Header", func(t *testing.T) {
 		resp := httptest.NewRecorder()
 		c, r := gin.CreateTestContext(resp)
 
-		r.Use(AuthMiddleware())
+		r.Use(AuthMiddleware(tokens, nil))
 		r.GET("/test", func(c *gin.Context) { c.Status(200) })
 
 		c.Request, _ = http.NewRequest("GET", "/test", nil)
@@ -35,7 +39,7 @@ func TestAuthMiddleware(t *testing.T) {
 		resp := httptest.NewRecorder()
 		c, r := gin.CreateTestContext(resp)
 
-		r.Use(AuthMiddleware())
+		r.Use(AuthMiddleware(tokens, nil))
 		r.GET("/test", func(c *gin.Context) { c.Status(200) })
 
 		c.Request, _ = http.NewRequest("GET", "/test", nil)
@@ -50,17 +54,20 @@ func TestAuthMiddleware(t *testing.T) {
 		resp := httptest.NewRecorder()
 		c, r := gin.CreateTestContext(resp)
 
+		issued := time.Now().Add(-time.Minute).Truncate(time.Second)
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 			"user_id": float64(123),
+			"iat":     issued.Unix(),
 			"exp":     time.Now().Add(time.Hour).Unix(),
 		})
 		tokenString, _ := token.SignedString([]byte(secret))
 
-		r.Use(AuthMiddleware())
+		r.Use(AuthMiddleware(tokens, nil))
 		r.GET("/test", func(c *gin.Context) {
 			userID, exists := c.Get("userID")
 			assert.True(t, exists)
 			assert.Equal(t, uint(123), userID)
+			assert.True(t, issued.Equal(c.GetTime("authTime")))
 			c.Status(http.StatusOK)
 		})
 
@@ -70,4 +77,155 @@ func TestAuthMiddleware(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, resp.Code)
 	})
+
+	t.Run("Algorithm None Rejected", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		c, r := gin.CreateTestContext(resp)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+			"user_id": float64(123),
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		})
+		tokenString, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+		r.Use(AuthMiddleware(tokens, nil))
+		r.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		c.Request, _ = http.NewRequest("GET", "/test", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+		r.ServeHTTP(resp, c.Request)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+}
+
+type fakeKeys map[string]*models.APIKey
+
+func (f fakeKeys) AuthenticateAPIKey(raw string) (*models.APIKey, error) {
+	if k, ok := f[raw]; ok {
+		return k, nil
+	}
+	return nil, errors.New("invalid api key")
+}
+
+type fakeUsers map[uint]*models.User
+
+func (f fakeUsers) GetUserByID(id uint) (*models.User, error) {
+	if u, ok := f[id]; ok {
+		return u, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestAuthMiddlewareAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key, _ := auth.NewHMACKey("test", []byte("test_secret"))
+	tokens, _ := auth.NewTokenManager("", time.Hour, key)
+	keys := fakeKeys{"sk_good": {Name: "kiosk", Scopes: "read:bookings"}}
+
+	newRouter := func(keys APIKeyAuthenticator) *gin.Engine {
+		r := gin.New()
+		r.Use(AuthMiddleware(tokens, keys))
+		r.GET("/bookings", RequireScope(models.ScopeReadBookings), func(c *gin.Context) {
+			_, hasUser := c.Get("userID")
+			assert.False(t, hasUser)
+			c.Status(http.StatusOK)
+		})
+		r.POST("/bookings", RequireScope(models.ScopeWriteBookings), func(c *gin.Context) { c.Status(http.StatusCreated) })
+		return r
+	}
+
+	cases := []struct {
+		name   string
+		keys   APIKeyAuthenticator
+		method string
+		apiKey string
+		code   int
+	}{
+		{"Valid Key With Scope", keys, "GET", "sk_good", http.StatusOK},
+		{"Valid Key Without Scope", keys, "POST", "sk_good", http.StatusForbidden},
+		{"Invalid Key", keys, "GET", "sk_bad", http.StatusUnauthorized},
+		{"Keys Disabled", nil, "GET", "sk_good", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/bookings", nil)
+			req.Header.Set(APIKeyHeader, tc.apiKey)
+			w := httptest.NewRecorder()
+			newRouter(tc.keys).ServeHTTP(w, req)
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+
+	t.Run("JWT Bypasses Scope Check", func(t *testing.T) {
+		tokenString, _ := tokens.Issue(1)
+		req := httptest.NewRequest("POST", "/bookings", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+		newRouter(keys).ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := fakeUsers{1: {Role: "admin"}, 2: {Role: "client"}, 4: {Role: "staff"}}
+
+	for _, tc := range []struct {
+		name   string
+		userID interface{}
+		path   string
+		code   int
+	}{
+		{"Admin", uint(1), "/admin", http.StatusOK},
+		{"Client", uint(2), "/admin", http.StatusForbidden},
+		{"Unknown User", uint(3), "/admin", http.StatusForbidden},
+		{"API Key", nil, "/admin", http.StatusForbidden},
+		{"Staff On Admin", uint(4), "/admin", http.StatusForbidden},
+		{"Staff On Staff Route", uint(4), "/clients", http.StatusOK},
+		{"Admin On Staff Route", uint(1), "/clients", http.StatusOK},
+		{"Client On Staff Route", uint(2), "/clients", http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tc.userID != nil {
+					c.Set("userID", tc.userID)
+				}
+			})
+			r.GET("/admin", RequireRole(users, "admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+			r.GET("/clients", RequireRole(users, "staff", "admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+}
+
+type ticketsFunc func(ticket string) (uint, error)
+
+func (f ticketsFunc) RedeemTicket(_ context.Context, ticket string) (uint, error) { return f(ticket) }
+
+func TestStreamTicket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tickets := ticketsFunc(func(ticket string) (uint, error) {
+		if ticket == "good" {
+			return 5, nil
+		}
+		return 0, errors.New("invalid or expired stream ticket")
+	})
+	r := gin.New()
+	r.GET("/live", StreamTicket(tickets, "ticket"), func(c *gin.Context) {
+		c.String(http.StatusOK, "%d", c.GetUint("userID"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/live?ticket=good", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "5", w.Body.String())
+
+	// JWT в URL больше не принимается.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/live?access_token=abc", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"beauty-salon/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale выбирает язык ответа: сначала язык, сохранённый в профиле (если
+// users != nil и пользователь уже аутентифицирован), затем Accept-Language,
+// иначе язык по умолчанию. Явный выбор клиента важнее настроек браузера.
+// Выбранный язык сохраняется в контексте под ключом "locale".
+func Locale(users UserLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var locale string
+		if id, ok := c.Get("userID"); ok && users != nil {
+			if u, err := users.GetUserByID(id.(uint)); err == nil {
+				locale = u.Locale
+			}
+		}
+		if locale == "" {
+			locale = i18n.Negotiate(c.GetHeader("Accept-Language"))
+		}
+		if locale == "" {
+			locale = i18n.DefaultLocale
+		}
+		c.Set("locale", locale)
+		c.Next()
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := fakeUsers{1: {Locale: "kk"}, 2: {}}
+
+	for _, tc := range []struct {
+		name   string
+		header string
+		userID interface{}
+		want   string
+	}{
+		{"Default", "", nil, "ru"},
+		{"Header", "en-US,en;q=0.9", nil, "en"},
+		{"Unsupported Header", "de", nil, "ru"},
+		{"Profile", "", uint(1), "kk"},
+		{"Profile Beats Header", "en", uint(1), "kk"},
+		{"Profile Without Locale", "", uint(2), "ru"},
+		{"Header When Profile Has No Locale", "en", uint(2), "en"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tc.userID != nil {
+					c.Set("userID", tc.userID)
+				}
+			})
+			r.GET("/", Locale(users), func(c *gin.Context) { c.String(http.StatusOK, c.GetString("locale")) })
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Accept-Language", tc.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.want, w.Body.String())
+		})
+	}
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Locale(nil))
+	r.GET("/admin", RequireRole(fakeUsers{1: {Role: "admin"}}, "admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest("GET", "/admin", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Доступ запрещён")
+
+	req.Header.Set("Accept-Language", "en")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), "Forbidden")
+}
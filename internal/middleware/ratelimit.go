@@ -1,32 +1,74 @@
 package middleware
 
 import (
+	"beauty-salon/internal/i18n"
+	"beauty-salon/internal/models"
 	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"net/http"
 	"time"
 )
 
+// RateLimiter считает запросы по IP. Для запросов с API-ключом (лимитер стоит
+// после AuthMiddleware) используется отдельный счётчик ключа и его собственный
+// лимит, если он задан.
 func RateLimiter(rdb *redis.Client, limit int, window time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
 		key := "rate_limit:" + c.ClientIP()
+		max := limit
 
-		count, err := rdb.Incr(ctx, key).Result()
-		if err != nil {
+		if v, ok := c.Get("apiKey"); ok {
+			apiKey := v.(*models.APIKey)
+			key = fmt.Sprintf("rate_limit:api_key:%d", apiKey.ID)
+			if apiKey.RateLimit > 0 {
+				max = apiKey.RateLimit
+			}
+		} else if c.GetBool("rateLimited") {
+			// IP уже посчитан лимитером уровнем выше.
+			c.Next()
+			return
+		} else if c.GetHeader(APIKeyHeader) != "" {
+			// Ключ ещё не проверен, поэтому запрос сразу засчитывается IP — иначе
+			// параллельная пачка запросов с мусорным ключом прошла бы мимо лимита.
+			// Если ключ принят, запрос посчитал лимитер ключа, и IP его возвращает.
+			c.Set("rateLimited", true)
+			count, err := hit(ctx, rdb, key, window)
+			if err == nil && count > int64(max) {
+				tooMany(c)
+				return
+			}
 			c.Next()
+			if _, ok := c.Get("apiKey"); ok && err == nil {
+				rdb.Decr(ctx, key)
+			}
 			return
 		}
+		c.Set("rateLimited", true)
 
-		if count == 1 {
-			rdb.Expire(ctx, key, window)
+		count, err := hit(ctx, rdb, key, window)
+		if err != nil {
+			c.Next()
+			return
 		}
-
-		if count > int64(limit) {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Slow down, too many requests"})
+		if count > int64(max) {
+			tooMany(c)
 			return
 		}
 		c.Next()
 	}
 }
+
+func hit(ctx context.Context, rdb *redis.Client, key string, window time.Duration) (int64, error) {
+	count, err := rdb.Incr(ctx, key).Result()
+	if err == nil && count == 1 {
+		rdb.Expire(ctx, key, window)
+	}
+	return count, err
+}
+
+func tooMany(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": i18n.T(c.GetString("locale"), "Slow down, too many requests")})
+}
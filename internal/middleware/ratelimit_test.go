@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"beauty-salon/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redismock/v9"
 	"github.com/redis/go-redis/v9"
@@ -74,4 +75,77 @@ func TestRateLimiter(t *testing.T) {
 		assert.Equal(t, http.StatusOK, resp.Code)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("Per API Key Limit", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		apiKey := &models.APIKey{RateLimit: 1}
+		apiKey.ID = 7
+
+		r := gin.New()
+		r.Use(RateLimiter(db, 100, time.Minute))
+		r.Use(func(c *gin.Context) { c.Set("apiKey", apiKey) })
+		r.Use(RateLimiter(db, 100, time.Minute))
+		r.GET("/test", func(c *gin.Context) { c.Status(200) })
+
+		// Запрос по ключу не расходует лимит IP: до проверки ключа он засчитан
+		// IP, а после — возвращён, и остаётся только счётчик ключа.
+		mock.ExpectIncr("rate_limit:127.0.0.1").SetVal(1)
+		mock.ExpectExpire("rate_limit:127.0.0.1", time.Minute).SetVal(true)
+		mock.ExpectIncr("rate_limit:api_key:7").SetVal(2)
+		mock.ExpectDecr("rate_limit:127.0.0.1").SetVal(0)
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		req.Header.Set(APIKeyHeader, "sk_test")
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("IP Counted Once", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+
+		r := gin.New()
+		r.Use(RateLimiter(db, 5, time.Minute))
+		r.Use(RateLimiter(db, 5, time.Minute))
+		r.GET("/test", func(c *gin.Context) { c.Status(200) })
+
+		mock.ExpectIncr("rate_limit:127.0.0.1").SetVal(2)
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejected API Key Counted Per IP", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+
+		r := gin.New()
+		r.Use(RateLimiter(db, 5, time.Minute))
+		r.GET("/test", func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) })
+
+		mock.ExpectIncr("rate_limit:127.0.0.1").SetVal(3)
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		req.Header.Set(APIKeyHeader, "sk_bad")
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+		// Переполненный счётчик IP отсекает перебор ключей до проверки.
+		mock.ExpectIncr("rate_limit:127.0.0.1").SetVal(6)
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
@@ -1,20 +1,64 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"beauty-salon/internal/money"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
-	Username string `gorm:"unique;not null" json:"username"`
-	Password string `json:"-"`
-	Role     string `gorm:"default:client" json:"role"` // client, admin
+	Username string  `gorm:"unique;not null" json:"username"`
+	Password string  `json:"-"`
+	Role     string  `gorm:"default:client" json:"role"`         // client, staff, admin
+	Phone    *string `gorm:"uniqueIndex" json:"phone,omitempty"` // E.164, например +77011234567
+	Email    *string `gorm:"uniqueIndex" json:"email,omitempty"`
+	FullName string  `json:"full_name"`
+	Locale   string  `json:"locale,omitempty"` // ru, en, kk; пусто — язык по умолчанию
 }
 
 type Service struct {
 	gorm.Model
-	Title       string  `json:"title"`
-	Price       float64 `json:"price"`
-	DurationMin int     `json:"duration_min"` // Длительность процедуры в минутах
-	Description string  `json:"description"`
+	Title       string      `json:"title"`
+	Category    string      `gorm:"index" json:"category,omitempty"` // категория для ставки НДС
+	Price       money.Money `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	DurationMin int         `json:"duration_min"` // Длительность процедуры в минутах
+	Description string      `json:"description"`
+	// Переводы названия и описания, ключ — язык (en, kk). Основной текст — на русском.
+	Translations map[string]ServiceText `gorm:"serializer:json;type:jsonb" json:"translations,omitempty"`
+}
+
+// DefaultServiceDuration — сколько занимает процедура без указанной длительности.
+const DefaultServiceDuration = 30 * time.Minute
+
+// Duration — сколько процедура занимает мастера.
+func (s *Service) Duration() time.Duration {
+	if s.DurationMin <= 0 {
+		return DefaultServiceDuration
+	}
+	return time.Duration(s.DurationMin) * time.Minute
+}
+
+type ServiceText struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// Localized возвращает копию услуги с текстом на языке locale.
+// Непереведённые поля остаются на основном языке.
+func (s Service) Localized(locale string) Service {
+	if t, ok := s.Translations[locale]; ok {
+		if t.Title != "" {
+			s.Title = t.Title
+		}
+		if t.Description != "" {
+			s.Description = t.Description
+		}
+	}
+	return s
 }
 
 type Staff struct {
@@ -29,9 +73,642 @@ type Booking struct {
 	ServiceID uint   `json:"service_id"`
 	StaffID   uint   `json:"staff_id"`
 	Date      string `json:"date"`                          // YYYY-MM-DD HH:MM
-	Status    string `gorm:"default:pending" json:"status"` // pending, confirmed, cancelled
+	Status    string `gorm:"default:pending" json:"status"` // pending, awaiting_payment, confirmed, completed, cancelled
+	// Предоплата и срок её внесения; нулевая сумма — предоплата не нужна.
+	DepositAmount money.Money `gorm:"embedded;embeddedPrefix:deposit_" json:"deposit_amount,omitzero"`
+	PaymentDueAt  *time.Time  `json:"payment_due_at,omitempty"`
+	// Промокод и скидка по правилам на момент записи; окончательно скидка
+	// считается на кассе.
+	PromoCode string      `gorm:"size:32" json:"promo_code,omitempty"`
+	Discount  money.Money `gorm:"embedded;embeddedPrefix:discount_" json:"discount,omitzero"`
+	// Сеанс купленного пакета, которым оплачен визит; при отмене сеанс возвращается.
+	PackageSessionID *uint `gorm:"index" json:"package_session_id,omitempty"`
+	// Абонемент, в который входит визит; такие визиты считаются в лимит периода.
+	SubscriptionID *uint `gorm:"index" json:"subscription_id,omitempty"`
 
 	User    User    `gorm:"foreignKey:UserID" json:"user"`
 	Service Service `gorm:"foreignKey:ServiceID" json:"service"`
 	Staff   Staff   `gorm:"foreignKey:StaffID" json:"staff"`
 }
+
+// Prepaid сообщает, что визит уже оплачен пакетом или абонементом.
+func (b *Booking) Prepaid() bool { return b.PackageSessionID != nil || b.SubscriptionID != nil }
+
+// UserIdentity связывает пользователя с внешней учётной записью (OIDC).
+type UserIdentity struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;index" json:"user_id"`
+	Provider string `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"provider"` // google, yandex, ...
+	Subject  string `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"-"`
+	Email    string `json:"email"`
+}
+
+// Области доступа API-ключей.
+const (
+	ScopeReadBookings  = "read:bookings"
+	ScopeWriteBookings = "write:bookings"
+	ScopeReadCatalog   = "read:catalog" // услуги и мастера
+)
+
+var APIKeyScopes = []string{ScopeReadBookings, ScopeWriteBookings, ScopeReadCatalog}
+
+// APIKey — ключ для интеграций и устройств (планшет администратора, бухгалтерия).
+// Хранится только SHA-256 секрета; Prefix нужен для поиска ключа.
+type APIKey struct {
+	gorm.Model
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"uniqueIndex;not null" json:"prefix"`
+	KeyHash    string     `gorm:"not null" json:"-"`
+	Scopes     string     `json:"scopes"`     // через запятую: read:bookings,write:bookings
+	RateLimit  int        `json:"rate_limit"` // запросов в минуту, 0 — общий лимит
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedBy  uint       `json:"created_by"`
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	return containsItem(k.Scopes, scope)
+}
+
+// DataExport — заявка на выгрузку персональных данных пользователя (zip с JSON).
+type DataExport struct {
+	gorm.Model
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	RequestedBy uint       `json:"requested_by"`
+	Status      string     `gorm:"default:pending;index" json:"status"` // pending, processing, ready, failed, expired
+	Token       string     `gorm:"index" json:"-"`                      // секрет ссылки на скачивание
+	Archive     []byte     `json:"-"`
+	Error       string     `json:"error,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LeaseUntil  *time.Time `json:"-"` // до какого момента заявка закреплена за обработчиком
+}
+
+// ClientProfile — карточка клиента, которую видят мастера и администраторы.
+type ClientProfile struct {
+	gorm.Model
+	UserID            uint   `gorm:"uniqueIndex;not null" json:"user_id"`
+	Allergies         string `json:"allergies"`         // через запятую: "краситель X, латекс"
+	Contraindications string `json:"contraindications"` // через запятую
+	PreferredStaffID  *uint  `json:"preferred_staff_id"`
+	RiskLevel         string `gorm:"default:low" json:"risk_level"` // low, medium, high — для правил предоплаты
+
+	PreferredStaff *Staff `gorm:"foreignKey:PreferredStaffID" json:"preferred_staff,omitempty"`
+}
+
+// ClientNote — заметка персонала о клиенте. Клиенту не показывается.
+type ClientNote struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;index" json:"user_id"`
+	AuthorID uint   `json:"author_id"`
+	Text     string `gorm:"not null" json:"text"`
+}
+
+// VisitHistory — агрегированная история посещений клиента.
+type VisitHistory struct {
+	TotalVisits int64       `json:"total_visits"`
+	TotalSpend  money.Money `json:"total_spend"`
+	LastVisit   string      `json:"last_visit,omitempty"` // YYYY-MM-DD HH:MM
+	Visits      []Booking   `json:"visits"`
+}
+
+// Notification — сообщение клиенту в одном канале. Текст рендерится при постановке
+// в очередь; доставка повторяется с нарастающей задержкой.
+type Notification struct {
+	gorm.Model
+	UserID        uint       `gorm:"not null;index" json:"user_id"`
+	BookingID     *uint      `gorm:"index" json:"booking_id"`
+	Event         string     `json:"event"`
+	Channel       string     `json:"channel"` // sms, email, push
+	To            string     `json:"-"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	Status        string     `gorm:"default:pending;index" json:"status"` // pending, sent, failed
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index" json:"-"`
+	LastError     string     `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at"`
+	DedupKey      *string    `gorm:"uniqueIndex" json:"-"` // защищает от повторных напоминаний
+}
+
+// NotificationPreference — от каких каналов и событий клиент отписался.
+type NotificationPreference struct {
+	gorm.Model
+	UserID           uint   `gorm:"uniqueIndex;not null" json:"user_id"`
+	DisabledChannels string `json:"disabled_channels"` // через запятую: sms,email
+	DisabledEvents   string `json:"disabled_events"`   // через запятую: booking_reminder
+}
+
+func (p *NotificationPreference) Allows(channel, event string) bool {
+	return !containsItem(p.DisabledChannels, channel) && !containsItem(p.DisabledEvents, event)
+}
+
+func containsItem(list, item string) bool {
+	for _, s := range strings.Split(list, ",") {
+		if strings.TrimSpace(s) == item {
+			return true
+		}
+	}
+	return false
+}
+
+// Виды согласий клиента. Согласие на уведомления — по каналу:
+// ConsentNotifications + "sms".
+const (
+	ConsentPersonalData  = "personal_data"  // обработка персональных данных
+	ConsentNotifications = "notifications:" // уведомления по каналу
+)
+
+// Consent — запись журнала согласий: выдача (Granted) или отзыв. Журнал
+// только пополняется, действует последняя запись того же вида.
+type Consent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Kind      string    `gorm:"not null" json:"kind"`
+	Granted   bool      `json:"granted"`
+	Source    string    `json:"source"` // sign_up, oidc, notification_preferences
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OutboxEvent — доменное событие, записанное в одной транзакции с изменением
+// состояния. Relay публикует его подписчикам и отмечает published.
+type OutboxEvent struct {
+	gorm.Model
+	EventID       string     `gorm:"uniqueIndex;not null" json:"event_id"` // ключ дедупликации у получателей
+	Type          string     `gorm:"index;not null" json:"type"`
+	AggregateID   uint       `json:"aggregate_id"`
+	Payload       string     `gorm:"type:jsonb" json:"payload"`
+	Status        string     `gorm:"default:pending;index" json:"status"` // pending, published
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	PublishedAt   *time.Time `json:"published_at"`
+}
+
+// WebhookSubscription — подписка внешней системы (CRM, бухгалтерия) на события.
+type WebhookSubscription struct {
+	gorm.Model
+	URL        string `gorm:"not null" json:"url"`
+	Secret     string `gorm:"not null" json:"-"` // ключ HMAC-подписи
+	EventTypes string `json:"event_types"`       // через запятую; "*" — все события
+	Active     bool   `gorm:"default:true" json:"active"`
+	CreatedBy  uint   `json:"created_by"`
+}
+
+func (w *WebhookSubscription) Accepts(eventType string) bool {
+	return containsItem(w.EventTypes, "*") || containsItem(w.EventTypes, eventType)
+}
+
+// WebhookDelivery — доставка одного события одной подписке и журнал попыток.
+type WebhookDelivery struct {
+	gorm.Model
+	SubscriptionID uint       `gorm:"not null;uniqueIndex:idx_delivery_subscription_event" json:"subscription_id"`
+	EventID        string     `gorm:"not null;uniqueIndex:idx_delivery_subscription_event" json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Body           string     `gorm:"type:jsonb" json:"body"`
+	Status         string     `gorm:"default:pending;index" json:"status"` // pending, delivered, failed
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"next_attempt_at"`
+	ResponseCode   int        `json:"response_code"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+// CalendarFeed — секретная ссылка на ICS-подписку: записи клиента (UserID)
+// или расписание мастера (StaffID). Хранится только SHA-256 токена.
+type CalendarFeed struct {
+	gorm.Model
+	UserID    *uint  `gorm:"uniqueIndex" json:"user_id,omitempty"`
+	StaffID   *uint  `gorm:"uniqueIndex" json:"staff_id,omitempty"`
+	TokenHash string `gorm:"uniqueIndex;not null" json:"-"`
+}
+
+// PushDevice — устройство, на которое уходят push-уведомления клиента.
+// У клиента одно устройство: новая регистрация заменяет прежнюю.
+type PushDevice struct {
+	gorm.Model
+	UserID   uint   `gorm:"uniqueIndex;not null" json:"user_id"`
+	Platform string `gorm:"not null" json:"platform"` // ios, android, web
+	Token    string `gorm:"not null" json:"-"`        // токен APNs/FCM/Web Push
+}
+
+// Payment — счёт на оплату записи и его оплата через провайдера эквайринга.
+type Payment struct {
+	gorm.Model
+	BookingID         uint        `gorm:"not null;index" json:"booking_id"`
+	UserID            uint        `gorm:"not null;index" json:"user_id"`
+	Amount            money.Money `gorm:"embedded" json:"amount"`
+	RefundedAmount    money.Money `gorm:"embedded;embeddedPrefix:refunded_" json:"refunded_amount"`
+	Provider          string      `json:"provider"`
+	ProviderPaymentID string      `gorm:"index" json:"-"`
+	Status            string      `gorm:"default:pending;index" json:"status"`    // pending, succeeded, failed, partially_refunded, refunded
+	Kind              string      `gorm:"default:full" json:"kind"`               // full, deposit, membership
+	SubscriptionID    *uint       `gorm:"index" json:"subscription_id,omitempty"` // оплата периода абонемента, BookingID = 0
+	IdempotencyKey    string      `gorm:"uniqueIndex;not null" json:"-"`
+	ConfirmationURL   string      `json:"confirmation_url,omitempty"`
+	PaidAt            *time.Time  `json:"paid_at"`
+	RetainedAt        *time.Time  `json:"retained_at,omitempty"` // предоплата удержана за позднюю отмену
+
+	Refunds []PaymentRefund `json:"refunds,omitempty"`
+}
+
+// Refundable — сколько ещё можно вернуть по платежу.
+func (p *Payment) Refundable() money.Money {
+	if p.Status != "succeeded" && p.Status != "partially_refunded" {
+		return money.Zero(p.Amount.Currency)
+	}
+	return p.Amount.Sub(p.RefundedAmount)
+}
+
+// PaymentRefund — полный или частичный возврат по платежу.
+type PaymentRefund struct {
+	gorm.Model
+	PaymentID        uint        `gorm:"not null;index" json:"payment_id"`
+	Amount           money.Money `gorm:"embedded" json:"amount"`
+	Reason           string      `json:"reason"`
+	ProviderRefundID string      `json:"-"`
+	Status           string      `gorm:"default:pending" json:"status"` // pending, succeeded, failed
+	CreatedBy        uint        `json:"created_by"`                    // 0 — автоматический возврат при отмене
+}
+
+// Уровни риска клиента: чем выше, тем строже правила предоплаты.
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+var RiskLevels = []string{RiskLow, RiskMedium, RiskHigh}
+
+// DepositRule — правило предоплаты при записи. Пустые ServiceID, StaffID и
+// RiskLevel подходят к любой записи.
+type DepositRule struct {
+	gorm.Model
+	ServiceID *uint       `gorm:"index" json:"service_id,omitempty"`
+	StaffID   *uint       `gorm:"index" json:"staff_id,omitempty"`
+	RiskLevel string      `json:"risk_level,omitempty"`
+	Kind      string      `gorm:"not null" json:"kind"`            // fixed, percent
+	Amount    money.Money `gorm:"embedded" json:"amount,omitzero"` // fixed: сумма
+	Percent   int         `json:"percent,omitempty"`               // percent: доля цены услуги
+}
+
+func (r *DepositRule) Matches(b *Booking, riskLevel string) bool {
+	return (r.ServiceID == nil || *r.ServiceID == b.ServiceID) &&
+		(r.StaffID == nil || *r.StaffID == b.StaffID) &&
+		(r.RiskLevel == "" || r.RiskLevel == riskLevel)
+}
+
+// DepositFor возвращает сумму предоплаты для услуги ценой price. Фиксированная
+// сумма в другой валюте к услуге не применяется.
+func (r *DepositRule) DepositFor(price money.Money) money.Money {
+	if r.Kind == "percent" {
+		return price.Percent(int64(r.Percent), money.HalfUp)
+	}
+	if !r.Amount.SameCurrency(price) {
+		return money.Zero(price.Currency)
+	}
+	return money.Min(r.Amount, price)
+}
+
+// Receipt — чек за визит: Total = Subtotal - Discount + Tips, а при ценах
+// без НДС (TaxMode exclusive) ещё и + Tax.
+type Receipt struct {
+	gorm.Model
+	BookingID uint        `gorm:"uniqueIndex;not null" json:"booking_id"` // у записи один чек
+	UserID    uint        `gorm:"index" json:"user_id"`
+	StaffID   uint        `gorm:"index" json:"staff_id"`
+	Subtotal  money.Money `gorm:"embedded;embeddedPrefix:subtotal_" json:"subtotal"`
+	Discount  money.Money `gorm:"embedded;embeddedPrefix:discount_" json:"discount"`
+	Tips      money.Money `gorm:"embedded;embeddedPrefix:tips_" json:"tips"`
+	Total     money.Money `gorm:"embedded;embeddedPrefix:total_" json:"total"`
+	Change    money.Money `gorm:"embedded;embeddedPrefix:change_" json:"change"` // сдача с наличных
+	TaxMode   string      `json:"tax_mode,omitempty"`                            // inclusive, exclusive
+	Tax       money.Money `gorm:"embedded;embeddedPrefix:tax_" json:"tax"`       // НДС по всем строкам
+	CreatedBy uint        `json:"created_by"`
+	// Регистрационный номер чека в фискальном регистраторе; пусто — ещё не передан.
+	FiscalID     string     `json:"fiscal_id,omitempty"`
+	FiscalizedAt *time.Time `json:"fiscalized_at,omitempty"`
+
+	Items   []ReceiptItem   `json:"items"`
+	Tenders []ReceiptTender `json:"tenders"`
+	// Подарочные карты, проданные этим чеком.
+	GiftCards []GiftCard `gorm:"foreignKey:SoldReceiptID" json:"gift_cards,omitempty"`
+	// Пакеты услуг, проданные этим чеком.
+	Packages []ClientPackage `gorm:"foreignKey:ReceiptID" json:"packages,omitempty"`
+	// Баллы лояльности, списанные в оплату этого чека.
+	PointsRedeemed int64 `json:"points_redeemed,omitempty"`
+	// Применённые правила скидок — по ним считаются лимиты использования.
+	Redemptions []DiscountRedemption `gorm:"foreignKey:ReceiptID" json:"redemptions,omitempty"`
+}
+
+// ReceiptItem — строка чека. У скидок сумма отрицательная. Скидка по чеку
+// распределяется по строкам услуг и товаров (Discount), НДС считается с
+// суммы строки за вычетом этой доли.
+type ReceiptItem struct {
+	gorm.Model
+	ReceiptID uint        `gorm:"not null;index" json:"-"`
+	Kind      string      `json:"kind"` // service, addon, product, package, gift_card, discount, tip
+	Title     string      `json:"title"`
+	Category  string      `json:"category,omitempty"`
+	Quantity  int         `json:"quantity"`
+	UnitPrice money.Money `gorm:"embedded;embeddedPrefix:unit_price_" json:"unit_price"`
+	Amount    money.Money `gorm:"embedded" json:"amount"`
+	Discount  money.Money `gorm:"embedded;embeddedPrefix:discount_" json:"discount,omitzero"`
+	TaxRate   int         `json:"tax_rate"` // в сотых долях процента: 1200 — 12%
+	Tax       money.Money `gorm:"embedded;embeddedPrefix:tax_" json:"tax"`
+	// Мастер, которому засчитываются услуга, допуслуга или чаевые.
+	StaffID *uint `gorm:"index" json:"staff_id,omitempty"`
+}
+
+// Taxable сообщает, что строка — продажа услуги, пакета услуг или товара, а не
+// скидка или чаевые.
+func (it *ReceiptItem) Taxable() bool {
+	return it.Kind == "service" || it.Kind == "addon" || it.Kind == "product" || it.Kind == "package"
+}
+
+// ReceiptTender — часть оплаты чека одним способом.
+type ReceiptTender struct {
+	gorm.Model
+	ReceiptID uint        `gorm:"not null;index" json:"-"`
+	Method    string      `json:"method"` // cash, card, gift_card, online (зачтённая онлайн-оплата)
+	Amount    money.Money `gorm:"embedded" json:"amount"`
+	Reference string      `json:"reference,omitempty"` // код подарочной карты
+	PaymentID *uint       `json:"payment_id,omitempty"`
+}
+
+// TaxRate — ставка НДС для категории услуг или товаров. Ставка с пустой
+// категорией действует для всего, у чего своей ставки нет.
+type TaxRate struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Category  string    `gorm:"uniqueIndex;not null" json:"category"`
+	Name      string    `json:"name"`
+	Rate      int       `json:"rate"` // в сотых долях процента: 1200 — 12%
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GiftCard — подарочный сертификат. Баланс меняется только вместе с записью
+// в журнале GiftCardEntry, в одной транзакции.
+type GiftCard struct {
+	gorm.Model
+	Code          string      `gorm:"uniqueIndex;not null" json:"code"`
+	Initial       money.Money `gorm:"embedded;embeddedPrefix:initial_" json:"initial"`
+	Balance       money.Money `gorm:"embedded;embeddedPrefix:balance_" json:"balance"`
+	ExpiresAt     *time.Time  `json:"expires_at,omitempty"`
+	SoldReceiptID *uint       `gorm:"index" json:"sold_receipt_id,omitempty"` // пусто — выпущена администратором
+	CreatedBy     uint        `json:"created_by"`
+}
+
+// Операции по подарочной карте.
+const (
+	GiftCardIssue  = "issue"  // выпуск, начисление номинала
+	GiftCardRedeem = "redeem" // оплата на кассе
+)
+
+// GiftCardEntry — запись журнала операций по карте. Записи только добавляются:
+// ни изменить, ни удалить их нельзя, поэтому у модели нет UpdatedAt и DeletedAt.
+type GiftCardEntry struct {
+	ID           uint        `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time   `json:"created_at"`
+	GiftCardID   uint        `gorm:"not null;index" json:"gift_card_id"`
+	Kind         string      `gorm:"not null" json:"kind"`
+	Amount       money.Money `gorm:"embedded" json:"amount"` // начисление положительное, списание отрицательное
+	BalanceAfter money.Money `gorm:"embedded;embeddedPrefix:balance_after_" json:"balance_after"`
+	ReceiptID    *uint       `gorm:"index" json:"receipt_id,omitempty"`
+	CreatedBy    uint        `json:"created_by"`
+}
+
+// DiscountRule — правило скидки. Правило с кодом действует только по
+// промокоду, без кода — применяется само ко всем подходящим визитам.
+// Пустые условия не ограничивают: без ServiceIDs и Categories скидка идёт
+// на все услуги и товары чека.
+type DiscountRule struct {
+	gorm.Model
+	Name             string      `gorm:"not null" json:"name"`
+	Code             string      `gorm:"size:32;index:idx_discount_rules_code,unique,where:code <> '' AND deleted_at IS NULL" json:"code,omitempty"`
+	Kind             string      `gorm:"not null" json:"kind"`            // fixed, percent
+	Amount           money.Money `gorm:"embedded" json:"amount,omitzero"` // fixed: сумма
+	Percent          int         `json:"percent,omitempty"`               // percent: доля суммы подходящих строк
+	ServiceIDs       string      `json:"service_ids,omitempty"`           // через запятую: 1,4
+	Categories       string      `json:"categories,omitempty"`            // через запятую: hair,nails
+	StartsAt         *time.Time  `json:"starts_at,omitempty"`
+	EndsAt           *time.Time  `json:"ends_at,omitempty"`
+	Weekdays         string      `json:"weekdays,omitempty"` // через запятую, 1 — понедельник, 7 — воскресенье
+	HourFrom         int         `json:"hour_from,omitempty"`
+	HourTo           int         `json:"hour_to,omitempty"` // окно [hour_from, hour_to); 0 и 0 — весь день
+	FirstVisitOnly   bool        `json:"first_visit_only,omitempty"`
+	MaxUses          int         `json:"max_uses,omitempty"`            // 0 — без ограничения
+	MaxUsesPerClient int         `json:"max_uses_per_client,omitempty"` // 0 — без ограничения
+}
+
+// ActiveAt проверяет срок действия и временное окно правила для визита в at.
+func (r *DiscountRule) ActiveAt(at time.Time) bool {
+	if (r.StartsAt != nil && at.Before(*r.StartsAt)) || (r.EndsAt != nil && !at.Before(*r.EndsAt)) {
+		return false
+	}
+	if r.Weekdays != "" {
+		day := int(at.Weekday())
+		if day == 0 {
+			day = 7
+		}
+		if !containsItem(r.Weekdays, strconv.Itoa(day)) {
+			return false
+		}
+	}
+	if r.HourFrom != 0 || r.HourTo != 0 {
+		return at.Hour() >= r.HourFrom && at.Hour() < r.HourTo
+	}
+	return true
+}
+
+// Covers сообщает, распространяется ли правило на строку чека: услугу
+// serviceID (0 — не услуга) категории category.
+func (r *DiscountRule) Covers(serviceID uint, category string) bool {
+	if r.ServiceIDs == "" && r.Categories == "" {
+		return true
+	}
+	return (serviceID != 0 && containsItem(r.ServiceIDs, strconv.FormatUint(uint64(serviceID), 10))) ||
+		(category != "" && containsItem(r.Categories, category))
+}
+
+// DiscountRedemption — применение правила скидки в чеке.
+type DiscountRedemption struct {
+	ID        uint        `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	RuleID    uint        `gorm:"not null;index" json:"rule_id"`
+	UserID    uint        `gorm:"index" json:"user_id"`
+	ReceiptID uint        `gorm:"not null;index" json:"receipt_id"`
+	Amount    money.Money `gorm:"embedded" json:"amount"`
+}
+
+// Операции с баллами лояльности.
+const (
+	LoyaltyEarn   = "earn"   // начисление за оплаченный визит
+	LoyaltyBonus  = "bonus"  // бонус за отзыв, приглашённого друга и т. п.
+	LoyaltyRedeem = "redeem" // оплата баллами на кассе
+	LoyaltyExpire = "expire" // сгорание
+)
+
+// LoyaltyEntry — запись журнала баллов клиента. Начисления списываются по
+// очереди, начиная с ближайших к сгоранию: в Remaining — сколько баллов
+// начисления ещё не потрачено и не сгорело.
+type LoyaltyEntry struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	Kind      string     `gorm:"not null" json:"kind"`
+	Points    int64      `json:"points"` // начисление положительное, списание отрицательное
+	Remaining int64      `json:"-"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	ReceiptID *uint      `gorm:"index:idx_loyalty_entries_receipt_earn,unique,where:kind = 'earn'" json:"receipt_id,omitempty"` // одно начисление на чек
+	Reason    string     `json:"reason,omitempty"`
+	CreatedBy uint       `json:"created_by,omitempty"`
+}
+
+// Package — пакет услуг по единой цене: «10 сеансов лазера», «свадебный».
+type Package struct {
+	gorm.Model
+	Name         string        `gorm:"not null" json:"name"`
+	Category     string        `json:"category,omitempty"` // категория для ставки НДС
+	Price        money.Money   `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	ValidityDays int           `json:"validity_days,omitempty"` // 0 — бессрочно
+	Items        []PackageItem `json:"items"`
+}
+
+type PackageItem struct {
+	ID        uint    `gorm:"primarykey" json:"id"`
+	PackageID uint    `gorm:"not null;index" json:"-"`
+	ServiceID uint    `gorm:"not null" json:"service_id"`
+	Quantity  int     `json:"quantity"`
+	Service   Service `gorm:"foreignKey:ServiceID" json:"service,omitzero"`
+}
+
+// ClientPackage — пакет, купленный клиентом, с остатком сеансов по каждой услуге.
+type ClientPackage struct {
+	gorm.Model
+	UserID    uint                   `gorm:"not null;index" json:"user_id"`
+	PackageID uint                   `gorm:"not null;index" json:"package_id"`
+	Name      string                 `json:"name"`
+	ExpiresAt *time.Time             `json:"expires_at,omitempty"`
+	ReceiptID *uint                  `gorm:"index" json:"receipt_id,omitempty"`
+	Sessions  []ClientPackageSession `json:"sessions"`
+}
+
+type ClientPackageSession struct {
+	ID              uint `gorm:"primarykey" json:"id"`
+	ClientPackageID uint `gorm:"not null;index" json:"-"`
+	ServiceID       uint `gorm:"not null;index" json:"service_id"`
+	Total           int  `json:"total"`
+	Remaining       int  `json:"remaining"`
+}
+
+// Статусы абонемента клиента.
+const (
+	SubscriptionPending   = "pending"   // ждёт первой оплаты
+	SubscriptionActive    = "active"    // период оплачен
+	SubscriptionPastDue   = "past_due"  // продление не оплачено, идёт льготный срок
+	SubscriptionCancelled = "cancelled" // отменён клиентом
+	SubscriptionExpired   = "expired"   // не оплачен до конца льготного срока
+)
+
+// MembershipPlan — абонемент с оплатой раз в PeriodMonths месяцев: скидка
+// на все услуги и товары и услуги, включённые в абонемент.
+type MembershipPlan struct {
+	gorm.Model
+	Name            string              `gorm:"not null" json:"name"`
+	Price           money.Money         `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	PeriodMonths    int                 `gorm:"default:1" json:"period_months"`
+	DiscountPercent int                 `json:"discount_percent,omitempty"`
+	Benefits        []MembershipBenefit `gorm:"foreignKey:PlanID" json:"benefits"`
+}
+
+// MembershipBenefit — услуга, входящая в абонемент: PerPeriod визитов за
+// период, 0 — без ограничений.
+type MembershipBenefit struct {
+	ID        uint    `gorm:"primarykey" json:"id"`
+	PlanID    uint    `gorm:"not null;index" json:"-"`
+	ServiceID uint    `gorm:"not null" json:"service_id"`
+	PerPeriod int     `json:"per_period"`
+	Service   Service `gorm:"foreignKey:ServiceID" json:"service,omitzero"`
+}
+
+// Subscription — абонемент клиента. Период [CurrentPeriodStart,
+// CurrentPeriodEnd) оплачен; неоплаченное продление ждёт до GraceUntil.
+// У клиента не больше одного незавершённого абонемента.
+type Subscription struct {
+	gorm.Model
+	UserID             uint           `gorm:"not null;index:idx_subscriptions_current,unique,where:status <> 'cancelled' AND status <> 'expired' AND deleted_at IS NULL" json:"user_id"`
+	PlanID             uint           `gorm:"not null;index" json:"plan_id"`
+	Status             string         `gorm:"not null;index" json:"status"`
+	CurrentPeriodStart *time.Time     `json:"current_period_start,omitempty"`
+	CurrentPeriodEnd   *time.Time     `json:"current_period_end,omitempty"`
+	GraceUntil         *time.Time     `json:"grace_until,omitempty"`
+	CancelAtPeriodEnd  bool           `json:"cancel_at_period_end"`
+	Plan               MembershipPlan `gorm:"foreignKey:PlanID" json:"plan,omitzero"`
+	Payments           []Payment      `gorm:"foreignKey:SubscriptionID" json:"payments,omitempty"`
+}
+
+// CoversAt сообщает, действует ли абонемент в момент at: в оплаченном
+// периоде, а при просрочке — до конца льготного срока.
+func (s *Subscription) CoversAt(at time.Time) bool {
+	if s.CurrentPeriodStart == nil || s.CurrentPeriodEnd == nil || at.Before(*s.CurrentPeriodStart) {
+		return false
+	}
+	switch s.Status {
+	case SubscriptionActive:
+		return at.Before(*s.CurrentPeriodEnd)
+	case SubscriptionPastDue:
+		return s.GraceUntil != nil && at.Before(*s.GraceUntil)
+	}
+	return false
+}
+
+// Виды схем оплаты мастера.
+const (
+	CommissionPercent = "percent" // процент от выручки за услуги
+	CommissionFixed   = "fixed"   // фиксированная сумма за каждую услугу
+	CommissionTiered  = "tiered"  // процент зависит от выручки мастера за месяц
+)
+
+// CommissionScheme — схема оплаты мастера, у мастера она одна.
+type CommissionScheme struct {
+	ID        uint             `gorm:"primarykey" json:"id"`
+	StaffID   uint             `gorm:"uniqueIndex;not null" json:"staff_id"`
+	Kind      string           `gorm:"not null" json:"kind"`
+	Percent   int              `json:"percent,omitempty"`
+	Amount    money.Money      `gorm:"embedded;embeddedPrefix:amount_" json:"amount,omitzero"`
+	Tiers     []CommissionTier `gorm:"foreignKey:SchemeID;constraint:OnDelete:CASCADE" json:"tiers,omitempty"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// CommissionTier — ступень схемы tiered: Percent действует, когда выручка
+// мастера за месяц не меньше From.
+type CommissionTier struct {
+	ID       uint        `gorm:"primarykey" json:"id"`
+	SchemeID uint        `gorm:"not null;index" json:"-"`
+	From     money.Money `gorm:"embedded;embeddedPrefix:from_" json:"from"`
+	Percent  int         `json:"percent"`
+}
+
+// Commission считает оплату мастера за выручку revenue от services услуг.
+// Для tiered ступень выбирается по monthRevenue — выручке мастера за весь
+// календарный месяц, в который попадает revenue: процент ступени действует
+// на всю сумму, а не только на превышение порога. Сумма всегда в валюте
+// revenue: фиксированная ставка и ступени в другой валюте не применяются.
+func (s *CommissionScheme) Commission(revenue money.Money, services int, monthRevenue money.Money) money.Money {
+	switch s.Kind {
+	case CommissionPercent:
+		return revenue.Percent(int64(s.Percent), money.HalfUp)
+	case CommissionFixed:
+		if s.Amount.SameCurrency(revenue) {
+			return s.Amount.Mul(int64(services))
+		}
+	case CommissionTiered:
+		percent := 0
+		for _, t := range s.Tiers {
+			if t.From.SameCurrency(monthRevenue) && monthRevenue.Cmp(t.From) >= 0 {
+				percent = t.Percent
+			}
+		}
+		return revenue.Percent(int64(percent), money.HalfUp)
+	}
+	return money.Zero(revenue.Currency)
+}
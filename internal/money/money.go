@@ -0,0 +1,245 @@
+// Package money — денежные суммы в минимальных единицах валюты (тиынах,
+// копейках, центах) с кодом ISO 4217. float64 для денег не годится:
+// 0.1 + 0.2 != 0.3, и ошибки копятся в итогах.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency — валюта салона. Суммы без кода валюты во входных данных считаются в ней.
+const DefaultCurrency = "KZT"
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+)
+
+// exponents — число знаков после запятой у поддерживаемых валют.
+var exponents = map[string]int{
+	"KZT": 2, "RUB": 2, "KGS": 2, "UZS": 2, "USD": 2, "EUR": 2,
+	"JPY": 0,
+}
+
+// Rounding — правило округления при делении суммы.
+type Rounding int
+
+const (
+	HalfUp   Rounding = iota // половина — от нуля: 0,5 → 1; -0,5 → -1
+	HalfEven                 // банковское: половина — к чётному
+	Down                     // отбросить дробную часть
+)
+
+// Правила округления. Скидки округляются как на кассе — половина вверх.
+// Налоги считаются построчно, поэтому для них банковское округление:
+// на сумме чека ошибки строк не копятся в одну сторону.
+const (
+	DiscountRounding = HalfUp
+	TaxRounding      = HalfEven
+)
+
+// Money хранится в БД как вложенная структура (gorm:"embedded"):
+// колонки amount и currency с префиксом поля.
+type Money struct {
+	Minor    int64  `gorm:"column:amount"`
+	Currency string `gorm:"size:3"`
+}
+
+func New(minor int64, currency string) Money { return Money{Minor: minor, Currency: currency} }
+
+func Zero(currency string) Money { return Money{Currency: currency} }
+
+// Exponent возвращает число знаков после запятой; для неизвестной валюты — 2.
+func Exponent(currency string) int {
+	if e, ok := exponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// Known сообщает, что валюта поддерживается.
+func Known(currency string) bool {
+	_, ok := exponents[currency]
+	return ok
+}
+
+// Parse разбирает десятичную строку вида "5000.50". Лишние знаки после
+// запятой — ошибка, а не округление: сумма должна быть точной.
+func Parse(s, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	currency = strings.ToUpper(currency)
+	exp, ok := exponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || !digits(whole) || !digits(frac) || len(frac) > exp {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	minor, err := strconv.ParseInt(whole+frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	if neg {
+		minor = -minor
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// currencyOf возвращает общую валюту двух сумм. Пустая валюта у нулевого
+// значения совместима с любой; разные валюты — ошибка программы.
+func currencyOf(a, b Money) string {
+	switch {
+	case a.Currency == "":
+		return b.Currency
+	case b.Currency == "" || a.Currency == b.Currency:
+		return a.Currency
+	}
+	panic(fmt.Sprintf("money: currency mismatch %s and %s", a.Currency, b.Currency))
+}
+
+// SameCurrency сообщает, что суммы можно складывать.
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == "" || o.Currency == "" || m.Currency == o.Currency
+}
+
+func (m Money) Add(o Money) Money { return Money{Minor: m.Minor + o.Minor, Currency: currencyOf(m, o)} }
+func (m Money) Sub(o Money) Money { return Money{Minor: m.Minor - o.Minor, Currency: currencyOf(m, o)} }
+func (m Money) Mul(n int64) Money { return Money{Minor: m.Minor * n, Currency: m.Currency} }
+func (m Money) Neg() Money        { return Money{Minor: -m.Minor, Currency: m.Currency} }
+
+// Ratio возвращает m·num/den, округлённое по правилу r.
+func (m Money) Ratio(num, den int64, r Rounding) Money {
+	return Money{Minor: divRound(m.Minor*num, den, r), Currency: m.Currency}
+}
+
+// Percent возвращает percent процентов от суммы.
+func (m Money) Percent(percent int64, r Rounding) Money { return m.Ratio(percent, 100, r) }
+
+func (m Money) Cmp(o Money) int {
+	currencyOf(m, o)
+	switch {
+	case m.Minor < o.Minor:
+		return -1
+	case m.Minor > o.Minor:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) IsZero() bool     { return m.Minor == 0 }
+func (m Money) IsPositive() bool { return m.Minor > 0 }
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+func Min(a, b Money) Money {
+	if a.Cmp(b) <= 0 {
+		return Money{Minor: a.Minor, Currency: currencyOf(a, b)}
+	}
+	return Money{Minor: b.Minor, Currency: currencyOf(a, b)}
+}
+
+func Max(a, b Money) Money {
+	if a.Cmp(b) >= 0 {
+		return Money{Minor: a.Minor, Currency: currencyOf(a, b)}
+	}
+	return Money{Minor: b.Minor, Currency: currencyOf(a, b)}
+}
+
+// Decimal возвращает сумму десятичной строкой: "5000.50".
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	s := strconv.FormatInt(minor, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) String() string { return strings.TrimSpace(m.Decimal() + " " + m.Currency) }
+
+type jsonMoney struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON пишет {"amount":"5000.50","currency":"KZT"}: строка, чтобы
+// клиенты не превращали сумму в float.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON принимает объект {"amount": "5000.50", "currency": "KZT"}
+// или только сумму ("5000.50" или 5000.5) в валюте по умолчанию.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var in struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &in); err != nil {
+			return err
+		}
+	} else {
+		in.Amount = data
+	}
+	amount := string(bytes.TrimSpace(in.Amount))
+	if s, err := strconv.Unquote(amount); err == nil {
+		amount = s
+	}
+	parsed, err := Parse(amount, in.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func divRound(n, d int64, r Rounding) int64 {
+	if d < 0 {
+		n, d = -n, -d
+	}
+	q, rem := n/d, n%d
+	if rem == 0 || r == Down {
+		return q
+	}
+	step := int64(1)
+	if n < 0 {
+		step, rem = -1, -rem
+	}
+	switch {
+	case 2*rem > d, 2*rem == d && (r == HalfUp || q%2 != 0):
+		q += step
+	}
+	return q
+}
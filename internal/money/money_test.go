@@ -0,0 +1,77 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]int64{"5000.5": 500050, "5000.50": 500050, "0.07": 7, "12": 1200, "-3.10": -310} {
+		m, err := Parse(in, "KZT")
+		require.NoError(t, err, in)
+		assert.Equal(t, want, m.Minor, in)
+	}
+	m, err := Parse("1500", "jpy")
+	require.NoError(t, err)
+	assert.Equal(t, New(1500, "JPY"), m)
+
+	for _, in := range []string{"", "1.005", "1,5", "abc", ".5", "1e3"} {
+		_, err := Parse(in, "KZT")
+		assert.ErrorIs(t, err, ErrInvalidAmount, in)
+	}
+	_, err = Parse("1", "XXX")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestDecimal(t *testing.T) {
+	assert.Equal(t, "5000.50", New(500050, "KZT").Decimal())
+	assert.Equal(t, "0.07", New(7, "KZT").Decimal())
+	assert.Equal(t, "-0.50", New(-50, "KZT").Decimal())
+	assert.Equal(t, "1500", New(1500, "JPY").Decimal())
+	assert.Equal(t, "12.00 USD", New(1200, "USD").String())
+}
+
+func TestRatioRounding(t *testing.T) {
+	m := New(250, "KZT")                                       // 2.50
+	assert.Equal(t, int64(13), m.Ratio(1, 20, HalfUp).Minor)   // 12.5 → 13
+	assert.Equal(t, int64(12), m.Ratio(1, 20, HalfEven).Minor) // 12.5 → 12
+	assert.Equal(t, int64(12), m.Ratio(1, 20, Down).Minor)
+	assert.Equal(t, int64(-13), m.Neg().Ratio(1, 20, HalfUp).Minor)
+	assert.Equal(t, int64(14), New(270, "KZT").Ratio(1, 20, HalfEven).Minor) // 13.5 → 14
+	// НДС 12% внутри цены 1120.00 — ровно 120.00.
+	assert.Equal(t, int64(12000), New(112000, "KZT").Ratio(12, 112, TaxRounding).Minor)
+	assert.Equal(t, int64(80005), New(800050, "KZT").Percent(10, DiscountRounding).Minor)
+}
+
+func TestArithmetic(t *testing.T) {
+	var total Money
+	total = total.Add(New(100, "KZT")).Add(New(250, "KZT").Mul(2))
+	assert.Equal(t, New(600, "KZT"), total)
+	assert.Equal(t, New(100, "KZT"), Min(total, New(100, "KZT")))
+	assert.Equal(t, New(600, "KZT"), Max(total, Money{}))
+	assert.Panics(t, func() { New(1, "KZT").Add(New(1, "USD")) })
+	assert.False(t, New(1, "KZT").SameCurrency(New(1, "USD")))
+}
+
+func TestJSON(t *testing.T) {
+	b, err := json.Marshal(New(500050, "KZT"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"5000.50","currency":"KZT"}`, string(b))
+
+	var v struct {
+		A Money `json:"a"`
+		B Money `json:"b"`
+		C Money `json:"c"`
+		D Money `json:"d"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"a":{"amount":"12.30","currency":"USD"},"b":"1500","c":99.9,"d":null}`), &v))
+	assert.Equal(t, New(1230, "USD"), v.A)
+	assert.Equal(t, New(150000, "KZT"), v.B)
+	assert.Equal(t, New(9990, "KZT"), v.C)
+	assert.Equal(t, Money{}, v.D)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"a":"1.001"}`), &v))
+}
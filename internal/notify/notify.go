@@ -0,0 +1,226 @@
+// Package notify — каналы доставки уведомлений и шаблоны сообщений.
+package notify
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/sms"
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Channel доставляет сообщение по одному каналу: sms, email, push, telegram.
+type Channel interface {
+	Name() string
+	// Address возвращает адрес пользователя в этом канале или "", если его нет.
+	Address(u *models.User) string
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// SMSChannel отправляет уведомления через SMS-шлюз. Тема не используется.
+type SMSChannel struct {
+	Sender sms.Sender
+}
+
+func (SMSChannel) Name() string { return "sms" }
+
+func (SMSChannel) Address(u *models.User) string {
+	if u.Phone == nil {
+		return ""
+	}
+	return *u.Phone
+}
+
+func (c SMSChannel) Send(ctx context.Context, to, _, body string) error {
+	return c.Sender.Send(ctx, to, body)
+}
+
+type EmailSender interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// Attachment — файл, приложенный к письму (например, приглашение .ics).
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// AttachmentSender — почтовый отправитель, который умеет вложения.
+type AttachmentSender interface {
+	SendEmailWithAttachments(ctx context.Context, to, subject, body string, files []Attachment) error
+}
+
+// AttachingChannel — канал, способный приложить файлы к сообщению.
+type AttachingChannel interface {
+	Channel
+	SendWithAttachments(ctx context.Context, to, subject, body string, files []Attachment) error
+}
+
+type EmailChannel struct {
+	Sender EmailSender
+}
+
+func (EmailChannel) Name() string { return "email" }
+
+func (EmailChannel) Address(u *models.User) string {
+	if u.Email == nil {
+		return ""
+	}
+	return *u.Email
+}
+
+func (c EmailChannel) Send(ctx context.Context, to, subject, body string) error {
+	return c.Sender.SendEmail(ctx, to, subject, body)
+}
+
+// SendWithAttachments отправляет письмо с вложениями; если отправитель их
+// не поддерживает, письмо уходит без них.
+func (c EmailChannel) SendWithAttachments(ctx context.Context, to, subject, body string, files []Attachment) error {
+	if s, ok := c.Sender.(AttachmentSender); ok && len(files) > 0 {
+		return s.SendEmailWithAttachments(ctx, to, subject, body, files)
+	}
+	return c.Sender.SendEmail(ctx, to, subject, body)
+}
+
+type TelegramSender interface {
+	SendText(ctx context.Context, chatID, text string) error
+}
+
+// TelegramChats находит чат, привязанный к пользователю ("" — не привязан).
+type TelegramChats interface {
+	TelegramChatID(userID uint) string
+}
+
+// TelegramChannel отправляет уведомления в чат с ботом салона.
+type TelegramChannel struct {
+	Sender TelegramSender
+	Chats  TelegramChats
+}
+
+func (TelegramChannel) Name() string { return "telegram" }
+
+func (c TelegramChannel) Address(u *models.User) string { return c.Chats.TelegramChatID(u.ID) }
+
+func (c TelegramChannel) Send(ctx context.Context, to, subject, body string) error {
+	return c.Sender.SendText(ctx, to, subject+"\n"+body)
+}
+
+// PushSender доставляет push-уведомление на устройство (APNs, FCM, Web Push).
+type PushSender interface {
+	SendPush(ctx context.Context, token, title, body string) error
+}
+
+// PushDevices находит токен устройства пользователя ("" — не зарегистрировано).
+type PushDevices interface {
+	PushToken(userID uint) string
+}
+
+// PushChannel отправляет уведомления на зарегистрированное устройство клиента.
+type PushChannel struct {
+	Sender  PushSender
+	Devices PushDevices
+}
+
+func (PushChannel) Name() string { return "push" }
+
+func (c PushChannel) Address(u *models.User) string { return c.Devices.PushToken(u.ID) }
+
+func (c PushChannel) Send(ctx context.Context, to, subject, body string) error {
+	return c.Sender.SendPush(ctx, to, subject, body)
+}
+
+// LogPushSender пишет в лог факт отправки push вместо самой отправки — для разработки.
+type LogPushSender struct{}
+
+func (LogPushSender) SendPush(_ context.Context, token, title, body string) error {
+	log.Printf("[push] to=%s title=%q len=%d", MaskAddress(token), title, len([]rune(body)))
+	return nil
+}
+
+// LogEmailSender пишет в лог факт отправки письма вместо самой отправки — для
+// разработки. Тело не логируется, а адрес маскируется: в письмах имена, даты
+// визитов и ссылки, а лог читают не только адресаты.
+type LogEmailSender struct{}
+
+func (LogEmailSender) SendEmail(_ context.Context, to, subject, body string) error {
+	log.Printf("[email] to=%s subject=%q len=%d", MaskAddress(to), subject, len([]rune(body)))
+	return nil
+}
+
+func (LogEmailSender) SendEmailWithAttachments(_ context.Context, to, subject, body string, files []Attachment) error {
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name
+	}
+	log.Printf("[email] to=%s subject=%q len=%d attachments=%v", MaskAddress(to), subject, len([]rune(body)), names)
+	return nil
+}
+
+// MaskAddress скрывает адрес для логов: от email остаются первая буква и домен,
+// от телефона и токенов — последние четыре символа.
+func MaskAddress(to string) string {
+	if i := strings.LastIndex(to, "@"); i > 0 {
+		_, size := utf8.DecodeRuneInString(to)
+		return to[:size] + "***" + to[i:]
+	}
+	if utf8.RuneCountInString(to) <= 4 {
+		return "***"
+	}
+	r := []rune(to)
+	return "***" + string(r[len(r)-4:])
+}
+
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// FakeChannel запоминает отправленные сообщения — для тестов.
+// Адрес берётся из телефона пользователя.
+type FakeChannel struct {
+	ChannelName string
+	Err         error
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (f *FakeChannel) Name() string { return f.ChannelName }
+
+func (f *FakeChannel) Address(u *models.User) string {
+	if u.Phone == nil {
+		return ""
+	}
+	return *u.Phone
+}
+
+func (f *FakeChannel) Send(_ context.Context, to, subject, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.sent = append(f.sent, Message{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+func (f *FakeChannel) SendWithAttachments(_ context.Context, to, subject, body string, files []Attachment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.sent = append(f.sent, Message{To: to, Subject: subject, Body: body, Attachments: files})
+	return nil
+}
+
+func (f *FakeChannel) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.sent...)
+}
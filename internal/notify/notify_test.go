@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"beauty-salon/internal/i18n"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/sms"
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	d := Data{Name: "Анна", Service: "Стрижка", Staff: "Ольга", Date: "2025-05-02 10:00"}
+	for _, locale := range i18n.Locales {
+		for _, event := range Events {
+			subject, body, err := Render(locale, event, d)
+			require.NoError(t, err, event)
+			assert.NotEmpty(t, subject, event)
+			assert.Contains(t, body, "Стрижка", event)
+			assert.Contains(t, body, "2025-05-02 10:00", event)
+		}
+	}
+
+	subject, _, err := Render("kk", EventBookingCancelled, d)
+	require.NoError(t, err)
+	assert.Equal(t, "Жазба болдырылмады", subject)
+
+	// Неизвестный язык — шаблоны по умолчанию.
+	subject, _, err = Render("de", EventBookingCancelled, d)
+	require.NoError(t, err)
+	assert.Equal(t, "Запись отменена", subject)
+
+	_, _, err = Render("ru", "unknown", d)
+	assert.Error(t, err)
+}
+
+func TestSMSChannel(t *testing.T) {
+	sender := &sms.FakeSender{}
+	ch := SMSChannel{Sender: sender}
+	phone := "+77010000000"
+
+	assert.Equal(t, "", ch.Address(&models.User{}))
+	assert.Equal(t, phone, ch.Address(&models.User{Phone: &phone}))
+
+	require.NoError(t, ch.Send(context.Background(), phone, "тема", "текст"))
+	last, _ := sender.Last()
+	assert.Equal(t, sms.Message{Phone: phone, Text: "текст"}, last)
+}
+
+func TestEmailChannel(t *testing.T) {
+	ch := EmailChannel{Sender: LogEmailSender{}}
+	email := "anna@example.com"
+
+	assert.Equal(t, "", ch.Address(&models.User{}))
+	assert.Equal(t, email, ch.Address(&models.User{Email: &email}))
+	assert.NoError(t, ch.Send(context.Background(), email, "тема", "текст"))
+}
+
+type plainEmail struct{ sent int }
+
+func (p *plainEmail) SendEmail(context.Context, string, string, string) error {
+	p.sent++
+	return nil
+}
+
+type attachingEmail struct {
+	plainEmail
+	files []Attachment
+}
+
+func (a *attachingEmail) SendEmailWithAttachments(_ context.Context, _, _, _ string, files []Attachment) error {
+	a.files = files
+	return nil
+}
+
+func TestMaskAddress(t *testing.T) {
+	assert.Equal(t, "a***@example.com", MaskAddress("anna@example.com"))
+	assert.Equal(t, "***4567", MaskAddress("+77011234567"))
+	assert.Equal(t, "***", MaskAddress("1234"))
+	assert.Equal(t, "***", MaskAddress(""))
+}
+
+func TestLogEmailSenderHidesContent(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	require.NoError(t, LogEmailSender{}.SendEmail(context.Background(), "anna@example.com", "Запись подтверждена", "Анна, ждём вас 2025-05-02"))
+	assert.Contains(t, buf.String(), "a***@example.com")
+	assert.Contains(t, buf.String(), "Запись подтверждена")
+	assert.NotContains(t, buf.String(), "anna@")
+	assert.NotContains(t, buf.String(), "ждём вас")
+}
+
+func TestEmailChannelAttachments(t *testing.T) {
+	files := []Attachment{{Name: "booking-1.ics", ContentType: "text/calendar", Data: []byte("BEGIN:VCALENDAR")}}
+
+	rich := &attachingEmail{}
+	assert.NoError(t, EmailChannel{Sender: rich}.SendWithAttachments(context.Background(), "a@example.com", "тема", "текст", files))
+	assert.Equal(t, files, rich.files)
+	assert.Equal(t, 0, rich.sent)
+
+	// Отправитель без вложений получает обычное письмо.
+	plain := &plainEmail{}
+	assert.NoError(t, EmailChannel{Sender: plain}.SendWithAttachments(context.Background(), "a@example.com", "тема", "текст", files))
+	assert.Equal(t, 1, plain.sent)
+}
+
+type fakeTelegram map[uint]string
+
+func (f fakeTelegram) TelegramChatID(userID uint) string { return f[userID] }
+
+func (f fakeTelegram) SendText(_ context.Context, chatID, text string) error {
+	f[0] = chatID + ":" + text
+	return nil
+}
+
+func TestTelegramChannel(t *testing.T) {
+	tg := fakeTelegram{4: "100500"}
+	ch := TelegramChannel{Sender: tg, Chats: tg}
+	u := &models.User{}
+	u.ID = 4
+
+	assert.Equal(t, "100500", ch.Address(u))
+	assert.Equal(t, "", ch.Address(&models.User{}))
+	require.NoError(t, ch.Send(context.Background(), "100500", "Напоминание", "текст"))
+	assert.Equal(t, "100500:Напоминание\nтекст", tg[0])
+}
+
+type fakePush map[uint]string
+
+func (f fakePush) PushToken(userID uint) string { return f[userID] }
+
+func (f fakePush) SendPush(_ context.Context, token, title, body string) error {
+	f[0] = token + ":" + title + "\n" + body
+	return nil
+}
+
+func TestPushChannel(t *testing.T) {
+	push := fakePush{4: "fcm-token"}
+	ch := PushChannel{Sender: push, Devices: push}
+	u := &models.User{}
+	u.ID = 4
+
+	assert.Equal(t, "push", ch.Name())
+	assert.Equal(t, "fcm-token", ch.Address(u))
+	assert.Equal(t, "", ch.Address(&models.User{}))
+	require.NoError(t, ch.Send(context.Background(), "fcm-token", "Напоминание", "текст"))
+	assert.Equal(t, "fcm-token:Напоминание\nтекст", push[0])
+}
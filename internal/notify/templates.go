@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"beauty-salon/internal/i18n"
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// События, о которых уведомляется клиент.
+const (
+	EventBookingCreated     = "booking_created"
+	EventBookingConfirmed   = "booking_confirmed"
+	EventBookingRescheduled = "booking_rescheduled"
+	EventBookingCancelled   = "booking_cancelled"
+	EventBookingReminder    = "booking_reminder"
+)
+
+var Events = []string{EventBookingCreated, EventBookingConfirmed, EventBookingRescheduled, EventBookingCancelled, EventBookingReminder}
+
+// Data — значения, доступные в шаблонах.
+type Data struct {
+	Name    string
+	Service string
+	Staff   string
+	Date    string
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func newTemplate(name, subject, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New(name + ".subject").Parse(subject)),
+		body:    template.Must(template.New(name + ".body").Parse(body)),
+	}
+}
+
+// templates — шаблоны по языкам; русский используется, если перевода нет.
+var templates = map[string]map[string]messageTemplate{
+	i18n.RU: {
+		EventBookingCreated: newTemplate("ru."+EventBookingCreated,
+			"Запись создана",
+			"{{.Name}}, вы записаны на «{{.Service}}» к мастеру {{.Staff}} на {{.Date}}. Мы подтвердим запись в ближайшее время."),
+		EventBookingConfirmed: newTemplate("ru."+EventBookingConfirmed,
+			"Запись подтверждена",
+			"{{.Name}}, ваша запись на «{{.Service}}» {{.Date}} подтверждена. Мастер: {{.Staff}}."),
+		EventBookingRescheduled: newTemplate("ru."+EventBookingRescheduled,
+			"Запись перенесена",
+			"{{.Name}}, ваша запись на «{{.Service}}» перенесена на {{.Date}}. Мастер: {{.Staff}}."),
+		EventBookingCancelled: newTemplate("ru."+EventBookingCancelled,
+			"Запись отменена",
+			"{{.Name}}, ваша запись на «{{.Service}}» {{.Date}} отменена."),
+		EventBookingReminder: newTemplate("ru."+EventBookingReminder,
+			"Напоминание о записи",
+			"{{.Name}}, напоминаем: {{.Date}} вас ждёт «{{.Service}}», мастер {{.Staff}}."),
+	},
+	i18n.EN: {
+		EventBookingCreated: newTemplate("en."+EventBookingCreated,
+			"Booking created",
+			"{{.Name}}, you are booked for \"{{.Service}}\" with {{.Staff}} on {{.Date}}. We will confirm your booking shortly."),
+		EventBookingConfirmed: newTemplate("en."+EventBookingConfirmed,
+			"Booking confirmed",
+			"{{.Name}}, your booking for \"{{.Service}}\" on {{.Date}} is confirmed. Specialist: {{.Staff}}."),
+		EventBookingRescheduled: newTemplate("en."+EventBookingRescheduled,
+			"Booking rescheduled",
+			"{{.Name}}, your booking for \"{{.Service}}\" has been moved to {{.Date}}. Specialist: {{.Staff}}."),
+		EventBookingCancelled: newTemplate("en."+EventBookingCancelled,
+			"Booking cancelled",
+			"{{.Name}}, your booking for \"{{.Service}}\" on {{.Date}} has been cancelled."),
+		EventBookingReminder: newTemplate("en."+EventBookingReminder,
+			"Booking reminder",
+			"{{.Name}}, a reminder: \"{{.Service}}\" with {{.Staff}} on {{.Date}}."),
+	},
+	i18n.KK: {
+		EventBookingCreated: newTemplate("kk."+EventBookingCreated,
+			"Жазба жасалды",
+			"{{.Name}}, сіз {{.Date}} уақытына {{.Staff}} шеберге «{{.Service}}» қызметіне жазылдыңыз. Жазбаны жақын арада растаймыз."),
+		EventBookingConfirmed: newTemplate("kk."+EventBookingConfirmed,
+			"Жазба расталды",
+			"{{.Name}}, {{.Date}} уақытындағы «{{.Service}}» жазбаңыз расталды. Шебер: {{.Staff}}."),
+		EventBookingRescheduled: newTemplate("kk."+EventBookingRescheduled,
+			"Жазба ауыстырылды",
+			"{{.Name}}, «{{.Service}}» жазбаңыз {{.Date}} уақытына ауыстырылды. Шебер: {{.Staff}}."),
+		EventBookingCancelled: newTemplate("kk."+EventBookingCancelled,
+			"Жазба болдырылмады",
+			"{{.Name}}, {{.Date}} уақытындағы «{{.Service}}» жазбаңыз болдырылмады."),
+		EventBookingReminder: newTemplate("kk."+EventBookingReminder,
+			"Жазба туралы еске салу",
+			"{{.Name}}, еске саламыз: {{.Date}} сізді «{{.Service}}» күтеді, шебер {{.Staff}}."),
+	},
+}
+
+// Render подставляет данные в шаблон события на языке locale.
+func Render(locale, event string, d Data) (subject, body string, err error) {
+	byEvent, ok := templates[locale]
+	if !ok {
+		byEvent = templates[i18n.DefaultLocale]
+	}
+	t, ok := byEvent[event]
+	if !ok {
+		return "", "", fmt.Errorf("unknown notification event %q", event)
+	}
+	var s, b bytes.Buffer
+	if err := t.subject.Execute(&s, d); err != nil {
+		return "", "", err
+	}
+	if err := t.body.Execute(&b, d); err != nil {
+		return "", "", err
+	}
+	return s.String(), b.String(), nil
+}
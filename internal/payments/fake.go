@@ -0,0 +1,132 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// SignatureHeader — заголовок с HMAC-SHA256 тела уведомления.
+const SignatureHeader = "X-Payment-Signature"
+
+// FakeProvider хранит платежи в памяти и подписывает уведомления общим
+// секретом — для тестов и локальной разработки.
+type FakeProvider struct {
+	Secret string
+	// Err, если задан, возвращается из CreatePayment и Refund.
+	Err error
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+	keys     map[string]string // ключ идемпотентности -> id платежа или возврата
+	seq      int
+}
+
+type fakePayment struct {
+	Payment
+	amount   int64
+	refunded int64
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{Secret: secret, payments: map[string]*fakePayment{}, keys: map[string]string{}}
+}
+
+func (*FakeProvider) Name() string { return "fake" }
+
+func (f *FakeProvider) CreatePayment(_ context.Context, req CreateRequest) (*Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if id, ok := f.keys[req.IdempotencyKey]; ok {
+		p := f.payments[id].Payment
+		return &p, nil
+	}
+	if req.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	f.seq++
+	id := fmt.Sprintf("fake_pay_%d", f.seq)
+	p := &fakePayment{
+		Payment: Payment{ID: id, Status: StatusPending, ConfirmationURL: "https://pay.example/checkout/" + id},
+		amount:  req.Amount,
+	}
+	f.payments[id] = p
+	f.keys[req.IdempotencyKey] = id
+	out := p.Payment
+	return &out, nil
+}
+
+func (f *FakeProvider) Refund(_ context.Context, req RefundRequest) (*Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if id, ok := f.keys[req.IdempotencyKey]; ok {
+		return &Refund{ID: id, Status: StatusSucceeded}, nil
+	}
+	p, ok := f.payments[req.PaymentID]
+	if !ok || p.Status != StatusSucceeded {
+		return nil, fmt.Errorf("payment %s cannot be refunded", req.PaymentID)
+	}
+	if req.Amount <= 0 || p.refunded+req.Amount > p.amount {
+		return nil, errors.New("refund exceeds payment amount")
+	}
+	p.refunded += req.Amount
+	f.seq++
+	id := fmt.Sprintf("fake_ref_%d", f.seq)
+	f.keys[req.IdempotencyKey] = id
+	return &Refund{ID: id, Status: StatusSucceeded}, nil
+}
+
+// Settle меняет статус платежа, как если бы клиент оплатил (или не оплатил)
+// его на странице провайдера, и возвращает подписанное уведомление.
+func (f *FakeProvider) Settle(paymentID, status string) (http.Header, []byte, error) {
+	f.mu.Lock()
+	p, ok := f.payments[paymentID]
+	if ok {
+		p.Status = status
+	}
+	f.mu.Unlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("payment %s not found", paymentID)
+	}
+	body, err := json.Marshal(Notification{PaymentID: paymentID, Status: status})
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set(SignatureHeader, Sign(f.Secret, body))
+	return header, body, nil
+}
+
+func (f *FakeProvider) ParseNotification(header http.Header, body []byte) (*Notification, error) {
+	// С пустым секретом подпись подделает любой — такие уведомления не принимаются.
+	got, err := hex.DecodeString(header.Get(SignatureHeader))
+	if err != nil || f.Secret == "" || !hmac.Equal(got, sum(f.Secret, body)) {
+		return nil, ErrInvalidSignature
+	}
+	var n Notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Sign возвращает hex(HMAC-SHA256(secret, body)).
+func Sign(secret string, body []byte) string { return hex.EncodeToString(sum(secret, body)) }
+
+func sum(secret string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
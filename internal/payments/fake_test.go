@@ -0,0 +1,78 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProviderPayment(t *testing.T) {
+	ctx := context.Background()
+	f := NewFakeProvider("secret")
+
+	p, err := f.CreatePayment(ctx, CreateRequest{IdempotencyKey: "k1", Amount: 500000, Currency: "KZT"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, p.Status)
+	assert.NotEmpty(t, p.ConfirmationURL)
+
+	again, err := f.CreatePayment(ctx, CreateRequest{IdempotencyKey: "k1", Amount: 500000, Currency: "KZT"})
+	require.NoError(t, err)
+	assert.Equal(t, p.ID, again.ID)
+
+	_, err = f.Refund(ctx, RefundRequest{IdempotencyKey: "r0", PaymentID: p.ID, Amount: 100})
+	assert.Error(t, err, "unpaid payment cannot be refunded")
+
+	header, body, err := f.Settle(p.ID, StatusSucceeded)
+	require.NoError(t, err)
+	n, err := f.ParseNotification(header, body)
+	require.NoError(t, err)
+	assert.Equal(t, Notification{PaymentID: p.ID, Status: StatusSucceeded}, *n)
+
+	r, err := f.Refund(ctx, RefundRequest{IdempotencyKey: "r1", PaymentID: p.ID, Amount: 200000})
+	require.NoError(t, err)
+	retry, err := f.Refund(ctx, RefundRequest{IdempotencyKey: "r1", PaymentID: p.ID, Amount: 200000})
+	require.NoError(t, err)
+	assert.Equal(t, r.ID, retry.ID)
+
+	_, err = f.Refund(ctx, RefundRequest{IdempotencyKey: "r2", PaymentID: p.ID, Amount: 300001})
+	assert.Error(t, err)
+	_, err = f.Refund(ctx, RefundRequest{IdempotencyKey: "r3", PaymentID: p.ID, Amount: 300000})
+	assert.NoError(t, err)
+}
+
+func TestFakeProviderSignature(t *testing.T) {
+	f := NewFakeProvider("secret")
+	body := []byte(`{"payment_id":"fake_pay_1","status":"succeeded"}`)
+
+	for _, tc := range []struct {
+		name, signature string
+		ok              bool
+	}{
+		{"Valid", Sign("secret", body), true},
+		{"Wrong Secret", Sign("other", body), false},
+		{"Missing", "", false},
+		{"Not Hex", "zz", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(SignatureHeader, tc.signature)
+			_, err := f.ParseNotification(header, body)
+			if tc.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidSignature))
+			}
+		})
+	}
+
+	t.Run("Empty Secret", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(SignatureHeader, Sign("", body))
+		_, err := NewFakeProvider("").ParseNotification(header, body)
+		assert.True(t, errors.Is(err, ErrInvalidSignature))
+	})
+}
@@ -0,0 +1,59 @@
+// Package payments — абстракция эквайринга: платежи, возвраты и уведомления
+// провайдера о статусе. Суммы — в минимальных единицах валюты (тиынах).
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Статусы платежа у провайдера.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+type CreateRequest struct {
+	// IdempotencyKey защищает от двойного списания при повторе запроса.
+	IdempotencyKey string
+	Amount         int64
+	Currency       string
+	Description    string
+}
+
+type Payment struct {
+	ID     string
+	Status string
+	// ConfirmationURL — страница, на которой клиент вводит данные карты.
+	ConfirmationURL string
+}
+
+type RefundRequest struct {
+	IdempotencyKey string
+	PaymentID      string
+	Amount         int64
+}
+
+type Refund struct {
+	ID     string
+	Status string
+}
+
+// Notification — уведомление провайдера о смене статуса платежа.
+type Notification struct {
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"`
+}
+
+// Provider — интеграция с эквайрингом.
+type Provider interface {
+	Name() string
+	CreatePayment(ctx context.Context, req CreateRequest) (*Payment, error)
+	Refund(ctx context.Context, req RefundRequest) (*Refund, error)
+	// ParseNotification проверяет подпись уведомления и разбирает его.
+	ParseNotification(header http.Header, body []byte) (*Notification, error)
+}
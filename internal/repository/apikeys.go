@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"time"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(k *models.APIKey) error
+	GetAPIKeyByPrefix(prefix string) (*models.APIKey, error)
+	GetAllAPIKeys() ([]models.APIKey, error)
+	DeleteAPIKey(id string) error
+	TouchAPIKey(id uint, at time.Time) error
+}
+
+func (r *PostgresRepository) CreateAPIKey(k *models.APIKey) error { return r.db.Create(k).Error }
+func (r *PostgresRepository) GetAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("prefix = ?", prefix).First(&key).Error
+	return &key, err
+}
+func (r *PostgresRepository) GetAllAPIKeys() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Order("id").Find(&keys).Error
+	return keys, err
+}
+func (r *PostgresRepository) DeleteAPIKey(id string) error {
+	return r.db.Delete(&models.APIKey{}, "id = ?", id).Error
+}
+func (r *PostgresRepository) TouchAPIKey(id uint, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
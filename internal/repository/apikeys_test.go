@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) TestCreateAPIKey() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "api_keys"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := repo.CreateAPIKey(&models.APIKey{Name: "kiosk", Prefix: "abcd1234", KeyHash: "h"})
+	assert.NoError(s.T(), err)
+}
+
+func (s *RepositorySuite) TestGetAPIKeyByPrefix() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "api_keys" WHERE prefix = $1 AND "api_keys"."deleted_at" IS NULL`)).
+		WithArgs("abcd1234", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "prefix", "scopes"}).AddRow(1, "abcd1234", "read:bookings"))
+
+	res, err := repo.GetAPIKeyByPrefix("abcd1234")
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), res.HasScope(models.ScopeReadBookings))
+}
+
+func (s *RepositorySuite) TestGetAllAPIKeys() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "api_keys" WHERE "api_keys"."deleted_at" IS NULL ORDER BY id`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	res, err := repo.GetAllAPIKeys()
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), res, 2)
+}
+
+func (s *RepositorySuite) TestDeleteAPIKey() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "api_keys" SET "deleted_at"=`)).
+		WithArgs(sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.DeleteAPIKey("1"))
+}
+
+func (s *RepositorySuite) TestTouchAPIKey() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Now()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "api_keys" SET "last_used_at"=$1 WHERE id = $2`)).
+		WithArgs(at, uint(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.TouchAPIKey(1, at))
+}
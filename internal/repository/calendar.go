@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CalendarRepository interface {
+	ReplaceCalendarFeed(f *models.CalendarFeed) error
+	DeleteCalendarFeed(f *models.CalendarFeed) error
+	GetCalendarFeedByHash(hash string) (*models.CalendarFeed, error)
+
+	GetUserByID(id uint) (*models.User, error)
+	GetStaffByID(id string) (*models.Staff, error)
+	GetCalendarBooking(id string) (*models.Booking, error)
+	GetCalendarBookingsByUser(userID uint, from string) ([]models.Booking, error)
+	GetCalendarBookingsByStaff(staffID uint, from string) ([]models.Booking, error)
+}
+
+// ReplaceCalendarFeed выпускает новую ссылку владельца; старая перестаёт работать.
+func (r *PostgresRepository) ReplaceCalendarFeed(f *models.CalendarFeed) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := feedOwner(tx.Unscoped(), f).Delete(&models.CalendarFeed{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(f).Error
+	})
+}
+
+func (r *PostgresRepository) DeleteCalendarFeed(f *models.CalendarFeed) error {
+	return feedOwner(r.db.Unscoped(), f).Delete(&models.CalendarFeed{}).Error
+}
+
+func (r *PostgresRepository) GetCalendarFeedByHash(hash string) (*models.CalendarFeed, error) {
+	var f models.CalendarFeed
+	err := r.db.Where("token_hash = ?", hash).First(&f).Error
+	return &f, err
+}
+
+// Календарю нужны и отменённые записи: отмена удаляет запись мягко,
+// а клиент календаря должен получить STATUS:CANCELLED.
+func (r *PostgresRepository) GetCalendarBooking(id string) (*models.Booking, error) {
+	var booking models.Booking
+	err := calendarBookings(r.db).First(&booking, "id = ?", id).Error
+	return &booking, err
+}
+
+// GetCalendarBookingsByUser возвращает записи клиента с даты from (YYYY-MM-DD).
+func (r *PostgresRepository) GetCalendarBookingsByUser(userID uint, from string) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := calendarBookings(r.db).Where("user_id = ? AND date >= ?", userID, from).Order("date").Find(&bookings).Error
+	return bookings, err
+}
+
+// GetCalendarBookingsByStaff возвращает записи мастера с даты from (YYYY-MM-DD).
+func (r *PostgresRepository) GetCalendarBookingsByStaff(staffID uint, from string) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := calendarBookings(r.db).Where("staff_id = ? AND date >= ?", staffID, from).Order("date").Find(&bookings).Error
+	return bookings, err
+}
+
+func calendarBookings(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Preload("User").Preload("Service").Preload("Staff")
+}
+
+func feedOwner(db *gorm.DB, f *models.CalendarFeed) *gorm.DB {
+	if f.StaffID != nil {
+		return db.Where("staff_id = ?", *f.StaffID)
+	}
+	return db.Where("user_id = ?", f.UserID)
+}
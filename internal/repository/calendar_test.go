@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) TestReplaceCalendarFeed() {
+	repo := NewPostgresRepository(s.db)
+	staffID := uint(3)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "calendar_feeds" WHERE staff_id = $1`)).
+		WithArgs(uint(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "calendar_feeds"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	s.mock.ExpectCommit()
+
+	f := &models.CalendarFeed{StaffID: &staffID, TokenHash: "hash"}
+	assert.NoError(s.T(), repo.ReplaceCalendarFeed(f))
+	assert.Equal(s.T(), uint(5), f.ID)
+}
+
+func (s *RepositorySuite) TestDeleteCalendarFeed() {
+	repo := NewPostgresRepository(s.db)
+	userID := uint(4)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "calendar_feeds" WHERE user_id = $1`)).
+		WithArgs(uint(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.DeleteCalendarFeed(&models.CalendarFeed{UserID: &userID}))
+}
+
+func (s *RepositorySuite) TestGetCalendarBookingsByStaff() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE staff_id = $1 AND date >= $2 ORDER BY date`)).
+		WithArgs(uint(3), "2026-02-01").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "service_id", "staff_id", "date", "deleted_at"}).
+			AddRow(1, 4, 2, 3, "2026-03-10 14:00", time.Now()))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "services" WHERE "services"."id" = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(2, "Haircut"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "staffs" WHERE "staffs"."id" = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(3, "Anna"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(4, "olga"))
+
+	res, err := repo.GetCalendarBookingsByStaff(3, "2026-02-01")
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), res, 1)
+	assert.True(s.T(), res[0].DeletedAt.Valid)
+	assert.Equal(s.T(), "Haircut", res[0].Service.Title)
+	assert.Equal(s.T(), "olga", res[0].User.Username)
+}
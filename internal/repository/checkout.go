@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CheckoutRepository interface {
+	GetBookingByID(id string) (*models.Booking, error)
+	GetStaffByID(id string) (*models.Staff, error)
+	GetPaymentsByBooking(bookingID uint) ([]models.Payment, error)
+
+	CreateReceipt(rec *models.Receipt) error
+	GetReceiptByBooking(bookingID string) (*models.Receipt, error)
+	GetReceiptsByUser(userID uint) ([]models.Receipt, error)
+}
+
+// CreateReceipt проводит чек и закрывает запись в одной транзакции: вместе
+// с чеком выпускаются проданные подарочные карты, списываются оплаты картами
+// и баллы, учитываются применённые правила скидок.
+// Второй чек на ту же запись отклоняет уникальный индекс.
+func (r *PostgresRepository) CreateReceipt(rec *models.Receipt) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkDiscountLimits(tx, rec); err != nil {
+			return err
+		}
+		if err := tx.Create(rec).Error; err != nil {
+			return err
+		}
+		for i := range rec.GiftCards {
+			if err := issueGiftCard(tx, &rec.GiftCards[i]); err != nil {
+				return err
+			}
+		}
+		if rec.PointsRedeemed > 0 {
+			if err := redeemPoints(tx, rec.UserID, rec.PointsRedeemed, rec.ID, rec.CreatedBy, time.Now()); err != nil {
+				return err
+			}
+		}
+		for _, t := range rec.Tenders {
+			if t.Method != "gift_card" {
+				continue
+			}
+			if err := redeemGiftCard(tx, t.Reference, t.Amount, rec.ID, rec.CreatedBy); err != nil {
+				return err
+			}
+		}
+		b := &models.Booking{Model: gorm.Model{ID: rec.BookingID}}
+		if err := updateBooking(tx, b, map[string]interface{}{"status": "completed"}); err != nil {
+			return err
+		}
+		return addEvent(tx, events.ReceiptCreated, rec.ID, events.ReceiptPayload{
+			ReceiptID: rec.ID, BookingID: rec.BookingID, UserID: rec.UserID, StaffID: rec.StaffID,
+			Subtotal: rec.Subtotal, Discount: rec.Discount, Tips: rec.Tips, Total: rec.Total, Tax: rec.Tax,
+		})
+	})
+}
+
+func (r *PostgresRepository) GetReceiptByBooking(bookingID string) (*models.Receipt, error) {
+	var rec models.Receipt
+	err := receipts(r.db).First(&rec, "booking_id = ?", bookingID).Error
+	return &rec, err
+}
+
+func (r *PostgresRepository) GetReceiptsByUser(userID uint) ([]models.Receipt, error) {
+	var list []models.Receipt
+	err := receipts(r.db).Where("user_id = ?", userID).Order("id DESC").Find(&list).Error
+	return list, err
+}
+
+func receipts(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Tenders", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("GiftCards", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) TestCreateReceipt() {
+	repo := NewPostgresRepository(s.db)
+	price, zero := money.New(500000, "KZT"), money.Zero("KZT")
+	rec := &models.Receipt{
+		BookingID: 1, UserID: 5, StaffID: 3, Subtotal: price, Discount: zero, Tips: zero, Total: price, Change: zero, Tax: zero,
+		Items:   []models.ReceiptItem{{Kind: "service", Title: "Стрижка", Quantity: 1, UnitPrice: price, Amount: price}},
+		Tenders: []models.ReceiptTender{{Method: "cash", Amount: price}},
+	}
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "receipts"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "receipt_items"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "receipt_tenders"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1`)).
+		WithArgs(uint(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "staff_id", "status", "date"}).AddRow(1, 5, 3, "confirmed", "2026-03-01 10:00"))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "bookings" SET "status"=$1`)).
+		WithArgs("completed", sqlmock.AnyArg(), uint(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "booking.status_changed", uint(1),
+			sqlmock.AnyArg(), "pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "booking.completed", uint(1),
+			sqlmock.AnyArg(), "pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "receipt.created", uint(7),
+			`{"receipt_id":7,"booking_id":1,"user_id":5,"staff_id":3,"subtotal":{"amount":"5000.00","currency":"KZT"},`+
+				`"discount":{"amount":"0.00","currency":"KZT"},"tips":{"amount":"0.00","currency":"KZT"},"total":{"amount":"5000.00","currency":"KZT"},"tax":{"amount":"0.00","currency":"KZT"}}`,
+			"pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.CreateReceipt(rec))
+	assert.Equal(s.T(), uint(7), rec.ID)
+}
+
+func (s *RepositorySuite) TestGetReceiptByBooking() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "receipts" WHERE booking_id = $1`)).
+		WithArgs("1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "booking_id", "total_amount", "total_currency"}).AddRow(7, 1, 500000, "KZT"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "gift_cards" WHERE "gift_cards"."sold_receipt_id" = $1`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "receipt_items" WHERE "receipt_items"."receipt_id" = $1`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "receipt_id", "kind"}).AddRow(1, 7, "service"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "receipt_tenders" WHERE "receipt_tenders"."receipt_id" = $1`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "receipt_id", "method"}).AddRow(1, 7, "cash"))
+
+	rec, err := repo.GetReceiptByBooking("1")
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), rec.Items, 1)
+	assert.Equal(s.T(), "cash", rec.Tenders[0].Method)
+	assert.Equal(s.T(), money.New(500000, "KZT"), rec.Total)
+}
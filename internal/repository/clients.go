@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClientRepository interface {
+	GetUserByID(id uint) (*models.User, error)
+	GetStaffByID(id string) (*models.Staff, error)
+	GetBookingByID(id string) (*models.Booking, error)
+
+	GetClientProfile(userID uint) (*models.ClientProfile, error)
+	SaveClientProfile(p *models.ClientProfile) error
+	GetClientNotes(userID uint) ([]models.ClientNote, error)
+	CreateClientNote(n *models.ClientNote) error
+	DeleteClientNote(userID uint, noteID string) error
+	GetVisits(userID uint, before string) ([]models.Booking, error)
+	GetReceiptsByUser(userID uint) ([]models.Receipt, error)
+}
+
+func (r *PostgresRepository) GetClientProfile(userID uint) (*models.ClientProfile, error) {
+	var p models.ClientProfile
+	err := r.db.Preload("PreferredStaff").Where("user_id = ?", userID).First(&p).Error
+	return &p, err
+}
+
+// SaveClientProfile создаёт карточку или обновляет существующую по user_id.
+func (r *PostgresRepository) SaveClientProfile(p *models.ClientProfile) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"allergies", "contraindications", "preferred_staff_id", "risk_level", "updated_at"}),
+	}).Create(p).Error
+}
+
+func (r *PostgresRepository) GetClientNotes(userID uint) ([]models.ClientNote, error) {
+	var notes []models.ClientNote
+	err := r.db.Where("user_id = ?", userID).Order("id desc").Find(&notes).Error
+	return notes, err
+}
+
+func (r *PostgresRepository) CreateClientNote(n *models.ClientNote) error {
+	return r.db.Create(n).Error
+}
+
+func (r *PostgresRepository) DeleteClientNote(userID uint, noteID string) error {
+	res := r.db.Where("user_id = ?", userID).Delete(&models.ClientNote{}, noteID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetVisits возвращает состоявшиеся визиты: завершённые записи с датой не позже before.
+// Date хранится как "YYYY-MM-DD HH:MM", поэтому строки сравниваются как даты.
+func (r *PostgresRepository) GetVisits(userID uint, before string) ([]models.Booking, error) {
+	var visits []models.Booking
+	err := r.db.Preload("Service").Preload("Staff").
+		Where("user_id = ? AND status = ? AND date <= ?", userID, "completed", before).
+		Order("date desc").Find(&visits).Error
+	return visits, err
+}
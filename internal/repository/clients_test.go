@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestSaveClientProfile() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "client_profiles"`) + `.*` +
+		regexp.QuoteMeta(`ON CONFLICT ("user_id") DO UPDATE SET "allergies"="excluded"."allergies"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := repo.SaveClientProfile(&models.ClientProfile{UserID: 3, Allergies: "латекс"})
+	assert.NoError(s.T(), err)
+}
+
+func (s *RepositorySuite) TestGetClientNotes() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "client_notes" WHERE user_id = $1 AND "client_notes"."deleted_at" IS NULL ORDER BY id desc`)).
+		WithArgs(uint(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text"}).AddRow(2, "b").AddRow(1, "a"))
+
+	res, err := repo.GetClientNotes(3)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), res, 2)
+}
+
+func (s *RepositorySuite) TestDeleteClientNote() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "client_notes" SET "deleted_at"=$1 WHERE user_id = $2 AND "client_notes"."id" = $3`)).
+		WithArgs(sqlmock.AnyArg(), uint(3), "7").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	// Заметка другого клиента не удаляется.
+	assert.ErrorIs(s.T(), repo.DeleteClientNote(3, "7"), gorm.ErrRecordNotFound)
+}
+
+func (s *RepositorySuite) TestGetVisits() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE (user_id = $1 AND status = $2 AND date <= $3) AND "bookings"."deleted_at" IS NULL ORDER BY date desc`)).
+		WithArgs(uint(3), "completed", "2025-05-01 10:00").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "service_id", "staff_id"}).AddRow(1, 3, 1, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "services"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "price_amount", "price_currency"}).AddRow(1, 500000, "KZT"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "staffs"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	res, err := repo.GetVisits(3, "2025-05-01 10:00")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), money.New(500000, "KZT"), res[0].Service.Price)
+}
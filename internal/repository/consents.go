@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// addConsent дописывает запись в журнал согласий внутри транзакции tx.
+func addConsent(tx *gorm.DB, userID uint, kind string, granted bool, source string) error {
+	return tx.Create(&models.Consent{UserID: userID, Kind: kind, Granted: granted, Source: source}).Error
+}
+
+func (r *PostgresRepository) GetConsentsByUser(userID uint) ([]models.Consent, error) {
+	var consents []models.Consent
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&consents).Error
+	return consents, err
+}
+
+// channelConsents сравнивает списки отключённых каналов: канал, включённый
+// снова, — выданное согласие, отключённый — отозванное.
+func channelConsents(userID uint, before, after string) []models.Consent {
+	prev, next := splitList(before), splitList(after)
+	var list []models.Consent
+	for _, ch := range sortedKeys(prev) {
+		if !next[ch] {
+			list = append(list, models.Consent{UserID: userID, Kind: models.ConsentNotifications + ch, Granted: true})
+		}
+	}
+	for _, ch := range sortedKeys(next) {
+		if !prev[ch] {
+			list = append(list, models.Consent{UserID: userID, Kind: models.ConsentNotifications + ch})
+		}
+	}
+	return list
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func splitList(list string) map[string]bool {
+	set := map[string]bool{}
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DepositRepository interface {
+	GetServiceByID(id string) (*models.Service, error)
+	GetClientProfile(userID uint) (*models.ClientProfile, error)
+
+	CreateDepositRule(r *models.DepositRule) error
+	GetDepositRules() ([]models.DepositRule, error)
+	DeleteDepositRule(id string) error
+	GetExpiredUnpaidBookings(now time.Time) ([]models.Booking, error)
+}
+
+func (r *PostgresRepository) CreateDepositRule(rule *models.DepositRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *PostgresRepository) GetDepositRules() ([]models.DepositRule, error) {
+	var rules []models.DepositRule
+	err := r.db.Order("id").Find(&rules).Error
+	return rules, err
+}
+
+func (r *PostgresRepository) DeleteDepositRule(id string) error {
+	res := r.db.Delete(&models.DepositRule{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetExpiredUnpaidBookings возвращает записи, предоплата по которым не пришла в срок.
+func (r *PostgresRepository) GetExpiredUnpaidBookings(now time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.Where("status = ? AND payment_due_at < ?", "awaiting_payment", now).Order("id").Find(&bookings).Error
+	return bookings, err
+}
+
+// RetainPayment отмечает предоплату удержанной. Повторная отметка ничего не меняет.
+func (r *PostgresRepository) RetainPayment(id uint, at time.Time) error {
+	return r.db.Model(&models.Payment{}).Where("id = ? AND retained_at IS NULL", id).Update("retained_at", at).Error
+}
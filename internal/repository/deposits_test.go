@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestGetExpiredUnpaidBookings() {
+	repo := NewPostgresRepository(s.db)
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE (status = $1 AND payment_due_at < $2) AND "bookings"."deleted_at" IS NULL ORDER BY id`)).
+		WithArgs("awaiting_payment", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(4, "awaiting_payment"))
+
+	list, err := repo.GetExpiredUnpaidBookings(now)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), list, 1)
+}
+
+func (s *RepositorySuite) TestDeleteDepositRuleNotFound() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "deposit_rules" SET "deleted_at"=$1 WHERE id = $2`)).
+		WithArgs(sqlmock.AnyArg(), "7").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	assert.ErrorIs(s.T(), repo.DeleteDepositRule("7"), gorm.ErrRecordNotFound)
+}
+
+func (s *RepositorySuite) TestRetainPayment() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "payments" SET "retained_at"=$1,"updated_at"=$2 WHERE (id = $3 AND retained_at IS NULL) AND "payments"."deleted_at" IS NULL`)).
+		WithArgs(at, sqlmock.AnyArg(), uint(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.RetainPayment(9, at))
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrDiscountLimit = errors.New("promo code usage limit reached")
+
+type DiscountRepository interface {
+	GetServiceByID(id string) (*models.Service, error)
+
+	CreateDiscountRule(r *models.DiscountRule) error
+	GetDiscountRules() ([]models.DiscountRule, error)
+	GetDiscountRuleByCode(code string) (*models.DiscountRule, error)
+	DeleteDiscountRule(id string) error
+	CountDiscountRedemptions(ruleID, userID uint) (total, byUser int64, err error)
+	CountCompletedVisits(userID uint) (int64, error)
+}
+
+func (r *PostgresRepository) CreateDiscountRule(rule *models.DiscountRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *PostgresRepository) GetDiscountRules() ([]models.DiscountRule, error) {
+	var rules []models.DiscountRule
+	err := r.db.Order("id").Find(&rules).Error
+	return rules, err
+}
+
+func (r *PostgresRepository) GetDiscountRuleByCode(code string) (*models.DiscountRule, error) {
+	var rule models.DiscountRule
+	err := r.db.First(&rule, "code = ?", code).Error
+	return &rule, err
+}
+
+func (r *PostgresRepository) DeleteDiscountRule(id string) error {
+	res := r.db.Delete(&models.DiscountRule{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CountDiscountRedemptions возвращает, сколько раз правило применено всего и клиентом userID.
+func (r *PostgresRepository) CountDiscountRedemptions(ruleID, userID uint) (total, byUser int64, err error) {
+	return countRedemptions(r.db, ruleID, userID)
+}
+
+func (r *PostgresRepository) CountCompletedVisits(userID uint) (int64, error) {
+	var n int64
+	err := r.db.Model(&models.Booking{}).Where("user_id = ? AND status = ?", userID, "completed").Count(&n).Error
+	return n, err
+}
+
+func countRedemptions(db *gorm.DB, ruleID, userID uint) (total, byUser int64, err error) {
+	if err = db.Model(&models.DiscountRedemption{}).Where("rule_id = ?", ruleID).Count(&total).Error; err != nil {
+		return 0, 0, err
+	}
+	err = db.Model(&models.DiscountRedemption{}).Where("rule_id = ? AND user_id = ?", ruleID, userID).Count(&byUser).Error
+	return total, byUser, err
+}
+
+// checkDiscountLimits перепроверяет лимиты правил под блокировкой: при записи
+// они проверялись без неё, и два визита могли занять последнее использование.
+func checkDiscountLimits(tx *gorm.DB, rec *models.Receipt) error {
+	for _, d := range rec.Redemptions {
+		var rule models.DiscountRule
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&rule, d.RuleID).Error; err != nil {
+			return err
+		}
+		if rule.MaxUses == 0 && rule.MaxUsesPerClient == 0 {
+			continue
+		}
+		total, byUser, err := countRedemptions(tx, rule.ID, d.UserID)
+		if err != nil {
+			return err
+		}
+		if (rule.MaxUses > 0 && total >= int64(rule.MaxUses)) || (rule.MaxUsesPerClient > 0 && byUser >= int64(rule.MaxUsesPerClient)) {
+			return ErrDiscountLimit
+		}
+	}
+	return nil
+}
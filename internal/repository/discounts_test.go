@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) TestCountDiscountRedemptions() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "discount_redemptions" WHERE rule_id = $1`)).
+		WithArgs(uint(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "discount_redemptions" WHERE rule_id = $1 AND user_id = $2`)).
+		WithArgs(uint(7), uint(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	total, mine, err := repo.CountDiscountRedemptions(7, 5)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(12), total)
+	assert.Equal(s.T(), int64(1), mine)
+}
+
+func (s *RepositorySuite) TestCreateReceiptDiscountLimit() {
+	repo := NewPostgresRepository(s.db)
+	rec := &models.Receipt{BookingID: 1, UserID: 5,
+		Redemptions: []models.DiscountRedemption{{RuleID: 7, UserID: 5, Amount: money.New(50000, "KZT")}}}
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "discount_rules" WHERE "discount_rules"."id" = $1 ORDER BY "discount_rules"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "max_uses_per_client"}).AddRow(7, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "discount_redemptions" WHERE rule_id = $1`)).
+		WithArgs(uint(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "discount_redemptions" WHERE rule_id = $1 AND user_id = $2`)).
+		WithArgs(uint(7), uint(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectRollback()
+
+	assert.ErrorIs(s.T(), repo.CreateReceipt(rec), ErrDiscountLimit)
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExportRepository interface {
+	GetUserByID(id uint) (*models.User, error)
+	GetBookingsByUser(userID uint) ([]models.Booking, error)
+	GetIdentitiesByUser(userID uint) ([]models.UserIdentity, error)
+	GetConsentsByUser(userID uint) ([]models.Consent, error)
+
+	CreateExport(e *models.DataExport) error
+	GetActiveExport(userID uint, now time.Time) (*models.DataExport, error)
+	GetExportByID(id string) (*models.DataExport, error)
+	GetExportByToken(token string) (*models.DataExport, error)
+	ClaimPendingExport(now time.Time, lease time.Duration) (*models.DataExport, error)
+	SaveExport(e *models.DataExport) error
+	PurgeExpiredExports(now time.Time) error
+}
+
+func (r *PostgresRepository) GetBookingsByUser(userID uint) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.Preload("Service").Preload("Staff").Where("user_id = ?", userID).Order("id").Find(&bookings).Error
+	return bookings, err
+}
+
+func (r *PostgresRepository) GetIdentitiesByUser(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Find(&identities).Error
+	return identities, err
+}
+
+func (r *PostgresRepository) CreateExport(e *models.DataExport) error { return r.db.Create(e).Error }
+
+// GetActiveExport возвращает последнюю заявку пользователя, которая ещё в работе
+// или уже собрана и не истекла.
+func (r *PostgresRepository) GetActiveExport(userID uint, now time.Time) (*models.DataExport, error) {
+	var e models.DataExport
+	err := r.db.Omit("archive").
+		Where("user_id = ? AND (status IN ? OR (status = ? AND expires_at > ?))", userID, []string{"pending", "processing"}, "ready", now).
+		Order("id DESC").First(&e).Error
+	return &e, err
+}
+func (r *PostgresRepository) GetExportByID(id string) (*models.DataExport, error) {
+	var e models.DataExport
+	err := r.db.Omit("archive").First(&e, "id = ?", id).Error
+	return &e, err
+}
+func (r *PostgresRepository) GetExportByToken(token string) (*models.DataExport, error) {
+	var e models.DataExport
+	err := r.db.Where("token = ? AND status = ?", token, "ready").First(&e).Error
+	return &e, err
+}
+
+// ClaimPendingExport забирает одну заявку в работу на lease. SKIP LOCKED позволяет
+// запускать обработчик на нескольких репликах без двойной обработки. Заявка,
+// оставшаяся в processing после падения обработчика, снова берётся по истечении lease.
+func (r *PostgresRepository) ClaimPendingExport(now time.Time, lease time.Duration) (*models.DataExport, error) {
+	var e models.DataExport
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND lease_until < ?)", "pending", "processing", now).
+			Order("id").First(&e).Error; err != nil {
+			return err
+		}
+		until := now.Add(lease)
+		e.Status, e.LeaseUntil = "processing", &until
+		return tx.Model(&e).Updates(map[string]interface{}{"status": e.Status, "lease_until": e.LeaseUntil}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *PostgresRepository) SaveExport(e *models.DataExport) error { return r.db.Save(e).Error }
+
+// PurgeExpiredExports удаляет архивы с истёкшим сроком, сами заявки остаются.
+func (r *PostgresRepository) PurgeExpiredExports(now time.Time) error {
+	return r.db.Model(&models.DataExport{}).
+		Where("expires_at < ? AND archive IS NOT NULL", now).
+		Updates(map[string]interface{}{"archive": nil, "token": "", "status": "expired"}).Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) TestGetBookingsByUser() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE user_id = $1 AND "bookings"."deleted_at" IS NULL ORDER BY id`)).
+		WithArgs(uint(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(1, 3))
+
+	res, err := repo.GetBookingsByUser(3)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), res, 1)
+}
+
+func (s *RepositorySuite) TestGetExportByToken() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "data_exports" WHERE (token = $1 AND status = $2)`)).
+		WithArgs("tok", "ready", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "token", "archive"}).AddRow(1, "tok", []byte("zip")))
+
+	res, err := repo.GetExportByToken("tok")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("zip"), res.Archive)
+}
+
+func (s *RepositorySuite) TestGetActiveExport() {
+	repo := NewPostgresRepository(s.db)
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT "data_exports"."id","data_exports"."created_at","data_exports"."updated_at","data_exports"."deleted_at","data_exports"."user_id","data_exports"."requested_by","data_exports"."status","data_exports"."token","data_exports"."error","data_exports"."expires_at","data_exports"."lease_until" FROM "data_exports" WHERE (user_id = $1 AND (status IN ($2,$3) OR (status = $4 AND expires_at > $5)))`)).
+		WithArgs(uint(3), "pending", "processing", "ready", now, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "status"}).AddRow(7, 3, "pending"))
+
+	e, err := repo.GetActiveExport(3, now)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), uint(7), e.ID)
+}
+
+func (s *RepositorySuite) TestClaimPendingExport() {
+	repo := NewPostgresRepository(s.db)
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "data_exports" WHERE (status = $1 OR (status = $2 AND lease_until < $3)) AND "data_exports"."deleted_at" IS NULL ORDER BY id,"data_exports"."id" LIMIT $4 FOR UPDATE SKIP LOCKED`)).
+		WithArgs("pending", "processing", now, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "status"}).AddRow(7, 3, "processing"))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "data_exports" SET "lease_until"=$1,"status"=$2`)).
+		WithArgs(now.Add(10*time.Minute), "processing", sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	e, err := repo.ClaimPendingExport(now, 10*time.Minute)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "processing", e.Status)
+	assert.Equal(s.T(), now.Add(10*time.Minute), *e.LeaseUntil)
+	assert.Equal(s.T(), uint(3), e.UserID)
+}
+
+func (s *RepositorySuite) TestGetConsentsByUser() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "consents" WHERE user_id = $1 ORDER BY id`)).
+		WithArgs(uint(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "kind", "granted"}).AddRow(1, 3, "personal_data", true))
+
+	res, err := repo.GetConsentsByUser(3)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "personal_data", res[0].Kind)
+}
+
+func (s *RepositorySuite) TestPurgeExpiredExports() {
+	repo := NewPostgresRepository(s.db)
+	now := time.Now()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "data_exports" SET "archive"=$1,"status"=$2,"token"=$3`)).
+		WithArgs(nil, "expired", "", sqlmock.AnyArg(), now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.PurgeExpiredExports(now))
+}
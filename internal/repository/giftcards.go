@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrGiftCardBalance = errors.New("gift card balance is too low")
+
+type GiftCardRepository interface {
+	CreateGiftCard(card *models.GiftCard) error
+	GetGiftCardByCode(code string) (*models.GiftCard, error)
+	GetGiftCardEntries(cardID uint) ([]models.GiftCardEntry, error)
+}
+
+// CreateGiftCard выпускает карту и пишет начисление номинала в журнал.
+func (r *PostgresRepository) CreateGiftCard(card *models.GiftCard) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(card).Error; err != nil {
+			return err
+		}
+		return issueGiftCard(tx, card)
+	})
+}
+
+func (r *PostgresRepository) GetGiftCardByCode(code string) (*models.GiftCard, error) {
+	var card models.GiftCard
+	err := r.db.First(&card, "code = ?", code).Error
+	return &card, err
+}
+
+func (r *PostgresRepository) GetGiftCardEntries(cardID uint) ([]models.GiftCardEntry, error) {
+	var list []models.GiftCardEntry
+	err := r.db.Where("gift_card_id = ?", cardID).Order("id").Find(&list).Error
+	return list, err
+}
+
+func issueGiftCard(tx *gorm.DB, card *models.GiftCard) error {
+	return tx.Create(&models.GiftCardEntry{
+		GiftCardID: card.ID, Kind: models.GiftCardIssue, Amount: card.Initial, BalanceAfter: card.Balance,
+		ReceiptID: card.SoldReceiptID, CreatedBy: card.CreatedBy,
+	}).Error
+}
+
+// redeemGiftCard списывает amount с карты под блокировкой строки, чтобы две
+// кассы не потратили один остаток дважды.
+func redeemGiftCard(tx *gorm.DB, code string, amount money.Money, receiptID uint, by uint) error {
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, "code = ?", code).Error; err != nil {
+		return err
+	}
+	if !card.Balance.SameCurrency(amount) || card.Balance.Cmp(amount) < 0 {
+		return ErrGiftCardBalance
+	}
+	card.Balance = card.Balance.Sub(amount)
+	if err := tx.Model(&card).Update("balance_amount", card.Balance.Minor).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.GiftCardEntry{
+		GiftCardID: card.ID, Kind: models.GiftCardRedeem, Amount: amount.Neg(), BalanceAfter: card.Balance,
+		ReceiptID: &receiptID, CreatedBy: by,
+	}).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestCreateGiftCard() {
+	repo := NewPostgresRepository(s.db)
+	amount := money.New(1000000, "KZT")
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "gift_cards"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "gift_card_entries"`)).
+		WithArgs(sqlmock.AnyArg(), uint(4), "issue", int64(1000000), "KZT", int64(1000000), "KZT", nil, uint(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	card := &models.GiftCard{Code: "ABCD", Initial: amount, Balance: amount, CreatedBy: 2}
+	assert.NoError(s.T(), repo.CreateGiftCard(card))
+	assert.Equal(s.T(), uint(4), card.ID)
+}
+
+func (s *RepositorySuite) TestRedeemGiftCard() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "gift_cards" WHERE code = $1 AND "gift_cards"."deleted_at" IS NULL ORDER BY "gift_cards"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs("ABCD", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "balance_amount", "balance_currency"}).AddRow(4, "ABCD", 300000, "KZT"))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "gift_cards" SET "balance_amount"=$1`)).
+		WithArgs(int64(100000), sqlmock.AnyArg(), uint(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "gift_card_entries"`)).
+		WithArgs(sqlmock.AnyArg(), uint(4), "redeem", int64(-200000), "KZT", int64(100000), "KZT", uint(7), uint(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	s.mock.ExpectCommit()
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		return redeemGiftCard(tx, "ABCD", money.New(200000, "KZT"), 7, 2)
+	})
+	assert.NoError(s.T(), err)
+}
+
+func (s *RepositorySuite) TestRedeemGiftCardBalanceTooLow() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "gift_cards" WHERE code = $1`)).
+		WithArgs("ABCD", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "balance_amount", "balance_currency"}).AddRow(4, "ABCD", 100000, "KZT"))
+	s.mock.ExpectRollback()
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		return redeemGiftCard(tx, "ABCD", money.New(200000, "KZT"), 7, 2)
+	})
+	assert.ErrorIs(s.T(), err, ErrGiftCardBalance)
+}
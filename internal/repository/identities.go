@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	GetUserByIdentity(provider, subject string) (*models.User, error)
+	CreateIdentity(i *models.UserIdentity) error
+	CreateUserWithIdentity(u *models.User, i *models.UserIdentity) error
+	UsernameTaken(username string) (bool, error)
+}
+
+// TelegramRepository — привязка чатов Telegram к пользователям через UserIdentity.
+type TelegramRepository interface {
+	GetUserByID(id uint) (*models.User, error)
+	GetUserByIdentity(provider, subject string) (*models.User, error)
+	GetIdentityByUser(userID uint, provider string) (*models.UserIdentity, error)
+	CreateIdentity(i *models.UserIdentity) error
+	DeleteIdentity(userID uint, provider string) error
+}
+
+func (r *PostgresRepository) GetUserByIdentity(provider, subject string) (*models.User, error) {
+	var user models.User
+	err := r.db.Joins("JOIN user_identities ON user_identities.user_id = users.id AND user_identities.deleted_at IS NULL").
+		Where("user_identities.provider = ? AND user_identities.subject = ?", provider, subject).
+		First(&user).Error
+	return &user, err
+}
+
+func (r *PostgresRepository) CreateIdentity(i *models.UserIdentity) error {
+	return r.db.Create(i).Error
+}
+
+func (r *PostgresRepository) GetIdentityByUser(userID uint, provider string) (*models.UserIdentity, error) {
+	var i models.UserIdentity
+	err := r.db.Where("user_id = ? AND provider = ?", userID, provider).First(&i).Error
+	return &i, err
+}
+
+// DeleteIdentity отвязывает учётку. Удаление без soft delete, иначе
+// уникальный индекс не даст привязать ту же учётку заново.
+func (r *PostgresRepository) DeleteIdentity(userID uint, provider string) error {
+	return r.db.Unscoped().Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.UserIdentity{}).Error
+}
+
+// CreateUserWithIdentity создаёт пользователя и его внешнюю учётку в одной транзакции.
+func (r *PostgresRepository) CreateUserWithIdentity(u *models.User, i *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		i.UserID = u.ID
+		if err := tx.Create(i).Error; err != nil {
+			return err
+		}
+		if err := addConsent(tx, u.ID, models.ConsentPersonalData, true, "oidc"); err != nil {
+			return err
+		}
+		return addEvent(tx, events.UserRegistered, u.ID, events.UserRegisteredPayload{UserID: u.ID, Username: u.Username})
+	})
+}
+
+func (r *PostgresRepository) UsernameTaken(username string) (bool, error) {
+	var user models.User
+	err := r.db.Unscoped().Select("id").Where("username = ?", username).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestGetUserByIdentity() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT "users"."id"`)).
+		WithArgs("google", "sub-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(4, "anna"))
+
+	res, err := repo.GetUserByIdentity("google", "sub-1")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "anna", res.Username)
+}
+
+func (s *RepositorySuite) TestCreateUserWithIdentity() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user_identities"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "consents"`)).
+		WithArgs(uint(7), "personal_data", true, "oidc", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	identity := &models.UserIdentity{Provider: "google", Subject: "sub-1"}
+	err := repo.CreateUserWithIdentity(&models.User{Username: "anna"}, identity)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), uint(7), identity.UserID)
+}
+
+func (s *RepositorySuite) TestCreateUserWithIdentityRollback() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user_identities"`)).
+		WillReturnError(gorm.ErrDuplicatedKey)
+	s.mock.ExpectRollback()
+
+	err := repo.CreateUserWithIdentity(&models.User{Username: "anna"}, &models.UserIdentity{})
+	assert.Error(s.T(), err)
+}
+
+func (s *RepositorySuite) TestUsernameTaken() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE username = $1`)).
+		WithArgs("anna", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	taken, err := repo.UsernameTaken("anna")
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), taken)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE username = $1`)).
+		WithArgs("free", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	taken, err = repo.UsernameTaken("free")
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), taken)
+}
+
+func (s *RepositorySuite) TestGetIdentityByUser() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_identities" WHERE (user_id = $1 AND provider = $2) AND "user_identities"."deleted_at" IS NULL`)).
+		WithArgs(4, "telegram", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "provider", "subject"}).AddRow(1, 4, "telegram", "100500"))
+
+	res, err := repo.GetIdentityByUser(4, "telegram")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "100500", res.Subject)
+}
+
+func (s *RepositorySuite) TestDeleteIdentity() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "user_identities" WHERE user_id = $1 AND provider = $2`)).
+		WithArgs(4, "telegram").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.DeleteIdentity(4, "telegram"))
+}
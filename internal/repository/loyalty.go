@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrLoyaltyBalance = errors.New("not enough loyalty points")
+
+type LoyaltyRepository interface {
+	GetReceiptByID(id uint) (*models.Receipt, error)
+
+	AddLoyaltyEntry(e *models.LoyaltyEntry) error
+	GetLoyaltyEntries(userID uint) ([]models.LoyaltyEntry, error)
+	GetLoyaltyBalance(userID uint, at time.Time) (int64, error)
+	GetLoyaltyEarned(userID uint, since time.Time) (int64, error)
+	ExpireLoyaltyPoints(at time.Time) (int, error)
+}
+
+// AddLoyaltyEntry записывает начисление. Повторное начисление за тот же чек
+// пропускается — событие о чеке может прийти дважды.
+func (r *PostgresRepository) AddLoyaltyEntry(e *models.LoyaltyEntry) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(e).Error
+}
+
+func (r *PostgresRepository) GetLoyaltyEntries(userID uint) ([]models.LoyaltyEntry, error) {
+	var list []models.LoyaltyEntry
+	err := r.db.Where("user_id = ?", userID).Order("id desc").Find(&list).Error
+	return list, err
+}
+
+// GetLoyaltyBalance возвращает баллы, доступные к списанию в момент at.
+func (r *PostgresRepository) GetLoyaltyBalance(userID uint, at time.Time) (int64, error) {
+	var n int64
+	err := availablePoints(r.db, userID, at).Select("COALESCE(SUM(remaining), 0)").Scan(&n).Error
+	return n, err
+}
+
+// GetLoyaltyEarned возвращает баллы, начисленные с since, — по ним считается уровень.
+func (r *PostgresRepository) GetLoyaltyEarned(userID uint, since time.Time) (int64, error) {
+	var n int64
+	err := r.db.Model(&models.LoyaltyEntry{}).
+		Where("user_id = ? AND kind IN ? AND created_at >= ?", userID, []string{models.LoyaltyEarn, models.LoyaltyBonus}, since).
+		Select("COALESCE(SUM(points), 0)").Scan(&n).Error
+	return n, err
+}
+
+// ExpireLoyaltyPoints списывает несгоревший остаток просроченных начислений
+// и возвращает число таких начислений.
+func (r *PostgresRepository) ExpireLoyaltyPoints(at time.Time) (int, error) {
+	var expired []models.LoyaltyEntry
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("remaining > 0 AND expires_at <= ?", at).Order("id").Find(&expired).Error; err != nil {
+			return err
+		}
+		for _, e := range expired {
+			if err := tx.Model(&models.LoyaltyEntry{}).Where("id = ?", e.ID).Update("remaining", 0).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.LoyaltyEntry{UserID: e.UserID, Kind: models.LoyaltyExpire, Points: -e.Remaining}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(expired), err
+}
+
+func availablePoints(db *gorm.DB, userID uint, at time.Time) *gorm.DB {
+	return db.Model(&models.LoyaltyEntry{}).
+		Where("user_id = ? AND remaining > 0 AND (expires_at IS NULL OR expires_at > ?)", userID, at)
+}
+
+// redeemPoints списывает баллы в оплату чека под блокировкой начислений:
+// сначала те, что сгорят раньше.
+func redeemPoints(tx *gorm.DB, userID uint, points int64, receiptID, by uint, at time.Time) error {
+	var credits []models.LoyaltyEntry
+	if err := availablePoints(tx, userID, at).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Order("expires_at NULLS LAST, id").Find(&credits).Error; err != nil {
+		return err
+	}
+	left := points
+	for _, c := range credits {
+		if left == 0 {
+			break
+		}
+		take := min(c.Remaining, left)
+		if err := tx.Model(&models.LoyaltyEntry{}).Where("id = ?", c.ID).Update("remaining", c.Remaining-take).Error; err != nil {
+			return err
+		}
+		left -= take
+	}
+	if left > 0 {
+		return ErrLoyaltyBalance
+	}
+	return tx.Create(&models.LoyaltyEntry{UserID: userID, Kind: models.LoyaltyRedeem, Points: -points, ReceiptID: &receiptID, CreatedBy: by}).Error
+}
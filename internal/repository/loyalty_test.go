@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestGetLoyaltyBalance() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(SUM(remaining), 0) FROM "loyalty_entries" WHERE user_id = $1 AND remaining > 0 AND (expires_at IS NULL OR expires_at > $2)`)).
+		WithArgs(uint(4), at).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(640))
+
+	n, err := repo.GetLoyaltyBalance(4, at)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(640), n)
+}
+
+func (s *RepositorySuite) TestRedeemPointsOldestFirst() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loyalty_entries" WHERE user_id = $1 AND remaining > 0 AND (expires_at IS NULL OR expires_at > $2) ORDER BY expires_at NULLS LAST, id FOR UPDATE`)).
+		WithArgs(uint(4), at).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "remaining"}).AddRow(1, 4, 30).AddRow(2, 4, 100))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "loyalty_entries" SET "remaining"=$1 WHERE id = $2`)).
+		WithArgs(int64(0), uint(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "loyalty_entries" SET "remaining"=$1 WHERE id = $2`)).
+		WithArgs(int64(80), uint(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "loyalty_entries"`)).
+		WithArgs(sqlmock.AnyArg(), uint(4), "redeem", int64(-50), int64(0), nil, uint(7), "", uint(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectCommit()
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error { return redeemPoints(tx, 4, 50, 7, 9, at) })
+	assert.NoError(s.T(), err)
+}
+
+func (s *RepositorySuite) TestRedeemPointsNotEnough() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loyalty_entries"`)).
+		WithArgs(uint(4), at).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "remaining"}).AddRow(1, 4, 30))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "loyalty_entries" SET "remaining"=$1 WHERE id = $2`)).
+		WithArgs(int64(0), uint(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectRollback()
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error { return redeemPoints(tx, 4, 50, 7, 9, at) })
+	assert.ErrorIs(s.T(), err, ErrLoyaltyBalance)
+}
+
+func (s *RepositorySuite) TestExpireLoyaltyPoints() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loyalty_entries" WHERE remaining > 0 AND expires_at <= $1 ORDER BY id FOR UPDATE`)).
+		WithArgs(at).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "kind", "points", "remaining"}).AddRow(1, 4, models.LoyaltyEarn, 72, 40))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "loyalty_entries" SET "remaining"=$1 WHERE id = $2`)).
+		WithArgs(0, uint(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "loyalty_entries"`)).
+		WithArgs(sqlmock.AnyArg(), uint(4), "expire", int64(-40), int64(0), nil, nil, "", uint(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	s.mock.ExpectCommit()
+
+	n, err := repo.ExpireLoyaltyPoints(at)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, n)
+}
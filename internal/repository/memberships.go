@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrMembershipLimit = errors.New("membership visit limit reached")
+
+type MembershipRepository interface {
+	GetUserByID(id uint) (*models.User, error)
+	GetServiceByID(id string) (*models.Service, error)
+
+	CreateMembershipPlan(p *models.MembershipPlan) error
+	GetMembershipPlans() ([]models.MembershipPlan, error)
+	GetMembershipPlanByID(id uint) (*models.MembershipPlan, error)
+	DeleteMembershipPlan(id string) error
+
+	CreateSubscription(s *models.Subscription) error
+	SaveSubscription(s *models.Subscription) error
+	GetSubscriptionByID(id uint) (*models.Subscription, error)
+	GetCurrentSubscription(userID uint) (*models.Subscription, error)
+	GetDueSubscriptions(at time.Time) ([]models.Subscription, error)
+	CountMembershipVisits(subscriptionID, serviceID uint, since time.Time) (int64, error)
+
+	CreateMembershipPayment(p *models.Payment) (*models.Payment, bool, error)
+	SavePayment(p *models.Payment) error
+	SetPaymentStatus(id uint, status string, at time.Time) (*models.Payment, bool, error)
+}
+
+func (r *PostgresRepository) CreateMembershipPlan(p *models.MembershipPlan) error {
+	return r.db.Create(p).Error
+}
+
+func (r *PostgresRepository) GetMembershipPlans() ([]models.MembershipPlan, error) {
+	var list []models.MembershipPlan
+	err := r.db.Preload("Benefits").Order("id").Find(&list).Error
+	return list, err
+}
+
+func (r *PostgresRepository) GetMembershipPlanByID(id uint) (*models.MembershipPlan, error) {
+	var p models.MembershipPlan
+	err := r.db.Preload("Benefits").First(&p, id).Error
+	return &p, err
+}
+
+// DeleteMembershipPlan снимает абонемент с продажи; оформленные продлеваются дальше.
+func (r *PostgresRepository) DeleteMembershipPlan(id string) error {
+	res := r.db.Delete(&models.MembershipPlan{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) CreateSubscription(s *models.Subscription) error {
+	return r.db.Omit(clause.Associations).Create(s).Error
+}
+
+func (r *PostgresRepository) SaveSubscription(s *models.Subscription) error {
+	return r.db.Omit(clause.Associations).Save(s).Error
+}
+
+func (r *PostgresRepository) GetSubscriptionByID(id uint) (*models.Subscription, error) {
+	var s models.Subscription
+	err := r.db.Preload("Plan.Benefits").First(&s, id).Error
+	return &s, err
+}
+
+// GetCurrentSubscription возвращает незавершённый абонемент клиента с
+// платежами, последний — первым.
+func (r *PostgresRepository) GetCurrentSubscription(userID uint) (*models.Subscription, error) {
+	var s models.Subscription
+	err := r.db.Preload("Plan.Benefits").Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("id DESC") }).
+		Where("user_id = ? AND status IN ?", userID, []string{models.SubscriptionPending, models.SubscriptionActive, models.SubscriptionPastDue}).
+		First(&s).Error
+	return &s, err
+}
+
+// GetDueSubscriptions возвращает абонементы, которые пора продлить, и
+// неоплаченные после льготного срока.
+func (r *PostgresRepository) GetDueSubscriptions(at time.Time) ([]models.Subscription, error) {
+	var list []models.Subscription
+	err := r.db.Preload("Plan").
+		Where("(status = ? AND current_period_end <= ?) OR (status IN ? AND grace_until <= ?)",
+			models.SubscriptionActive, at, []string{models.SubscriptionPending, models.SubscriptionPastDue}, at).
+		Order("id").Find(&list).Error
+	return list, err
+}
+
+// CountMembershipVisits считает неотменённые записи на услугу по абонементу,
+// сделанные начиная с since.
+func (r *PostgresRepository) CountMembershipVisits(subscriptionID, serviceID uint, since time.Time) (int64, error) {
+	return countMembershipVisits(r.db, subscriptionID, serviceID, since)
+}
+
+// CreateMembershipPayment выставляет счёт по абонементу. Если по нему уже
+// ждёт оплаты другой счёт, возвращается он и created = false.
+func (r *PostgresRepository) CreateMembershipPayment(p *models.Payment) (*models.Payment, bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var s models.Subscription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, *p.SubscriptionID).Error; err != nil {
+			return err
+		}
+		var pending models.Payment
+		err := tx.Where("subscription_id = ? AND status = ?", s.ID, "pending").Order("id").First(&pending).Error
+		if err == nil {
+			p = &pending
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		created = true
+		return tx.Create(p).Error
+	})
+	return p, created, err
+}
+
+func countMembershipVisits(db *gorm.DB, subscriptionID, serviceID uint, since time.Time) (int64, error) {
+	var n int64
+	err := db.Model(&models.Booking{}).
+		Where("subscription_id = ? AND service_id = ? AND status <> ? AND created_at >= ?", subscriptionID, serviceID, "cancelled", since).
+		Count(&n).Error
+	return n, err
+}
+
+// useMembershipVisit проверяет под блокировкой абонемента, что визит b ещё
+// укладывается в лимит услуги за текущий период.
+func useMembershipVisit(tx *gorm.DB, b *models.Booking) error {
+	var s models.Subscription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, *b.SubscriptionID).Error; err != nil {
+		return err
+	}
+	if s.CurrentPeriodStart == nil || (s.Status != models.SubscriptionActive && s.Status != models.SubscriptionPastDue) {
+		return ErrMembershipLimit
+	}
+	var benefit models.MembershipBenefit
+	err := tx.Where("plan_id = ? AND service_id = ?", s.PlanID, b.ServiceID).First(&benefit).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMembershipLimit
+	}
+	if err != nil || benefit.PerPeriod == 0 {
+		return err
+	}
+	n, err := countMembershipVisits(tx, s.ID, b.ServiceID, *s.CurrentPeriodStart)
+	if err != nil {
+		return err
+	}
+	if n >= int64(benefit.PerPeriod) {
+		return ErrMembershipLimit
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestCreateMembershipPaymentReturnsPending() {
+	repo := NewPostgresRepository(s.db)
+	sub := uint(3)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "subscriptions" WHERE "subscriptions"."id" = $1 AND "subscriptions"."deleted_at" IS NULL ORDER BY "subscriptions"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs(uint(3), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "status"}).AddRow(3, 4, models.SubscriptionPastDue))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "payments" WHERE (subscription_id = $1 AND status = $2) AND "payments"."deleted_at" IS NULL ORDER BY id`)).
+		WithArgs(uint(3), "pending", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "status"}).AddRow(11, 3, "pending"))
+	s.mock.ExpectCommit()
+
+	p, created, err := repo.CreateMembershipPayment(&models.Payment{SubscriptionID: &sub})
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), created)
+	assert.Equal(s.T(), uint(11), p.ID)
+}
+
+func (s *RepositorySuite) TestUseMembershipVisitLimit() {
+	repo := NewPostgresRepository(s.db)
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	sub := uint(3)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "subscriptions" WHERE "subscriptions"."id" = $1`)).
+		WithArgs(uint(3), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "plan_id", "status", "current_period_start"}).AddRow(3, 2, models.SubscriptionActive, start))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "membership_benefits" WHERE plan_id = $1 AND service_id = $2`)).
+		WithArgs(uint(2), uint(6), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "plan_id", "service_id", "per_period"}).AddRow(1, 2, 6, 2))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "bookings" WHERE (subscription_id = $1 AND service_id = $2 AND status <> $3 AND created_at >= $4) AND "bookings"."deleted_at" IS NULL`)).
+		WithArgs(uint(3), uint(6), "cancelled", start).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	s.mock.ExpectRollback()
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		return useMembershipVisit(tx, &models.Booking{ServiceID: 6, SubscriptionID: &sub})
+	})
+	assert.ErrorIs(s.T(), err, ErrMembershipLimit)
+}
+
+func (s *RepositorySuite) TestGetDueSubscriptions() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Date(2026, 4, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "subscriptions" WHERE ((status = $1 AND current_period_end <= $2) OR (status IN ($3,$4) AND grace_until <= $5)) AND "subscriptions"."deleted_at" IS NULL ORDER BY id`)).
+		WithArgs(models.SubscriptionActive, at, models.SubscriptionPending, models.SubscriptionPastDue, at).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "plan_id", "status"}).AddRow(3, 2, models.SubscriptionActive))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "membership_plans" WHERE "membership_plans"."id" = $1 AND "membership_plans"."deleted_at" IS NULL`)).
+		WithArgs(uint(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Укладки"))
+
+	list, err := repo.GetDueSubscriptions(at)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), list, 1)
+	assert.Equal(s.T(), "Укладки", list[0].Plan.Name)
+}
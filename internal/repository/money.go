@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"beauty-salon/internal/money"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// legacyReceiptMoney переносит суммы чеков, которые хранились в тиынах
+// с одной валютой на весь чек.
+var legacyReceiptMoney = []string{
+	`UPDATE receipt_items SET unit_price_amount = unit_price, unit_price_currency = r.currency, currency = r.currency
+		FROM receipts r WHERE r.id = receipt_items.receipt_id AND unit_price_amount IS NULL`,
+	`UPDATE receipt_tenders SET currency = r.currency
+		FROM receipts r WHERE r.id = receipt_tenders.receipt_id AND receipt_tenders.currency IS NULL`,
+	`UPDATE receipts SET subtotal_amount = subtotal, subtotal_currency = currency,
+		discount_amount = discount, discount_currency = currency, tips_amount = tips, tips_currency = currency,
+		total_amount = total, total_currency = currency, change_amount = change, change_currency = currency
+		WHERE total_amount IS NULL`,
+	"ALTER TABLE receipt_items DROP COLUMN unit_price",
+	"ALTER TABLE receipts DROP COLUMN subtotal, DROP COLUMN discount, DROP COLUMN tips, DROP COLUMN total, DROP COLUMN change, DROP COLUMN currency",
+}
+
+// MigrateMoney переносит суммы из прежних колонок в колонки money.Money
+// (<поле>_amount и <поле>_currency). Вызывается после AutoMigrate, повторный
+// запуск ничего не меняет. currency — валюта старых сумм: кода валюты у них не было.
+func MigrateMoney(db *gorm.DB, currency string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Цены услуг хранились как float64 в основных единицах.
+		legacy, err := hasColumn(tx, "services", "price")
+		if err != nil {
+			return err
+		}
+		if legacy {
+			scale := int64(math.Pow10(money.Exponent(currency)))
+			err := tx.Exec("UPDATE services SET price_amount = ROUND(price * ?), price_currency = ? WHERE price_amount IS NULL", scale, currency).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec("ALTER TABLE services DROP COLUMN price").Error; err != nil {
+				return err
+			}
+		}
+
+		if legacy, err = hasColumn(tx, "receipts", "currency"); err != nil {
+			return err
+		}
+		if legacy {
+			for _, q := range legacyReceiptMoney {
+				if err := tx.Exec(q).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// Остальные суммы и раньше были в тиынах — им нужна только валюта.
+		if err := tx.Exec("UPDATE payments SET refunded_currency = currency WHERE refunded_currency IS NULL").Error; err != nil {
+			return err
+		}
+		err = tx.Exec(`UPDATE payment_refunds SET currency = p.currency
+			FROM payments p WHERE p.id = payment_refunds.payment_id AND payment_refunds.currency IS NULL`).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("UPDATE bookings SET deposit_currency = ? WHERE deposit_currency IS NULL", currency).Error; err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE deposit_rules SET currency = ? WHERE currency IS NULL", currency).Error
+	})
+}
+
+func hasColumn(tx *gorm.DB, table, column string) (bool, error) {
+	var n int64
+	err := tx.Raw("SELECT count(*) FROM information_schema.columns WHERE table_schema = CURRENT_SCHEMA() AND table_name = ? AND column_name = ?",
+		table, column).Scan(&n).Error
+	return n > 0, err
+}
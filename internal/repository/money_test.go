@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) expectColumn(table, column string, exists bool) {
+	n := 0
+	if exists {
+		n = 1
+	}
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM information_schema.columns`)).
+		WithArgs(table, column).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(n))
+}
+
+func (s *RepositorySuite) TestMigrateMoney() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.expectColumn("services", "price", true)
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE services SET price_amount = ROUND(price * $1), price_currency = $2`)).
+		WithArgs(int64(100), "KZT").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE services DROP COLUMN price`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.expectColumn("receipts", "currency", true)
+	for _, q := range legacyReceiptMoney {
+		s.mock.ExpectExec(regexp.QuoteMeta(q)).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE payments SET refunded_currency = currency`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE payment_refunds SET currency = p.currency`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE bookings SET deposit_currency = $1`)).
+		WithArgs("KZT").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE deposit_rules SET currency = $1`)).
+		WithArgs("KZT").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), MigrateMoney(repo.db, "KZT"))
+}
+
+func (s *RepositorySuite) TestMigrateMoneyAlreadyDone() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.expectColumn("services", "price", false)
+	s.expectColumn("receipts", "currency", false)
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE payments`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE payment_refunds`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE bookings`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE deposit_rules`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), MigrateMoney(repo.db, "KZT"))
+}
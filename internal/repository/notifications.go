@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	GetUserByID(id uint) (*models.User, error)
+	GetBookingByID(id string) (*models.Booking, error)
+	GetUpcomingBookings(from, to string) ([]models.Booking, error)
+
+	GetNotificationPreference(userID uint) (*models.NotificationPreference, error)
+	SaveNotificationPreference(p *models.NotificationPreference) error
+
+	CreateNotification(n *models.Notification) error
+	GetNotificationsByUser(userID uint) ([]models.Notification, error)
+	ClaimDueNotification(now time.Time, lease time.Duration) (*models.Notification, error)
+	SaveNotification(n *models.Notification) error
+}
+
+// GetUpcomingBookings возвращает записи с датой в интервале (from, to], о которых
+// стоит напомнить: без отменённых и без неоплаченных — те ещё могут сгореть.
+func (r *PostgresRepository) GetUpcomingBookings(from, to string) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.Preload("User").Preload("Service").Preload("Staff").
+		Where("status NOT IN ? AND date > ? AND date <= ?", []string{"cancelled", "awaiting_payment"}, from, to).
+		Order("date").Find(&bookings).Error
+	return bookings, err
+}
+
+func (r *PostgresRepository) GetNotificationPreference(userID uint) (*models.NotificationPreference, error) {
+	var p models.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).First(&p).Error
+	return &p, err
+}
+
+// SaveNotificationPreference сохраняет настройки и пишет в журнал согласий
+// каждый включённый или отключённый канал.
+func (r *PostgresRepository) SaveNotificationPreference(p *models.NotificationPreference) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var prev models.NotificationPreference
+		if err := tx.Where("user_id = ?", p.UserID).Limit(1).Find(&prev).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"disabled_channels", "disabled_events", "updated_at"}),
+		}).Create(p).Error; err != nil {
+			return err
+		}
+		for _, c := range channelConsents(p.UserID, prev.DisabledChannels, p.DisabledChannels) {
+			if err := addConsent(tx, c.UserID, c.Kind, c.Granted, "notification_preferences"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateNotification молча пропускает уведомление с уже существующим DedupKey.
+func (r *PostgresRepository) CreateNotification(n *models.Notification) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(n).Error
+}
+
+func (r *PostgresRepository) GetNotificationsByUser(userID uint) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Where("user_id = ?", userID).Order("id desc").Find(&notifications).Error
+	return notifications, err
+}
+
+// ClaimDueNotification берёт одно уведомление, которое пора отправить, и сдвигает
+// его next_attempt_at на lease: если обработчик упадёт, попытка повторится позже.
+func (r *PostgresRepository) ClaimDueNotification(now time.Time, lease time.Duration) (*models.Notification, error) {
+	var n models.Notification
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).Order("next_attempt_at").First(&n).Error; err != nil {
+			return err
+		}
+		n.NextAttemptAt = now.Add(lease)
+		return tx.Model(&n).Update("next_attempt_at", n.NextAttemptAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *PostgresRepository) SaveNotification(n *models.Notification) error {
+	return r.db.Save(n).Error
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) TestGetUpcomingBookings() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE (status NOT IN ($1,$2) AND date > $3 AND date <= $4) AND "bookings"."deleted_at" IS NULL ORDER BY date`)).
+		WithArgs("cancelled", "awaiting_payment", "2025-05-01 10:00", "2025-05-02 10:00").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	res, err := repo.GetUpcomingBookings("2025-05-01 10:00", "2025-05-02 10:00")
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), res)
+}
+
+func (s *RepositorySuite) TestCreateNotificationSkipsDuplicates() {
+	repo := NewPostgresRepository(s.db)
+	key := "reminder:1"
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "notifications"`) + `.*` + regexp.QuoteMeta(`ON CONFLICT DO NOTHING`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectCommit()
+
+	err := repo.CreateNotification(&models.Notification{UserID: 1, Event: "booking_reminder", DedupKey: &key})
+	assert.NoError(s.T(), err)
+}
+
+func (s *RepositorySuite) TestSaveNotificationPreference() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "notification_preferences" WHERE user_id = $1 AND "notification_preferences"."deleted_at" IS NULL LIMIT $2`)).
+		WithArgs(uint(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "disabled_channels"}).AddRow(1, 1, "email"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "notification_preferences"`) + `.*` +
+		regexp.QuoteMeta(`ON CONFLICT ("user_id") DO UPDATE SET "disabled_channels"="excluded"."disabled_channels"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	// email снова включён, sms отключён — две записи в журнале согласий.
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "consents"`)).
+		WithArgs(uint(1), "notifications:email", true, "notification_preferences", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "consents"`)).
+		WithArgs(uint(1), "notifications:sms", false, "notification_preferences", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.SaveNotificationPreference(&models.NotificationPreference{UserID: 1, DisabledChannels: "sms"}))
+}
+
+func (s *RepositorySuite) TestClaimDueNotification() {
+	repo := NewPostgresRepository(s.db)
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "notifications" WHERE (status = $1 AND next_attempt_at <= $2) AND "notifications"."deleted_at" IS NULL ORDER BY next_attempt_at,"notifications"."id" LIMIT $3 FOR UPDATE SKIP LOCKED`)).
+		WithArgs("pending", now, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "channel"}).AddRow(4, "sms"))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "notifications" SET "next_attempt_at"=$1`)).
+		WithArgs(now.Add(5*time.Minute), sqlmock.AnyArg(), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	n, err := repo.ClaimDueNotification(now, 5*time.Minute)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "sms", n.Channel)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	ClaimOutboxEvent(now time.Time, lease time.Duration) (*models.OutboxEvent, error)
+	SaveOutboxEvent(e *models.OutboxEvent) error
+}
+
+// addEvent пишет событие в outbox внутри транзакции tx.
+func addEvent(tx *gorm.DB, eventType string, aggregateID uint, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return tx.Create(&models.OutboxEvent{
+		EventID:       events.NewID(),
+		Type:          eventType,
+		AggregateID:   aggregateID,
+		Payload:       string(data),
+		Status:        "pending",
+		NextAttemptAt: now,
+	}).Error
+}
+
+func bookingPayload(b *models.Booking) events.BookingPayload {
+	return events.BookingPayload{
+		BookingID: b.ID, UserID: b.UserID, ServiceID: b.ServiceID,
+		StaffID: b.StaffID, Date: b.Date, Status: b.Status,
+	}
+}
+
+// ClaimOutboxEvent берёт самое старое неопубликованное событие и сдвигает его
+// next_attempt_at на lease, чтобы другие реплики его не взяли.
+func (r *PostgresRepository) ClaimOutboxEvent(now time.Time, lease time.Duration) (*models.OutboxEvent, error) {
+	var e models.OutboxEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).Order("id").First(&e).Error; err != nil {
+			return err
+		}
+		e.NextAttemptAt = now.Add(lease)
+		return tx.Model(&e).Update("next_attempt_at", e.NextAttemptAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *PostgresRepository) SaveOutboxEvent(e *models.OutboxEvent) error { return r.db.Save(e).Error }
+
+// lockBooking читает запись под блокировкой; nil, если её нет.
+func lockBooking(tx *gorm.DB, id interface{}) (*models.Booking, error) {
+	var b models.Booking
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&b, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &b, err
+}
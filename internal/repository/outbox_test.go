@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestUpdateBookingReschedule() {
+	b := &models.Booking{Model: gorm.Model{ID: 1}}
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1`)).
+		WithArgs(uint(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "service_id", "staff_id", "status", "date"}).AddRow(1, 5, 2, 3, "confirmed", "2025-05-02 10:00"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "staffs" WHERE "staffs"."id" = $1`)).
+		WithArgs(uint(3), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "services" WHERE "services"."id" = $1`)).
+		WithArgs(uint(2), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "duration_min"}).AddRow(2, 60))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE (staff_id = $1 AND date LIKE $2 AND status <> $3) AND id <> $4`)).
+		WithArgs(uint(3), "2025-05-03%", "cancelled", uint(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "bookings" SET "date"=$1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "booking.rescheduled", uint(1),
+			`{"booking_id":1,"user_id":5,"staff_id":3,"from":"2025-05-02 10:00","to":"2025-05-03 12:00"}`, "pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.UpdateBooking(b, map[string]interface{}{"date": "2025-05-03 12:00"})
+	assert.NoError(s.T(), err)
+}
+
+func (s *RepositorySuite) TestUpdateBookingRescheduleSlotTaken() {
+	b := &models.Booking{Model: gorm.Model{ID: 1}}
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1`)).
+		WithArgs(uint(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "service_id", "staff_id", "status", "date"}).AddRow(1, 5, 2, 3, "confirmed", "2025-05-02 10:00"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "staffs" WHERE "staffs"."id" = $1`)).
+		WithArgs(uint(4), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "services" WHERE "services"."id" = $1`)).
+		WithArgs(uint(2), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "duration_min"}).AddRow(2, 60))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE (staff_id = $1 AND date LIKE $2 AND status <> $3) AND id <> $4`)).
+		WithArgs(uint(4), "2025-05-02%", "cancelled", uint(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_id", "staff_id", "date"}).AddRow(8, 2, 4, "2025-05-02 10:30"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "services" WHERE "services"."id" = $1`)).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "duration_min"}).AddRow(2, 60))
+	s.mock.ExpectRollback()
+
+	// У нового мастера в 10:30 уже есть запись — перевод к нему не проходит.
+	err := s.repo.UpdateBooking(b, map[string]interface{}{"staff_id": uint(4)})
+	assert.ErrorIs(s.T(), err, ErrSlotUnavailable)
+}
+
+func (s *RepositorySuite) TestUpdateBookingWithoutChangesWritesNoEvent() {
+	b := &models.Booking{Model: gorm.Model{ID: 1}}
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "confirmed"))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "bookings" SET "status"=$1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.UpdateBooking(b, map[string]interface{}{"status": "confirmed"})
+	assert.NoError(s.T(), err)
+}
+
+func (s *RepositorySuite) TestClaimOutboxEvent() {
+	repo := NewPostgresRepository(s.db)
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "outbox_events" WHERE (status = $1 AND next_attempt_at <= $2) AND "outbox_events"."deleted_at" IS NULL ORDER BY id,"outbox_events"."id" LIMIT $3 FOR UPDATE SKIP LOCKED`)).
+		WithArgs("pending", now, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type"}).AddRow(3, "booking.created"))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "next_attempt_at"=$1`)).
+		WithArgs(now.Add(time.Minute), sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	e, err := repo.ClaimOutboxEvent(now, time.Minute)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "booking.created", e.Type)
+}
+
+func (s *RepositorySuite) TestUpdateBookingCompleted() {
+	b := &models.Booking{Model: gorm.Model{ID: 1}}
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "service_id", "status", "date"}).AddRow(1, 5, 2, "confirmed", "2025-05-02 10:00"))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "bookings" SET "status"=$1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "booking.status_changed", uint(1),
+			sqlmock.AnyArg(), "pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "booking.completed", uint(1),
+			`{"booking_id":1,"user_id":5,"service_id":2,"staff_id":0,"date":"2025-05-02 10:00","status":"completed"}`,
+			"pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), s.repo.UpdateBooking(b, map[string]interface{}{"status": "completed"}))
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrPackageExhausted = errors.New("package has no sessions left")
+
+type PackageRepository interface {
+	GetServiceByID(id string) (*models.Service, error)
+
+	CreatePackage(p *models.Package) error
+	GetPackages() ([]models.Package, error)
+	GetPackageByID(id uint) (*models.Package, error)
+	DeletePackage(id string) error
+	GetClientPackages(userID uint) ([]models.ClientPackage, error)
+	FindPackageSession(userID, serviceID uint, at time.Time) (*models.ClientPackageSession, error)
+}
+
+func (r *PostgresRepository) CreatePackage(p *models.Package) error {
+	return r.db.Create(p).Error
+}
+
+func (r *PostgresRepository) GetPackages() ([]models.Package, error) {
+	var list []models.Package
+	err := r.db.Preload("Items").Order("id").Find(&list).Error
+	return list, err
+}
+
+func (r *PostgresRepository) GetPackageByID(id uint) (*models.Package, error) {
+	var p models.Package
+	err := r.db.Preload("Items.Service").First(&p, id).Error
+	return &p, err
+}
+
+// DeletePackage снимает пакет с продажи; купленные пакеты остаются в силе.
+func (r *PostgresRepository) DeletePackage(id string) error {
+	res := r.db.Delete(&models.Package{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetClientPackages(userID uint) ([]models.ClientPackage, error) {
+	var list []models.ClientPackage
+	err := r.db.Preload("Sessions", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("user_id = ?", userID).Order("id desc").Find(&list).Error
+	return list, err
+}
+
+// FindPackageSession ищет у клиента неизрасходованный сеанс услуги в пакете,
+// действующем в момент визита at; первым — пакет, который сгорит раньше.
+func (r *PostgresRepository) FindPackageSession(userID, serviceID uint, at time.Time) (*models.ClientPackageSession, error) {
+	var s models.ClientPackageSession
+	err := r.db.Joins("JOIN client_packages ON client_packages.id = client_package_sessions.client_package_id AND client_packages.deleted_at IS NULL").
+		Where("client_packages.user_id = ? AND client_package_sessions.service_id = ? AND client_package_sessions.remaining > 0", userID, serviceID).
+		Where("client_packages.expires_at IS NULL OR client_packages.expires_at > ?", at).
+		Order("client_packages.expires_at NULLS LAST, client_packages.id").
+		First(&s).Error
+	return &s, err
+}
+
+// usePackageSession списывает (delta = -1) или возвращает (delta = 1) сеанс пакета.
+func usePackageSession(tx *gorm.DB, id uint, delta int) error {
+	q := tx.Model(&models.ClientPackageSession{}).Where("id = ?", id)
+	if delta < 0 {
+		q = q.Where("remaining >= ?", -delta)
+	} else {
+		q = q.Where("remaining + ? <= total", delta)
+	}
+	res := q.Update("remaining", gorm.Expr("remaining + ?", delta))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 && delta < 0 {
+		return ErrPackageExhausted
+	}
+	return nil
+}
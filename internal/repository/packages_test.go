@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestFindPackageSession() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT "client_package_sessions"."id","client_package_sessions"."client_package_id","client_package_sessions"."service_id","client_package_sessions"."total","client_package_sessions"."remaining" FROM "client_package_sessions" JOIN client_packages ON client_packages.id = client_package_sessions.client_package_id AND client_packages.deleted_at IS NULL WHERE (client_packages.user_id = $1 AND client_package_sessions.service_id = $2 AND client_package_sessions.remaining > 0) AND (client_packages.expires_at IS NULL OR client_packages.expires_at > $3) ORDER BY client_packages.expires_at NULLS LAST, client_packages.id`)).
+		WithArgs(uint(4), uint(2), at, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "client_package_id", "service_id", "total", "remaining"}).AddRow(5, 3, 2, 5, 2))
+
+	session, err := repo.FindPackageSession(4, 2, at)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), uint(5), session.ID)
+	assert.Equal(s.T(), 2, session.Remaining)
+}
+
+func (s *RepositorySuite) TestUsePackageSessionExhausted() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "client_package_sessions" SET "remaining"=remaining + $1 WHERE id = $2 AND remaining >= $3`)).
+		WithArgs(-1, uint(5), 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error { return usePackageSession(tx, 5, -1) })
+	assert.ErrorIs(s.T(), err, ErrPackageExhausted)
+}
+
+func (s *RepositorySuite) TestUsePackageSessionReturn() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "client_package_sessions" SET "remaining"=remaining + $1 WHERE id = $2 AND remaining + $3 <= total`)).
+		WithArgs(1, uint(5), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error { return usePackageSession(tx, 5, 1) })
+	assert.NoError(s.T(), err)
+}
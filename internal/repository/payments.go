@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrRefundExceedsPayment = errors.New("refund exceeds paid amount")
+
+type PaymentRepository interface {
+	GetBookingByID(id string) (*models.Booking, error)
+
+	CreatePayment(p *models.Payment) error
+	SavePayment(p *models.Payment) error
+	GetPaymentByID(id string) (*models.Payment, error)
+	GetPaymentByKey(key string) (*models.Payment, error)
+	GetPaymentByProviderID(provider, providerID string) (*models.Payment, error)
+	GetPaymentsByBooking(bookingID uint) ([]models.Payment, error)
+	GetPaymentsByUser(userID uint) ([]models.Payment, error)
+	SetPaymentStatus(id uint, status string, at time.Time) (*models.Payment, bool, error)
+	RetainPayment(id uint, at time.Time) error
+
+	ReserveRefund(r *models.PaymentRefund) (*models.Payment, error)
+	CompleteRefund(r *models.PaymentRefund) error
+	FailRefund(r *models.PaymentRefund) error
+}
+
+func (r *PostgresRepository) CreatePayment(p *models.Payment) error { return r.db.Create(p).Error }
+func (r *PostgresRepository) SavePayment(p *models.Payment) error   { return r.db.Save(p).Error }
+
+func (r *PostgresRepository) GetPaymentByID(id string) (*models.Payment, error) {
+	var p models.Payment
+	err := r.db.Preload("Refunds").First(&p, "id = ?", id).Error
+	return &p, err
+}
+
+func (r *PostgresRepository) GetPaymentByKey(key string) (*models.Payment, error) {
+	var p models.Payment
+	err := r.db.Where("idempotency_key = ?", key).First(&p).Error
+	return &p, err
+}
+
+func (r *PostgresRepository) GetPaymentByProviderID(provider, providerID string) (*models.Payment, error) {
+	var p models.Payment
+	err := r.db.Where("provider = ? AND provider_payment_id = ?", provider, providerID).First(&p).Error
+	return &p, err
+}
+
+func (r *PostgresRepository) GetPaymentsByBooking(bookingID uint) ([]models.Payment, error) {
+	var list []models.Payment
+	err := r.db.Preload("Refunds").Where("booking_id = ?", bookingID).Order("id").Find(&list).Error
+	return list, err
+}
+
+func (r *PostgresRepository) GetPaymentsByUser(userID uint) ([]models.Payment, error) {
+	var list []models.Payment
+	err := r.db.Preload("Refunds").Where("user_id = ?", userID).Order("id DESC").Find(&list).Error
+	return list, err
+}
+
+// SetPaymentStatus переводит ожидающий платёж в итоговый статус. Повторное
+// уведомление провайдера ничего не меняет: changed будет false.
+func (r *PostgresRepository) SetPaymentStatus(id uint, status string, at time.Time) (*models.Payment, bool, error) {
+	var p *models.Payment
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if p, err = lockPayment(tx, id); err != nil {
+			return err
+		}
+		if p.Status != "pending" {
+			return nil
+		}
+		p.Status = status
+		updates := map[string]interface{}{"status": status}
+		if status == "succeeded" {
+			p.PaidAt = &at
+			updates["paid_at"] = at
+		}
+		if err := tx.Model(p).Updates(updates).Error; err != nil {
+			return err
+		}
+		changed = true
+		if status != "succeeded" {
+			return nil
+		}
+		return addEvent(tx, events.PaymentSucceeded, p.ID, paymentPayload(p))
+	})
+	return p, changed, err
+}
+
+// ReserveRefund резервирует сумму возврата под блокировкой платежа, чтобы
+// параллельные возвраты не превысили оплаченное. Нулевая сумма — весь остаток.
+func (r *PostgresRepository) ReserveRefund(ref *models.PaymentRefund) (*models.Payment, error) {
+	var p *models.Payment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if p, err = lockPayment(tx, ref.PaymentID); err != nil {
+			return err
+		}
+		left := p.Refundable()
+		if ref.Amount.IsZero() {
+			ref.Amount = left
+		}
+		if !ref.Amount.SameCurrency(left) || !ref.Amount.IsPositive() || ref.Amount.Cmp(left) > 0 {
+			return ErrRefundExceedsPayment
+		}
+		if err := adjustRefunded(tx, p, ref.Amount); err != nil {
+			return err
+		}
+		ref.Status = "pending"
+		return tx.Create(ref).Error
+	})
+	return p, err
+}
+
+// CompleteRefund отмечает возврат проведённым и пишет событие.
+func (r *PostgresRepository) CompleteRefund(ref *models.PaymentRefund) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		p, err := lockPayment(tx, ref.PaymentID)
+		if err != nil {
+			return err
+		}
+		ref.Status = "succeeded"
+		if err := tx.Model(ref).Updates(map[string]interface{}{"status": ref.Status, "provider_refund_id": ref.ProviderRefundID}).Error; err != nil {
+			return err
+		}
+		return addEvent(tx, events.PaymentRefunded, p.ID, events.PaymentRefundedPayload{
+			PaymentPayload: paymentPayload(p), RefundID: ref.ID, RefundAmount: ref.Amount,
+		})
+	})
+}
+
+// FailRefund снимает резерв, если провайдер отказал в возврате.
+func (r *PostgresRepository) FailRefund(ref *models.PaymentRefund) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		p, err := lockPayment(tx, ref.PaymentID)
+		if err != nil {
+			return err
+		}
+		if err := adjustRefunded(tx, p, ref.Amount.Neg()); err != nil {
+			return err
+		}
+		ref.Status = "failed"
+		return tx.Model(ref).Update("status", ref.Status).Error
+	})
+}
+
+func adjustRefunded(tx *gorm.DB, p *models.Payment, delta money.Money) error {
+	p.RefundedAmount = p.RefundedAmount.Add(delta)
+	switch {
+	case p.RefundedAmount.Cmp(p.Amount) >= 0:
+		p.Status = "refunded"
+	case p.RefundedAmount.IsPositive():
+		p.Status = "partially_refunded"
+	default:
+		p.Status = "succeeded"
+	}
+	return tx.Model(p).Updates(map[string]interface{}{
+		"refunded_amount": p.RefundedAmount.Minor, "refunded_currency": p.RefundedAmount.Currency, "status": p.Status,
+	}).Error
+}
+
+func lockPayment(tx *gorm.DB, id uint) (*models.Payment, error) {
+	var p models.Payment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, "id = ?", id).Error
+	return &p, err
+}
+
+func paymentPayload(p *models.Payment) events.PaymentPayload {
+	return events.PaymentPayload{
+		PaymentID: p.ID, BookingID: p.BookingID, UserID: p.UserID, Amount: p.Amount,
+		RefundedAmount: p.RefundedAmount, Status: p.Status, SubscriptionID: p.SubscriptionID,
+	}
+}
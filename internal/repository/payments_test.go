@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var paymentColumns = []string{"id", "booking_id", "user_id", "amount", "refunded_amount", "refunded_currency", "currency", "status"}
+
+func (s *RepositorySuite) expectPaymentLock(status string, refunded int64) {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "payments" WHERE id = $1 AND "payments"."deleted_at" IS NULL ORDER BY "payments"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs(uint(9), 1).
+		WillReturnRows(sqlmock.NewRows(paymentColumns).AddRow(9, 1, 5, 500000, refunded, "KZT", "KZT", status))
+}
+
+func (s *RepositorySuite) TestSetPaymentStatus() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.expectPaymentLock("pending", 0)
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "payments" SET "paid_at"=$1,"status"=$2,"updated_at"=$3 WHERE "payments"."deleted_at" IS NULL AND "id" = $4`)).
+		WithArgs(at, "succeeded", sqlmock.AnyArg(), uint(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "payment.succeeded", uint(9),
+			`{"payment_id":9,"booking_id":1,"user_id":5,"amount":{"amount":"5000.00","currency":"KZT"},"refunded_amount":{"amount":"0.00","currency":"KZT"},"status":"succeeded"}`,
+			"pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	p, changed, err := repo.SetPaymentStatus(9, "succeeded", at)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), changed)
+	assert.Equal(s.T(), "succeeded", p.Status)
+}
+
+func (s *RepositorySuite) TestSetPaymentStatusRepeated() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.expectPaymentLock("succeeded", 0)
+	s.mock.ExpectCommit()
+
+	_, changed, err := repo.SetPaymentStatus(9, "succeeded", time.Now())
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), changed)
+}
+
+func (s *RepositorySuite) TestReserveRefund() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.expectPaymentLock("succeeded", 0)
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "payments" SET "refunded_amount"=$1,"refunded_currency"=$2,"status"=$3,"updated_at"=$4 WHERE "payments"."deleted_at" IS NULL AND "id" = $5`)).
+		WithArgs(int64(200000), "KZT", "partially_refunded", sqlmock.AnyArg(), uint(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "payment_refunds"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectCommit()
+
+	ref := &models.PaymentRefund{PaymentID: 9, Amount: money.New(200000, "KZT")}
+	p, err := repo.ReserveRefund(ref)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "partially_refunded", p.Status)
+	assert.Equal(s.T(), "pending", ref.Status)
+	assert.Equal(s.T(), uint(3), ref.ID)
+}
+
+func (s *RepositorySuite) TestReserveRefundFullRemainder() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.expectPaymentLock("partially_refunded", 200000)
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "payments" SET "refunded_amount"=$1,"refunded_currency"=$2,"status"=$3`)).
+		WithArgs(int64(500000), "KZT", "refunded", sqlmock.AnyArg(), uint(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "payment_refunds"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	s.mock.ExpectCommit()
+
+	ref := &models.PaymentRefund{PaymentID: 9}
+	_, err := repo.ReserveRefund(ref)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), money.New(300000, "KZT"), ref.Amount)
+}
+
+func (s *RepositorySuite) TestReserveRefundTooLarge() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.expectPaymentLock("partially_refunded", 200000)
+	s.mock.ExpectRollback()
+
+	_, err := repo.ReserveRefund(&models.PaymentRefund{PaymentID: 9, Amount: money.New(300001, "KZT")})
+	assert.ErrorIs(s.T(), err, ErrRefundExceedsPayment)
+}
+
+func (s *RepositorySuite) TestFailRefund() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.expectPaymentLock("partially_refunded", 200000)
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "payments" SET "refunded_amount"=$1,"refunded_currency"=$2,"status"=$3`)).
+		WithArgs(int64(0), "KZT", "succeeded", sqlmock.AnyArg(), uint(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "payment_refunds" SET "status"=$1`)).
+		WithArgs("failed", sqlmock.AnyArg(), uint(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	ref := &models.PaymentRefund{PaymentID: 9, Amount: money.New(200000, "KZT")}
+	ref.ID = 3
+	assert.NoError(s.T(), repo.FailRefund(ref))
+	assert.Equal(s.T(), "failed", ref.Status)
+}
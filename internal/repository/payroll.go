@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PayrollRepository interface {
+	GetAllStaff() ([]models.Staff, error)
+	GetStaffByID(id string) (*models.Staff, error)
+
+	SaveCommissionScheme(s *models.CommissionScheme) error
+	GetCommissionSchemes() ([]models.CommissionScheme, error)
+	DeleteCommissionScheme(staffID string) error
+
+	GetReceiptsBetween(from, to time.Time) ([]models.Receipt, error)
+}
+
+// SaveCommissionScheme заменяет схему мастера вместе со ступенями.
+func (r *PostgresRepository) SaveCommissionScheme(s *models.CommissionScheme) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Ступени прежней схемы удаляет ON DELETE CASCADE.
+		if err := tx.Delete(&models.CommissionScheme{}, "staff_id = ?", s.StaffID).Error; err != nil {
+			return err
+		}
+		return tx.Create(s).Error
+	})
+}
+
+func (r *PostgresRepository) GetCommissionSchemes() ([]models.CommissionScheme, error) {
+	var list []models.CommissionScheme
+	err := r.db.Preload("Tiers", func(db *gorm.DB) *gorm.DB { return db.Order("from_amount") }).
+		Order("staff_id").Find(&list).Error
+	return list, err
+}
+
+func (r *PostgresRepository) DeleteCommissionScheme(staffID string) error {
+	res := r.db.Delete(&models.CommissionScheme{}, "staff_id = ?", staffID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetReceiptsBetween возвращает чеки, пробитые в [from, to), со строками.
+func (r *PostgresRepository) GetReceiptsBetween(from, to time.Time) ([]models.Receipt, error) {
+	var list []models.Receipt
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("created_at >= ? AND created_at < ?", from, to).Order("id").Find(&list).Error
+	return list, err
+}
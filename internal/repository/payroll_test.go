@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestSaveCommissionScheme() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "commission_schemes" WHERE staff_id = $1`)).
+		WithArgs(uint(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "commission_schemes"`)).
+		WithArgs(uint(2), "tiered", 0, int64(0), "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "commission_tiers"`)).
+		WithArgs(uint(4), int64(0), "KZT", 30, uint(4), int64(50000000), "KZT", 40).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	s.mock.ExpectCommit()
+
+	scheme := &models.CommissionScheme{StaffID: 2, Kind: models.CommissionTiered, Tiers: []models.CommissionTier{
+		{From: money.Zero("KZT"), Percent: 30},
+		{From: money.New(50000000, "KZT"), Percent: 40},
+	}}
+	assert.NoError(s.T(), repo.SaveCommissionScheme(scheme))
+	assert.Equal(s.T(), uint(4), scheme.ID)
+}
+
+func (s *RepositorySuite) TestDeleteCommissionSchemeNotFound() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "commission_schemes" WHERE staff_id = $1`)).
+		WithArgs("9").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	assert.ErrorIs(s.T(), repo.DeleteCommissionScheme("9"), gorm.ErrRecordNotFound)
+}
+
+func (s *RepositorySuite) TestGetReceiptsBetween() {
+	repo := NewPostgresRepository(s.db)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "receipts" WHERE (created_at >= $1 AND created_at < $2) AND "receipts"."deleted_at" IS NULL ORDER BY id`)).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "staff_id"}).AddRow(7, 2))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "receipt_items" WHERE "receipt_items"."receipt_id" = $1 AND "receipt_items"."deleted_at" IS NULL ORDER BY id`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "receipt_id", "kind", "staff_id"}).AddRow(1, 7, "service", 2))
+
+	list, err := repo.GetReceiptsBetween(from, to)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), list, 1)
+	assert.Equal(s.T(), uint(2), *list[0].Items[0].StaffID)
+}
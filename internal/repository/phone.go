@@ -0,0 +1,14 @@
+package repository
+
+import "beauty-salon/internal/models"
+
+type PhoneRepository interface {
+	GetUserByPhone(phone string) (*models.User, error)
+	CreateUser(u *models.User) error
+}
+
+func (r *PostgresRepository) GetUserByPhone(phone string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("phone = ?", phone).First(&user).Error
+	return &user, err
+}
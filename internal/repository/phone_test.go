@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) TestGetUserByPhone() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE phone = $1 AND "users"."deleted_at" IS NULL`)).
+		WithArgs("+77011234567", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone"}).AddRow(1, "+77011234567"))
+
+	res, err := repo.GetUserByPhone("+77011234567")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "+77011234567", *res.Phone)
+}
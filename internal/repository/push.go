@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type PushRepository interface {
+	ReplacePushDevice(d *models.PushDevice) error
+	DeletePushDevice(userID uint) error
+	GetPushDevice(userID uint) (*models.PushDevice, error)
+}
+
+// ReplacePushDevice регистрирует устройство пользователя вместо прежнего.
+func (r *PostgresRepository) ReplacePushDevice(d *models.PushDevice) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", d.UserID).Delete(&models.PushDevice{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(d).Error
+	})
+}
+
+func (r *PostgresRepository) DeletePushDevice(userID uint) error {
+	return r.db.Unscoped().Where("user_id = ?", userID).Delete(&models.PushDevice{}).Error
+}
+
+func (r *PostgresRepository) GetPushDevice(userID uint) (*models.PushDevice, error) {
+	var d models.PushDevice
+	err := r.db.Where("user_id = ?", userID).First(&d).Error
+	return &d, err
+}
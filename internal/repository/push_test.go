@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) TestReplacePushDevice() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "push_devices" WHERE user_id = $1`)).
+		WithArgs(uint(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "push_devices"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	s.mock.ExpectCommit()
+
+	d := &models.PushDevice{UserID: 4, Platform: "ios", Token: "apns-token"}
+	assert.NoError(s.T(), repo.ReplacePushDevice(d))
+	assert.Equal(s.T(), uint(2), d.ID)
+}
+
+func (s *RepositorySuite) TestGetPushDevice() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "push_devices" WHERE user_id = $1 AND "push_devices"."deleted_at" IS NULL`)).
+		WithArgs(uint(4), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token"}).AddRow(2, 4, "apns-token"))
+
+	d, err := repo.GetPushDevice(4)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "apns-token", d.Token)
+}
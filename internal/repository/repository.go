@@ -1,16 +1,27 @@
 package repository
 
 import (
+	"beauty-salon/internal/events"
 	"beauty-salon/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrSlotUnavailable = errors.New("slot is not available")
+
 type Repository interface {
 	// Users
 	CreateUser(u *models.User) error
 	GetUserByUsername(username string) (*models.User, error)
 	GetUserByID(id uint) (*models.User, error)
 	GetAllUsers() ([]models.User, error)
+	UpdateUser(u *models.User, updates map[string]interface{}) error
+	AnonymizeUser(id uint) error
 	DeleteUser(id string) error
 
 	// Services
@@ -29,10 +40,18 @@ type Repository interface {
 	CreateBooking(b *models.Booking) error
 	GetAllBookings() ([]models.Booking, error)
 	GetBookingByID(id string) (*models.Booking, error)
+	GetBookingsByUser(userID uint) ([]models.Booking, error)
+	GetBookingsOnDay(day string) ([]models.Booking, error)
 	UpdateBooking(b *models.Booking, updates map[string]interface{}) error
 	DeleteBooking(id string) error
 }
 
+// IsUniqueViolation сообщает, что запись нарушила уникальный индекс.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 type PostgresRepository struct {
 	db *gorm.DB
 }
@@ -42,7 +61,17 @@ func NewPostgresRepository(db *gorm.DB) *PostgresRepository {
 }
 
 // Users
-func (r *PostgresRepository) CreateUser(u *models.User) error { return r.db.Create(u).Error }
+func (r *PostgresRepository) CreateUser(u *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		if err := addConsent(tx, u.ID, models.ConsentPersonalData, true, "sign_up"); err != nil {
+			return err
+		}
+		return addEvent(tx, events.UserRegistered, u.ID, events.UserRegisteredPayload{UserID: u.ID, Username: u.Username})
+	})
+}
 func (r *PostgresRepository) GetUserByUsername(username string) (*models.User, error) {
 	var user models.User
 	err := r.db.Where("username = ?", username).First(&user).Error
@@ -58,6 +87,35 @@ func (r *PostgresRepository) GetAllUsers() ([]models.User, error) {
 	err := r.db.Find(&users).Error
 	return users, err
 }
+func (r *PostgresRepository) UpdateUser(u *models.User, updates map[string]interface{}) error {
+	return r.db.Model(u).Updates(updates).Error
+}
+
+// AnonymizeUser стирает персональные данные и мягко удаляет пользователя.
+// Записи остаются, чтобы история визитов сохранилась для бухгалтерии.
+func (r *PostgresRepository) AnonymizeUser(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"username":  fmt.Sprintf("deleted_user_%d", id),
+			"password":  "",
+			"full_name": "",
+			"phone":     nil,
+			"email":     nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+		// Привязки, карточка клиента, уведомления, ссылки на календарь, собранные
+		// выгрузки данных и push-устройства — персональные данные, удаляем насовсем.
+		for _, m := range []interface{}{&models.UserIdentity{}, &models.ClientProfile{}, &models.ClientNote{},
+			&models.Notification{}, &models.NotificationPreference{}, &models.CalendarFeed{}, &models.DataExport{}, &models.PushDevice{}} {
+			if err := tx.Unscoped().Where("user_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.User{}, id).Error
+	})
+}
 func (r *PostgresRepository) DeleteUser(id string) error {
 	return r.db.Delete(&models.User{}, "id = ?", id).Error
 }
@@ -95,7 +153,63 @@ func (r *PostgresRepository) DeleteStaff(id string) error {
 }
 
 // Bookings
-func (r *PostgresRepository) CreateBooking(b *models.Booking) error { return r.db.Create(b).Error }
+func (r *PostgresRepository) CreateBooking(b *models.Booking) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkStaffFree(tx, b); err != nil {
+			return err
+		}
+		if b.PackageSessionID != nil {
+			if err := usePackageSession(tx, *b.PackageSessionID, -1); err != nil {
+				return err
+			}
+		}
+		if b.SubscriptionID != nil {
+			if err := useMembershipVisit(tx, b); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(b).Error; err != nil {
+			return err
+		}
+		return addEvent(tx, events.BookingCreated, b.ID, bookingPayload(b))
+	})
+}
+
+// checkStaffFree проверяет, что мастер свободен на время записи. Строка мастера
+// блокируется до конца транзакции: параллельные записи к нему идут по очереди
+// и видят друг друга. Запись без мастера или без времени слот не занимает.
+func checkStaffFree(tx *gorm.DB, b *models.Booking) error {
+	const layout = "2006-01-02 15:04"
+	at, err := time.Parse(layout, b.Date)
+	if b.StaffID == 0 || err != nil {
+		return nil
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Staff{}, b.StaffID).Error; err != nil {
+		return err
+	}
+	var srv models.Service
+	if err := tx.First(&srv, b.ServiceID).Error; err != nil {
+		return err
+	}
+	q := tx.Preload("Service").Where("staff_id = ? AND date LIKE ? AND status <> ?", b.StaffID, b.Date[:10]+"%", "cancelled")
+	if b.ID != 0 {
+		// При переносе запись не должна мешать сама себе.
+		q = q.Where("id <> ?", b.ID)
+	}
+	var day []models.Booking
+	if err := q.Find(&day).Error; err != nil {
+		return err
+	}
+	end := at.Add(srv.Duration())
+	for _, o := range day {
+		from, err := time.Parse(layout, o.Date)
+		if err == nil && at.Before(from.Add(o.Service.Duration())) && from.Before(end) {
+			return ErrSlotUnavailable
+		}
+	}
+	return nil
+}
+
 func (r *PostgresRepository) GetAllBookings() ([]models.Booking, error) {
 	var bookings []models.Booking
 	err := r.db.Preload("User").Preload("Service").Preload("Staff").Find(&bookings).Error
@@ -106,9 +220,117 @@ func (r *PostgresRepository) GetBookingByID(id string) (*models.Booking, error)
 	err := r.db.Preload("User").Preload("Service").Preload("Staff").First(&booking, "id = ?", id).Error
 	return &booking, err
 }
+
+// GetBookingsOnDay возвращает действующие записи на день YYYY-MM-DD — по ним считается занятость мастеров.
+func (r *PostgresRepository) GetBookingsOnDay(day string) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.Preload("Service").Where("date LIKE ? AND status <> ?", day+"%", "cancelled").Order("date").Find(&bookings).Error
+	return bookings, err
+}
+
+// UpdateBooking сохраняет изменения и пишет события о смене статуса и переносе.
 func (r *PostgresRepository) UpdateBooking(b *models.Booking, updates map[string]interface{}) error {
-	return r.db.Model(b).Updates(updates).Error
+	return r.db.Transaction(func(tx *gorm.DB) error { return updateBooking(tx, b, updates) })
+}
+
+func updateBooking(tx *gorm.DB, b *models.Booking, updates map[string]interface{}) error {
+	current, err := lockBooking(tx, b.ID)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		if err := checkMovedBooking(tx, current, updates); err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(b).Updates(updates).Error; err != nil {
+		return err
+	}
+	if current == nil {
+		return nil
+	}
+	if status, ok := updates["status"].(string); ok && status != current.Status {
+		if err := packageSessionOnStatus(tx, current, status); err != nil {
+			return err
+		}
+		date := current.Date
+		if d, ok := updates["date"].(string); ok {
+			date = d
+		}
+		if err := addEvent(tx, events.BookingStatusChanged, current.ID, events.BookingStatusChangedPayload{
+			BookingID: current.ID, UserID: current.UserID, StaffID: current.StaffID, Date: date, From: current.Status, To: status,
+		}); err != nil {
+			return err
+		}
+		if status == "completed" {
+			completed := *current
+			completed.Status = status
+			if err := addEvent(tx, events.BookingCompleted, current.ID, bookingPayload(&completed)); err != nil {
+				return err
+			}
+		}
+	}
+	if date, ok := updates["date"].(string); ok && date != current.Date {
+		return addEvent(tx, events.BookingRescheduled, current.ID, events.BookingRescheduledPayload{
+			BookingID: current.ID, UserID: current.UserID, StaffID: current.StaffID, From: current.Date, To: date,
+		})
+	}
+	return nil
+}
+
+// checkMovedBooking заново проверяет занятость мастера, если запись переносят
+// на другое время или к другому мастеру.
+func checkMovedBooking(tx *gorm.DB, current *models.Booking, updates map[string]interface{}) error {
+	moved := *current
+	date, dateOK := updates["date"].(string)
+	staffID, staffOK := updates["staff_id"].(uint)
+	if dateOK {
+		moved.Date = date
+	}
+	if staffOK {
+		moved.StaffID = staffID
+	}
+	if status, ok := updates["status"].(string); ok {
+		moved.Status = status
+	}
+	if moved.Date == current.Date && moved.StaffID == current.StaffID || moved.Status == "cancelled" {
+		return nil
+	}
+	return checkStaffFree(tx, &moved)
 }
+
+// packageSessionOnStatus возвращает сеанс пакета при отмене записи и снова
+// списывает его, если отменённую запись восстановили.
+func packageSessionOnStatus(tx *gorm.DB, current *models.Booking, status string) error {
+	switch {
+	case current.PackageSessionID == nil:
+		return nil
+	case status == "cancelled":
+		return usePackageSession(tx, *current.PackageSessionID, 1)
+	case current.Status == "cancelled":
+		return usePackageSession(tx, *current.PackageSessionID, -1)
+	}
+	return nil
+}
+
+// DeleteBooking отменяет запись (мягкое удаление) и пишет событие о смене статуса.
 func (r *PostgresRepository) DeleteBooking(id string) error {
-	return r.db.Delete(&models.Booking{}, "id = ?", id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockBooking(tx, id)
+		if err != nil || current == nil {
+			return err
+		}
+		if err := tx.Delete(&models.Booking{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if current.Status == "cancelled" {
+			return nil
+		}
+		if err := packageSessionOnStatus(tx, current, "cancelled"); err != nil {
+			return err
+		}
+		return addEvent(tx, events.BookingStatusChanged, current.ID, events.BookingStatusChangedPayload{
+			BookingID: current.ID, UserID: current.UserID, StaffID: current.StaffID, Date: current.Date, From: current.Status, To: "cancelled",
+		})
+	})
 }
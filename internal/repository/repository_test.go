@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/driver/postgres"
@@ -50,6 +51,13 @@ func (s *RepositorySuite) TestCreateUser() {
 	s.mock.ExpectBegin()
 	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users"`)).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "consents"`)).
+		WithArgs(uint(1), "personal_data", true, "sign_up", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "user.registered", uint(1),
+			`{"user_id":1,"username":"test"}`, "pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	s.mock.ExpectCommit()
 
 	err := s.repo.CreateUser(user)
@@ -86,6 +94,44 @@ func (s *RepositorySuite) TestGetAllUsers() {
 	assert.Len(s.T(), res, 2)
 }
 
+func (s *RepositorySuite) TestUpdateUser() {
+	user := &models.User{Username: "old"}
+	user.ID = 1
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "full_name"=$1,"updated_at"=$2 WHERE "users"."deleted_at" IS NULL AND "id" = $3`)).
+		WithArgs("Anna", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.UpdateUser(user, map[string]interface{}{"full_name": "Anna"})
+	assert.NoError(s.T(), err)
+}
+
+func (s *RepositorySuite) TestAnonymizeUser() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "email"=$1,"full_name"=$2,"password"=$3,"phone"=$4,"username"=$5,"updated_at"=$6 WHERE id = $7`)).
+		WithArgs(nil, "", "", nil, "deleted_user_4", sqlmock.AnyArg(), uint(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, table := range []string{"user_identities", "client_profiles", "client_notes", "notifications", "notification_preferences", "calendar_feeds", "data_exports", "push_devices"} {
+		s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "` + table + `" WHERE user_id = $1`)).
+			WithArgs(uint(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1 WHERE "users"."id" = $2`)).
+		WithArgs(sqlmock.AnyArg(), uint(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.AnonymizeUser(4)
+	assert.NoError(s.T(), err)
+}
+
+func (s *RepositorySuite) TestIsUniqueViolation() {
+	assert.True(s.T(), IsUniqueViolation(&pgconn.PgError{Code: "23505"}))
+	assert.False(s.T(), IsUniqueViolation(&pgconn.PgError{Code: "23503"}))
+	assert.False(s.T(), IsUniqueViolation(errors.New("boom")))
+}
+
 func (s *RepositorySuite) TestDeleteUser() {
 	s.mock.ExpectBegin()
 	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=`)).
@@ -198,6 +244,20 @@ func (s *RepositorySuite) TestGetAllBookings() {
 	assert.Len(s.T(), res, 1)
 }
 
+func (s *RepositorySuite) TestGetBookingsOnDay() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE (date LIKE $1 AND status <> $2) AND "bookings"."deleted_at" IS NULL ORDER BY date`)).
+		WithArgs("2025-05-02%", "cancelled").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_id", "staff_id", "date"}).AddRow(1, 1, 2, "2025-05-02 10:00"))
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "services" WHERE "services"."id" = $1 AND "services"."deleted_at" IS NULL`)).
+		WithArgs(uint(1)).WillReturnRows(sqlmock.NewRows([]string{"id", "duration_min"}).AddRow(1, 60))
+
+	res, err := s.repo.GetBookingsOnDay("2025-05-02")
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), res, 1)
+	assert.Equal(s.T(), 60, res[0].Service.DurationMin)
+}
+
 func (s *RepositorySuite) TestGetBookingByID() {
 	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1 AND "bookings"."deleted_at" IS NULL`)).
 		WithArgs("1", 1).
@@ -222,9 +282,16 @@ func (s *RepositorySuite) TestUpdateBooking() {
 	updates := map[string]interface{}{"status": "confirmed"}
 
 	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1 AND "bookings"."deleted_at" IS NULL ORDER BY "bookings"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs(uint(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "staff_id", "status", "date"}).AddRow(1, 5, 3, "pending", "2025-05-02 10:00"))
 	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "bookings" SET "status"=$1,"updated_at"=$2 WHERE "bookings"."deleted_at" IS NULL AND "id" = $3`)).
 		WithArgs("confirmed", sqlmock.AnyArg(), uint(1)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "booking.status_changed", uint(1),
+			`{"booking_id":1,"user_id":5,"staff_id":3,"date":"2025-05-02 10:00","from":"pending","to":"confirmed"}`, "pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	s.mock.ExpectCommit()
 
 	err := s.repo.UpdateBooking(b, updates)
@@ -252,8 +319,18 @@ func (s *RepositorySuite) TestCreateBooking() {
 			booking.StaffID,
 			booking.Date, // поле Date
 			booking.Status,
+			int64(0), // deposit_amount
+			"",       // deposit_currency
+			nil,      // payment_due_at
+			"",       // promo_code
+			int64(0), // discount_amount
+			"",       // discount_currency
+			nil,      // package_session_id
+			nil,      // subscription_id
 		).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 	s.mock.ExpectCommit()
 
@@ -263,6 +340,29 @@ func (s *RepositorySuite) TestCreateBooking() {
 	assert.Equal(s.T(), uint(1), booking.ID)
 }
 
+func (s *RepositorySuite) TestCreateBookingSlotTaken() {
+	booking := &models.Booking{UserID: 1, ServiceID: 2, StaffID: 3, Date: "2026-01-20 11:00"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "staffs" WHERE "staffs"."id" = $1 AND "staffs"."deleted_at" IS NULL ORDER BY "staffs"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs(uint(3), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "services" WHERE "services"."id" = $1`)).
+		WithArgs(uint(2), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "duration_min"}).AddRow(2, 60))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE (staff_id = $1 AND date LIKE $2 AND status <> $3)`)).
+		WithArgs(uint(3), "2026-01-20%", "cancelled").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_id", "staff_id", "date"}).AddRow(9, 4, 3, "2026-01-20 10:30"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "services" WHERE "services"."id" = $1`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "duration_min"}).AddRow(4, 45))
+	s.mock.ExpectRollback()
+
+	// 10:30–11:15 пересекается с 11:00–12:00: запись не создаётся.
+	err := s.repo.CreateBooking(booking)
+	assert.ErrorIs(s.T(), err, ErrSlotUnavailable)
+}
+
 func (s *RepositorySuite) TestCreateBooking_Error() {
 	booking := &models.Booking{UserID: 1}
 
@@ -282,6 +382,9 @@ func (s *RepositorySuite) TestDeleteBooking() {
 	bookingID := "1"
 
 	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1`)).
+		WithArgs(bookingID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "staff_id", "status", "date"}).AddRow(1, 5, 3, "confirmed", "2025-05-02 10:00"))
 
 	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "bookings" SET "deleted_at"=`)).
 		WithArgs(
@@ -289,6 +392,10 @@ func (s *RepositorySuite) TestDeleteBooking() {
 			bookingID,
 		).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "booking.status_changed", uint(1),
+			`{"booking_id":1,"user_id":5,"staff_id":3,"date":"2025-05-02 10:00","from":"confirmed","to":"cancelled"}`, "pending", 0, sqlmock.AnyArg(), "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 	s.mock.ExpectCommit()
 
@@ -301,6 +408,9 @@ func (s *RepositorySuite) TestDeleteBooking_Error() {
 	bookingID := "99"
 
 	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1`)).
+		WithArgs(bookingID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "staff_id", "status", "date"}).AddRow(1, 5, 3, "confirmed", "2025-05-02 10:00"))
 	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "bookings" SET "deleted_at"=`)).
 		WithArgs(sqlmock.AnyArg(), bookingID).
 		WillReturnError(errors.New("db error on delete"))
@@ -312,3 +422,13 @@ func (s *RepositorySuite) TestDeleteBooking_Error() {
 	assert.Error(s.T(), err)
 	assert.Equal(s.T(), "db error on delete", err.Error())
 }
+
+func (s *RepositorySuite) TestDeleteBooking_NotFound() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bookings" WHERE id = $1`)).
+		WithArgs("42", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), s.repo.DeleteBooking("42"))
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TaxRepository interface {
+	GetTaxRates() ([]models.TaxRate, error)
+	SaveTaxRate(rate *models.TaxRate) error
+	DeleteTaxRate(id string) error
+}
+
+type FiscalRepository interface {
+	GetReceiptByID(id uint) (*models.Receipt, error)
+	GetReceiptByBooking(bookingID string) (*models.Receipt, error)
+	SetReceiptFiscalID(id uint, fiscalID string, at time.Time) error
+}
+
+func (r *PostgresRepository) GetTaxRates() ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	err := r.db.Order("category").Find(&rates).Error
+	return rates, err
+}
+
+// SaveTaxRate создаёт ставку или меняет существующую для той же категории.
+func (r *PostgresRepository) SaveTaxRate(rate *models.TaxRate) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "rate", "updated_at"}),
+	}).Create(rate).Error
+}
+
+func (r *PostgresRepository) DeleteTaxRate(id string) error {
+	res := r.db.Delete(&models.TaxRate{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetReceiptByID(id uint) (*models.Receipt, error) {
+	var rec models.Receipt
+	err := receipts(r.db).First(&rec, "id = ?", id).Error
+	return &rec, err
+}
+
+// SetReceiptFiscalID запоминает регистрацию чека. Номер, выданный раньше, не перезаписывается.
+func (r *PostgresRepository) SetReceiptFiscalID(id uint, fiscalID string, at time.Time) error {
+	return r.db.Model(&models.Receipt{}).Where("id = ? AND (fiscal_id IS NULL OR fiscal_id = '')", id).
+		Updates(map[string]interface{}{"fiscal_id": fiscalID, "fiscalized_at": at}).Error
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func (s *RepositorySuite) TestSaveTaxRate() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "tax_rates"`)+`.*`+
+		regexp.QuoteMeta(`ON CONFLICT ("category") DO UPDATE SET "name"="excluded"."name","rate"="excluded"."rate"`)).
+		WithArgs("cosmetics", "НДС 12%", 1200, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	s.mock.ExpectCommit()
+
+	rate := &models.TaxRate{Category: "cosmetics", Name: "НДС 12%", Rate: 1200}
+	assert.NoError(s.T(), repo.SaveTaxRate(rate))
+	assert.Equal(s.T(), uint(2), rate.ID)
+}
+
+func (s *RepositorySuite) TestDeleteTaxRateNotFound() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "tax_rates" WHERE id = $1`)).
+		WithArgs("5").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	assert.ErrorIs(s.T(), repo.DeleteTaxRate("5"), gorm.ErrRecordNotFound)
+}
+
+func (s *RepositorySuite) TestSetReceiptFiscalID() {
+	repo := NewPostgresRepository(s.db)
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "receipts" SET "fiscal_id"=$1,"fiscalized_at"=$2,"updated_at"=$3 WHERE (id = $4 AND (fiscal_id IS NULL OR fiscal_id = '')) AND "receipts"."deleted_at" IS NULL`)).
+		WithArgs("FILE-7", at, sqlmock.AnyArg(), uint(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.SetReceiptFiscalID(7, "FILE-7", at))
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	CreateWebhook(w *models.WebhookSubscription) error
+	GetAllWebhooks() ([]models.WebhookSubscription, error)
+	GetActiveWebhooks() ([]models.WebhookSubscription, error)
+	GetWebhookByID(id uint) (*models.WebhookSubscription, error)
+	DeleteWebhook(id string) error
+
+	CreateDelivery(d *models.WebhookDelivery) error
+	GetDeliveries(subscriptionID string) ([]models.WebhookDelivery, error)
+	GetDeliveryByID(id string) (*models.WebhookDelivery, error)
+	ClaimDueDelivery(now time.Time, lease time.Duration) (*models.WebhookDelivery, error)
+	SaveDelivery(d *models.WebhookDelivery) error
+}
+
+func (r *PostgresRepository) CreateWebhook(w *models.WebhookSubscription) error {
+	return r.db.Create(w).Error
+}
+func (r *PostgresRepository) GetAllWebhooks() ([]models.WebhookSubscription, error) {
+	var hooks []models.WebhookSubscription
+	err := r.db.Order("id").Find(&hooks).Error
+	return hooks, err
+}
+func (r *PostgresRepository) GetActiveWebhooks() ([]models.WebhookSubscription, error) {
+	var hooks []models.WebhookSubscription
+	err := r.db.Where("active = ?", true).Order("id").Find(&hooks).Error
+	return hooks, err
+}
+func (r *PostgresRepository) GetWebhookByID(id uint) (*models.WebhookSubscription, error) {
+	var w models.WebhookSubscription
+	err := r.db.First(&w, id).Error
+	return &w, err
+}
+func (r *PostgresRepository) DeleteWebhook(id string) error {
+	return r.db.Delete(&models.WebhookSubscription{}, "id = ?", id).Error
+}
+
+// CreateDelivery пропускает повтор того же события для той же подписки.
+func (r *PostgresRepository) CreateDelivery(d *models.WebhookDelivery) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(d).Error
+}
+func (r *PostgresRepository) GetDeliveries(subscriptionID string) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Where("subscription_id = ?", subscriptionID).Order("id desc").Limit(100).Find(&deliveries).Error
+	return deliveries, err
+}
+func (r *PostgresRepository) GetDeliveryByID(id string) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := r.db.First(&d, "id = ?", id).Error
+	return &d, err
+}
+
+// ClaimDueDelivery берёт доставку, которую пора отправить, и сдвигает её
+// next_attempt_at на lease, чтобы её не взяла другая реплика.
+func (r *PostgresRepository) ClaimDueDelivery(now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).Order("next_attempt_at").First(&d).Error; err != nil {
+			return err
+		}
+		d.NextAttemptAt = now.Add(lease)
+		return tx.Model(&d).Update("next_attempt_at", d.NextAttemptAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *PostgresRepository) SaveDelivery(d *models.WebhookDelivery) error { return r.db.Save(d).Error }
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"beauty-salon/internal/models"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositorySuite) TestGetActiveWebhooks() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_subscriptions" WHERE active = $1 AND "webhook_subscriptions"."deleted_at" IS NULL ORDER BY id`)).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "event_types"}).AddRow(1, "https://crm.example.com/hook", "*"))
+
+	res, err := repo.GetActiveWebhooks()
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), res[0].Accepts("booking.created"))
+}
+
+func (s *RepositorySuite) TestCreateDeliverySkipsDuplicates() {
+	repo := NewPostgresRepository(s.db)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "webhook_deliveries"`) + `.*` + regexp.QuoteMeta(`ON CONFLICT DO NOTHING`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectCommit()
+
+	assert.NoError(s.T(), repo.CreateDelivery(&models.WebhookDelivery{SubscriptionID: 1, EventID: "ev-1"}))
+}
+
+func (s *RepositorySuite) TestClaimDueDelivery() {
+	repo := NewPostgresRepository(s.db)
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_deliveries" WHERE (status = $1 AND next_attempt_at <= $2) AND "webhook_deliveries"."deleted_at" IS NULL ORDER BY next_attempt_at,"webhook_deliveries"."id" LIMIT $3 FOR UPDATE SKIP LOCKED`)).
+		WithArgs("pending", now, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id"}).AddRow(9, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "webhook_deliveries" SET "next_attempt_at"=$1`)).
+		WithArgs(now.Add(time.Minute), sqlmock.AnyArg(), 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	d, err := repo.ClaimDueDelivery(now, time.Minute)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), uint(1), d.SubscriptionID)
+}
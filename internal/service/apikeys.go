@@ -0,0 +1,134 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrInvalidScope  = errors.New("invalid scope")
+)
+
+const (
+	apiKeyPrefixLen = 8
+	// last_used_at обновляется не чаще раза в минуту, чтобы не писать в БД на каждый запрос.
+	apiKeyTouchInterval = time.Minute
+)
+
+type APIKeys interface {
+	CreateAPIKey(name string, scopes []string, expiresAt *time.Time, rateLimit int, createdBy uint) (string, *models.APIKey, error)
+	GetAPIKeys() ([]models.APIKey, error)
+	RevokeAPIKey(id string) error
+	AuthenticateAPIKey(raw string) (*models.APIKey, error)
+}
+
+type APIKeyService struct {
+	repo repository.APIKeyRepository
+	now  func() time.Time
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo, now: time.Now}
+}
+
+// CreateAPIKey возвращает ключ в открытом виде — он показывается один раз.
+// Формат: sk_<prefix>_<secret>.
+func (s *APIKeyService) CreateAPIKey(name string, scopes []string, expiresAt *time.Time, rateLimit int, createdBy uint) (string, *models.APIKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if len(scopes) == 0 {
+		return "", nil, ErrInvalidScope
+	}
+	for _, sc := range scopes {
+		if !validScope(sc) {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidScope, sc)
+		}
+	}
+	if rateLimit < 0 {
+		return "", nil, fmt.Errorf("%w: rate_limit must not be negative", ErrInvalidAPIKey)
+	}
+
+	prefix, err := randomHex(apiKeyPrefixLen / 2)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", nil, err
+	}
+	raw := "sk_" + prefix + "_" + secret
+
+	key := &models.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(raw),
+		Scopes:    strings.Join(scopes, ","),
+		RateLimit: rateLimit,
+		ExpiresAt: expiresAt,
+		CreatedBy: createdBy,
+	}
+	if err := s.repo.CreateAPIKey(key); err != nil {
+		return "", nil, err
+	}
+	return raw, key, nil
+}
+
+func (s *APIKeyService) GetAPIKeys() ([]models.APIKey, error) { return s.repo.GetAllAPIKeys() }
+func (s *APIKeyService) RevokeAPIKey(id string) error         { return s.repo.DeleteAPIKey(id) }
+
+func (s *APIKeyService) AuthenticateAPIKey(raw string) (*models.APIKey, error) {
+	parts := strings.Split(raw, "_")
+	if len(parts) != 3 || parts[0] != "sk" || len(parts[1]) != apiKeyPrefixLen {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.repo.GetAPIKeyByPrefix(parts[1])
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashAPIKey(raw))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	now := s.now()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.repo.TouchAPIKey(key.ID, now); err == nil {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range models.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Ключи высокоэнтропийные, поэтому достаточно SHA-256 без соли.
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) CreateAPIKey(k *models.APIKey) error { return m.Called(k).Error(0) }
+func (m *MockAPIKeyRepo) GetAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	args := m.Called(prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+func (m *MockAPIKeyRepo) GetAllAPIKeys() ([]models.APIKey, error) {
+	args := m.Called()
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+func (m *MockAPIKeyRepo) DeleteAPIKey(id string) error { return m.Called(id).Error(0) }
+func (m *MockAPIKeyRepo) TouchAPIKey(id uint, at time.Time) error {
+	return m.Called(id, at).Error(0)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		repo := new(MockAPIKeyRepo)
+		svc := NewAPIKeyService(repo)
+
+		var stored *models.APIKey
+		repo.On("CreateAPIKey", mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*models.APIKey)
+		}).Return(nil).Once()
+
+		raw, key, err := svc.CreateAPIKey("kiosk", []string{models.ScopeReadBookings, models.ScopeWriteBookings}, nil, 30, 1)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(raw, "sk_"+key.Prefix+"_"))
+		assert.NotContains(t, stored.KeyHash, raw)
+		assert.Equal(t, hashAPIKey(raw), stored.KeyHash)
+		assert.Equal(t, "read:bookings,write:bookings", stored.Scopes)
+		assert.Equal(t, 30, stored.RateLimit)
+	})
+
+	t.Run("Invalid Scope", func(t *testing.T) {
+		svc := NewAPIKeyService(new(MockAPIKeyRepo))
+		_, _, err := svc.CreateAPIKey("kiosk", []string{"admin:everything"}, nil, 0, 1)
+		assert.ErrorIs(t, err, ErrInvalidScope)
+
+		_, _, err = svc.CreateAPIKey("kiosk", nil, nil, 0, 1)
+		assert.ErrorIs(t, err, ErrInvalidScope)
+	})
+
+	t.Run("Missing Name", func(t *testing.T) {
+		svc := NewAPIKeyService(new(MockAPIKeyRepo))
+		_, _, err := svc.CreateAPIKey(" ", []string{models.ScopeReadBookings}, nil, 0, 1)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+		_, _, err = svc.CreateAPIKey("kiosk", []string{models.ScopeReadBookings}, nil, -1, 1)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	raw := "sk_abcd1234_" + strings.Repeat("f", 48)
+
+	newKey := func() *models.APIKey {
+		k := &models.APIKey{Prefix: "abcd1234", KeyHash: hashAPIKey(raw), Scopes: "read:bookings"}
+		k.ID = 5
+		return k
+	}
+	setup := func() (*APIKeyService, *MockAPIKeyRepo) {
+		repo := new(MockAPIKeyRepo)
+		svc := NewAPIKeyService(repo)
+		svc.now = func() time.Time { return now }
+		return svc, repo
+	}
+
+	t.Run("Valid Key Touches Last Used", func(t *testing.T) {
+		svc, repo := setup()
+		repo.On("GetAPIKeyByPrefix", "abcd1234").Return(newKey(), nil).Once()
+		repo.On("TouchAPIKey", uint(5), now).Return(nil).Once()
+
+		key, err := svc.AuthenticateAPIKey(raw)
+		require.NoError(t, err)
+		assert.Equal(t, now, *key.LastUsedAt)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Recently Used Is Not Touched", func(t *testing.T) {
+		svc, repo := setup()
+		k := newKey()
+		recent := now.Add(-10 * time.Second)
+		k.LastUsedAt = &recent
+		repo.On("GetAPIKeyByPrefix", "abcd1234").Return(k, nil).Once()
+
+		_, err := svc.AuthenticateAPIKey(raw)
+		assert.NoError(t, err)
+		repo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		svc, repo := setup()
+		k := newKey()
+		past := now.Add(-time.Hour)
+		k.ExpiresAt = &past
+		repo.On("GetAPIKeyByPrefix", "abcd1234").Return(k, nil).Once()
+
+		_, err := svc.AuthenticateAPIKey(raw)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+
+	t.Run("Wrong Secret", func(t *testing.T) {
+		svc, repo := setup()
+		repo.On("GetAPIKeyByPrefix", "abcd1234").Return(newKey(), nil).Once()
+
+		_, err := svc.AuthenticateAPIKey("sk_abcd1234_" + strings.Repeat("0", 48))
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+
+	t.Run("Unknown Prefix", func(t *testing.T) {
+		svc, repo := setup()
+		repo.On("GetAPIKeyByPrefix", "00000000").Return(nil, errors.New("record not found")).Once()
+
+		_, err := svc.AuthenticateAPIKey("sk_00000000_x")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		svc, _ := setup()
+		for _, bad := range []string{"", "sk_", "pk_abcd1234_x", "sk_short_x"} {
+			_, err := svc.AuthenticateAPIKey(bad)
+			assert.ErrorIs(t, err, ErrInvalidAPIKey, bad)
+		}
+	})
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	repo := new(MockAPIKeyRepo)
+	svc := NewAPIKeyService(repo)
+	repo.On("DeleteAPIKey", "3").Return(nil).Once()
+	repo.On("GetAllAPIKeys").Return([]models.APIKey{{Name: "kiosk"}}, nil).Once()
+
+	assert.NoError(t, svc.RevokeAPIKey("3"))
+	keys, err := svc.GetAPIKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"beauty-salon/internal/i18n"
+	"beauty-salon/internal/ical"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/notify"
+	"beauty-salon/internal/repository"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrFeedNotFound = errors.New("calendar feed not found")
+
+const (
+	calendarUIDDomain = "beauty-salon"
+	// Мастер видит в календаре и прошедший месяц, клиент — только предстоящие визиты.
+	staffFeedHistory = 30 * 24 * time.Hour
+)
+
+type Calendars interface {
+	CreateUserFeed(userID uint) (string, error)
+	DeleteUserFeed(userID uint) error
+	CreateStaffFeed(staffID string) (string, error)
+	DeleteStaffFeed(staffID string) error
+	Feed(token string) ([]byte, error)
+	BookingICS(bookingID string, userID uint) ([]byte, error)
+}
+
+type CalendarService struct {
+	repo    repository.CalendarRepository
+	feedURL string // префикс ссылки на подписку, к нему добавляется <token>.ics
+	loc     *time.Location
+	now     func() time.Time
+}
+
+func NewCalendarService(repo repository.CalendarRepository, feedURL string) *CalendarService {
+	return &CalendarService{repo: repo, feedURL: feedURL, loc: time.Local, now: time.Now}
+}
+
+// CreateUserFeed выпускает ссылку на календарь записей клиента. Прежняя ссылка
+// перестаёт работать; новая показывается один раз.
+func (s *CalendarService) CreateUserFeed(userID uint) (string, error) {
+	return s.createFeed(&models.CalendarFeed{UserID: &userID})
+}
+
+func (s *CalendarService) DeleteUserFeed(userID uint) error {
+	return s.repo.DeleteCalendarFeed(&models.CalendarFeed{UserID: &userID})
+}
+
+func (s *CalendarService) CreateStaffFeed(staffID string) (string, error) {
+	staff, err := s.repo.GetStaffByID(staffID)
+	if err != nil {
+		return "", err
+	}
+	return s.createFeed(&models.CalendarFeed{StaffID: &staff.ID})
+}
+
+func (s *CalendarService) DeleteStaffFeed(staffID string) error {
+	staff, err := s.repo.GetStaffByID(staffID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteCalendarFeed(&models.CalendarFeed{StaffID: &staff.ID})
+}
+
+func (s *CalendarService) createFeed(f *models.CalendarFeed) (string, error) {
+	token, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+	f.TokenHash = hashAPIKey(token)
+	if err := s.repo.ReplaceCalendarFeed(f); err != nil {
+		return "", err
+	}
+	return s.feedURL + token + ".ics", nil
+}
+
+// Feed отдаёт календарь по секретному токену из ссылки.
+func (s *CalendarService) Feed(token string) ([]byte, error) {
+	f, err := s.repo.GetCalendarFeedByHash(hashAPIKey(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFeedNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().In(s.loc)
+	if f.StaffID != nil {
+		return s.staffFeed(*f.StaffID, now)
+	}
+	return s.userFeed(*f.UserID, now)
+}
+
+func (s *CalendarService) userFeed(userID uint, now time.Time) ([]byte, error) {
+	u, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := s.repo.GetCalendarBookingsByUser(userID, now.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	cal := ical.Calendar{Name: i18n.T(u.Locale, "Salon bookings")}
+	for i := range bookings {
+		if e, ok := s.clientEvent(&bookings[i], u.Locale); ok {
+			cal.Events = append(cal.Events, e)
+		}
+	}
+	return cal.Encode(now), nil
+}
+
+func (s *CalendarService) staffFeed(staffID uint, now time.Time) ([]byte, error) {
+	staff, err := s.repo.GetStaffByID(strconv.FormatUint(uint64(staffID), 10))
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := s.repo.GetCalendarBookingsByStaff(staffID, now.Add(-staffFeedHistory).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	cal := ical.Calendar{Name: staff.FullName}
+	for i := range bookings {
+		b := &bookings[i]
+		client := displayName(&b.User)
+		if e, ok := s.event(b, b.Service.Title+" — "+client, i18n.T(i18n.DefaultLocale, "Client")+": "+client); ok {
+			cal.Events = append(cal.Events, e)
+		}
+	}
+	return cal.Encode(now), nil
+}
+
+// BookingICS отдаёт одну запись файлом .ics — клиенту-владельцу, мастерам и администраторам.
+func (s *CalendarService) BookingICS(bookingID string, userID uint) ([]byte, error) {
+	b, err := s.repo.GetCalendarBooking(bookingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.UserID != userID {
+		u, err := s.repo.GetUserByID(userID)
+		if err != nil || (u.Role != "staff" && u.Role != "admin") {
+			return nil, ErrBookingNotFound
+		}
+	}
+	return s.bookingCalendar(b), nil
+}
+
+// BookingAttachments прикладывает приглашение .ics к письмам о записи.
+// Отмена тоже уходит с файлом: календарь клиента отметит событие отменённым.
+func (s *CalendarService) BookingAttachments(event string, bookingID uint) ([]notify.Attachment, error) {
+	if event == notify.EventBookingReminder {
+		return nil, nil
+	}
+	b, err := s.repo.GetCalendarBooking(strconv.FormatUint(uint64(bookingID), 10))
+	if err != nil {
+		return nil, err
+	}
+	return []notify.Attachment{{
+		Name:        fmt.Sprintf("booking-%d.ics", b.ID),
+		ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+		Data:        s.bookingCalendar(b),
+	}}, nil
+}
+
+func (s *CalendarService) bookingCalendar(b *models.Booking) []byte {
+	cal := ical.Calendar{Method: "PUBLISH"}
+	if e, ok := s.clientEvent(b, b.User.Locale); ok {
+		cal.Events = append(cal.Events, e)
+	}
+	return cal.Encode(s.now())
+}
+
+func (s *CalendarService) clientEvent(b *models.Booking, locale string) (ical.Event, bool) {
+	return s.event(b, b.Service.Localized(locale).Title, i18n.T(locale, "Staff")+": "+b.Staff.FullName)
+}
+
+// event переводит запись в VEVENT. UID зависит только от ID записи, поэтому
+// перенос и отмена обновляют то же событие в календаре.
+func (s *CalendarService) event(b *models.Booking, summary, description string) (ical.Event, bool) {
+	start, err := time.ParseInLocation(bookingDateLayout, b.Date, s.loc)
+	if err != nil {
+		return ical.Event{}, false
+	}
+	modified := b.UpdatedAt
+	status := ical.StatusTentative
+	switch {
+	case b.DeletedAt.Valid || b.Status == "cancelled":
+		status = ical.StatusCancelled
+		if b.DeletedAt.Valid {
+			modified = b.DeletedAt.Time
+		}
+	case b.Status == "confirmed" || b.Status == "completed":
+		status = ical.StatusConfirmed
+	}
+	return ical.Event{
+		UID:         fmt.Sprintf("booking-%d@%s", b.ID, calendarUIDDomain),
+		Summary:     summary,
+		Description: description,
+		Start:       start,
+		End:         start.Add(b.Service.Duration()),
+		Status:      status,
+		// Секунды с создания растут при каждом изменении — годятся как SEQUENCE.
+		Sequence:     max(0, int(modified.Sub(b.CreatedAt)/time.Second)),
+		LastModified: modified,
+	}, true
+}
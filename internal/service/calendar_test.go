@@ -0,0 +1,176 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/notify"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockCalendarRepo struct {
+	MockRepo
+}
+
+func (m *MockCalendarRepo) ReplaceCalendarFeed(f *models.CalendarFeed) error {
+	return m.Called(f).Error(0)
+}
+func (m *MockCalendarRepo) DeleteCalendarFeed(f *models.CalendarFeed) error {
+	return m.Called(f).Error(0)
+}
+func (m *MockCalendarRepo) GetCalendarFeedByHash(hash string) (*models.CalendarFeed, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CalendarFeed), args.Error(1)
+}
+func (m *MockCalendarRepo) GetCalendarBooking(id string) (*models.Booking, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+func (m *MockCalendarRepo) GetCalendarBookingsByUser(userID uint, from string) ([]models.Booking, error) {
+	args := m.Called(userID, from)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
+func (m *MockCalendarRepo) GetCalendarBookingsByStaff(staffID uint, from string) ([]models.Booking, error) {
+	args := m.Called(staffID, from)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
+
+func newTestCalendar() (*CalendarService, *MockCalendarRepo) {
+	repo := new(MockCalendarRepo)
+	svc := NewCalendarService(repo, "https://salon.example/api/v1/calendar/")
+	svc.loc = time.UTC
+	svc.now = func() time.Time { return time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC) }
+	return svc, repo
+}
+
+func calendarBooking() models.Booking {
+	created := time.Date(2026, 2, 20, 12, 0, 0, 0, time.UTC)
+	b := models.Booking{
+		UserID: 4, StaffID: 3, Date: "2026-03-10 14:00", Status: "confirmed",
+		User:    models.User{Username: "olga", FullName: "Ольга"},
+		Service: models.Service{Title: "Стрижка", DurationMin: 90, Translations: map[string]models.ServiceText{"en": {Title: "Haircut"}}},
+		Staff:   models.Staff{FullName: "Анна"},
+	}
+	b.ID, b.CreatedAt, b.UpdatedAt = 7, created, created.Add(time.Hour)
+	return b
+}
+
+func TestCreateCalendarFeed(t *testing.T) {
+	svc, repo := newTestCalendar()
+	var saved *models.CalendarFeed
+	repo.On("ReplaceCalendarFeed", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*models.CalendarFeed)
+	}).Return(nil).Once()
+
+	url, err := svc.CreateUserFeed(4)
+	require.NoError(t, err)
+	token := strings.TrimSuffix(strings.TrimPrefix(url, "https://salon.example/api/v1/calendar/"), ".ics")
+	assert.Len(t, token, 48)
+	assert.Equal(t, uint(4), *saved.UserID)
+	assert.Nil(t, saved.StaffID)
+	assert.Equal(t, hashAPIKey(token), saved.TokenHash)
+}
+
+func TestCalendarFeed(t *testing.T) {
+	t.Run("Client", func(t *testing.T) {
+		svc, repo := newTestCalendar()
+		userID := uint(4)
+		repo.On("GetCalendarFeedByHash", hashAPIKey("tok")).Return(&models.CalendarFeed{UserID: &userID}, nil).Once()
+		repo.On("GetUserByID", uint(4)).Return(&models.User{Locale: "en"}, nil).Once()
+		repo.On("GetCalendarBookingsByUser", uint(4), "2026-03-01").Return([]models.Booking{calendarBooking()}, nil).Once()
+
+		out, err := svc.Feed("tok")
+		require.NoError(t, err)
+		ics := string(out)
+		assert.Contains(t, ics, "X-WR-CALNAME:Salon bookings\r\n")
+		assert.Contains(t, ics, "UID:booking-7@beauty-salon\r\n")
+		assert.Contains(t, ics, "SUMMARY:Haircut\r\n")
+		assert.Contains(t, ics, "DESCRIPTION:Staff: Анна\r\n")
+		assert.Contains(t, ics, "DTSTART:20260310T140000Z\r\n")
+		assert.Contains(t, ics, "DTEND:20260310T153000Z\r\n")
+		assert.Contains(t, ics, "STATUS:CONFIRMED\r\n")
+		assert.Contains(t, ics, "SEQUENCE:3600\r\n")
+	})
+
+	t.Run("Staff With Cancelled Booking", func(t *testing.T) {
+		svc, repo := newTestCalendar()
+		staffID := uint(3)
+		cancelled := calendarBooking()
+		cancelled.DeletedAt = gorm.DeletedAt{Time: cancelled.UpdatedAt.Add(time.Hour), Valid: true}
+		repo.On("GetCalendarFeedByHash", hashAPIKey("tok")).Return(&models.CalendarFeed{StaffID: &staffID}, nil).Once()
+		repo.On("GetStaffByID", "3").Return(&models.Staff{FullName: "Анна"}, nil).Once()
+		repo.On("GetCalendarBookingsByStaff", uint(3), "2026-01-30").Return([]models.Booking{cancelled}, nil).Once()
+
+		out, err := svc.Feed("tok")
+		require.NoError(t, err)
+		ics := string(out)
+		assert.Contains(t, ics, "X-WR-CALNAME:Анна\r\n")
+		assert.Contains(t, ics, "SUMMARY:Стрижка — Ольга\r\n")
+		assert.Contains(t, ics, "DESCRIPTION:Клиент: Ольга\r\n")
+		assert.Contains(t, ics, "STATUS:CANCELLED\r\n")
+		assert.Contains(t, ics, "SEQUENCE:7200\r\n")
+	})
+
+	t.Run("Unknown Token", func(t *testing.T) {
+		svc, repo := newTestCalendar()
+		repo.On("GetCalendarFeedByHash", hashAPIKey("tok")).Return(nil, gorm.ErrRecordNotFound).Once()
+		_, err := svc.Feed("tok")
+		assert.ErrorIs(t, err, ErrFeedNotFound)
+	})
+}
+
+func TestBookingICS(t *testing.T) {
+	b := calendarBooking()
+
+	t.Run("Owner", func(t *testing.T) {
+		svc, repo := newTestCalendar()
+		repo.On("GetCalendarBooking", "7").Return(&b, nil).Once()
+		out, err := svc.BookingICS("7", 4)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "METHOD:PUBLISH\r\n")
+	})
+
+	t.Run("Staff", func(t *testing.T) {
+		svc, repo := newTestCalendar()
+		repo.On("GetCalendarBooking", "7").Return(&b, nil).Once()
+		repo.On("GetUserByID", uint(9)).Return(&models.User{Role: "staff"}, nil).Once()
+		_, err := svc.BookingICS("7", 9)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Other Client", func(t *testing.T) {
+		svc, repo := newTestCalendar()
+		repo.On("GetCalendarBooking", "7").Return(&b, nil).Once()
+		repo.On("GetUserByID", uint(5)).Return(&models.User{Role: "client"}, nil).Once()
+		_, err := svc.BookingICS("7", 5)
+		assert.ErrorIs(t, err, ErrBookingNotFound)
+	})
+}
+
+func TestCalendarBookingAttachments(t *testing.T) {
+	svc, repo := newTestCalendar()
+	b := calendarBooking()
+	repo.On("GetCalendarBooking", "7").Return(&b, nil).Once()
+
+	files, err := svc.BookingAttachments(notify.EventBookingConfirmed, 7)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "booking-7.ics", files[0].Name)
+	assert.Contains(t, string(files[0].Data), "UID:booking-7@beauty-salon")
+
+	files, err = svc.BookingAttachments(notify.EventBookingReminder, 7)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,582 @@
+package service
+
+import (
+	"beauty-salon/internal/i18n"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCheckout    = errors.New("invalid checkout")
+	ErrAlreadyCheckedOut  = errors.New("booking is already checked out")
+	ErrInsufficientTender = errors.New("tenders do not cover the total")
+	ErrReceiptNotFound    = errors.New("receipt not found")
+)
+
+// Способы оплаты на кассе. TenderOnline добавляется сам: это уже
+// проведённые онлайн-платежи по записи, например предоплата.
+const (
+	TenderCash     = "cash"
+	TenderCard     = "card"
+	TenderGiftCard = "gift_card"
+	TenderOnline   = "online"
+)
+
+// CheckoutInput — то, что администратор пробивает при закрытии визита.
+// Суммы — в валюте услуги; услуга из записи добавляется в чек сама.
+type CheckoutInput struct {
+	Items     []CheckoutItem     `json:"items"`
+	Discounts []CheckoutDiscount `json:"discounts"`
+	Tip       money.Money        `json:"tip"`  // чаевые мастеру записи
+	Tips      []CheckoutTip      `json:"tips"` // чаевые другим мастерам
+	Tenders   []CheckoutTender   `json:"tenders"`
+	PromoCode string             `json:"promo_code"` // пусто — промокод из записи
+	Points    int64              `json:"points"`     // баллы лояльности в оплату
+}
+
+type CheckoutItem struct {
+	Kind      string      `json:"kind"` // addon, product, package, gift_card
+	Title     string      `json:"title"`
+	Category  string      `json:"category"` // категория для ставки НДС
+	Quantity  int         `json:"quantity"` // 0 — одна штука
+	UnitPrice money.Money `json:"unit_price"`
+	PackageID uint        `json:"package_id"` // package: цена и название берутся из пакета
+}
+
+// CheckoutDiscount — скидка суммой (amount) или процентом от подытога (percent).
+type CheckoutDiscount struct {
+	Title   string      `json:"title"`
+	Amount  money.Money `json:"amount"`
+	Percent int         `json:"percent"`
+}
+
+// CheckoutTip — чаевые мастеру; без staff_id — мастеру записи.
+type CheckoutTip struct {
+	StaffID uint        `json:"staff_id"`
+	Amount  money.Money `json:"amount"`
+}
+
+type CheckoutTender struct {
+	Method    string      `json:"method"` // cash, card, gift_card
+	Amount    money.Money `json:"amount"`
+	Reference string      `json:"reference"`
+}
+
+type Checkout interface {
+	Checkout(bookingID string, in CheckoutInput, by uint) (*models.Receipt, error)
+	GetReceipt(bookingID string) (*models.Receipt, error)
+}
+
+type CheckoutService struct {
+	repo        repository.CheckoutRepository
+	taxes       TaxPolicy
+	giftCards   GiftCardPolicy
+	discounts   DiscountPolicy
+	loyalty     LoyaltyPolicy
+	packages    PackagePolicy
+	memberships MembershipPolicy
+	refunds     OnlineRefunds
+}
+
+// OnlineRefunds возвращает клиенту излишек онлайн-оплаты — это PaymentService.
+type OnlineRefunds interface {
+	Refund(ctx context.Context, paymentID string, amount money.Money, reason string, by uint) (*models.PaymentRefund, error)
+}
+
+// TaxPolicy считает НДС по строкам чека — это TaxService.
+type TaxPolicy interface {
+	ApplyTax(rec *models.Receipt) error
+}
+
+// GiftCardPolicy выпускает и проверяет подарочные карты — это GiftCardService.
+type GiftCardPolicy interface {
+	NewCard(amount money.Money, by uint) (*models.GiftCard, error)
+	CheckRedeemable(code string, amount money.Money) (string, error)
+}
+
+// DiscountPolicy подбирает скидки по правилам и промокоду — это DiscountService.
+type DiscountPolicy interface {
+	Evaluate(t DiscountTarget) ([]AppliedDiscount, error)
+}
+
+// LoyaltyPolicy даёт скидку уровня клиента и принимает баллы в оплату — это LoyaltyService.
+type LoyaltyPolicy interface {
+	TierDiscount(userID uint) (tier string, percent int, err error)
+	PointsValue(userID uint, points int64, currency string) (money.Money, error)
+}
+
+// PackagePolicy продаёт пакеты услуг — это PackageService.
+type PackagePolicy interface {
+	Sell(packageID, userID uint) (*models.ClientPackage, *models.Package, error)
+}
+
+// MembershipPolicy даёт скидку по абонементу клиента — это MembershipService.
+type MembershipPolicy interface {
+	MemberDiscount(userID uint) (plan string, percent int, err error)
+}
+
+func NewCheckoutService(repo repository.CheckoutRepository) *CheckoutService {
+	return &CheckoutService{repo: repo}
+}
+
+// SetTaxes включает расчёт НДС в чеках.
+func (s *CheckoutService) SetTaxes(t TaxPolicy) { s.taxes = t }
+
+// SetGiftCards включает продажу и оплату подарочных карт на кассе.
+func (s *CheckoutService) SetGiftCards(g GiftCardPolicy) { s.giftCards = g }
+
+// SetDiscounts включает скидки по правилам и промокодам.
+func (s *CheckoutService) SetDiscounts(d DiscountPolicy) { s.discounts = d }
+
+// SetLoyalty включает скидки уровней и оплату баллами.
+func (s *CheckoutService) SetLoyalty(l LoyaltyPolicy) { s.loyalty = l }
+
+// SetPackages включает продажу пакетов услуг на кассе.
+func (s *CheckoutService) SetPackages(p PackagePolicy) { s.packages = p }
+
+// SetMemberships включает скидки по абонементам.
+func (s *CheckoutService) SetMemberships(m MembershipPolicy) { s.memberships = m }
+
+// SetRefunds включает возврат излишка онлайн-оплаты при закрытии визита.
+// Без него чек, который меньше онлайн-оплаты, не проводится.
+func (s *CheckoutService) SetRefunds(r OnlineRefunds) { s.refunds = r }
+
+// RegisterExportSections добавляет чеки в выгрузку персональных данных.
+func (s *CheckoutService) RegisterExportSections(e *ExportService) {
+	e.AddSection("receipts", func(id uint) (interface{}, error) { return s.repo.GetReceiptsByUser(id) })
+}
+
+// Checkout пробивает чек по записи и отмечает визит состоявшимся.
+// Проведённые онлайн-платежи (предоплата) засчитываются первыми;
+// остаток оплачивается переданными способами, сдача — только с наличных.
+func (s *CheckoutService) Checkout(bookingID string, in CheckoutInput, by uint) (*models.Receipt, error) {
+	b, err := s.repo.GetBookingByID(bookingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.Status == "cancelled" {
+		return nil, fmt.Errorf("%w: booking is cancelled", ErrInvalidCheckout)
+	}
+
+	price := b.Service.Price
+	if price.Currency == "" {
+		price.Currency = money.DefaultCurrency
+	}
+	cur := price.Currency
+	zero := money.Zero(cur)
+	rec := &models.Receipt{BookingID: b.ID, UserID: b.UserID, StaffID: b.StaffID, CreatedBy: by,
+		Subtotal: zero, Discount: zero, Tips: zero, Total: zero, Change: zero, Tax: zero}
+	// Строки чека — на языке клиента, чек получает он.
+	locale := b.User.Locale
+	if locale == "" {
+		locale = i18n.DefaultLocale
+	}
+	title := b.Service.Localized(locale).Title
+	// Визит оплачен сеансом пакета или входит в абонемент: услуга в чеке бесплатна.
+	visit := receiptLine("service", title, b.Service.Category, 1, price)
+	switch {
+	case b.PackageSessionID != nil:
+		visit = receiptLine("service", fmt.Sprintf(i18n.T(locale, "%s (package)"), title), b.Service.Category, 1, zero)
+	case b.SubscriptionID != nil:
+		visit = receiptLine("service", fmt.Sprintf(i18n.T(locale, "%s (membership)"), title), b.Service.Category, 1, zero)
+	}
+	visit.StaffID = &b.StaffID
+	rec.Items = append(rec.Items, visit)
+	for _, it := range in.Items {
+		if it.Kind == "package" && s.packages != nil {
+			if err := s.sellPackages(rec, it); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if it.Kind == TenderGiftCard && s.giftCards != nil {
+			if err := s.sellGiftCards(rec, it, by, locale); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if it.Kind != "addon" && it.Kind != "product" {
+			return nil, fmt.Errorf("%w: item kind must be addon or product", ErrInvalidCheckout)
+		}
+		if it.Quantity == 0 {
+			it.Quantity = 1
+		}
+		if strings.TrimSpace(it.Title) == "" || it.Quantity < 0 || it.UnitPrice.IsNegative() {
+			return nil, fmt.Errorf("%w: item needs a title, quantity and price", ErrInvalidCheckout)
+		}
+		if err := sameCurrency(it.UnitPrice, cur); err != nil {
+			return nil, err
+		}
+		it.UnitPrice.Currency = cur
+		line := receiptLine(it.Kind, strings.TrimSpace(it.Title), strings.TrimSpace(it.Category), it.Quantity, it.UnitPrice)
+		if it.Kind == "addon" {
+			line.StaffID = &b.StaffID // допуслугу делает мастер записи
+		}
+		rec.Items = append(rec.Items, line)
+	}
+	// Скидки считаются только от услуг и товаров: проданная подарочная карта —
+	// это аванс, её номинал не уменьшается.
+	goods := zero
+	for _, it := range rec.Items {
+		rec.Subtotal = rec.Subtotal.Add(it.Amount)
+		if it.Taxable() {
+			goods = goods.Add(it.Amount)
+		}
+	}
+
+	if s.discounts != nil {
+		if err := s.applyRules(rec, b, in.PromoCode); err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range in.Discounts {
+		amount := d.Amount
+		switch {
+		case d.Percent != 0 && !d.Amount.IsZero(), d.Percent < 0 || d.Percent > 100, d.Amount.IsNegative():
+			return nil, fmt.Errorf("%w: discount needs either amount or percent 1-100", ErrInvalidCheckout)
+		case d.Percent != 0:
+			amount = goods.Percent(int64(d.Percent), money.DiscountRounding)
+		}
+		if err := sameCurrency(amount, cur); err != nil {
+			return nil, err
+		}
+		amount.Currency = cur
+		rec.Discount = rec.Discount.Add(amount)
+		rec.Items = append(rec.Items, receiptLine("discount", strings.TrimSpace(d.Title), "", 1, amount.Neg()))
+	}
+	if rec.Discount.Cmp(goods) > 0 {
+		return nil, fmt.Errorf("%w: discount exceeds subtotal", ErrInvalidCheckout)
+	}
+	if s.memberships != nil && b.UserID != 0 {
+		if err := s.applyMembership(rec, goods, locale); err != nil {
+			return nil, err
+		}
+	}
+	if s.loyalty != nil && b.UserID != 0 {
+		if err := s.applyLoyalty(rec, goods, in.Points, locale); err != nil {
+			return nil, err
+		}
+	} else if in.Points != 0 {
+		return nil, fmt.Errorf("%w: loyalty points are not accepted", ErrInvalidCheckout)
+	}
+	for _, t := range append([]CheckoutTip{{Amount: in.Tip}}, in.Tips...) {
+		if err := s.addTip(rec, b, t); err != nil {
+			return nil, err
+		}
+	}
+	allocateDiscount(rec)
+	if s.taxes != nil {
+		if err := s.taxes.ApplyTax(rec); err != nil {
+			return nil, err
+		}
+	}
+	rec.Total = rec.Subtotal.Sub(rec.Discount).Add(rec.Tips)
+	if rec.TaxMode == TaxExclusive {
+		rec.Total = rec.Total.Add(rec.Tax)
+	}
+
+	surplus, err := s.tender(rec, in.Tenders)
+	if err != nil {
+		return nil, err
+	}
+	if len(surplus) > 0 && s.refunds == nil {
+		return nil, fmt.Errorf("%w: online payment exceeds the total", ErrInvalidCheckout)
+	}
+	if err := s.repo.CreateReceipt(rec); err != nil {
+		if repository.IsUniqueViolation(err) {
+			return nil, ErrAlreadyCheckedOut
+		}
+		return nil, err
+	}
+	// Возврат — после проведения чека: до него излишек ещё мог уйти в оплату.
+	for _, p := range surplus {
+		id := strconv.FormatUint(uint64(p.ID), 10)
+		if _, err := s.refunds.Refund(context.Background(), id, p.Amount, "checkout surplus", by); err != nil {
+			log.Printf("checkout: refund surplus %s of payment %d: %v", p.Amount, p.ID, err)
+		}
+	}
+	return rec, nil
+}
+
+// tender распределяет оплату чека по способам и считает сдачу. Возвращает
+// онлайн-платежи с суммой, которая в чек не вошла.
+func (s *CheckoutService) tender(rec *models.Receipt, tenders []CheckoutTender) ([]models.Payment, error) {
+	cur := rec.Total.Currency
+	due := rec.Total
+	paid, err := s.repo.GetPaymentsByBooking(rec.BookingID)
+	if err != nil {
+		return nil, err
+	}
+	var surplus []models.Payment
+	for _, p := range paid {
+		// Удержанная за позднюю отмену предоплата в зачёт не идёт.
+		available := p.Refundable()
+		if !available.IsPositive() || p.RetainedAt != nil || !available.SameCurrency(due) {
+			continue
+		}
+		if amount := money.Min(available, due); amount.IsPositive() {
+			id := p.ID
+			rec.Tenders = append(rec.Tenders, models.ReceiptTender{Method: TenderOnline, Amount: amount, PaymentID: &id})
+			due = due.Sub(amount)
+			available = available.Sub(amount)
+		}
+		if available.IsPositive() {
+			surplus = append(surplus, models.Payment{Model: p.Model, Amount: available})
+		}
+	}
+
+	cash := money.Zero(cur)
+	for _, t := range tenders {
+		if !t.Amount.IsPositive() {
+			return nil, fmt.Errorf("%w: tender amount must be positive", ErrInvalidCheckout)
+		}
+		if err := sameCurrency(t.Amount, cur); err != nil {
+			return nil, err
+		}
+		t.Amount.Currency = cur
+		switch t.Method {
+		case TenderCash:
+			cash = cash.Add(t.Amount)
+		case TenderCard:
+		case TenderGiftCard:
+			if s.giftCards == nil || strings.TrimSpace(t.Reference) == "" {
+				return nil, fmt.Errorf("%w: gift card tender needs a reference", ErrInvalidCheckout)
+			}
+			code, err := s.giftCards.CheckRedeemable(t.Reference, t.Amount)
+			if err != nil {
+				return nil, err
+			}
+			t.Reference = code
+		default:
+			return nil, fmt.Errorf("%w: tender method must be cash, card or gift_card", ErrInvalidCheckout)
+		}
+		rec.Tenders = append(rec.Tenders, models.ReceiptTender{Method: t.Method, Amount: t.Amount, Reference: strings.TrimSpace(t.Reference)})
+		due = due.Sub(t.Amount)
+	}
+	if due.IsPositive() {
+		return nil, ErrInsufficientTender
+	}
+	// Переплатить можно только наличными — излишек возвращается сдачей.
+	if due.Neg().Cmp(cash) > 0 {
+		return nil, fmt.Errorf("%w: only cash can exceed the total", ErrInvalidCheckout)
+	}
+	rec.Change = due.Neg()
+	return surplus, nil
+}
+
+// applyRules добавляет в чек скидки по правилам и промокоду. Применения
+// правил сохраняются вместе с чеком — по ним считаются лимиты.
+func (s *CheckoutService) applyRules(rec *models.Receipt, b *models.Booking, code string) error {
+	if strings.TrimSpace(code) == "" {
+		code = b.PromoCode
+	}
+	t := DiscountTarget{UserID: b.UserID, Date: b.Date, PromoCode: code}
+	for _, it := range rec.Items {
+		if !it.Taxable() {
+			continue
+		}
+		line := DiscountLine{Category: it.Category, Amount: it.Amount}
+		if it.Kind == "service" {
+			line.ServiceID = b.ServiceID
+		}
+		t.Lines = append(t.Lines, line)
+	}
+	applied, err := s.discounts.Evaluate(t)
+	if err != nil {
+		return err
+	}
+	for _, d := range applied {
+		rec.Discount = rec.Discount.Add(d.Amount)
+		rec.Items = append(rec.Items, receiptLine("discount", d.Name, "", 1, d.Amount.Neg()))
+		rec.Redemptions = append(rec.Redemptions, models.DiscountRedemption{RuleID: d.RuleID, UserID: b.UserID, Amount: d.Amount})
+	}
+	return nil
+}
+
+// applyLoyalty добавляет скидку уровня клиента и оплату баллами. Баллы
+// уменьшают сумму как скидка и не могут превысить остаток за услуги и товары.
+func (s *CheckoutService) applyLoyalty(rec *models.Receipt, goods money.Money, points int64, locale string) error {
+	tier, percent, err := s.loyalty.TierDiscount(rec.UserID)
+	if err != nil {
+		return err
+	}
+	if percent > 0 {
+		amount := money.Min(goods.Percent(int64(percent), money.DiscountRounding), goods.Sub(rec.Discount))
+		if amount.IsPositive() {
+			rec.Discount = rec.Discount.Add(amount)
+			rec.Items = append(rec.Items, receiptLine("discount", fmt.Sprintf(i18n.T(locale, "%s tier discount"), tier), "", 1, amount.Neg()))
+		}
+	}
+	if points == 0 {
+		return nil
+	}
+	value, err := s.loyalty.PointsValue(rec.UserID, points, goods.Currency)
+	if err != nil {
+		return err
+	}
+	if value.Cmp(goods.Sub(rec.Discount)) > 0 {
+		return fmt.Errorf("%w: points exceed the amount due", ErrInvalidCheckout)
+	}
+	rec.Discount = rec.Discount.Add(value)
+	rec.PointsRedeemed = points
+	rec.Items = append(rec.Items, receiptLine("discount", i18n.T(locale, "Paid with points"), "", 1, value.Neg()))
+	return nil
+}
+
+// applyMembership добавляет скидку по абонементу клиента.
+func (s *CheckoutService) applyMembership(rec *models.Receipt, goods money.Money, locale string) error {
+	plan, percent, err := s.memberships.MemberDiscount(rec.UserID)
+	if err != nil || percent == 0 {
+		return err
+	}
+	amount := money.Min(goods.Percent(int64(percent), money.DiscountRounding), goods.Sub(rec.Discount))
+	if amount.IsPositive() {
+		rec.Discount = rec.Discount.Add(amount)
+		rec.Items = append(rec.Items, receiptLine("discount", fmt.Sprintf(i18n.T(locale, "%s membership discount"), plan), "", 1, amount.Neg()))
+	}
+	return nil
+}
+
+// addTip добавляет в чек чаевые мастеру — по ним считается выплата мастеру.
+func (s *CheckoutService) addTip(rec *models.Receipt, b *models.Booking, t CheckoutTip) error {
+	if t.Amount.IsNegative() {
+		return fmt.Errorf("%w: tip must not be negative", ErrInvalidCheckout)
+	}
+	if err := sameCurrency(t.Amount, rec.Tips.Currency); err != nil {
+		return err
+	}
+	if t.Amount.IsZero() {
+		return nil
+	}
+	staffID, name := b.StaffID, b.Staff.FullName
+	if t.StaffID != 0 && t.StaffID != b.StaffID {
+		st, err := s.repo.GetStaffByID(strconv.FormatUint(uint64(t.StaffID), 10))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: unknown tip staff_id", ErrInvalidCheckout)
+		}
+		if err != nil {
+			return err
+		}
+		staffID, name = st.ID, st.FullName
+	}
+	tip := money.New(t.Amount.Minor, rec.Tips.Currency)
+	rec.Tips = rec.Tips.Add(tip)
+	line := receiptLine("tip", name, "", 1, tip)
+	line.StaffID = &staffID
+	rec.Items = append(rec.Items, line)
+	return nil
+}
+
+// sellPackages добавляет в чек проданные клиенту пакеты услуг.
+func (s *CheckoutService) sellPackages(rec *models.Receipt, it CheckoutItem) error {
+	if it.Quantity == 0 {
+		it.Quantity = 1
+	}
+	if it.Quantity < 0 || it.PackageID == 0 {
+		return fmt.Errorf("%w: package needs package_id and quantity", ErrInvalidCheckout)
+	}
+	var pkg *models.Package
+	for i := 0; i < it.Quantity; i++ {
+		cp, p, err := s.packages.Sell(it.PackageID, rec.UserID)
+		if err != nil {
+			return err
+		}
+		if err := sameCurrency(p.Price, rec.Subtotal.Currency); err != nil {
+			return err
+		}
+		rec.Packages = append(rec.Packages, *cp)
+		pkg = p
+	}
+	rec.Items = append(rec.Items, receiptLine("package", pkg.Name, pkg.Category, it.Quantity, money.New(pkg.Price.Minor, rec.Subtotal.Currency)))
+	return nil
+}
+
+// sellGiftCards добавляет в чек проданные подарочные карты — по одной на штуку.
+func (s *CheckoutService) sellGiftCards(rec *models.Receipt, it CheckoutItem, by uint, locale string) error {
+	if it.Quantity == 0 {
+		it.Quantity = 1
+	}
+	if it.Quantity < 0 || !it.UnitPrice.IsPositive() {
+		return fmt.Errorf("%w: gift card needs a positive price", ErrInvalidCheckout)
+	}
+	if err := sameCurrency(it.UnitPrice, rec.Subtotal.Currency); err != nil {
+		return err
+	}
+	it.UnitPrice.Currency = rec.Subtotal.Currency
+	title := strings.TrimSpace(it.Title)
+	if title == "" {
+		title = i18n.T(locale, "Gift card")
+	}
+	for i := 0; i < it.Quantity; i++ {
+		card, err := s.giftCards.NewCard(it.UnitPrice, by)
+		if err != nil {
+			return err
+		}
+		rec.GiftCards = append(rec.GiftCards, *card)
+	}
+	rec.Items = append(rec.Items, receiptLine(TenderGiftCard, title, "", it.Quantity, it.UnitPrice))
+	return nil
+}
+
+func (s *CheckoutService) GetReceipt(bookingID string) (*models.Receipt, error) {
+	rec, err := s.repo.GetReceiptByBooking(bookingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReceiptNotFound
+	}
+	return rec, err
+}
+
+func receiptLine(kind, title, category string, quantity int, unitPrice money.Money) models.ReceiptItem {
+	zero := money.Zero(unitPrice.Currency)
+	return models.ReceiptItem{Kind: kind, Title: title, Category: category, Quantity: quantity,
+		UnitPrice: unitPrice, Amount: unitPrice.Mul(int64(quantity)), Discount: zero, Tax: zero}
+}
+
+// allocateDiscount разносит скидку по чеку на строки услуг и товаров
+// пропорционально их сумме. Остаток от округления достаётся самой крупной строке.
+func allocateDiscount(rec *models.Receipt) {
+	var goods int64
+	for _, it := range rec.Items {
+		if it.Taxable() {
+			goods += it.Amount.Minor
+		}
+	}
+	if rec.Discount.IsZero() || goods <= 0 {
+		return
+	}
+	left, largest := rec.Discount, -1
+	for i := range rec.Items {
+		it := &rec.Items[i]
+		if !it.Taxable() {
+			continue
+		}
+		it.Discount = rec.Discount.Ratio(it.Amount.Minor, goods, money.Down)
+		left = left.Sub(it.Discount)
+		if largest < 0 || it.Amount.Cmp(rec.Items[largest].Amount) > 0 {
+			largest = i
+		}
+	}
+	if largest >= 0 {
+		rec.Items[largest].Discount = rec.Items[largest].Discount.Add(left)
+	}
+}
+
+// sameCurrency проверяет, что сумма из запроса в валюте чека.
+func sameCurrency(m money.Money, currency string) error {
+	if m.Currency != "" && m.Currency != currency {
+		return fmt.Errorf("%w: currency must be %s", ErrInvalidCheckout, currency)
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/payments"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockCheckoutRepo struct {
+	mock.Mock
+}
+
+func (m *MockCheckoutRepo) GetBookingByID(id string) (*models.Booking, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+func (m *MockCheckoutRepo) GetStaffByID(id string) (*models.Staff, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Staff), args.Error(1)
+}
+func (m *MockCheckoutRepo) GetPaymentsByBooking(bookingID uint) ([]models.Payment, error) {
+	args := m.Called(bookingID)
+	return args.Get(0).([]models.Payment), args.Error(1)
+}
+func (m *MockCheckoutRepo) CreateReceipt(rec *models.Receipt) error { return m.Called(rec).Error(0) }
+func (m *MockCheckoutRepo) GetReceiptByBooking(bookingID string) (*models.Receipt, error) {
+	args := m.Called(bookingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Receipt), args.Error(1)
+}
+func (m *MockCheckoutRepo) GetReceiptsByUser(userID uint) ([]models.Receipt, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Receipt), args.Error(1)
+}
+
+type MockOnlineRefunds struct {
+	mock.Mock
+}
+
+func (m *MockOnlineRefunds) Refund(ctx context.Context, paymentID string, amount money.Money, reason string, by uint) (*models.PaymentRefund, error) {
+	args := m.Called(ctx, paymentID, amount, reason, by)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentRefund), args.Error(1)
+}
+
+func checkoutBooking() *models.Booking {
+	b := &models.Booking{UserID: 4, StaffID: 2, Status: "confirmed",
+		Service: models.Service{Title: "Стрижка", Price: money.New(500050, "KZT")}, Staff: models.Staff{FullName: "Ольга"}}
+	b.ID = 1
+	return b
+}
+
+func kzt(minor int64) money.Money { return money.New(minor, "KZT") }
+
+func TestCheckout(t *testing.T) {
+	t.Run("Split Tender With Change", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		svc := NewCheckoutService(repo)
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{
+			Items:     []CheckoutItem{{Kind: "product", Title: "Шампунь", Quantity: 2, UnitPrice: kzt(150000)}},
+			Discounts: []CheckoutDiscount{{Title: "Постоянный клиент", Percent: 10}},
+			Tip:       kzt(50000),
+			Tenders:   []CheckoutTender{{Method: "card", Amount: kzt(500000)}, {Method: "cash", Amount: kzt(300000)}},
+		}, 9)
+		require.NoError(t, err)
+		// 5000.50 + 2×1500 = 8000.50; скидка 10% = 800.05; чаевые 500.
+		assert.Equal(t, kzt(800050), rec.Subtotal)
+		assert.Equal(t, kzt(80005), rec.Discount)
+		assert.Equal(t, kzt(770045), rec.Total)
+		assert.Equal(t, kzt(29955), rec.Change)
+		assert.Len(t, rec.Items, 4)
+		assert.Equal(t, "Ольга", rec.Items[3].Title)
+		assert.Equal(t, uint(9), rec.CreatedBy)
+	})
+
+	t.Run("VAT On Top", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		taxes := new(MockTaxRepo)
+		svc := NewCheckoutService(repo)
+		svc.SetTaxes(NewTaxService(taxes, TaxExclusive))
+		taxes.On("GetTaxRates").Return(testRates, nil).Once()
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{
+			Items:     []CheckoutItem{{Kind: "product", Title: "Пластырь", Category: "medical", Quantity: 2, UnitPrice: kzt(150000)}},
+			Discounts: []CheckoutDiscount{{Percent: 10}},
+			Tenders:   []CheckoutTender{{Method: "card", Amount: kzt(774050)}},
+		}, 9)
+		require.NoError(t, err)
+		// 8000.50 - 800.05 + НДС 540.05 (12% с 4500.45 за услугу, пластырь без НДС).
+		assert.Equal(t, kzt(54005), rec.Tax)
+		assert.Equal(t, kzt(774050), rec.Total)
+		assert.Equal(t, TaxExclusive, rec.TaxMode)
+		assert.Equal(t, kzt(50005), rec.Items[0].Discount)
+	})
+
+	t.Run("Credits Deposit", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		svc := NewCheckoutService(repo)
+		deposit := models.Payment{Amount: kzt(100000), Status: "succeeded", Kind: "deposit"}
+		deposit.ID = 3
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{{Status: "failed", Amount: kzt(100)}, deposit}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{Tenders: []CheckoutTender{{Method: "card", Amount: kzt(400050)}}}, 9)
+		require.NoError(t, err)
+		require.Len(t, rec.Tenders, 2)
+		assert.Equal(t, TenderOnline, rec.Tenders[0].Method)
+		assert.Equal(t, kzt(100000), rec.Tenders[0].Amount)
+		assert.Equal(t, uint(3), *rec.Tenders[0].PaymentID)
+		assert.True(t, rec.Change.IsZero())
+	})
+
+	t.Run("Gift Cards", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		cards := new(MockGiftCardRepo)
+		svc := NewCheckoutService(repo)
+		svc.SetGiftCards(newGiftCardService(cards))
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+		cards.On("GetGiftCardByCode", "ABCD2345EFGH6789").Return(giftCard(300000, giftNow.Add(time.Hour)), nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{
+			Items:     []CheckoutItem{{Kind: "gift_card", Quantity: 2, UnitPrice: kzt(1000000)}},
+			Discounts: []CheckoutDiscount{{Percent: 10}},
+			Tenders: []CheckoutTender{{Method: "gift_card", Amount: kzt(300000), Reference: "abcd-2345-efgh-6789"},
+				{Method: "card", Amount: kzt(2150045)}},
+		}, 9)
+		require.NoError(t, err)
+		// Скидка 10% только от услуги: номинал карт не уменьшается.
+		assert.Equal(t, kzt(50005), rec.Discount)
+		assert.Equal(t, kzt(2450045), rec.Total)
+		assert.True(t, rec.Items[1].Discount.IsZero())
+		require.Len(t, rec.GiftCards, 2)
+		assert.NotEqual(t, rec.GiftCards[0].Code, rec.GiftCards[1].Code)
+		assert.Equal(t, kzt(1000000), rec.GiftCards[0].Balance)
+		assert.Equal(t, "ABCD2345EFGH6789", rec.Tenders[0].Reference)
+	})
+
+	t.Run("Promo Code From Booking", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		discounts := new(MockDiscountRepo)
+		svc := NewCheckoutService(repo)
+		svc.SetDiscounts(NewDiscountService(discounts))
+		b := checkoutBooking()
+		b.PromoCode = "SPRING"
+		promo := discountRule(7, models.DiscountRule{Name: "Весна", Code: "SPRING", Kind: "percent", Percent: 20, Categories: "cosmetics"})
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+		discounts.On("GetDiscountRules").Return([]models.DiscountRule{}, nil).Once()
+		discounts.On("GetDiscountRuleByCode", "SPRING").Return(&promo, nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{
+			Items:   []CheckoutItem{{Kind: "product", Title: "Крем", Category: "cosmetics", UnitPrice: kzt(300000)}},
+			Tenders: []CheckoutTender{{Method: "card", Amount: kzt(740050)}},
+		}, 9)
+		require.NoError(t, err)
+		// 20% только от крема.
+		assert.Equal(t, kzt(60000), rec.Discount)
+		assert.Equal(t, "Весна", rec.Items[2].Title)
+		require.Len(t, rec.Redemptions, 1)
+		assert.Equal(t, models.DiscountRedemption{RuleID: 7, UserID: 4, Amount: kzt(60000)}, rec.Redemptions[0])
+	})
+
+	t.Run("Loyalty Tier And Points", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		loyalty := new(MockLoyaltyRepo)
+		svc := NewCheckoutService(repo)
+		svc.SetLoyalty(newLoyaltyService(loyalty))
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+		loyalty.On("GetLoyaltyEarned", uint(4), mock.Anything).Return(int64(2500), nil).Once()
+		loyalty.On("GetLoyaltyBalance", uint(4), loyaltyNow).Return(int64(1000), nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{Points: 1000, Tip: kzt(10000),
+			Tenders: []CheckoutTender{{Method: "cash", Amount: kzt(385047)}}}, 9)
+		require.NoError(t, err)
+		// Gold: 5% от 5000.50 = 250.03, ещё 1000 баллов по 1 ₸.
+		assert.Equal(t, kzt(125003), rec.Discount)
+		assert.Equal(t, "Скидка уровня gold", rec.Items[1].Title)
+		assert.Equal(t, int64(1000), rec.PointsRedeemed)
+		assert.Equal(t, kzt(385047), rec.Total)
+	})
+
+	t.Run("Line Titles In Client Locale", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		loyalty := new(MockLoyaltyRepo)
+		svc := NewCheckoutService(repo)
+		svc.SetLoyalty(newLoyaltyService(loyalty))
+		b := checkoutBooking()
+		b.User.Locale = "kk"
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+		loyalty.On("GetLoyaltyEarned", uint(4), mock.Anything).Return(int64(2500), nil).Once()
+		loyalty.On("GetLoyaltyBalance", uint(4), loyaltyNow).Return(int64(0), nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{Tenders: []CheckoutTender{{Method: "cash", Amount: kzt(475047)}}}, 9)
+		require.NoError(t, err)
+		assert.Equal(t, "gold деңгейінің жеңілдігі", rec.Items[1].Title)
+	})
+
+	t.Run("Packages", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		packages := new(MockPackageRepo)
+		svc := NewCheckoutService(repo)
+		svc.SetPackages(newPackageService(packages))
+		b := checkoutBooking()
+		session := uint(5)
+		b.PackageSessionID = &session
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+		packages.On("GetPackageByID", uint(3)).Return(laserPackage(), nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{
+			Items:   []CheckoutItem{{Kind: "package", PackageID: 3}},
+			Tenders: []CheckoutTender{{Method: "card", Amount: kzt(10000000)}},
+		}, 9)
+		require.NoError(t, err)
+		// Визит оплачен сеансом пакета, в чеке только новый пакет.
+		assert.True(t, rec.Items[0].Amount.IsZero())
+		assert.Equal(t, "Стрижка (по пакету)", rec.Items[0].Title)
+		assert.Equal(t, "package", rec.Items[1].Kind)
+		assert.Equal(t, kzt(10000000), rec.Total)
+		require.Len(t, rec.Packages, 1)
+		assert.Equal(t, 5, rec.Packages[0].Sessions[0].Remaining)
+	})
+
+	t.Run("Membership", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		memberships := new(MockMembershipRepo)
+		svc := NewCheckoutService(repo)
+		svc.SetMemberships(newMembershipService(memberships, payments.NewFakeProvider("secret"), &fakeClock{t: membershipNow}))
+		b := checkoutBooking()
+		sub := uint(3)
+		b.SubscriptionID = &sub
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+		memberships.On("GetCurrentSubscription", uint(4)).Return(activeSubscription(), nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{
+			Items:   []CheckoutItem{{Kind: "product", Title: "Крем", UnitPrice: kzt(300000)}},
+			Tenders: []CheckoutTender{{Method: "card", Amount: kzt(270000)}},
+		}, 9)
+		require.NoError(t, err)
+		// Укладка входит в абонемент, на крем — 10%.
+		assert.Equal(t, "Стрижка (по абонементу)", rec.Items[0].Title)
+		assert.Equal(t, kzt(30000), rec.Discount)
+		assert.Equal(t, "Скидка по абонементу «Укладки»", rec.Items[2].Title)
+		assert.Equal(t, kzt(270000), rec.Total)
+	})
+
+	t.Run("Points Without Loyalty", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		svc := NewCheckoutService(repo)
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+
+		_, err := svc.Checkout("1", CheckoutInput{Points: 10}, 9)
+		assert.ErrorIs(t, err, ErrInvalidCheckout)
+	})
+
+	t.Run("Gift Card Balance Too Low", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		cards := new(MockGiftCardRepo)
+		svc := NewCheckoutService(repo)
+		svc.SetGiftCards(newGiftCardService(cards))
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		cards.On("GetGiftCardByCode", "ABCD2345EFGH6789").Return(giftCard(100000, giftNow.Add(time.Hour)), nil).Once()
+
+		_, err := svc.Checkout("1", CheckoutInput{Tenders: []CheckoutTender{
+			{Method: "gift_card", Amount: kzt(500050), Reference: "ABCD2345EFGH6789"}}}, 9)
+		assert.ErrorIs(t, err, ErrGiftCardInsufficient)
+		repo.AssertNotCalled(t, "CreateReceipt", mock.Anything)
+	})
+
+	t.Run("Not Enough", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		svc := NewCheckoutService(repo)
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+
+		_, err := svc.Checkout("1", CheckoutInput{Tenders: []CheckoutTender{{Method: "cash", Amount: kzt(100)}}}, 9)
+		assert.ErrorIs(t, err, ErrInsufficientTender)
+		repo.AssertNotCalled(t, "CreateReceipt", mock.Anything)
+	})
+
+	t.Run("Tips For Several Masters", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		svc := NewCheckoutService(repo)
+		st := &models.Staff{FullName: "Айгерим"}
+		st.ID = 5
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetStaffByID", "5").Return(st, nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+
+		rec, err := svc.Checkout("1", CheckoutInput{
+			Items:   []CheckoutItem{{Kind: "addon", Title: "Укладка", UnitPrice: kzt(100000)}},
+			Tip:     kzt(50000),
+			Tips:    []CheckoutTip{{StaffID: 5, Amount: kzt(20000)}},
+			Tenders: []CheckoutTender{{Method: "card", Amount: kzt(670050)}},
+		}, 9)
+		require.NoError(t, err)
+		assert.Equal(t, kzt(70000), rec.Tips)
+		assert.Equal(t, kzt(670050), rec.Total)
+		require.Len(t, rec.Items, 4)
+		assert.Equal(t, uint(2), *rec.Items[0].StaffID)
+		assert.Equal(t, uint(2), *rec.Items[1].StaffID)
+		assert.Equal(t, "Ольга", rec.Items[2].Title)
+		assert.Equal(t, uint(2), *rec.Items[2].StaffID)
+		assert.Equal(t, "Айгерим", rec.Items[3].Title)
+		assert.Equal(t, uint(5), *rec.Items[3].StaffID)
+
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetStaffByID", "8").Return(nil, gorm.ErrRecordNotFound).Once()
+		_, err = svc.Checkout("1", CheckoutInput{Tips: []CheckoutTip{{StaffID: 8, Amount: kzt(100)}}}, 9)
+		assert.ErrorIs(t, err, ErrInvalidCheckout)
+	})
+
+	t.Run("Refunds Online Surplus", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		refunds := new(MockOnlineRefunds)
+		svc := NewCheckoutService(repo)
+		paid := models.Payment{Amount: kzt(500050), Status: "succeeded", Kind: "full"}
+		paid.ID = 3
+		b := checkoutBooking()
+		repo.On("GetBookingByID", "1").Return(b, nil)
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{paid}, nil)
+		in := CheckoutInput{Discounts: []CheckoutDiscount{{Title: "Промокод", Amount: kzt(50005)}}}
+
+		// Без возвратов излишек не может остаться незамеченным.
+		_, err := svc.Checkout("1", in, 9)
+		assert.ErrorIs(t, err, ErrInvalidCheckout)
+
+		svc.SetRefunds(refunds)
+		repo.On("CreateReceipt", mock.Anything).Return(nil).Once()
+		refunds.On("Refund", mock.Anything, "3", kzt(50005), "checkout surplus", uint(9)).Return(&models.PaymentRefund{}, nil).Once()
+		rec, err := svc.Checkout("1", in, 9)
+		require.NoError(t, err)
+		require.Len(t, rec.Tenders, 1)
+		assert.Equal(t, kzt(450045), rec.Tenders[0].Amount)
+		refunds.AssertExpectations(t)
+	})
+
+	t.Run("Card Overpayment", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		svc := NewCheckoutService(repo)
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+
+		_, err := svc.Checkout("1", CheckoutInput{Tenders: []CheckoutTender{{Method: "card", Amount: kzt(600000)}}}, 9)
+		assert.ErrorIs(t, err, ErrInvalidCheckout)
+	})
+
+	t.Run("Invalid Input", func(t *testing.T) {
+		for _, in := range []CheckoutInput{
+			{Items: []CheckoutItem{{Kind: "service", Title: "x", UnitPrice: kzt(1)}}},
+			{Items: []CheckoutItem{{Kind: "addon", UnitPrice: kzt(1)}}},
+			{Discounts: []CheckoutDiscount{{Amount: kzt(10), Percent: 5}}},
+			{Discounts: []CheckoutDiscount{{Amount: kzt(600000)}}},
+			{Items: []CheckoutItem{{Kind: "addon", Title: "x", UnitPrice: money.New(1, "USD")}}},
+			{Tip: kzt(-1)},
+			{Items: []CheckoutItem{{Kind: "gift_card", UnitPrice: kzt(1000000)}}},
+		} {
+			repo := new(MockCheckoutRepo)
+			svc := NewCheckoutService(repo)
+			repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+			_, err := svc.Checkout("1", in, 9)
+			assert.ErrorIs(t, err, ErrInvalidCheckout, "%+v", in)
+		}
+	})
+
+	t.Run("Already Checked Out", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		svc := NewCheckoutService(repo)
+		repo.On("GetBookingByID", "1").Return(checkoutBooking(), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreateReceipt", mock.Anything).Return(&pgconn.PgError{Code: "23505"}).Once()
+
+		_, err := svc.Checkout("1", CheckoutInput{Tenders: []CheckoutTender{{Method: "cash", Amount: kzt(500050)}}}, 9)
+		assert.ErrorIs(t, err, ErrAlreadyCheckedOut)
+	})
+
+	t.Run("Cancelled Booking", func(t *testing.T) {
+		repo := new(MockCheckoutRepo)
+		svc := NewCheckoutService(repo)
+		repo.On("GetBookingByID", "1").Return(nil, gorm.ErrRecordNotFound).Once()
+
+		_, err := svc.Checkout("1", CheckoutInput{}, 9)
+		assert.ErrorIs(t, err, ErrBookingNotFound)
+	})
+}
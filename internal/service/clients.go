@@ -0,0 +1,205 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/repository"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrClientNotFound    = errors.New("client not found")
+	ErrNoteNotFound      = errors.New("note not found")
+	ErrInvalidClientCard = errors.New("invalid client card")
+)
+
+const maxNoteLength = 2000
+
+// ClientCard — всё, что мастеру нужно знать о клиенте перед визитом.
+type ClientCard struct {
+	Client  *models.User          `json:"client"`
+	Profile *models.ClientProfile `json:"profile"`
+	Notes   []models.ClientNote   `json:"notes"`
+	History *models.VisitHistory  `json:"history"`
+}
+
+// ClientProfileInput заменяет карточку целиком.
+type ClientProfileInput struct {
+	Allergies         []string `json:"allergies"`
+	Contraindications []string `json:"contraindications"`
+	PreferredStaffID  *uint    `json:"preferred_staff_id"`
+	RiskLevel         string   `json:"risk_level"` // low, medium, high; пусто — low
+}
+
+type ClientCards interface {
+	GetCard(userID uint) (*ClientCard, error)
+	GetCardForBooking(bookingID string) (*ClientCard, error)
+	SaveProfile(userID uint, in ClientProfileInput) (*models.ClientProfile, error)
+	AddNote(userID, authorID uint, text string) (*models.ClientNote, error)
+	DeleteNote(userID uint, noteID string) error
+	GetVisitHistory(userID uint) (*models.VisitHistory, error)
+}
+
+type ClientCardService struct {
+	repo repository.ClientRepository
+	now  func() time.Time
+}
+
+func NewClientCardService(repo repository.ClientRepository) *ClientCardService {
+	return &ClientCardService{repo: repo, now: time.Now}
+}
+
+// RegisterExportSections добавляет карточку и заметки в выгрузку персональных данных.
+func (s *ClientCardService) RegisterExportSections(e *ExportService) {
+	e.AddSection("client_profile", func(id uint) (interface{}, error) { return s.profile(id) })
+	e.AddSection("notes", func(id uint) (interface{}, error) { return s.repo.GetClientNotes(id) })
+}
+
+func (s *ClientCardService) GetCard(userID uint) (*ClientCard, error) {
+	u, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, ErrClientNotFound
+	}
+	p, err := s.profile(userID)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.repo.GetClientNotes(userID)
+	if err != nil {
+		return nil, err
+	}
+	h, err := s.GetVisitHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientCard{Client: u, Profile: p, Notes: notes, History: h}, nil
+}
+
+func (s *ClientCardService) GetCardForBooking(bookingID string) (*ClientCard, error) {
+	b, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, ErrClientNotFound
+	}
+	return s.GetCard(b.UserID)
+}
+
+func (s *ClientCardService) SaveProfile(userID uint, in ClientProfileInput) (*models.ClientProfile, error) {
+	if _, err := s.repo.GetUserByID(userID); err != nil {
+		return nil, ErrClientNotFound
+	}
+	if in.RiskLevel == "" {
+		in.RiskLevel = models.RiskLow
+	}
+	if !validRiskLevel(in.RiskLevel) {
+		return nil, fmt.Errorf("%w: unknown risk_level", ErrInvalidClientCard)
+	}
+	p := &models.ClientProfile{
+		UserID:            userID,
+		Allergies:         joinFlags(in.Allergies),
+		Contraindications: joinFlags(in.Contraindications),
+		PreferredStaffID:  in.PreferredStaffID,
+		RiskLevel:         in.RiskLevel,
+	}
+	if in.PreferredStaffID != nil {
+		st, err := s.repo.GetStaffByID(strconv.FormatUint(uint64(*in.PreferredStaffID), 10))
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown preferred_staff_id", ErrInvalidClientCard)
+		}
+		p.PreferredStaff = st
+	}
+	if err := s.repo.SaveClientProfile(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *ClientCardService) AddNote(userID, authorID uint, text string) (*models.ClientNote, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxNoteLength {
+		return nil, fmt.Errorf("%w: note must be 1-%d characters", ErrInvalidClientCard, maxNoteLength)
+	}
+	if _, err := s.repo.GetUserByID(userID); err != nil {
+		return nil, ErrClientNotFound
+	}
+	n := &models.ClientNote{UserID: userID, AuthorID: authorID, Text: text}
+	if err := s.repo.CreateClientNote(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (s *ClientCardService) DeleteNote(userID uint, noteID string) error {
+	err := s.repo.DeleteClientNote(userID, noteID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNoteNotFound
+	}
+	return err
+}
+
+func (s *ClientCardService) GetVisitHistory(userID uint) (*models.VisitHistory, error) {
+	visits, err := s.repo.GetVisits(userID, s.now().Format("2006-01-02 15:04"))
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := s.repo.GetReceiptsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	h := &models.VisitHistory{TotalVisits: int64(len(visits)), Visits: visits}
+	// Траты — суммы чеков, то есть с учётом скидок, допуслуг и товаров. Чеки
+	// в другой валюте (если салон когда-то её менял) в сумму не входят.
+	for _, rec := range receipts {
+		if h.TotalSpend.SameCurrency(rec.Total) {
+			h.TotalSpend = h.TotalSpend.Add(rec.Total)
+		}
+	}
+	if h.TotalSpend.Currency == "" {
+		h.TotalSpend.Currency = money.DefaultCurrency
+	}
+	if len(visits) > 0 {
+		h.LastVisit = visits[0].Date // отсортированы по убыванию даты
+	}
+	return h, nil
+}
+
+// profile возвращает карточку клиента; если её ещё не заводили — пустую.
+func (s *ClientCardService) profile(userID uint) (*models.ClientProfile, error) {
+	p, err := s.repo.GetClientProfile(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.ClientProfile{UserID: userID, RiskLevel: models.RiskLow}, nil
+	}
+	return p, err
+}
+
+func validRiskLevel(level string) bool {
+	for _, l := range models.RiskLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// joinFlags нормализует список отметок: без пустых значений и повторов.
+func joinFlags(flags []string) string {
+	var out []string
+	seen := map[string]bool{}
+	for _, f := range flags {
+		for _, part := range strings.Split(f, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" || seen[strings.ToLower(part)] {
+				continue
+			}
+			seen[strings.ToLower(part)] = true
+			out = append(out, part)
+		}
+	}
+	return strings.Join(out, ", ")
+}
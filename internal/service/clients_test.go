@@ -0,0 +1,174 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockClientRepo struct {
+	mock.Mock
+}
+
+func (m *MockClientRepo) GetUserByID(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockClientRepo) GetStaffByID(id string) (*models.Staff, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Staff), args.Error(1)
+}
+func (m *MockClientRepo) GetBookingByID(id string) (*models.Booking, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+func (m *MockClientRepo) GetClientProfile(userID uint) (*models.ClientProfile, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientProfile), args.Error(1)
+}
+func (m *MockClientRepo) SaveClientProfile(p *models.ClientProfile) error {
+	return m.Called(p).Error(0)
+}
+func (m *MockClientRepo) GetClientNotes(userID uint) ([]models.ClientNote, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.ClientNote), args.Error(1)
+}
+func (m *MockClientRepo) CreateClientNote(n *models.ClientNote) error { return m.Called(n).Error(0) }
+func (m *MockClientRepo) DeleteClientNote(userID uint, noteID string) error {
+	return m.Called(userID, noteID).Error(0)
+}
+func (m *MockClientRepo) GetVisits(userID uint, before string) ([]models.Booking, error) {
+	args := m.Called(userID, before)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
+func (m *MockClientRepo) GetReceiptsByUser(userID uint) ([]models.Receipt, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Receipt), args.Error(1)
+}
+
+func TestGetClientCard(t *testing.T) {
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := new(MockClientRepo)
+	svc := NewClientCardService(repo)
+	svc.now = func() time.Time { return now }
+
+	repo.On("GetBookingByID", "12").Return(&models.Booking{UserID: 3}, nil).Once()
+	repo.On("GetUserByID", uint(3)).Return(&models.User{Username: "anna"}, nil).Once()
+	repo.On("GetClientProfile", uint(3)).Return(nil, gorm.ErrRecordNotFound).Once()
+	repo.On("GetClientNotes", uint(3)).Return([]models.ClientNote{{Text: "любит чай"}}, nil).Once()
+	repo.On("GetVisits", uint(3), "2025-05-01 10:00").Return([]models.Booking{
+		{Date: "2025-04-20 12:00", Service: models.Service{Price: kzt(500000)}},
+		{Date: "2025-03-02 15:30", Service: models.Service{Price: kzt(250050)}},
+	}, nil).Once()
+	// Траты считаются по чекам, а не по цене услуги из каталога.
+	repo.On("GetReceiptsByUser", uint(3)).Return([]models.Receipt{
+		{Total: kzt(400000)}, {Total: kzt(300050)}, {Total: money.New(1000, "USD")},
+	}, nil).Once()
+
+	card, err := svc.GetCardForBooking("12")
+	require.NoError(t, err)
+	assert.Equal(t, "anna", card.Client.Username)
+	assert.Equal(t, uint(3), card.Profile.UserID)
+	assert.Len(t, card.Notes, 1)
+	assert.Equal(t, int64(2), card.History.TotalVisits)
+	assert.Equal(t, kzt(700050), card.History.TotalSpend)
+	assert.Equal(t, "2025-04-20 12:00", card.History.LastVisit)
+}
+
+func TestGetClientCardUnknownClient(t *testing.T) {
+	repo := new(MockClientRepo)
+	svc := NewClientCardService(repo)
+	repo.On("GetUserByID", uint(9)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+	_, err := svc.GetCard(9)
+	assert.ErrorIs(t, err, ErrClientNotFound)
+}
+
+func TestSaveClientProfile(t *testing.T) {
+	t.Run("Normalizes Flags", func(t *testing.T) {
+		repo := new(MockClientRepo)
+		svc := NewClientCardService(repo)
+		staffID := uint(2)
+		repo.On("GetUserByID", uint(3)).Return(&models.User{}, nil).Once()
+		repo.On("GetStaffByID", "2").Return(&models.Staff{FullName: "Ольга"}, nil).Once()
+		repo.On("SaveClientProfile", mock.MatchedBy(func(p *models.ClientProfile) bool {
+			return p.UserID == 3 && p.Allergies == "Краситель X, латекс" && p.Contraindications == "" && p.RiskLevel == models.RiskLow
+		})).Return(nil).Once()
+
+		p, err := svc.SaveProfile(3, ClientProfileInput{
+			Allergies:        []string{" Краситель X", "латекс, краситель x", ""},
+			PreferredStaffID: &staffID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Ольга", p.PreferredStaff.FullName)
+	})
+
+	t.Run("Unknown Staff", func(t *testing.T) {
+		repo := new(MockClientRepo)
+		svc := NewClientCardService(repo)
+		staffID := uint(99)
+		repo.On("GetUserByID", uint(3)).Return(&models.User{}, nil).Once()
+		repo.On("GetStaffByID", "99").Return(nil, gorm.ErrRecordNotFound).Once()
+
+		_, err := svc.SaveProfile(3, ClientProfileInput{PreferredStaffID: &staffID})
+		assert.ErrorIs(t, err, ErrInvalidClientCard)
+		repo.AssertNotCalled(t, "SaveClientProfile", mock.Anything)
+	})
+
+	t.Run("Unknown Risk Level", func(t *testing.T) {
+		repo := new(MockClientRepo)
+		svc := NewClientCardService(repo)
+		repo.On("GetUserByID", uint(3)).Return(&models.User{}, nil).Once()
+
+		_, err := svc.SaveProfile(3, ClientProfileInput{RiskLevel: "extreme"})
+		assert.ErrorIs(t, err, ErrInvalidClientCard)
+		repo.AssertNotCalled(t, "SaveClientProfile", mock.Anything)
+	})
+}
+
+func TestClientNotes(t *testing.T) {
+	repo := new(MockClientRepo)
+	svc := NewClientCardService(repo)
+
+	_, err := svc.AddNote(3, 1, "   ")
+	assert.ErrorIs(t, err, ErrInvalidClientCard)
+
+	repo.On("GetUserByID", uint(3)).Return(&models.User{}, nil).Once()
+	repo.On("CreateClientNote", mock.MatchedBy(func(n *models.ClientNote) bool {
+		return n.UserID == 3 && n.AuthorID == 1 && n.Text == "аллергия на краситель X"
+	})).Return(nil).Once()
+	n, err := svc.AddNote(3, 1, " аллергия на краситель X ")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), n.AuthorID)
+
+	repo.On("DeleteClientNote", uint(3), "5").Return(gorm.ErrRecordNotFound).Once()
+	assert.ErrorIs(t, svc.DeleteNote(3, "5"), ErrNoteNotFound)
+}
+
+func TestClientCardExportSections(t *testing.T) {
+	repo := new(MockClientRepo)
+	svc := NewClientCardService(repo)
+	exp := NewExportService(new(MockExportRepo))
+	svc.RegisterExportSections(exp)
+
+	assert.Contains(t, exp.sections, "client_profile")
+	assert.Contains(t, exp.sections, "notes")
+}
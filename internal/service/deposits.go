@@ -0,0 +1,177 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidDepositRule  = errors.New("invalid deposit rule")
+	ErrDepositRuleNotFound = errors.New("deposit rule not found")
+)
+
+const (
+	// DefaultDepositPaymentTTL — сколько запись ждёт предоплату, прежде чем отмениться.
+	DefaultDepositPaymentTTL = 30 * time.Minute
+	// DefaultFreeCancellation — отмена раньше, чем за это время до визита, возвращает предоплату.
+	DefaultFreeCancellation = 24 * time.Hour
+)
+
+// DepositRuleInput описывает правило: fixed — сумма amount,
+// percent — percent процентов от цены услуги.
+type DepositRuleInput struct {
+	ServiceID *uint       `json:"service_id"`
+	StaffID   *uint       `json:"staff_id"`
+	RiskLevel string      `json:"risk_level"`
+	Kind      string      `json:"kind"`
+	Amount    money.Money `json:"amount"`
+	Percent   int         `json:"percent"`
+}
+
+type Deposits interface {
+	CreateRule(in DepositRuleInput) (*models.DepositRule, error)
+	GetRules() ([]models.DepositRule, error)
+	DeleteRule(id string) error
+}
+
+type DepositService struct {
+	repo             repository.DepositRepository
+	bookings         BookingStatusUpdater
+	ttl              time.Duration
+	freeCancellation time.Duration
+	now              func() time.Time
+}
+
+func NewDepositService(repo repository.DepositRepository, bookings BookingStatusUpdater, ttl, freeCancellation time.Duration) *DepositService {
+	return &DepositService{repo: repo, bookings: bookings, ttl: ttl, freeCancellation: freeCancellation, now: time.Now}
+}
+
+func (s *DepositService) CreateRule(in DepositRuleInput) (*models.DepositRule, error) {
+	rule := &models.DepositRule{ServiceID: in.ServiceID, StaffID: in.StaffID, RiskLevel: in.RiskLevel, Kind: in.Kind}
+	switch in.Kind {
+	case "fixed":
+		if !in.Amount.IsPositive() {
+			return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidDepositRule)
+		}
+		rule.Amount = in.Amount
+	case "percent":
+		if in.Percent <= 0 || in.Percent > 100 {
+			return nil, fmt.Errorf("%w: percent must be 1-100", ErrInvalidDepositRule)
+		}
+		rule.Percent = in.Percent
+	default:
+		return nil, fmt.Errorf("%w: kind must be fixed or percent", ErrInvalidDepositRule)
+	}
+	if in.RiskLevel != "" && !validRiskLevel(in.RiskLevel) {
+		return nil, fmt.Errorf("%w: unknown risk_level", ErrInvalidDepositRule)
+	}
+	if err := s.repo.CreateDepositRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *DepositService) GetRules() ([]models.DepositRule, error) { return s.repo.GetDepositRules() }
+
+func (s *DepositService) DeleteRule(id string) error {
+	err := s.repo.DeleteDepositRule(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrDepositRuleNotFound
+	}
+	return err
+}
+
+// Apply решает, нужна ли записи предоплата. Если подходит несколько правил,
+// берётся наибольшая сумма. Поля предоплаты из запроса не принимаются.
+func (s *DepositService) Apply(b *models.Booking) error {
+	b.DepositAmount, b.PaymentDueAt = money.Money{}, nil
+	if b.Status == "awaiting_payment" {
+		b.Status = ""
+	}
+	if b.Prepaid() {
+		return nil // визит уже оплачен пакетом или абонементом
+	}
+	rules, err := s.repo.GetDepositRules()
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	svc, err := s.repo.GetServiceByID(strconv.FormatUint(uint64(b.ServiceID), 10))
+	if err != nil {
+		return err
+	}
+	risk := models.RiskLow
+	p, err := s.repo.GetClientProfile(b.UserID)
+	switch {
+	case err == nil && p.RiskLevel != "":
+		risk = p.RiskLevel
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	amount := money.Zero(svc.Price.Currency)
+	for i := range rules {
+		if rules[i].Matches(b, risk) {
+			amount = money.Max(amount, rules[i].DepositFor(svc.Price))
+		}
+	}
+	if !amount.IsPositive() {
+		return nil
+	}
+	now := s.now()
+	due := now.Add(s.ttl)
+	// Ждать оплату дольше начала визита бессмысленно.
+	if at, err := time.ParseInLocation(bookingDateLayout, b.Date, now.Location()); err == nil && at.Before(due) {
+		due = at
+	}
+	b.Status, b.DepositAmount, b.PaymentDueAt = "awaiting_payment", amount, &due
+	return nil
+}
+
+// RetainsDeposit сообщает, что при отмене в момент at предоплата остаётся
+// салону: до визита (date, YYYY-MM-DD HH:MM) меньше бесплатного окна отмены.
+func (s *DepositService) RetainsDeposit(date string, at time.Time) bool {
+	visit, err := time.ParseInLocation(bookingDateLayout, date, s.now().Location())
+	if err != nil {
+		return false
+	}
+	return visit.Sub(at) < s.freeCancellation
+}
+
+// ExpireUnpaid отменяет записи, предоплата по которым не пришла в срок.
+func (s *DepositService) ExpireUnpaid() error {
+	bookings, err := s.repo.GetExpiredUnpaidBookings(s.now())
+	if err != nil {
+		return err
+	}
+	for _, b := range bookings {
+		if err := s.bookings.CancelBooking(strconv.FormatUint(uint64(b.ID), 10)); err != nil {
+			log.Printf("deposits: cancel unpaid booking %d: %v", b.ID, err)
+		}
+	}
+	return nil
+}
+
+// Run отменяет неоплаченные записи, пока не отменён ctx.
+func (s *DepositService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.ExpireUnpaid(); err != nil {
+			log.Printf("deposits: expire unpaid: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockDepositRepo struct {
+	mock.Mock
+}
+
+func (m *MockDepositRepo) GetServiceByID(id string) (*models.Service, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Service), args.Error(1)
+}
+func (m *MockDepositRepo) GetClientProfile(userID uint) (*models.ClientProfile, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientProfile), args.Error(1)
+}
+func (m *MockDepositRepo) CreateDepositRule(r *models.DepositRule) error { return m.Called(r).Error(0) }
+func (m *MockDepositRepo) GetDepositRules() ([]models.DepositRule, error) {
+	args := m.Called()
+	return args.Get(0).([]models.DepositRule), args.Error(1)
+}
+func (m *MockDepositRepo) DeleteDepositRule(id string) error { return m.Called(id).Error(0) }
+func (m *MockDepositRepo) GetExpiredUnpaidBookings(now time.Time) ([]models.Booking, error) {
+	args := m.Called(now)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
+
+var depositNow = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func newTestDeposits() (*DepositService, *MockDepositRepo, *fakeBookings) {
+	repo := new(MockDepositRepo)
+	bookings := &fakeBookings{}
+	svc := NewDepositService(repo, bookings, 30*time.Minute, 24*time.Hour)
+	svc.now = func() time.Time { return depositNow }
+	return svc, repo, bookings
+}
+
+func TestApplyDeposit(t *testing.T) {
+	serviceID, staffID := uint(2), uint(3)
+	rules := []models.DepositRule{
+		{Kind: "percent", Percent: 20},                                   // 20% от 5000.50 = 1000.10
+		{Kind: "fixed", Amount: kzt(300000), StaffID: &staffID},          // 3000
+		{Kind: "fixed", Amount: kzt(900000), RiskLevel: models.RiskHigh}, // больше цены
+		{Kind: "fixed", Amount: kzt(400000), ServiceID: &serviceID, StaffID: &staffID, RiskLevel: models.RiskMedium},
+	}
+	booking := func(staff uint) *models.Booking {
+		return &models.Booking{UserID: 4, ServiceID: serviceID, StaffID: staff, Date: "2026-03-05 12:00", DepositAmount: kzt(1)}
+	}
+
+	t.Run("Largest Matching Rule", func(t *testing.T) {
+		svc, repo, _ := newTestDeposits()
+		repo.On("GetDepositRules").Return(rules, nil).Once()
+		repo.On("GetServiceByID", "2").Return(&models.Service{Price: kzt(500050)}, nil).Once()
+		repo.On("GetClientProfile", uint(4)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		b := booking(3)
+		require.NoError(t, svc.Apply(b))
+		assert.Equal(t, "awaiting_payment", b.Status)
+		assert.Equal(t, kzt(300000), b.DepositAmount)
+		assert.Equal(t, depositNow.Add(30*time.Minute), *b.PaymentDueAt)
+	})
+
+	t.Run("Percent Of Price", func(t *testing.T) {
+		svc, repo, _ := newTestDeposits()
+		repo.On("GetDepositRules").Return(rules, nil).Once()
+		repo.On("GetServiceByID", "2").Return(&models.Service{Price: kzt(500050)}, nil).Once()
+		repo.On("GetClientProfile", uint(4)).Return(&models.ClientProfile{RiskLevel: models.RiskLow}, nil).Once()
+
+		b := booking(7)
+		require.NoError(t, svc.Apply(b))
+		assert.Equal(t, kzt(100010), b.DepositAmount)
+	})
+
+	t.Run("High Risk Capped At Price", func(t *testing.T) {
+		svc, repo, _ := newTestDeposits()
+		repo.On("GetDepositRules").Return(rules, nil).Once()
+		repo.On("GetServiceByID", "2").Return(&models.Service{Price: kzt(500050)}, nil).Once()
+		repo.On("GetClientProfile", uint(4)).Return(&models.ClientProfile{RiskLevel: models.RiskHigh}, nil).Once()
+
+		b := booking(3)
+		require.NoError(t, svc.Apply(b))
+		assert.Equal(t, kzt(500050), b.DepositAmount)
+	})
+
+	t.Run("Due No Later Than Visit", func(t *testing.T) {
+		svc, repo, _ := newTestDeposits()
+		repo.On("GetDepositRules").Return(rules, nil).Once()
+		repo.On("GetServiceByID", "2").Return(&models.Service{Price: kzt(500050)}, nil).Once()
+		repo.On("GetClientProfile", uint(4)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		b := booking(3)
+		b.Date = "2026-03-01 10:15"
+		require.NoError(t, svc.Apply(b))
+		assert.Equal(t, depositNow.Add(15*time.Minute), *b.PaymentDueAt)
+	})
+
+	t.Run("No Rules", func(t *testing.T) {
+		svc, repo, _ := newTestDeposits()
+		repo.On("GetDepositRules").Return([]models.DepositRule{}, nil).Once()
+
+		b := booking(3)
+		b.Status = "awaiting_payment"
+		require.NoError(t, svc.Apply(b))
+		assert.Empty(t, b.Status)
+		assert.True(t, b.DepositAmount.IsZero())
+		assert.Nil(t, b.PaymentDueAt)
+	})
+}
+
+func TestCreateDepositRule(t *testing.T) {
+	svc, repo, _ := newTestDeposits()
+	for _, in := range []DepositRuleInput{
+		{Kind: "fixed"},
+		{Kind: "percent", Percent: 120},
+		{Kind: "share", Amount: kzt(100)},
+		{Kind: "fixed", Amount: kzt(100), RiskLevel: "extreme"},
+	} {
+		_, err := svc.CreateRule(in)
+		assert.ErrorIs(t, err, ErrInvalidDepositRule, "%+v", in)
+	}
+
+	repo.On("CreateDepositRule", mock.MatchedBy(func(r *models.DepositRule) bool {
+		return r.Kind == "percent" && r.Percent == 30 && r.Amount.IsZero()
+	})).Return(nil).Once()
+	_, err := svc.CreateRule(DepositRuleInput{Kind: "percent", Percent: 30, Amount: kzt(500)})
+	require.NoError(t, err)
+}
+
+func TestExpireUnpaid(t *testing.T) {
+	svc, repo, bookings := newTestDeposits()
+	b := models.Booking{Status: "awaiting_payment"}
+	b.ID = 6
+	repo.On("GetExpiredUnpaidBookings", depositNow).Return([]models.Booking{b}, nil).Once()
+
+	require.NoError(t, svc.ExpireUnpaid())
+	assert.Equal(t, []string{"6"}, bookings.cancelled)
+}
+
+func TestRetainsDeposit(t *testing.T) {
+	svc, _, _ := newTestDeposits()
+	assert.False(t, svc.RetainsDeposit("2026-03-02 11:00", depositNow))
+	assert.True(t, svc.RetainsDeposit("2026-03-02 09:00", depositNow))
+	assert.False(t, svc.RetainsDeposit("", depositNow))
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/repository"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidDiscountRule    = errors.New("invalid discount rule")
+	ErrDiscountRuleNotFound   = errors.New("discount rule not found")
+	ErrInvalidQuote           = errors.New("invalid quote request")
+	ErrInvalidPromoCode       = errors.New("promo code is not valid")
+	ErrPromoCodeNotApplicable = errors.New("promo code does not apply")
+	ErrPromoCodeLimit         = repository.ErrDiscountLimit
+)
+
+// Условия, по которым сработало правило, — для пояснения в расчёте цены.
+const (
+	ReasonPromoCode  = "promo_code"
+	ReasonService    = "service"
+	ReasonDates      = "dates"
+	ReasonWeekdays   = "weekdays"
+	ReasonHours      = "hours"
+	ReasonFirstVisit = "first_visit"
+)
+
+// DiscountRuleInput описывает правило: fixed — сумма amount, percent — percent
+// процентов от подходящих строк. Пустой code — правило применяется само.
+type DiscountRuleInput struct {
+	Name             string      `json:"name"`
+	Code             string      `json:"code"`
+	Kind             string      `json:"kind"`
+	Amount           money.Money `json:"amount"`
+	Percent          int         `json:"percent"`
+	ServiceIDs       []uint      `json:"service_ids"`
+	Categories       []string    `json:"categories"`
+	StartsAt         *time.Time  `json:"starts_at"`
+	EndsAt           *time.Time  `json:"ends_at"`
+	Weekdays         []int       `json:"weekdays"` // 1 — понедельник, 7 — воскресенье
+	HourFrom         int         `json:"hour_from"`
+	HourTo           int         `json:"hour_to"`
+	FirstVisitOnly   bool        `json:"first_visit_only"`
+	MaxUses          int         `json:"max_uses"`
+	MaxUsesPerClient int         `json:"max_uses_per_client"`
+}
+
+// DiscountLine — строка визита, на которую может пойти скидка.
+type DiscountLine struct {
+	ServiceID uint // 0 — не услуга
+	Category  string
+	Amount    money.Money
+}
+
+// DiscountTarget — визит, для которого подбираются скидки.
+type DiscountTarget struct {
+	UserID    uint
+	Date      string // YYYY-MM-DD HH:MM
+	PromoCode string
+	Lines     []DiscountLine
+}
+
+// AppliedDiscount — скидка по правилу и условия, по которым оно сработало.
+type AppliedDiscount struct {
+	RuleID  uint        `json:"rule_id"`
+	Name    string      `json:"name"`
+	Code    string      `json:"code,omitempty"`
+	Amount  money.Money `json:"amount"`
+	Reasons []string    `json:"reasons,omitempty"`
+}
+
+type QuoteInput struct {
+	ServiceID uint   `json:"service_id"`
+	Date      string `json:"date"` // YYYY-MM-DD HH:MM
+	PromoCode string `json:"promo_code"`
+}
+
+// Quote — цена услуги со скидками и их пояснением.
+type Quote struct {
+	Price     money.Money       `json:"price"`
+	Discounts []AppliedDiscount `json:"discounts"`
+	Discount  money.Money       `json:"discount"`
+	Total     money.Money       `json:"total"`
+}
+
+type Discounts interface {
+	CreateRule(in DiscountRuleInput) (*models.DiscountRule, error)
+	GetRules() ([]models.DiscountRule, error)
+	DeleteRule(id string) error
+	Quote(in QuoteInput, userID uint) (*Quote, error)
+}
+
+type DiscountService struct {
+	repo repository.DiscountRepository
+	now  func() time.Time
+}
+
+func NewDiscountService(repo repository.DiscountRepository) *DiscountService {
+	return &DiscountService{repo: repo, now: time.Now}
+}
+
+func (s *DiscountService) CreateRule(in DiscountRuleInput) (*models.DiscountRule, error) {
+	rule := &models.DiscountRule{
+		Name: strings.TrimSpace(in.Name), Code: normalizePromoCode(in.Code), Kind: in.Kind,
+		StartsAt: in.StartsAt, EndsAt: in.EndsAt, HourFrom: in.HourFrom, HourTo: in.HourTo,
+		FirstVisitOnly: in.FirstVisitOnly, MaxUses: in.MaxUses, MaxUsesPerClient: in.MaxUsesPerClient,
+	}
+	if rule.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidDiscountRule)
+	}
+	if len(rule.Code) > 32 || strings.IndexFunc(rule.Code, func(r rune) bool { return !strings.ContainsRune(promoCodeChars, r) }) >= 0 {
+		return nil, fmt.Errorf("%w: code must be up to 32 letters and digits", ErrInvalidDiscountRule)
+	}
+	switch in.Kind {
+	case "fixed":
+		if !in.Amount.IsPositive() {
+			return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidDiscountRule)
+		}
+		rule.Amount = in.Amount
+	case "percent":
+		if in.Percent <= 0 || in.Percent > 100 {
+			return nil, fmt.Errorf("%w: percent must be 1-100", ErrInvalidDiscountRule)
+		}
+		rule.Percent = in.Percent
+	default:
+		return nil, fmt.Errorf("%w: kind must be fixed or percent", ErrInvalidDiscountRule)
+	}
+	if in.StartsAt != nil && in.EndsAt != nil && !in.EndsAt.After(*in.StartsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidDiscountRule)
+	}
+	if (in.HourFrom != 0 || in.HourTo != 0) && (in.HourFrom < 0 || in.HourTo > 24 || in.HourFrom >= in.HourTo) {
+		return nil, fmt.Errorf("%w: hours must satisfy 0 <= hour_from < hour_to <= 24", ErrInvalidDiscountRule)
+	}
+	if in.MaxUses < 0 || in.MaxUsesPerClient < 0 {
+		return nil, fmt.Errorf("%w: usage limits must not be negative", ErrInvalidDiscountRule)
+	}
+
+	var ids, days, categories []string
+	for _, id := range in.ServiceIDs {
+		ids = append(ids, strconv.FormatUint(uint64(id), 10))
+	}
+	for _, d := range in.Weekdays {
+		if d < 1 || d > 7 {
+			return nil, fmt.Errorf("%w: weekdays must be 1-7", ErrInvalidDiscountRule)
+		}
+		days = append(days, strconv.Itoa(d))
+	}
+	for _, c := range in.Categories {
+		if c = strings.TrimSpace(c); c != "" {
+			categories = append(categories, c)
+		}
+	}
+	rule.ServiceIDs, rule.Weekdays, rule.Categories = strings.Join(ids, ","), strings.Join(days, ","), strings.Join(categories, ",")
+
+	if err := s.repo.CreateDiscountRule(rule); err != nil {
+		if repository.IsUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: code is already in use", ErrInvalidDiscountRule)
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *DiscountService) GetRules() ([]models.DiscountRule, error) { return s.repo.GetDiscountRules() }
+
+func (s *DiscountService) DeleteRule(id string) error {
+	err := s.repo.DeleteDiscountRule(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrDiscountRuleNotFound
+	}
+	return err
+}
+
+// Quote считает цену услуги для визита в in.Date с подходящими скидками.
+func (s *DiscountService) Quote(in QuoteInput, userID uint) (*Quote, error) {
+	if in.ServiceID == 0 {
+		return nil, fmt.Errorf("%w: service_id is required", ErrInvalidQuote)
+	}
+	if _, err := time.ParseInLocation(bookingDateLayout, in.Date, s.now().Location()); err != nil {
+		return nil, fmt.Errorf("%w: date must be YYYY-MM-DD HH:MM", ErrInvalidQuote)
+	}
+	svc, err := s.repo.GetServiceByID(strconv.FormatUint(uint64(in.ServiceID), 10))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: service not found", ErrInvalidQuote)
+	}
+	if err != nil {
+		return nil, err
+	}
+	price := svc.Price
+	if price.Currency == "" {
+		price.Currency = money.DefaultCurrency
+	}
+	applied, err := s.Evaluate(DiscountTarget{UserID: userID, Date: in.Date, PromoCode: in.PromoCode,
+		Lines: []DiscountLine{{ServiceID: svc.ID, Category: svc.Category, Amount: price}}})
+	if err != nil {
+		return nil, err
+	}
+	q := &Quote{Price: price, Discounts: applied, Discount: money.Zero(price.Currency)}
+	for _, d := range applied {
+		q.Discount = q.Discount.Add(d.Amount)
+	}
+	q.Total = price.Sub(q.Discount)
+	return q, nil
+}
+
+// Apply считает скидку записи по правилам и промокоду. Скидка из запроса не
+// принимается; неподходящий промокод отклоняет запись.
+func (s *DiscountService) Apply(b *models.Booking) error {
+	b.PromoCode, b.Discount = normalizePromoCode(b.PromoCode), money.Money{}
+	if b.Prepaid() {
+		return nil // визит уже оплачен пакетом
+	}
+	q, err := s.Quote(QuoteInput{ServiceID: b.ServiceID, Date: b.Date, PromoCode: b.PromoCode}, b.UserID)
+	if errors.Is(err, ErrInvalidQuote) && b.PromoCode == "" {
+		// Без промокода неполная запись не повод отказать — скидку посчитает касса.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if q.Discount.IsPositive() {
+		b.Discount = q.Discount
+	}
+	return nil
+}
+
+// Evaluate подбирает скидки для визита: все подходящие правила без кода и
+// правило промокода. Общая скидка не превышает суммы строк. Лимиты
+// использования здесь проверяются без блокировки — окончательно это делает
+// касса при проведении чека.
+func (s *DiscountService) Evaluate(t DiscountTarget) ([]AppliedDiscount, error) {
+	at, err := time.ParseInLocation(bookingDateLayout, t.Date, s.now().Location())
+	if err != nil {
+		at = s.now()
+	}
+	rules, err := s.repo.GetDiscountRules()
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]models.DiscountRule, 0, len(rules))
+	for _, r := range rules {
+		if r.Code == "" {
+			candidates = append(candidates, r)
+		}
+	}
+	code := normalizePromoCode(t.PromoCode)
+	if code != "" {
+		promo, err := s.repo.GetDiscountRuleByCode(code)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidPromoCode
+		}
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, *promo)
+	}
+
+	left := money.Money{}
+	for _, l := range t.Lines {
+		left = left.Add(l.Amount)
+	}
+	var firstVisit *bool
+	var applied []AppliedDiscount
+	for i := range candidates {
+		r := &candidates[i]
+		reasons, why, err := s.matches(r, at, t.UserID, &firstVisit)
+		if err != nil {
+			return nil, err
+		}
+		base := money.Zero(left.Currency)
+		for _, l := range t.Lines {
+			if r.Covers(l.ServiceID, l.Category) {
+				base = base.Add(l.Amount)
+			}
+		}
+		if why == "" && !base.IsPositive() {
+			why = "no matching services"
+		}
+		if why == "" {
+			why, err = s.checkLimits(r, t.UserID)
+			if err != nil {
+				return nil, err
+			}
+			if why != "" && r.Code != "" {
+				return nil, ErrPromoCodeLimit
+			}
+		}
+		if why != "" {
+			if r.Code != "" {
+				return nil, fmt.Errorf("%w: %s", ErrPromoCodeNotApplicable, why)
+			}
+			continue
+		}
+
+		amount := base.Percent(int64(r.Percent), money.DiscountRounding)
+		if r.Kind == "fixed" {
+			if !r.Amount.SameCurrency(base) {
+				continue
+			}
+			amount = money.Min(r.Amount, base)
+		}
+		amount = money.Min(amount, left)
+		if !amount.IsPositive() {
+			continue
+		}
+		left = left.Sub(amount)
+		if r.Code != "" {
+			reasons = append([]string{ReasonPromoCode}, reasons...)
+		}
+		if r.ServiceIDs != "" || r.Categories != "" {
+			reasons = append(reasons, ReasonService)
+		}
+		applied = append(applied, AppliedDiscount{RuleID: r.ID, Name: r.Name, Code: r.Code, Amount: amount, Reasons: reasons})
+	}
+	return applied, nil
+}
+
+// matches проверяет условия правила по времени визита и истории клиента.
+// Возвращает сработавшие условия или причину, по которой правило не подошло.
+func (s *DiscountService) matches(r *models.DiscountRule, at time.Time, userID uint, firstVisit **bool) ([]string, string, error) {
+	if !r.ActiveAt(at) {
+		return nil, "outside the validity period", nil
+	}
+	var reasons []string
+	if r.StartsAt != nil || r.EndsAt != nil {
+		reasons = append(reasons, ReasonDates)
+	}
+	if r.Weekdays != "" {
+		reasons = append(reasons, ReasonWeekdays)
+	}
+	if r.HourFrom != 0 || r.HourTo != 0 {
+		reasons = append(reasons, ReasonHours)
+	}
+	if r.FirstVisitOnly {
+		if *firstVisit == nil {
+			n, err := s.repo.CountCompletedVisits(userID)
+			if err != nil {
+				return nil, "", err
+			}
+			first := n == 0
+			*firstVisit = &first
+		}
+		if !**firstVisit {
+			return nil, "not the first visit", nil
+		}
+		reasons = append(reasons, ReasonFirstVisit)
+	}
+	return reasons, "", nil
+}
+
+func (s *DiscountService) checkLimits(r *models.DiscountRule, userID uint) (string, error) {
+	if r.MaxUses == 0 && r.MaxUsesPerClient == 0 {
+		return "", nil
+	}
+	total, byUser, err := s.repo.CountDiscountRedemptions(r.ID, userID)
+	if err != nil {
+		return "", err
+	}
+	if (r.MaxUses > 0 && total >= int64(r.MaxUses)) || (r.MaxUsesPerClient > 0 && byUser >= int64(r.MaxUsesPerClient)) {
+		return "usage limit reached", nil
+	}
+	return "", nil
+}
+
+const promoCodeChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
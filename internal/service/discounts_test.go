@@ -0,0 +1,177 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockDiscountRepo struct {
+	mock.Mock
+}
+
+func (m *MockDiscountRepo) GetServiceByID(id string) (*models.Service, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Service), args.Error(1)
+}
+func (m *MockDiscountRepo) CreateDiscountRule(r *models.DiscountRule) error {
+	return m.Called(r).Error(0)
+}
+func (m *MockDiscountRepo) GetDiscountRules() ([]models.DiscountRule, error) {
+	args := m.Called()
+	return args.Get(0).([]models.DiscountRule), args.Error(1)
+}
+func (m *MockDiscountRepo) GetDiscountRuleByCode(code string) (*models.DiscountRule, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DiscountRule), args.Error(1)
+}
+func (m *MockDiscountRepo) DeleteDiscountRule(id string) error { return m.Called(id).Error(0) }
+func (m *MockDiscountRepo) CountDiscountRedemptions(ruleID, userID uint) (int64, int64, error) {
+	args := m.Called(ruleID, userID)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+func (m *MockDiscountRepo) CountCompletedVisits(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func discountRule(id uint, r models.DiscountRule) models.DiscountRule {
+	r.ID = id
+	return r
+}
+
+// Понедельник, 2 марта 2026, утро.
+const mondayMorning = "2026-03-02 10:00"
+
+func TestCreateDiscountRule(t *testing.T) {
+	repo := new(MockDiscountRepo)
+	svc := NewDiscountService(repo)
+	for _, in := range []DiscountRuleInput{
+		{Kind: "percent", Percent: 10},
+		{Name: "x", Kind: "percent", Percent: 101},
+		{Name: "x", Kind: "fixed"},
+		{Name: "x", Kind: "percent", Percent: 10, Code: "СКИДКА"},
+		{Name: "x", Kind: "percent", Percent: 10, Weekdays: []int{0}},
+		{Name: "x", Kind: "percent", Percent: 10, HourFrom: 18, HourTo: 12},
+		{Name: "x", Kind: "percent", Percent: 10, MaxUses: -1},
+	} {
+		_, err := svc.CreateRule(in)
+		assert.ErrorIs(t, err, ErrInvalidDiscountRule, "%+v", in)
+	}
+
+	repo.On("CreateDiscountRule", &models.DiscountRule{Name: "Утро будней", Code: "MORNING", Kind: "percent", Percent: 15,
+		ServiceIDs: "1,4", Categories: "hair", Weekdays: "1,2,3,4,5", HourFrom: 9, HourTo: 12}).Return(nil).Once()
+	_, err := svc.CreateRule(DiscountRuleInput{Name: " Утро будней ", Code: " morning ", Kind: "percent", Percent: 15,
+		ServiceIDs: []uint{1, 4}, Categories: []string{" hair ", ""}, Weekdays: []int{1, 2, 3, 4, 5}, HourFrom: 9, HourTo: 12})
+	require.NoError(t, err)
+
+	repo.On("DeleteDiscountRule", "9").Return(gorm.ErrRecordNotFound).Once()
+	assert.ErrorIs(t, svc.DeleteRule("9"), ErrDiscountRuleNotFound)
+}
+
+func TestEvaluateDiscounts(t *testing.T) {
+	lines := []DiscountLine{{ServiceID: 1, Category: "hair", Amount: kzt(500000)}, {Category: "cosmetics", Amount: kzt(300000)}}
+
+	t.Run("Automatic Rules", func(t *testing.T) {
+		repo := new(MockDiscountRepo)
+		repo.On("GetDiscountRules").Return([]models.DiscountRule{
+			discountRule(1, models.DiscountRule{Name: "Утро будней", Kind: "percent", Percent: 10, Weekdays: "1,2,3,4,5", HourFrom: 9, HourTo: 12}),
+			discountRule(2, models.DiscountRule{Name: "Выходные", Kind: "percent", Percent: 20, Weekdays: "6,7"}),
+			discountRule(3, models.DiscountRule{Name: "Уход", Kind: "fixed", Amount: kzt(50000), Categories: "cosmetics"}),
+			discountRule(4, models.DiscountRule{Name: "Промо", Code: "SPRING", Kind: "percent", Percent: 50}),
+		}, nil).Once()
+
+		applied, err := NewDiscountService(repo).Evaluate(DiscountTarget{UserID: 5, Date: mondayMorning, Lines: lines})
+		require.NoError(t, err)
+		require.Len(t, applied, 2)
+		assert.Equal(t, kzt(80000), applied[0].Amount)
+		assert.Equal(t, []string{ReasonWeekdays, ReasonHours}, applied[0].Reasons)
+		assert.Equal(t, kzt(50000), applied[1].Amount)
+		assert.Equal(t, []string{ReasonService}, applied[1].Reasons)
+	})
+
+	t.Run("Promo Code For First Visit", func(t *testing.T) {
+		repo := new(MockDiscountRepo)
+		promo := discountRule(7, models.DiscountRule{Name: "Первый визит", Code: "HELLO", Kind: "fixed", Amount: kzt(1000000),
+			ServiceIDs: "1", FirstVisitOnly: true, MaxUsesPerClient: 1})
+		repo.On("GetDiscountRules").Return([]models.DiscountRule{promo}, nil).Twice()
+		repo.On("GetDiscountRuleByCode", "HELLO").Return(&promo, nil).Twice()
+		repo.On("CountCompletedVisits", uint(5)).Return(int64(0), nil).Once()
+		repo.On("CountCompletedVisits", uint(6)).Return(int64(2), nil).Once()
+		repo.On("CountDiscountRedemptions", uint(7), uint(5)).Return(int64(10), int64(0), nil).Once()
+		svc := NewDiscountService(repo)
+
+		applied, err := svc.Evaluate(DiscountTarget{UserID: 5, Date: mondayMorning, PromoCode: " hello", Lines: lines})
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		// Фиксированная скидка не больше цены услуги, на которую выдана.
+		assert.Equal(t, kzt(500000), applied[0].Amount)
+		assert.Equal(t, []string{ReasonPromoCode, ReasonFirstVisit, ReasonService}, applied[0].Reasons)
+
+		_, err = svc.Evaluate(DiscountTarget{UserID: 6, Date: mondayMorning, PromoCode: "HELLO", Lines: lines})
+		assert.ErrorIs(t, err, ErrPromoCodeNotApplicable)
+	})
+
+	t.Run("Promo Code Limits", func(t *testing.T) {
+		repo := new(MockDiscountRepo)
+		promo := discountRule(7, models.DiscountRule{Name: "Весна", Code: "SPRING", Kind: "percent", Percent: 10, MaxUses: 100})
+		repo.On("GetDiscountRules").Return([]models.DiscountRule{}, nil).Twice()
+		repo.On("GetDiscountRuleByCode", "SPRING").Return(&promo, nil).Once()
+		repo.On("GetDiscountRuleByCode", "NOPE").Return(nil, gorm.ErrRecordNotFound).Once()
+		repo.On("CountDiscountRedemptions", uint(7), uint(5)).Return(int64(100), int64(0), nil).Once()
+		svc := NewDiscountService(repo)
+
+		_, err := svc.Evaluate(DiscountTarget{UserID: 5, Date: mondayMorning, PromoCode: "spring", Lines: lines})
+		assert.ErrorIs(t, err, ErrPromoCodeLimit)
+		_, err = svc.Evaluate(DiscountTarget{UserID: 5, Date: mondayMorning, PromoCode: "nope", Lines: lines})
+		assert.ErrorIs(t, err, ErrInvalidPromoCode)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		repo := new(MockDiscountRepo)
+		end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+		promo := discountRule(7, models.DiscountRule{Name: "Зима", Code: "WINTER", Kind: "percent", Percent: 10, EndsAt: &end})
+		repo.On("GetDiscountRules").Return([]models.DiscountRule{}, nil).Once()
+		repo.On("GetDiscountRuleByCode", "WINTER").Return(&promo, nil).Once()
+
+		_, err := NewDiscountService(repo).Evaluate(DiscountTarget{Date: mondayMorning, PromoCode: "WINTER", Lines: lines})
+		assert.ErrorIs(t, err, ErrPromoCodeNotApplicable)
+	})
+}
+
+func TestQuote(t *testing.T) {
+	repo := new(MockDiscountRepo)
+	svc := NewDiscountService(repo)
+	_, err := svc.Quote(QuoteInput{ServiceID: 1, Date: "завтра"}, 5)
+	assert.ErrorIs(t, err, ErrInvalidQuote)
+
+	service := &models.Service{Title: "Стрижка", Category: "hair", Price: kzt(500050)}
+	service.ID = 1
+	repo.On("GetServiceByID", "1").Return(service, nil).Twice()
+	repo.On("GetDiscountRules").Return([]models.DiscountRule{
+		discountRule(1, models.DiscountRule{Name: "Стрижки", Kind: "percent", Percent: 10, Categories: "hair"}),
+	}, nil).Twice()
+
+	q, err := svc.Quote(QuoteInput{ServiceID: 1, Date: mondayMorning}, 5)
+	require.NoError(t, err)
+	assert.Equal(t, kzt(500050), q.Price)
+	assert.Equal(t, kzt(50005), q.Discount)
+	assert.Equal(t, kzt(450045), q.Total)
+	require.Len(t, q.Discounts, 1)
+	assert.Equal(t, "Стрижки", q.Discounts[0].Name)
+
+	b := &models.Booking{UserID: 5, ServiceID: 1, Date: mondayMorning, Discount: kzt(100)}
+	require.NoError(t, svc.Apply(b))
+	assert.Equal(t, kzt(50005), b.Discount)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"archive/zip"
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExportNotFound = errors.New("export not found")
+	ErrExportExpired  = errors.New("export link has expired")
+)
+
+const (
+	exportLinkTTL = 24 * time.Hour
+	exportLease   = 10 * time.Minute // столько заявка закреплена за обработчиком
+)
+
+// ExportSection собирает данные пользователя для одного файла архива.
+// Модули (платежи, заметки, уведомления) регистрируют свои разделы через AddSection.
+type ExportSection func(userID uint) (interface{}, error)
+
+type DataExports interface {
+	RequestExport(userID, requestedBy uint) (*models.DataExport, error)
+	GetExport(id string) (*models.DataExport, error)
+	DownloadExport(token string) ([]byte, error)
+}
+
+type ExportService struct {
+	repo     repository.ExportRepository
+	mu       sync.RWMutex // sections: модули регистрируются, пока Run уже может собирать архив
+	sections map[string]ExportSection
+	now      func() time.Time
+}
+
+func NewExportService(repo repository.ExportRepository) *ExportService {
+	s := &ExportService{repo: repo, sections: map[string]ExportSection{}, now: time.Now}
+	s.AddSection("profile", func(id uint) (interface{}, error) { return repo.GetUserByID(id) })
+	s.AddSection("bookings", func(id uint) (interface{}, error) { return repo.GetBookingsByUser(id) })
+	s.AddSection("identities", func(id uint) (interface{}, error) { return repo.GetIdentitiesByUser(id) })
+	s.AddSection("consents", func(id uint) (interface{}, error) { return repo.GetConsentsByUser(id) })
+	return s
+}
+
+// AddSection добавляет в архив файл <name>.json.
+func (s *ExportService) AddSection(name string, fn ExportSection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sections[name] = fn
+}
+
+// RequestExport ставит выгрузку в очередь. Если заявка уже в работе или архив
+// ещё можно скачать, возвращается она — повторные запросы не копят архивы в базе.
+func (s *ExportService) RequestExport(userID, requestedBy uint) (*models.DataExport, error) {
+	if _, err := s.repo.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+	if e, err := s.repo.GetActiveExport(userID, s.now()); err == nil {
+		return e, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	e := &models.DataExport{UserID: userID, RequestedBy: requestedBy, Status: "pending"}
+	if err := s.repo.CreateExport(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (s *ExportService) GetExport(id string) (*models.DataExport, error) {
+	e, err := s.repo.GetExportByID(id)
+	if err != nil {
+		return nil, ErrExportNotFound
+	}
+	return e, nil
+}
+
+func (s *ExportService) DownloadExport(token string) ([]byte, error) {
+	if token == "" {
+		return nil, ErrExportNotFound
+	}
+	e, err := s.repo.GetExportByToken(token)
+	if err != nil {
+		return nil, ErrExportNotFound
+	}
+	if e.ExpiresAt == nil || !s.now().Before(*e.ExpiresAt) {
+		return nil, ErrExportExpired
+	}
+	return e.Archive, nil
+}
+
+// Run обрабатывает заявки в фоне, пока не отменён ctx.
+func (s *ExportService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for s.ProcessNext() {
+		}
+		if err := s.repo.PurgeExpiredExports(s.now()); err != nil {
+			log.Printf("export: purge failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNext собирает архив для одной заявки. Возвращает false, если очередь пуста.
+func (s *ExportService) ProcessNext() bool {
+	e, err := s.repo.ClaimPendingExport(s.now(), exportLease)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("export: claim failed: %v", err)
+		}
+		return false
+	}
+
+	archive, err := s.build(e.UserID)
+	if err != nil {
+		e.Status, e.Error = "failed", err.Error()
+	} else {
+		expires := s.now().Add(exportLinkTTL)
+		e.Status, e.Archive, e.Token, e.ExpiresAt = "ready", archive, auth.RandomToken(32), &expires
+	}
+	if err := s.repo.SaveExport(e); err != nil {
+		log.Printf("export %d: save failed: %v", e.ID, err)
+	}
+	return true
+}
+
+func (s *ExportService) build(userID uint) ([]byte, error) {
+	s.mu.RLock()
+	sections := make(map[string]ExportSection, len(s.sections))
+	names := make([]string, 0, len(s.sections))
+	for name, fn := range s.sections {
+		sections[name] = fn
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		data, err := sections[name](userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeJSONFile(zw, name+".json", data); err != nil {
+			return nil, err
+		}
+	}
+	manifest := map[string]interface{}{
+		"user_id":      strconv.FormatUint(uint64(userID), 10),
+		"generated_at": s.now().UTC().Format(time.RFC3339),
+		"files":        names,
+	}
+	if err := writeJSONFile(zw, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSONFile(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
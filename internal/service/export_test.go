@@ -0,0 +1,206 @@
+package service
+
+import (
+	"archive/zip"
+	"beauty-salon/internal/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockExportRepo struct {
+	mock.Mock
+}
+
+func (m *MockExportRepo) GetUserByID(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockExportRepo) GetBookingsByUser(userID uint) ([]models.Booking, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
+func (m *MockExportRepo) GetIdentitiesByUser(userID uint) ([]models.UserIdentity, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.UserIdentity), args.Error(1)
+}
+func (m *MockExportRepo) GetConsentsByUser(userID uint) ([]models.Consent, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Consent), args.Error(1)
+}
+func (m *MockExportRepo) CreateExport(e *models.DataExport) error { return m.Called(e).Error(0) }
+func (m *MockExportRepo) GetActiveExport(userID uint, now time.Time) (*models.DataExport, error) {
+	args := m.Called(userID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+func (m *MockExportRepo) GetExportByID(id string) (*models.DataExport, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+func (m *MockExportRepo) GetExportByToken(token string) (*models.DataExport, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+func (m *MockExportRepo) ClaimPendingExport(now time.Time, lease time.Duration) (*models.DataExport, error) {
+	args := m.Called(now, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+func (m *MockExportRepo) SaveExport(e *models.DataExport) error   { return m.Called(e).Error(0) }
+func (m *MockExportRepo) PurgeExpiredExports(now time.Time) error { return m.Called(now).Error(0) }
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	return files
+}
+
+func TestRequestExport(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		repo := new(MockExportRepo)
+		svc := NewExportService(repo)
+		repo.On("GetUserByID", uint(3)).Return(&models.User{}, nil).Once()
+		repo.On("GetActiveExport", uint(3), mock.Anything).Return(nil, gorm.ErrRecordNotFound).Once()
+		repo.On("CreateExport", mock.MatchedBy(func(e *models.DataExport) bool {
+			return e.UserID == 3 && e.RequestedBy == 1 && e.Status == "pending"
+		})).Return(nil).Once()
+
+		e, err := svc.RequestExport(3, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "pending", e.Status)
+	})
+
+	t.Run("Returns Active Export", func(t *testing.T) {
+		repo := new(MockExportRepo)
+		svc := NewExportService(repo)
+		active := &models.DataExport{UserID: 3, Status: "ready"}
+		repo.On("GetUserByID", uint(3)).Return(&models.User{}, nil).Once()
+		repo.On("GetActiveExport", uint(3), mock.Anything).Return(active, nil).Once()
+
+		e, err := svc.RequestExport(3, 3)
+		assert.NoError(t, err)
+		assert.Same(t, active, e)
+		repo.AssertNotCalled(t, "CreateExport", mock.Anything)
+	})
+
+	t.Run("Unknown User", func(t *testing.T) {
+		repo := new(MockExportRepo)
+		svc := NewExportService(repo)
+		repo.On("GetUserByID", uint(9)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		_, err := svc.RequestExport(9, 9)
+		assert.Error(t, err)
+		repo.AssertNotCalled(t, "CreateExport", mock.Anything)
+	})
+}
+
+func TestProcessNextExport(t *testing.T) {
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Builds Archive", func(t *testing.T) {
+		repo := new(MockExportRepo)
+		svc := NewExportService(repo)
+		svc.now = func() time.Time { return now }
+		svc.AddSection("notes", func(id uint) (interface{}, error) { return []string{"allergic to dye X"}, nil })
+
+		e := &models.DataExport{UserID: 3, Status: "processing"}
+		repo.On("ClaimPendingExport", now, exportLease).Return(e, nil).Once()
+		repo.On("GetUserByID", uint(3)).Return(&models.User{Username: "anna"}, nil).Once()
+		repo.On("GetBookingsByUser", uint(3)).Return([]models.Booking{{Date: "2025-04-01 10:00"}}, nil).Once()
+		repo.On("GetIdentitiesByUser", uint(3)).Return([]models.UserIdentity{}, nil).Once()
+		repo.On("GetConsentsByUser", uint(3)).Return([]models.Consent{
+			{UserID: 3, Kind: models.ConsentPersonalData, Granted: true, Source: "sign_up"}}, nil).Once()
+		repo.On("SaveExport", e).Return(nil).Once()
+
+		assert.True(t, svc.ProcessNext())
+		assert.Equal(t, "ready", e.Status)
+		assert.NotEmpty(t, e.Token)
+		assert.Equal(t, now.Add(exportLinkTTL), *e.ExpiresAt)
+
+		files := readZip(t, e.Archive)
+		assert.Contains(t, string(files["profile.json"]), `"username": "anna"`)
+		assert.Contains(t, string(files["bookings.json"]), "2025-04-01 10:00")
+		assert.Contains(t, string(files["notes.json"]), "allergic to dye X")
+		assert.Contains(t, string(files["consents.json"]), `"kind": "personal_data"`)
+
+		var manifest map[string]interface{}
+		require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+		assert.Equal(t, []interface{}{"bookings", "consents", "identities", "notes", "profile"}, manifest["files"])
+	})
+
+	t.Run("Section Error Marks Failed", func(t *testing.T) {
+		repo := new(MockExportRepo)
+		svc := NewExportService(repo)
+		e := &models.DataExport{UserID: 3}
+		repo.On("ClaimPendingExport", mock.Anything, exportLease).Return(e, nil).Once()
+		repo.On("GetBookingsByUser", uint(3)).Return([]models.Booking(nil), errors.New("db error")).Once()
+		repo.On("SaveExport", e).Return(nil).Once()
+
+		assert.True(t, svc.ProcessNext())
+		assert.Equal(t, "failed", e.Status)
+		assert.Equal(t, "db error", e.Error)
+		assert.Empty(t, e.Token)
+	})
+
+	t.Run("Empty Queue", func(t *testing.T) {
+		repo := new(MockExportRepo)
+		svc := NewExportService(repo)
+		repo.On("ClaimPendingExport", mock.Anything, exportLease).Return(nil, gorm.ErrRecordNotFound).Once()
+		assert.False(t, svc.ProcessNext())
+	})
+}
+
+func TestDownloadExport(t *testing.T) {
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := new(MockExportRepo)
+	svc := NewExportService(repo)
+	svc.now = func() time.Time { return now }
+
+	valid := now.Add(time.Hour)
+	expired := now.Add(-time.Hour)
+	repo.On("GetExportByToken", "good").Return(&models.DataExport{Archive: []byte("zip"), ExpiresAt: &valid}, nil)
+	repo.On("GetExportByToken", "old").Return(&models.DataExport{Archive: []byte("zip"), ExpiresAt: &expired}, nil)
+	repo.On("GetExportByToken", "nope").Return(nil, gorm.ErrRecordNotFound)
+
+	data, err := svc.DownloadExport("good")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("zip"), data)
+
+	_, err = svc.DownloadExport("old")
+	assert.ErrorIs(t, err, ErrExportExpired)
+
+	_, err = svc.DownloadExport("nope")
+	assert.ErrorIs(t, err, ErrExportNotFound)
+
+	_, err = svc.DownloadExport("")
+	assert.ErrorIs(t, err, ErrExportNotFound)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/fiscal"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// FiscalRegistrar передаёт чеки в фискальный регистратор (онлайн-ККМ).
+// Повторная передача того же чека должна вернуть прежнюю регистрацию.
+type FiscalRegistrar interface {
+	Register(ctx context.Context, r *fiscal.Receipt) (*fiscal.Registration, error)
+}
+
+type Fiscal interface {
+	GetFiscalReceipt(bookingID string) (*fiscal.Receipt, error)
+}
+
+type FiscalService struct {
+	repo      repository.FiscalRepository
+	registrar FiscalRegistrar
+}
+
+func NewFiscalService(repo repository.FiscalRepository, registrar FiscalRegistrar) *FiscalService {
+	return &FiscalService{repo: repo, registrar: registrar}
+}
+
+// Subscribe регистрирует каждый проведённый на кассе чек. Если регистратор
+// недоступен, событие будет доставлено повторно.
+func (s *FiscalService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.ReceiptCreated, s.onReceiptCreated)
+}
+
+// GetFiscalReceipt возвращает данные чека записи для регистратора — для выгрузки и сверки.
+func (s *FiscalService) GetFiscalReceipt(bookingID string) (*fiscal.Receipt, error) {
+	rec, err := s.repo.GetReceiptByBooking(bookingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fiscalReceipt(rec), nil
+}
+
+func (s *FiscalService) onReceiptCreated(ctx context.Context, e events.Event) error {
+	var p events.ReceiptPayload
+	if err := json.Unmarshal(e.Payload, &p); err != nil {
+		return err
+	}
+	rec, err := s.repo.GetReceiptByID(p.ReceiptID)
+	if err != nil || rec.FiscalID != "" {
+		return err
+	}
+	reg, err := s.registrar.Register(ctx, fiscalReceipt(rec))
+	if err != nil {
+		return err
+	}
+	return s.repo.SetReceiptFiscalID(rec.ID, reg.FiscalID, reg.RegisteredAt)
+}
+
+// fiscalReceipt переводит чек в формат регистратора. Строки скидок в нём не
+// нужны: скидка уже разнесена по позициям.
+func fiscalReceipt(rec *models.Receipt) *fiscal.Receipt {
+	out := &fiscal.Receipt{
+		Number: strconv.FormatUint(uint64(rec.ID), 10), BookingID: rec.BookingID, IssuedAt: rec.CreatedAt, CashierID: rec.CreatedBy,
+		TaxMode: rec.TaxMode, Total: rec.Total, Tax: rec.Tax, Change: rec.Change,
+	}
+	for _, it := range rec.Items {
+		if it.Kind == "discount" {
+			continue
+		}
+		amount := it.Amount.Sub(it.Discount)
+		if rec.TaxMode == TaxExclusive {
+			amount = amount.Add(it.Tax)
+		}
+		out.Items = append(out.Items, fiscal.Item{
+			Name: it.Title, Kind: it.Kind, Quantity: it.Quantity, Price: it.UnitPrice,
+			Discount: it.Discount, Amount: amount, TaxRate: it.TaxRate, Tax: it.Tax,
+		})
+	}
+	for _, t := range rec.Tenders {
+		out.Payments = append(out.Payments, fiscal.Payment{Method: t.Method, Amount: t.Amount})
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/fiscal"
+	"beauty-salon/internal/models"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockFiscalRepo struct {
+	mock.Mock
+}
+
+func (m *MockFiscalRepo) GetReceiptByID(id uint) (*models.Receipt, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Receipt), args.Error(1)
+}
+func (m *MockFiscalRepo) GetReceiptByBooking(bookingID string) (*models.Receipt, error) {
+	args := m.Called(bookingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Receipt), args.Error(1)
+}
+func (m *MockFiscalRepo) SetReceiptFiscalID(id uint, fiscalID string, at time.Time) error {
+	return m.Called(id, fiscalID, at).Error(0)
+}
+
+type fakeRegistrar struct {
+	got []*fiscal.Receipt
+}
+
+func (f *fakeRegistrar) Register(_ context.Context, r *fiscal.Receipt) (*fiscal.Registration, error) {
+	f.got = append(f.got, r)
+	return &fiscal.Registration{FiscalID: "F-" + r.Number, RegisteredAt: depositNow}, nil
+}
+
+func fiscalTestReceipt() *models.Receipt {
+	rec := taxedReceipt()
+	rec.ID, rec.BookingID, rec.CreatedBy = 7, 1, 9
+	rec.Total, rec.TaxMode, rec.Tax = kzt(770045), TaxExclusive, kzt(54005)
+	rec.Items[0].TaxRate, rec.Items[0].Tax = 1200, kzt(54005)
+	rec.Tenders = []models.ReceiptTender{{Method: "card", Amount: kzt(770045)}}
+	return rec
+}
+
+func TestFiscalReceipt(t *testing.T) {
+	r := fiscalReceipt(fiscalTestReceipt())
+	assert.Equal(t, "7", r.Number)
+	require.Len(t, r.Items, 3, "discount lines are folded into items")
+	// 5000.50 - 500.05 + 540.05 НДС сверху.
+	assert.Equal(t, kzt(504050), r.Items[0].Amount)
+	assert.Equal(t, kzt(270000), r.Items[1].Amount)
+	assert.Equal(t, "tip", r.Items[2].Kind)
+	assert.Equal(t, "card", r.Payments[0].Method)
+}
+
+func TestRegisterReceiptOnEvent(t *testing.T) {
+	repo := new(MockFiscalRepo)
+	registrar := &fakeRegistrar{}
+	svc := NewFiscalService(repo, registrar)
+	bus := events.NewBus(10)
+	svc.Subscribe(bus)
+
+	repo.On("GetReceiptByID", uint(7)).Return(fiscalTestReceipt(), nil).Once()
+	repo.On("SetReceiptFiscalID", uint(7), "F-7", depositNow).Return(nil).Once()
+	payload, _ := json.Marshal(events.ReceiptPayload{ReceiptID: 7, BookingID: 1})
+	require.NoError(t, bus.Publish(context.Background(), events.Event{ID: "ev-1", Type: events.ReceiptCreated, Payload: payload}))
+
+	// Уже зарегистрированный чек повторно не передаётся.
+	done := fiscalTestReceipt()
+	done.FiscalID = "F-7"
+	repo.On("GetReceiptByID", uint(7)).Return(done, nil).Once()
+	require.NoError(t, bus.Publish(context.Background(), events.Event{ID: "ev-2", Type: events.ReceiptCreated, Payload: payload}))
+
+	assert.Len(t, registrar.got, 1)
+	repo.AssertExpectations(t)
+}
+
+func TestGetFiscalReceipt(t *testing.T) {
+	repo := new(MockFiscalRepo)
+	svc := NewFiscalService(repo, &fakeRegistrar{})
+	repo.On("GetReceiptByBooking", "2").Return(nil, gorm.ErrRecordNotFound).Once()
+	_, err := svc.GetFiscalReceipt("2")
+	assert.ErrorIs(t, err, ErrReceiptNotFound)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/repository"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidGiftCard      = errors.New("invalid gift card")
+	ErrGiftCardNotFound     = errors.New("gift card not found")
+	ErrGiftCardExpired      = errors.New("gift card has expired")
+	ErrGiftCardInsufficient = repository.ErrGiftCardBalance
+)
+
+// DefaultGiftCardValidity — срок действия карты, если при выпуске не указан другой.
+const DefaultGiftCardValidity = 365 * 24 * time.Hour
+
+// giftCodeAlphabet — без 0/O и 1/I, чтобы код не путали при вводе с бумаги.
+const giftCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GiftCardInput — выпуск карты администратором (например, в подарок от салона).
+type GiftCardInput struct {
+	Amount    money.Money `json:"amount"`
+	ExpiresAt *time.Time  `json:"expires_at"` // пусто — DefaultGiftCardValidity
+}
+
+// GiftCardBalance — ответ на запрос баланса по коду.
+type GiftCardBalance struct {
+	Code      string      `json:"code"`
+	Balance   money.Money `json:"balance"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+	Expired   bool        `json:"expired"`
+}
+
+type GiftCards interface {
+	Issue(in GiftCardInput, by uint) (*models.GiftCard, error)
+	Balance(code string) (*GiftCardBalance, error)
+	Ledger(code string) ([]models.GiftCardEntry, error)
+}
+
+type GiftCardService struct {
+	repo     repository.GiftCardRepository
+	validity time.Duration
+	now      func() time.Time
+}
+
+func NewGiftCardService(repo repository.GiftCardRepository, validity time.Duration) *GiftCardService {
+	return &GiftCardService{repo: repo, validity: validity, now: time.Now}
+}
+
+func (s *GiftCardService) Issue(in GiftCardInput, by uint) (*models.GiftCard, error) {
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidGiftCard)
+	}
+	card, err := s.NewCard(in.Amount, by)
+	if err != nil {
+		return nil, err
+	}
+	if in.ExpiresAt != nil {
+		card.ExpiresAt = in.ExpiresAt
+	}
+	if err := s.repo.CreateGiftCard(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// NewCard готовит новую карту номиналом amount; сохраняется она вместе с чеком продажи.
+func (s *GiftCardService) NewCard(amount money.Money, by uint) (*models.GiftCard, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidGiftCard)
+	}
+	if amount.Currency == "" {
+		amount.Currency = money.DefaultCurrency
+	}
+	code, err := giftCardCode()
+	if err != nil {
+		return nil, err
+	}
+	expires := s.now().Add(s.validity)
+	return &models.GiftCard{Code: code, Initial: amount, Balance: amount, ExpiresAt: &expires, CreatedBy: by}, nil
+}
+
+// CheckRedeemable проверяет, что картой code можно оплатить amount, и
+// возвращает код в каноническом виде. Остаток ещё раз проверяется при
+// списании под блокировкой.
+func (s *GiftCardService) CheckRedeemable(code string, amount money.Money) (string, error) {
+	card, err := s.card(code)
+	if err != nil {
+		return "", err
+	}
+	if s.expired(card) {
+		return "", ErrGiftCardExpired
+	}
+	if !card.Balance.SameCurrency(amount) || card.Balance.Cmp(amount) < 0 {
+		return "", ErrGiftCardInsufficient
+	}
+	return card.Code, nil
+}
+
+func (s *GiftCardService) Balance(code string) (*GiftCardBalance, error) {
+	card, err := s.card(code)
+	if err != nil {
+		return nil, err
+	}
+	return &GiftCardBalance{Code: card.Code, Balance: card.Balance, ExpiresAt: card.ExpiresAt, Expired: s.expired(card)}, nil
+}
+
+func (s *GiftCardService) Ledger(code string) ([]models.GiftCardEntry, error) {
+	card, err := s.card(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetGiftCardEntries(card.ID)
+}
+
+func (s *GiftCardService) card(code string) (*models.GiftCard, error) {
+	card, err := s.repo.GetGiftCardByCode(normalizeGiftCode(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGiftCardNotFound
+	}
+	return card, err
+}
+
+func (s *GiftCardService) expired(card *models.GiftCard) bool {
+	return card.ExpiresAt != nil && !s.now().Before(*card.ExpiresAt)
+}
+
+// normalizeGiftCode приводит введённый код к виду из базы: без пробелов и
+// дефисов, заглавными буквами.
+func normalizeGiftCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// giftCardCode возвращает случайный код из 16 символов (80 бит).
+func giftCardCode() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = giftCodeAlphabet[int(b[i])%len(giftCodeAlphabet)]
+	}
+	return string(b), nil
+}
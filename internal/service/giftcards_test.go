@@ -0,0 +1,98 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockGiftCardRepo struct {
+	mock.Mock
+}
+
+func (m *MockGiftCardRepo) CreateGiftCard(card *models.GiftCard) error {
+	return m.Called(card).Error(0)
+}
+func (m *MockGiftCardRepo) GetGiftCardByCode(code string) (*models.GiftCard, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GiftCard), args.Error(1)
+}
+func (m *MockGiftCardRepo) GetGiftCardEntries(cardID uint) ([]models.GiftCardEntry, error) {
+	args := m.Called(cardID)
+	return args.Get(0).([]models.GiftCardEntry), args.Error(1)
+}
+
+var giftNow = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func newGiftCardService(repo *MockGiftCardRepo) *GiftCardService {
+	svc := NewGiftCardService(repo, DefaultGiftCardValidity)
+	svc.now = func() time.Time { return giftNow }
+	return svc
+}
+
+func giftCard(balance int64, expiresAt time.Time) *models.GiftCard {
+	card := &models.GiftCard{Code: "ABCD2345EFGH6789", Initial: kzt(1000000), Balance: kzt(balance), ExpiresAt: &expiresAt}
+	card.ID = 4
+	return card
+}
+
+func TestIssueGiftCard(t *testing.T) {
+	repo := new(MockGiftCardRepo)
+	svc := newGiftCardService(repo)
+
+	_, err := svc.Issue(GiftCardInput{Amount: kzt(0)}, 1)
+	assert.ErrorIs(t, err, ErrInvalidGiftCard)
+	past := giftNow.Add(-time.Hour)
+	_, err = svc.Issue(GiftCardInput{Amount: kzt(100), ExpiresAt: &past}, 1)
+	assert.ErrorIs(t, err, ErrInvalidGiftCard)
+
+	repo.On("CreateGiftCard", mock.Anything).Return(nil).Once()
+	card, err := svc.Issue(GiftCardInput{Amount: kzt(1000000)}, 1)
+	require.NoError(t, err)
+	assert.Len(t, card.Code, 16)
+	assert.Equal(t, kzt(1000000), card.Balance)
+	assert.Equal(t, giftNow.Add(DefaultGiftCardValidity), *card.ExpiresAt)
+	assert.Equal(t, uint(1), card.CreatedBy)
+}
+
+func TestCheckRedeemable(t *testing.T) {
+	repo := new(MockGiftCardRepo)
+	svc := newGiftCardService(repo)
+	repo.On("GetGiftCardByCode", "ABCD2345EFGH6789").Return(giftCard(300000, giftNow.Add(time.Hour)), nil).Twice()
+	repo.On("GetGiftCardByCode", "EXPIRED").Return(giftCard(300000, giftNow), nil).Once()
+	repo.On("GetGiftCardByCode", "NONE").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	code, err := svc.CheckRedeemable("abcd-2345-efgh-6789", kzt(200000))
+	require.NoError(t, err)
+	assert.Equal(t, "ABCD2345EFGH6789", code)
+	_, err = svc.CheckRedeemable("ABCD2345EFGH6789", kzt(300001))
+	assert.ErrorIs(t, err, ErrGiftCardInsufficient)
+	_, err = svc.CheckRedeemable("expired", kzt(100))
+	assert.ErrorIs(t, err, ErrGiftCardExpired)
+	_, err = svc.CheckRedeemable("none", kzt(100))
+	assert.ErrorIs(t, err, ErrGiftCardNotFound)
+}
+
+func TestGiftCardBalance(t *testing.T) {
+	repo := new(MockGiftCardRepo)
+	svc := newGiftCardService(repo)
+	repo.On("GetGiftCardByCode", "ABCD2345EFGH6789").Return(giftCard(250000, giftNow.Add(-time.Hour)), nil).Twice()
+	repo.On("GetGiftCardEntries", uint(4)).Return([]models.GiftCardEntry{{Kind: models.GiftCardIssue}}, nil).Once()
+
+	b, err := svc.Balance("ABCD2345EFGH6789")
+	require.NoError(t, err)
+	assert.Equal(t, kzt(250000), b.Balance)
+	assert.True(t, b.Expired)
+
+	entries, err := svc.Ledger("ABCD2345EFGH6789")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/events"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	liveFeedChannel = "live:bookings"
+	liveFeedBuffer  = 64
+)
+
+// StreamTicketTTL — сколько живёт неиспользованный билет на подключение к ленте.
+const StreamTicketTTL = 30 * time.Second
+
+var ErrInvalidStreamTicket = errors.New("invalid or expired stream ticket")
+
+// Виды изменений в живой ленте расписания.
+const (
+	FeedCreated   = "created"
+	FeedUpdated   = "updated"
+	FeedCancelled = "cancelled"
+)
+
+// FeedEvent — изменение записи, которое лента отправляет ресепшену.
+type FeedEvent struct {
+	ID         string          `json:"id"`   // ID доменного события
+	Kind       string          `json:"kind"` // created, updated, cancelled
+	Type       string          `json:"type"` // исходный тип события
+	BookingID  uint            `json:"booking_id"`
+	StaffID    uint            `json:"staff_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// FeedFilter отбирает события для подписчика; нулевые поля не фильтруют.
+// Филиалов в модели пока нет, поэтому фильтр только по мастеру.
+type FeedFilter struct {
+	StaffID uint
+}
+
+func (f FeedFilter) match(e FeedEvent) bool {
+	return f.StaffID == 0 || f.StaffID == e.StaffID
+}
+
+type LiveFeeds interface {
+	Subscribe(f FeedFilter) (<-chan FeedEvent, func())
+	IssueTicket(ctx context.Context, userID uint) (string, error)
+	RedeemTicket(ctx context.Context, ticket string) (uint, error)
+}
+
+type feedSubscriber struct {
+	filter FeedFilter
+	ch     chan FeedEvent
+}
+
+// LiveFeed — живая лента записей. Как Sink outbox'а она публикует события в
+// Redis, а Run на каждой реплике читает канал и раздаёт события своим
+// SSE-клиентам. Так событие, опубликованное одной репликой, видят все.
+type LiveFeed struct {
+	rdb *redis.Client
+
+	mu   sync.Mutex
+	subs map[*feedSubscriber]struct{}
+}
+
+func NewLiveFeed(rdb *redis.Client) *LiveFeed {
+	return &LiveFeed{rdb: rdb, subs: map[*feedSubscriber]struct{}{}}
+}
+
+func (f *LiveFeed) Name() string { return "live_feed" }
+
+// feedEvent переводит доменное событие в событие ленты. Завершение визита
+// не дублируется: о нём уже сообщило booking.status_changed.
+func feedEvent(e events.Event) (FeedEvent, bool) {
+	fe := FeedEvent{ID: e.ID, Type: e.Type, BookingID: e.AggregateID, OccurredAt: e.OccurredAt, Payload: e.Payload}
+	var p struct {
+		StaffID uint   `json:"staff_id"`
+		To      string `json:"to"`
+	}
+	if err := json.Unmarshal(e.Payload, &p); err != nil {
+		return fe, false
+	}
+	fe.StaffID = p.StaffID
+	switch e.Type {
+	case events.BookingCreated:
+		fe.Kind = FeedCreated
+	case events.BookingStatusChanged:
+		fe.Kind = FeedUpdated
+		if p.To == "cancelled" {
+			fe.Kind = FeedCancelled
+		}
+	case events.BookingRescheduled:
+		fe.Kind = FeedUpdated
+	default:
+		return fe, false
+	}
+	return fe, true
+}
+
+// Publish отправляет событие всем репликам через Redis pub/sub.
+func (f *LiveFeed) Publish(ctx context.Context, e events.Event) error {
+	fe, ok := feedEvent(e)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(fe)
+	if err != nil {
+		return err
+	}
+	return f.rdb.Publish(ctx, liveFeedChannel, data).Err()
+}
+
+// Run читает канал Redis, пока не отменён ctx. go-redis сам переподключается
+// после обрыва; события, пришедшие во время обрыва, теряются — лента служит
+// подсказкой обновить расписание, а не источником истины.
+func (f *LiveFeed) Run(ctx context.Context) {
+	sub := f.rdb.Subscribe(ctx, liveFeedChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var fe FeedEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &fe); err != nil {
+				log.Printf("live feed: %v", err)
+				continue
+			}
+			f.dispatch(fe)
+		}
+	}
+}
+
+// IssueTicket выдаёт одноразовый билет на подключение к ленте. EventSource не
+// передаёт заголовки, и билет идёт в URL вместо JWT: в логах доступа остаётся
+// только погашенный или истёкший через StreamTicketTTL билет.
+func (f *LiveFeed) IssueTicket(ctx context.Context, userID uint) (string, error) {
+	t := auth.RandomToken(32)
+	if err := f.rdb.Set(ctx, "live_ticket:"+t, userID, StreamTicketTTL).Err(); err != nil {
+		return "", err
+	}
+	return t, nil
+}
+
+// RedeemTicket гасит билет и возвращает пользователя, которому он выдан.
+func (f *LiveFeed) RedeemTicket(ctx context.Context, ticket string) (uint, error) {
+	if ticket == "" {
+		return 0, ErrInvalidStreamTicket
+	}
+	raw, err := f.rdb.GetDel(ctx, "live_ticket:"+ticket).Result()
+	if err != nil {
+		return 0, ErrInvalidStreamTicket
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidStreamTicket
+	}
+	return uint(id), nil
+}
+
+// Subscribe регистрирует подписчика. Канал закрывается вызовом cancel или
+// если клиент не успевает читать — тогда ему стоит переподключиться.
+func (f *LiveFeed) Subscribe(filter FeedFilter) (<-chan FeedEvent, func()) {
+	s := &feedSubscriber{filter: filter, ch: make(chan FeedEvent, liveFeedBuffer)}
+	f.mu.Lock()
+	f.subs[s] = struct{}{}
+	f.mu.Unlock()
+	return s.ch, func() { f.remove(s) }
+}
+
+func (f *LiveFeed) remove(s *feedSubscriber) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[s]; ok {
+		delete(f.subs, s)
+		close(s.ch)
+	}
+}
+
+func (f *LiveFeed) dispatch(e FeedEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for s := range f.subs {
+		if !s.filter.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			delete(f.subs, s)
+			close(s.ch)
+		}
+	}
+}
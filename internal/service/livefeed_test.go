@@ -0,0 +1,113 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bookingEvent(eventType string, payload interface{}) events.Event {
+	data, _ := json.Marshal(payload)
+	return events.Event{ID: "ev-" + eventType, Type: eventType, AggregateID: 1,
+		OccurredAt: time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC), Payload: data}
+}
+
+func TestFeedEvent(t *testing.T) {
+	for _, tc := range []struct {
+		event events.Event
+		kind  string
+	}{
+		{bookingEvent(events.BookingCreated, events.BookingPayload{BookingID: 1, StaffID: 3}), FeedCreated},
+		{bookingEvent(events.BookingStatusChanged, events.BookingStatusChangedPayload{StaffID: 3, To: "confirmed"}), FeedUpdated},
+		{bookingEvent(events.BookingStatusChanged, events.BookingStatusChangedPayload{StaffID: 3, To: "cancelled"}), FeedCancelled},
+		{bookingEvent(events.BookingRescheduled, events.BookingRescheduledPayload{StaffID: 3}), FeedUpdated},
+	} {
+		fe, ok := feedEvent(tc.event)
+		require.True(t, ok, tc.event.Type)
+		assert.Equal(t, tc.kind, fe.Kind, tc.event.Type)
+		assert.Equal(t, uint(3), fe.StaffID)
+		assert.Equal(t, uint(1), fe.BookingID)
+	}
+
+	_, ok := feedEvent(bookingEvent(events.BookingCompleted, events.BookingPayload{StaffID: 3}))
+	assert.False(t, ok)
+	_, ok = feedEvent(bookingEvent(events.UserRegistered, events.UserRegisteredPayload{UserID: 1}))
+	assert.False(t, ok)
+}
+
+func TestLiveFeedPublish(t *testing.T) {
+	db, rmock := redismock.NewClientMock()
+	feed := NewLiveFeed(db)
+
+	e := bookingEvent(events.BookingCreated, events.BookingPayload{BookingID: 1, StaffID: 3})
+	fe, _ := feedEvent(e)
+	data, _ := json.Marshal(fe)
+	rmock.ExpectPublish(liveFeedChannel, data).SetVal(1)
+
+	require.NoError(t, feed.Publish(context.Background(), e))
+	// Пользовательские события в ленту не попадают.
+	require.NoError(t, feed.Publish(context.Background(), bookingEvent(events.UserRegistered, events.UserRegisteredPayload{})))
+	assert.NoError(t, rmock.ExpectationsWereMet())
+}
+
+func TestLiveFeedTickets(t *testing.T) {
+	db, rmock := redismock.NewClientMock()
+	feed := NewLiveFeed(db)
+	ctx := context.Background()
+
+	rmock.Regexp().ExpectSet(`live_ticket:.+`, uint(4), StreamTicketTTL).SetVal("OK")
+	ticket, err := feed.IssueTicket(ctx, 4)
+	require.NoError(t, err)
+	assert.NotEmpty(t, ticket)
+
+	// Билет одноразовый: второе подключение с ним не проходит.
+	rmock.ExpectGetDel("live_ticket:" + ticket).SetVal("4")
+	rmock.ExpectGetDel("live_ticket:" + ticket).RedisNil()
+	id, err := feed.RedeemTicket(ctx, ticket)
+	require.NoError(t, err)
+	assert.Equal(t, uint(4), id)
+	_, err = feed.RedeemTicket(ctx, ticket)
+	assert.ErrorIs(t, err, ErrInvalidStreamTicket)
+
+	_, err = feed.RedeemTicket(ctx, "")
+	assert.ErrorIs(t, err, ErrInvalidStreamTicket)
+	assert.NoError(t, rmock.ExpectationsWereMet())
+}
+
+func TestLiveFeedDispatch(t *testing.T) {
+	feed := NewLiveFeed(nil)
+	all, cancelAll := feed.Subscribe(FeedFilter{})
+	defer cancelAll()
+	olga, cancelOlga := feed.Subscribe(FeedFilter{StaffID: 3})
+
+	feed.dispatch(FeedEvent{ID: "a", StaffID: 2})
+	feed.dispatch(FeedEvent{ID: "b", StaffID: 3})
+
+	assert.Equal(t, "a", (<-all).ID)
+	assert.Equal(t, "b", (<-all).ID)
+	assert.Equal(t, "b", (<-olga).ID)
+
+	cancelOlga()
+	_, open := <-olga
+	assert.False(t, open)
+	cancelOlga() // повторная отписка безопасна
+
+	t.Run("Slow Subscriber Is Dropped", func(t *testing.T) {
+		slow, cancel := feed.Subscribe(FeedFilter{})
+		defer cancel()
+		for i := 0; i <= liveFeedBuffer; i++ {
+			feed.dispatch(FeedEvent{})
+		}
+		n := 0
+		for range slow {
+			n++
+		}
+		assert.Equal(t, liveFeedBuffer, n)
+	})
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	ErrInvalidLoyaltyBonus  = errors.New("invalid loyalty bonus")
+	ErrLoyaltyPointsInvalid = errors.New("loyalty points must be positive")
+	ErrLoyaltyBalance       = repository.ErrLoyaltyBalance
+)
+
+// Поводы для бонусных баллов.
+const (
+	BonusReview   = "review"
+	BonusReferral = "referral"
+	BonusManual   = "manual" // произвольное начисление администратором
+)
+
+// TierBasic — уровень клиента, не набравшего баллов на серебряный.
+const TierBasic = "basic"
+
+// LoyaltyTier — уровень программы: от MinPoints баллов, начисленных за
+// последний год, клиент получает скидку Percent процентов.
+type LoyaltyTier struct {
+	Name      string `json:"name"`
+	MinPoints int64  `json:"min_points"`
+	Percent   int    `json:"percent"`
+}
+
+type LoyaltyConfig struct {
+	EarnPer       int64         // минорных единиц оплаты за балл: 10000 — балл за каждые 100 ₸
+	PointValue    int64         // цена балла при оплате, в минорных единицах
+	Expiry        time.Duration // срок жизни начисленных баллов
+	TierWindow    time.Duration // за какой период баллы идут в уровень
+	ReviewBonus   int64
+	ReferralBonus int64
+	Tiers         []LoyaltyTier // по возрастанию MinPoints
+}
+
+var DefaultLoyaltyConfig = LoyaltyConfig{
+	EarnPer:       10000,
+	PointValue:    100,
+	Expiry:        365 * 24 * time.Hour,
+	TierWindow:    365 * 24 * time.Hour,
+	ReviewBonus:   50,
+	ReferralBonus: 200,
+	Tiers:         []LoyaltyTier{{Name: "silver", MinPoints: 500, Percent: 3}, {Name: "gold", MinPoints: 2000, Percent: 5}},
+}
+
+// LoyaltyBonusInput — бонус клиенту; Points задаётся только для manual.
+type LoyaltyBonusInput struct {
+	Reason string `json:"reason"`
+	Points int64  `json:"points"`
+}
+
+// LoyaltySummary — баланс, уровень и история баллов клиента.
+type LoyaltySummary struct {
+	Balance  int64                 `json:"balance"`
+	Tier     string                `json:"tier"`
+	Discount int                   `json:"discount_percent"`
+	Earned   int64                 `json:"earned"` // за TierWindow — для уровня
+	NextTier *LoyaltyTier          `json:"next_tier,omitempty"`
+	History  []models.LoyaltyEntry `json:"history"`
+}
+
+type Loyalty interface {
+	Summary(userID uint) (*LoyaltySummary, error)
+	AwardBonus(userID uint, in LoyaltyBonusInput, by uint) (*models.LoyaltyEntry, error)
+}
+
+type LoyaltyService struct {
+	repo repository.LoyaltyRepository
+	cfg  LoyaltyConfig
+	now  func() time.Time
+}
+
+func NewLoyaltyService(repo repository.LoyaltyRepository, cfg LoyaltyConfig) *LoyaltyService {
+	return &LoyaltyService{repo: repo, cfg: cfg, now: time.Now}
+}
+
+// Subscribe начисляет баллы за каждый проведённый на кассе чек.
+func (s *LoyaltyService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.ReceiptCreated, s.onReceiptCreated)
+}
+
+func (s *LoyaltyService) Summary(userID uint) (*LoyaltySummary, error) {
+	now := s.now()
+	balance, err := s.repo.GetLoyaltyBalance(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	earned, err := s.repo.GetLoyaltyEarned(userID, now.Add(-s.cfg.TierWindow))
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.repo.GetLoyaltyEntries(userID)
+	if err != nil {
+		return nil, err
+	}
+	sum := &LoyaltySummary{Balance: balance, Tier: TierBasic, Earned: earned, History: history}
+	for i, t := range s.cfg.Tiers {
+		if earned < t.MinPoints {
+			sum.NextTier = &s.cfg.Tiers[i]
+			break
+		}
+		sum.Tier, sum.Discount = t.Name, t.Percent
+	}
+	return sum, nil
+}
+
+// AwardBonus начисляет бонус за отзыв, приглашённого друга или вручную.
+func (s *LoyaltyService) AwardBonus(userID uint, in LoyaltyBonusInput, by uint) (*models.LoyaltyEntry, error) {
+	points := in.Points
+	switch in.Reason {
+	case BonusReview:
+		points = s.cfg.ReviewBonus
+	case BonusReferral:
+		points = s.cfg.ReferralBonus
+	case BonusManual:
+	default:
+		return nil, fmt.Errorf("%w: reason must be review, referral or manual", ErrInvalidLoyaltyBonus)
+	}
+	if points <= 0 {
+		return nil, fmt.Errorf("%w: points must be positive", ErrInvalidLoyaltyBonus)
+	}
+	e := s.credit(userID, models.LoyaltyBonus, points)
+	e.Reason, e.CreatedBy = in.Reason, by
+	if err := s.repo.AddLoyaltyEntry(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// TierDiscount возвращает уровень клиента и его скидку в процентах.
+func (s *LoyaltyService) TierDiscount(userID uint) (string, int, error) {
+	earned, err := s.repo.GetLoyaltyEarned(userID, s.now().Add(-s.cfg.TierWindow))
+	if err != nil {
+		return "", 0, err
+	}
+	tier, percent := TierBasic, 0
+	for _, t := range s.cfg.Tiers {
+		if earned >= t.MinPoints {
+			tier, percent = t.Name, t.Percent
+		}
+	}
+	return tier, percent, nil
+}
+
+// PointsValue проверяет, что у клиента есть points баллов, и возвращает их
+// стоимость в валюте currency. Окончательно баллы списываются вместе с чеком.
+func (s *LoyaltyService) PointsValue(userID uint, points int64, currency string) (money.Money, error) {
+	if points <= 0 {
+		return money.Money{}, ErrLoyaltyPointsInvalid
+	}
+	balance, err := s.repo.GetLoyaltyBalance(userID, s.now())
+	if err != nil {
+		return money.Money{}, err
+	}
+	if balance < points {
+		return money.Money{}, ErrLoyaltyBalance
+	}
+	return money.New(points*s.cfg.PointValue, currency), nil
+}
+
+// ExpirePoints сжигает просроченные начисления.
+func (s *LoyaltyService) ExpirePoints() error {
+	n, err := s.repo.ExpireLoyaltyPoints(s.now())
+	if n > 0 {
+		log.Printf("loyalty: expired %d credits", n)
+	}
+	return err
+}
+
+// Run сжигает просроченные баллы, пока не отменён ctx.
+func (s *LoyaltyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.ExpirePoints(); err != nil {
+			log.Printf("loyalty: expire points: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// onReceiptCreated начисляет баллы за оплаченные услуги и товары — без
+// чаевых, проданных подарочных карт и части, оплаченной скидками и баллами.
+func (s *LoyaltyService) onReceiptCreated(ctx context.Context, e events.Event) error {
+	var p events.ReceiptPayload
+	if err := json.Unmarshal(e.Payload, &p); err != nil {
+		return err
+	}
+	if p.UserID == 0 {
+		return nil
+	}
+	rec, err := s.repo.GetReceiptByID(p.ReceiptID)
+	if err != nil {
+		return err
+	}
+	var spent int64
+	for _, it := range rec.Items {
+		if it.Taxable() {
+			spent += it.Amount.Sub(it.Discount).Minor
+		}
+	}
+	points := spent / s.cfg.EarnPer
+	if points <= 0 {
+		return nil
+	}
+	entry := s.credit(rec.UserID, models.LoyaltyEarn, points)
+	entry.ReceiptID = &rec.ID
+	return s.repo.AddLoyaltyEntry(entry)
+}
+
+func (s *LoyaltyService) credit(userID uint, kind string, points int64) *models.LoyaltyEntry {
+	e := &models.LoyaltyEntry{UserID: userID, Kind: kind, Points: points, Remaining: points}
+	if s.cfg.Expiry > 0 {
+		expires := s.now().Add(s.cfg.Expiry)
+		e.ExpiresAt = &expires
+	}
+	return e
+}
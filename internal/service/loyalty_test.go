@@ -0,0 +1,121 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockLoyaltyRepo struct {
+	mock.Mock
+}
+
+func (m *MockLoyaltyRepo) GetReceiptByID(id uint) (*models.Receipt, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Receipt), args.Error(1)
+}
+func (m *MockLoyaltyRepo) AddLoyaltyEntry(e *models.LoyaltyEntry) error { return m.Called(e).Error(0) }
+func (m *MockLoyaltyRepo) GetLoyaltyEntries(userID uint) ([]models.LoyaltyEntry, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.LoyaltyEntry), args.Error(1)
+}
+func (m *MockLoyaltyRepo) GetLoyaltyBalance(userID uint, at time.Time) (int64, error) {
+	args := m.Called(userID, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockLoyaltyRepo) GetLoyaltyEarned(userID uint, since time.Time) (int64, error) {
+	args := m.Called(userID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockLoyaltyRepo) ExpireLoyaltyPoints(at time.Time) (int, error) {
+	args := m.Called(at)
+	return args.Int(0), args.Error(1)
+}
+
+var loyaltyNow = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func newLoyaltyService(repo *MockLoyaltyRepo) *LoyaltyService {
+	svc := NewLoyaltyService(repo, DefaultLoyaltyConfig)
+	svc.now = func() time.Time { return loyaltyNow }
+	return svc
+}
+
+func TestEarnPointsOnReceipt(t *testing.T) {
+	repo := new(MockLoyaltyRepo)
+	bus := events.NewBus(10)
+	newLoyaltyService(repo).Subscribe(bus)
+
+	rec := taxedReceipt()
+	rec.ID, rec.UserID = 7, 4
+	repo.On("GetReceiptByID", uint(7)).Return(rec, nil).Once()
+	expires := loyaltyNow.Add(DefaultLoyaltyConfig.Expiry)
+	receiptID := uint(7)
+	// 4500.45 за стрижку и 2700 за пластырь после скидки; чаевые не в счёт.
+	repo.On("AddLoyaltyEntry", &models.LoyaltyEntry{UserID: 4, Kind: models.LoyaltyEarn, Points: 72, Remaining: 72,
+		ExpiresAt: &expires, ReceiptID: &receiptID}).Return(nil).Once()
+
+	payload, _ := json.Marshal(events.ReceiptPayload{ReceiptID: 7, UserID: 4})
+	require.NoError(t, bus.Publish(context.Background(), events.Event{ID: "ev-1", Type: events.ReceiptCreated, Payload: payload}))
+	repo.AssertExpectations(t)
+}
+
+func TestLoyaltySummary(t *testing.T) {
+	repo := new(MockLoyaltyRepo)
+	svc := newLoyaltyService(repo)
+	repo.On("GetLoyaltyBalance", uint(4), loyaltyNow).Return(int64(640), nil).Once()
+	repo.On("GetLoyaltyEarned", uint(4), loyaltyNow.Add(-DefaultLoyaltyConfig.TierWindow)).Return(int64(820), nil).Twice()
+	repo.On("GetLoyaltyEntries", uint(4)).Return([]models.LoyaltyEntry{{Kind: models.LoyaltyRedeem, Points: -180}}, nil).Once()
+
+	sum, err := svc.Summary(4)
+	require.NoError(t, err)
+	assert.Equal(t, int64(640), sum.Balance)
+	assert.Equal(t, "silver", sum.Tier)
+	assert.Equal(t, 3, sum.Discount)
+	assert.Equal(t, "gold", sum.NextTier.Name)
+	assert.Len(t, sum.History, 1)
+
+	tier, percent, err := svc.TierDiscount(4)
+	require.NoError(t, err)
+	assert.Equal(t, "silver", tier)
+	assert.Equal(t, 3, percent)
+}
+
+func TestAwardBonus(t *testing.T) {
+	repo := new(MockLoyaltyRepo)
+	svc := newLoyaltyService(repo)
+	_, err := svc.AwardBonus(4, LoyaltyBonusInput{Reason: "birthday"}, 1)
+	assert.ErrorIs(t, err, ErrInvalidLoyaltyBonus)
+	_, err = svc.AwardBonus(4, LoyaltyBonusInput{Reason: BonusManual}, 1)
+	assert.ErrorIs(t, err, ErrInvalidLoyaltyBonus)
+
+	repo.On("AddLoyaltyEntry", mock.MatchedBy(func(e *models.LoyaltyEntry) bool {
+		return e.Kind == models.LoyaltyBonus && e.Points == 200 && e.Remaining == 200 && e.Reason == BonusReferral && e.CreatedBy == 1
+	})).Return(nil).Once()
+	e, err := svc.AwardBonus(4, LoyaltyBonusInput{Reason: BonusReferral, Points: 5000}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), e.Points, "referral bonus is fixed by config")
+}
+
+func TestPointsValue(t *testing.T) {
+	repo := new(MockLoyaltyRepo)
+	svc := newLoyaltyService(repo)
+	repo.On("GetLoyaltyBalance", uint(4), loyaltyNow).Return(int64(300), nil).Twice()
+
+	v, err := svc.PointsValue(4, 250, "KZT")
+	require.NoError(t, err)
+	assert.Equal(t, kzt(25000), v)
+	_, err = svc.PointsValue(4, 301, "KZT")
+	assert.ErrorIs(t, err, ErrLoyaltyBalance)
+	_, err = svc.PointsValue(4, -1, "KZT")
+	assert.ErrorIs(t, err, ErrLoyaltyPointsInvalid)
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"beauty-salon/internal/i18n"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/payments"
+	"beauty-salon/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidMembershipPlan  = errors.New("invalid membership plan")
+	ErrMembershipPlanNotFound = errors.New("membership plan not found")
+	ErrAlreadySubscribed      = errors.New("client already has a membership")
+	ErrMembershipNotFound     = errors.New("membership not found")
+	ErrMembershipNotPayable   = errors.New("membership has nothing to pay")
+	ErrMembershipLimit        = repository.ErrMembershipLimit
+)
+
+// DefaultMembershipGrace — сколько абонемент действует после конца периода,
+// пока клиент не оплатил продление.
+const DefaultMembershipGrace = 3 * 24 * time.Hour
+
+// MembershipPlanInput описывает абонемент: цена за период, скидка на всё и
+// включённые услуги.
+type MembershipPlanInput struct {
+	Name            string                   `json:"name"`
+	Price           money.Money              `json:"price"`
+	PeriodMonths    int                      `json:"period_months"` // 0 — ежемесячно
+	DiscountPercent int                      `json:"discount_percent"`
+	Benefits        []MembershipBenefitInput `json:"benefits"`
+}
+
+type MembershipBenefitInput struct {
+	ServiceID uint `json:"service_id"`
+	PerPeriod int  `json:"per_period"` // 0 — без ограничений
+}
+
+type Memberships interface {
+	CreatePlan(in MembershipPlanInput) (*models.MembershipPlan, error)
+	GetPlans() ([]models.MembershipPlan, error)
+	DeletePlan(id string) error
+	Subscribe(ctx context.Context, userID, planID uint) (*models.Subscription, *models.Payment, error)
+	Current(userID uint) (*models.Subscription, error)
+	Pay(ctx context.Context, userID uint) (*models.Payment, error)
+	Cancel(userID uint) (*models.Subscription, error)
+}
+
+type MembershipService struct {
+	repo     repository.MembershipRepository
+	provider payments.Provider
+	grace    time.Duration
+	now      func() time.Time
+}
+
+func NewMembershipService(repo repository.MembershipRepository, provider payments.Provider, grace time.Duration) *MembershipService {
+	return &MembershipService{repo: repo, provider: provider, grace: grace, now: time.Now}
+}
+
+func (s *MembershipService) CreatePlan(in MembershipPlanInput) (*models.MembershipPlan, error) {
+	p := &models.MembershipPlan{Name: strings.TrimSpace(in.Name), Price: in.Price, PeriodMonths: in.PeriodMonths, DiscountPercent: in.DiscountPercent}
+	if p.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidMembershipPlan)
+	}
+	if !in.Price.IsPositive() {
+		return nil, fmt.Errorf("%w: price must be positive", ErrInvalidMembershipPlan)
+	}
+	if p.Price.Currency == "" {
+		p.Price.Currency = money.DefaultCurrency
+	}
+	if p.PeriodMonths == 0 {
+		p.PeriodMonths = 1
+	}
+	if p.PeriodMonths < 0 || p.PeriodMonths > 12 {
+		return nil, fmt.Errorf("%w: period_months must be 1-12", ErrInvalidMembershipPlan)
+	}
+	if in.DiscountPercent < 0 || in.DiscountPercent > 100 {
+		return nil, fmt.Errorf("%w: discount_percent must be 0-100", ErrInvalidMembershipPlan)
+	}
+	if in.DiscountPercent == 0 && len(in.Benefits) == 0 {
+		return nil, fmt.Errorf("%w: plan needs a discount or included services", ErrInvalidMembershipPlan)
+	}
+	seen := map[uint]bool{}
+	for _, b := range in.Benefits {
+		if b.PerPeriod < 0 || seen[b.ServiceID] {
+			return nil, fmt.Errorf("%w: each service must be listed once with a non-negative per_period", ErrInvalidMembershipPlan)
+		}
+		seen[b.ServiceID] = true
+		if _, err := s.repo.GetServiceByID(strconv.FormatUint(uint64(b.ServiceID), 10)); errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: service %d not found", ErrInvalidMembershipPlan, b.ServiceID)
+		} else if err != nil {
+			return nil, err
+		}
+		p.Benefits = append(p.Benefits, models.MembershipBenefit{ServiceID: b.ServiceID, PerPeriod: b.PerPeriod})
+	}
+	if err := s.repo.CreateMembershipPlan(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *MembershipService) GetPlans() ([]models.MembershipPlan, error) {
+	return s.repo.GetMembershipPlans()
+}
+
+func (s *MembershipService) DeletePlan(id string) error {
+	err := s.repo.DeleteMembershipPlan(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMembershipPlanNotFound
+	}
+	return err
+}
+
+// Subscribe оформляет абонемент и выставляет счёт за первый период.
+// Абонемент начинает действовать с оплатой; неоплаченный сгорает через
+// льготный срок.
+func (s *MembershipService) Subscribe(ctx context.Context, userID, planID uint) (*models.Subscription, *models.Payment, error) {
+	plan, err := s.repo.GetMembershipPlanByID(planID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrMembershipPlanNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	grace := s.now().Add(s.grace)
+	sub := &models.Subscription{UserID: userID, PlanID: plan.ID, Status: models.SubscriptionPending, GraceUntil: &grace}
+	if err := s.repo.CreateSubscription(sub); err != nil {
+		if repository.IsUniqueViolation(err) {
+			return nil, nil, ErrAlreadySubscribed
+		}
+		return nil, nil, err
+	}
+	sub.Plan = *plan
+	p, err := s.charge(ctx, sub)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.Status == "succeeded" {
+		// Провайдер провёл оплату сразу — абонемент уже активен.
+		if sub, err = s.repo.GetSubscriptionByID(sub.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return sub, p, nil
+}
+
+// Current возвращает незавершённый абонемент клиента с платежами.
+func (s *MembershipService) Current(userID uint) (*models.Subscription, error) {
+	sub, err := s.repo.GetCurrentSubscription(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMembershipNotFound
+	}
+	return sub, err
+}
+
+// Pay выставляет (или возвращает уже выставленный) счёт по неоплаченному
+// абонементу — например, после отказа банка при продлении.
+func (s *MembershipService) Pay(ctx context.Context, userID uint) (*models.Payment, error) {
+	sub, err := s.Current(userID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status != models.SubscriptionPending && sub.Status != models.SubscriptionPastDue {
+		return nil, ErrMembershipNotPayable
+	}
+	return s.charge(ctx, sub)
+}
+
+// Cancel отменяет абонемент: оплаченный действует до конца периода и больше
+// не продлевается, неоплаченный отменяется сразу.
+func (s *MembershipService) Cancel(userID uint) (*models.Subscription, error) {
+	sub, err := s.Current(userID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status == models.SubscriptionActive {
+		sub.CancelAtPeriodEnd = true
+	} else {
+		sub.Status = models.SubscriptionCancelled
+	}
+	if err := s.repo.SaveSubscription(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// PaymentSucceeded продлевает абонемент на период после поступления оплаты —
+// его вызывает PaymentService. Просроченный абонемент продлевается с конца
+// прошлого периода, новый начинается с момента оплаты.
+func (s *MembershipService) PaymentSucceeded(_ context.Context, p *models.Payment) error {
+	if p.SubscriptionID == nil {
+		return nil
+	}
+	sub, err := s.repo.GetSubscriptionByID(*p.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if sub.Status == models.SubscriptionCancelled || sub.Status == models.SubscriptionExpired {
+		// Клиент мог уже оформить новый абонемент — деньги возвращает администратор.
+		log.Printf("memberships: payment %d for closed subscription %d", p.ID, sub.ID)
+		return nil
+	}
+	start := s.now()
+	if sub.CurrentPeriodEnd != nil && (sub.Status == models.SubscriptionActive || sub.Status == models.SubscriptionPastDue) {
+		start = *sub.CurrentPeriodEnd
+	}
+	end := start.AddDate(0, sub.Plan.PeriodMonths, 0)
+	sub.Status, sub.CurrentPeriodStart, sub.CurrentPeriodEnd, sub.GraceUntil = models.SubscriptionActive, &start, &end, nil
+	return s.repo.SaveSubscription(sub)
+}
+
+// Renew продлевает абонементы, у которых кончился период: выставляет счёт
+// и даёт льготный срок на оплату. Отменённые клиентом завершаются, не
+// оплаченные за льготный срок — сгорают.
+func (s *MembershipService) Renew(ctx context.Context) error {
+	now := s.now()
+	subs, err := s.repo.GetDueSubscriptions(now)
+	if err != nil {
+		return err
+	}
+	for i := range subs {
+		if err := s.renew(ctx, &subs[i]); err != nil {
+			log.Printf("memberships: subscription %d: %v", subs[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// Run продлевает абонементы, пока не отменён ctx.
+func (s *MembershipService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Renew(ctx); err != nil {
+			log.Printf("memberships: renew: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *MembershipService) renew(ctx context.Context, sub *models.Subscription) error {
+	switch {
+	case sub.Status != models.SubscriptionActive:
+		sub.Status = models.SubscriptionExpired
+		return s.repo.SaveSubscription(sub)
+	case sub.CancelAtPeriodEnd:
+		sub.Status = models.SubscriptionCancelled
+		return s.repo.SaveSubscription(sub)
+	}
+	grace := sub.CurrentPeriodEnd.Add(s.grace)
+	sub.Status, sub.GraceUntil = models.SubscriptionPastDue, &grace
+	if err := s.repo.SaveSubscription(sub); err != nil {
+		return err
+	}
+	_, err := s.charge(ctx, sub)
+	return err
+}
+
+// charge выставляет счёт за период абонемента через провайдера. Пока по
+// абонементу ждёт оплаты один счёт, второй не создаётся.
+func (s *MembershipService) charge(ctx context.Context, sub *models.Subscription) (*models.Payment, error) {
+	key, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	price := sub.Plan.Price
+	p := &models.Payment{
+		UserID: sub.UserID, SubscriptionID: &sub.ID, Amount: price, RefundedAmount: money.Zero(price.Currency), Kind: "membership",
+		Provider: s.provider.Name(), Status: "pending", IdempotencyKey: "membership:" + key,
+	}
+	p, created, err := s.repo.CreateMembershipPayment(p)
+	if err != nil || !created {
+		return p, err
+	}
+	// Назначение платежа клиент видит на странице оплаты — на его языке.
+	locale := i18n.DefaultLocale
+	if u, err := s.repo.GetUserByID(sub.UserID); err == nil && u.Locale != "" {
+		locale = u.Locale
+	}
+	res, err := s.provider.CreatePayment(ctx, payments.CreateRequest{
+		IdempotencyKey: fmt.Sprintf("payment-%d", p.ID),
+		Amount:         p.Amount.Minor,
+		Currency:       p.Amount.Currency,
+		Description:    fmt.Sprintf(i18n.T(locale, "%s membership"), sub.Plan.Name),
+	})
+	if err != nil {
+		p.Status = "failed"
+		if serr := s.repo.SavePayment(p); serr != nil {
+			log.Printf("memberships: payment %d: %v", p.ID, serr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPaymentProvider, err)
+	}
+	p.ProviderPaymentID, p.ConfirmationURL = res.ID, res.ConfirmationURL
+	if err := s.repo.SavePayment(p); err != nil {
+		return nil, err
+	}
+	if res.Status == payments.StatusPending {
+		return p, nil
+	}
+	settled, changed, err := s.repo.SetPaymentStatus(p.ID, res.Status, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if changed && settled.Status == "succeeded" {
+		if err := s.PaymentSucceeded(ctx, settled); err != nil {
+			return nil, err
+		}
+	}
+	return settled, nil
+}
+
+// Apply применяет абонемент к записи: входящая в него услуга в пределах
+// лимита периода не оплачивается, на остальное действует скидка абонемента.
+func (s *MembershipService) Apply(b *models.Booking) error {
+	b.SubscriptionID = nil
+	if b.PackageSessionID != nil {
+		return nil // визит уже оплачен пакетом
+	}
+	sub, err := s.repo.GetCurrentSubscription(b.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	at, err := time.ParseInLocation(bookingDateLayout, b.Date, s.now().Location())
+	if err != nil {
+		at = s.now()
+	}
+	if !sub.CoversAt(at) || !sub.CoversAt(s.now()) {
+		return nil
+	}
+	for _, benefit := range sub.Plan.Benefits {
+		if benefit.ServiceID != b.ServiceID {
+			continue
+		}
+		if benefit.PerPeriod > 0 {
+			n, err := s.repo.CountMembershipVisits(sub.ID, b.ServiceID, *sub.CurrentPeriodStart)
+			if err != nil {
+				return err
+			}
+			if n >= int64(benefit.PerPeriod) {
+				break // лимит исчерпан — визит платный со скидкой
+			}
+		}
+		b.SubscriptionID, b.Discount = &sub.ID, money.Money{}
+		return nil
+	}
+	if sub.Plan.DiscountPercent == 0 {
+		return nil
+	}
+	svc, err := s.repo.GetServiceByID(strconv.FormatUint(uint64(b.ServiceID), 10))
+	if err != nil {
+		return err
+	}
+	amount := money.Min(svc.Price.Percent(int64(sub.Plan.DiscountPercent), money.DiscountRounding), svc.Price.Sub(b.Discount))
+	if amount.IsPositive() {
+		b.Discount = b.Discount.Add(amount)
+	}
+	return nil
+}
+
+// MemberDiscount возвращает название абонемента клиента и скидку по нему в
+// процентах; без действующего абонемента — 0.
+func (s *MembershipService) MemberDiscount(userID uint) (string, int, error) {
+	sub, err := s.repo.GetCurrentSubscription(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if !sub.CoversAt(s.now()) {
+		return "", 0, nil
+	}
+	return sub.Plan.Name, sub.Plan.DiscountPercent, nil
+}
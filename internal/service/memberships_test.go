@@ -0,0 +1,301 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/payments"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockMembershipRepo struct {
+	mock.Mock
+}
+
+func (m *MockMembershipRepo) GetUserByID(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockMembershipRepo) GetServiceByID(id string) (*models.Service, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Service), args.Error(1)
+}
+func (m *MockMembershipRepo) CreateMembershipPlan(p *models.MembershipPlan) error {
+	return m.Called(p).Error(0)
+}
+func (m *MockMembershipRepo) GetMembershipPlans() ([]models.MembershipPlan, error) {
+	args := m.Called()
+	return args.Get(0).([]models.MembershipPlan), args.Error(1)
+}
+func (m *MockMembershipRepo) GetMembershipPlanByID(id uint) (*models.MembershipPlan, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MembershipPlan), args.Error(1)
+}
+func (m *MockMembershipRepo) DeleteMembershipPlan(id string) error { return m.Called(id).Error(0) }
+func (m *MockMembershipRepo) CreateSubscription(s *models.Subscription) error {
+	return m.Called(s).Error(0)
+}
+func (m *MockMembershipRepo) SaveSubscription(s *models.Subscription) error {
+	return m.Called(s).Error(0)
+}
+func (m *MockMembershipRepo) GetSubscriptionByID(id uint) (*models.Subscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+func (m *MockMembershipRepo) GetCurrentSubscription(userID uint) (*models.Subscription, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+func (m *MockMembershipRepo) GetDueSubscriptions(at time.Time) ([]models.Subscription, error) {
+	args := m.Called(at)
+	return args.Get(0).([]models.Subscription), args.Error(1)
+}
+func (m *MockMembershipRepo) CountMembershipVisits(subscriptionID, serviceID uint, since time.Time) (int64, error) {
+	args := m.Called(subscriptionID, serviceID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// CreateMembershipPayment без заданного результата возвращает переданный платёж.
+func (m *MockMembershipRepo) CreateMembershipPayment(p *models.Payment) (*models.Payment, bool, error) {
+	args := m.Called(p)
+	if args.Get(0) == nil {
+		return p, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*models.Payment), args.Bool(1), args.Error(2)
+}
+func (m *MockMembershipRepo) SavePayment(p *models.Payment) error { return m.Called(p).Error(0) }
+func (m *MockMembershipRepo) SetPaymentStatus(id uint, status string, at time.Time) (*models.Payment, bool, error) {
+	args := m.Called(id, status, at)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*models.Payment), args.Bool(1), args.Error(2)
+}
+
+var membershipNow = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// fakeClock — часы, которые тест переводит вручную.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+func (c *fakeClock) Set(t time.Time)         { c.t = t }
+
+func newMembershipService(repo *MockMembershipRepo, provider payments.Provider, clock *fakeClock) *MembershipService {
+	svc := NewMembershipService(repo, provider, DefaultMembershipGrace)
+	svc.now = clock.Now
+	return svc
+}
+
+// blowoutPlan — «укладки без ограничений и 10% на всё».
+func blowoutPlan() *models.MembershipPlan {
+	p := &models.MembershipPlan{Name: "Укладки", Price: kzt(2500000), PeriodMonths: 1, DiscountPercent: 10,
+		Benefits: []models.MembershipBenefit{{ServiceID: 5}, {ServiceID: 6, PerPeriod: 2}}}
+	p.ID = 2
+	return p
+}
+
+func activeSubscription() *models.Subscription {
+	start, end := membershipNow, membershipNow.AddDate(0, 1, 0)
+	s := &models.Subscription{UserID: 4, PlanID: 2, Status: models.SubscriptionActive,
+		CurrentPeriodStart: &start, CurrentPeriodEnd: &end, Plan: *blowoutPlan()}
+	s.ID = 3
+	return s
+}
+
+func TestCreateMembershipPlan(t *testing.T) {
+	repo := new(MockMembershipRepo)
+	svc := newMembershipService(repo, payments.NewFakeProvider("secret"), &fakeClock{t: membershipNow})
+	repo.On("GetServiceByID", "5").Return(&models.Service{}, nil)
+	repo.On("GetServiceByID", "9").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("CreateMembershipPlan", mock.Anything).Return(nil).Once()
+
+	p, err := svc.CreatePlan(MembershipPlanInput{Name: "Укладки", Price: kzt(2500000), DiscountPercent: 10,
+		Benefits: []MembershipBenefitInput{{ServiceID: 5}}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.PeriodMonths)
+
+	for _, in := range []MembershipPlanInput{
+		{Price: kzt(100), DiscountPercent: 10},
+		{Name: "Без цены", DiscountPercent: 10},
+		{Name: "Пустой", Price: kzt(100)},
+		{Name: "Скидка", Price: kzt(100), DiscountPercent: 101},
+		{Name: "Период", Price: kzt(100), DiscountPercent: 10, PeriodMonths: 13},
+		{Name: "Дубль", Price: kzt(100), Benefits: []MembershipBenefitInput{{ServiceID: 5}, {ServiceID: 5}}},
+		{Name: "Нет услуги", Price: kzt(100), Benefits: []MembershipBenefitInput{{ServiceID: 9}}},
+	} {
+		_, err := svc.CreatePlan(in)
+		assert.ErrorIs(t, err, ErrInvalidMembershipPlan, in.Name)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestMembershipBillingCycle(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockMembershipRepo)
+	provider := payments.NewFakeProvider("secret")
+	clock := &fakeClock{t: membershipNow}
+	svc := newMembershipService(repo, provider, clock)
+
+	var saved []models.Subscription
+	repo.On("SaveSubscription", mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, *args.Get(0).(*models.Subscription))
+	}).Return(nil)
+	repo.On("SavePayment", mock.Anything).Return(nil)
+	repo.On("GetUserByID", uint(4)).Return(&models.User{Locale: "kk"}, nil)
+	repo.On("GetMembershipPlanByID", uint(2)).Return(blowoutPlan(), nil).Once()
+	repo.On("CreateSubscription", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Subscription).ID = 3
+	}).Return(nil).Once()
+	repo.On("CreateMembershipPayment", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Payment).ID = 11
+	}).Return(nil, true, nil).Once()
+
+	// Оформление: абонемент ждёт первой оплаты.
+	sub, p, err := svc.Subscribe(ctx, 4, 2)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubscriptionPending, sub.Status)
+	assert.Equal(t, membershipNow.Add(DefaultMembershipGrace), *sub.GraceUntil)
+	assert.Equal(t, "membership", p.Kind)
+	assert.Equal(t, kzt(2500000), p.Amount)
+	assert.Equal(t, uint(3), *p.SubscriptionID)
+	assert.NotEmpty(t, p.ConfirmationURL)
+
+	// Оплата пришла через час: период отсчитывается от неё.
+	clock.Advance(time.Hour)
+	repo.On("GetSubscriptionByID", uint(3)).Return(sub, nil).Once()
+	require.NoError(t, svc.PaymentSucceeded(ctx, p))
+	require.Len(t, saved, 1)
+	assert.Equal(t, models.SubscriptionActive, saved[0].Status)
+	assert.Equal(t, clock.Now(), *saved[0].CurrentPeriodStart)
+	periodEnd := clock.Now().AddDate(0, 1, 0)
+	assert.Equal(t, periodEnd, *saved[0].CurrentPeriodEnd)
+	assert.Nil(t, saved[0].GraceUntil)
+
+	// Период кончился: выставлен счёт на продление, идёт льготный срок.
+	clock.Set(periodEnd.Add(time.Minute))
+	active := saved[0]
+	repo.On("GetDueSubscriptions", clock.Now()).Return([]models.Subscription{active}, nil).Once()
+	repo.On("CreateMembershipPayment", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Payment).ID = 12
+	}).Return(nil, true, nil).Once()
+	require.NoError(t, svc.Renew(ctx))
+	require.Len(t, saved, 2)
+	assert.Equal(t, models.SubscriptionPastDue, saved[1].Status)
+	assert.Equal(t, periodEnd.Add(DefaultMembershipGrace), *saved[1].GraceUntil)
+	assert.True(t, saved[1].CoversAt(clock.Now()), "benefits continue during the grace period")
+
+	// Банк отказал, клиент не оплатил до конца льготного срока — абонемент сгорел.
+	clock.Set(periodEnd.Add(DefaultMembershipGrace))
+	repo.On("GetDueSubscriptions", clock.Now()).Return([]models.Subscription{saved[1]}, nil).Once()
+	require.NoError(t, svc.Renew(ctx))
+	require.Len(t, saved, 3)
+	assert.Equal(t, models.SubscriptionExpired, saved[2].Status)
+	assert.False(t, saved[2].CoversAt(clock.Now()))
+	repo.AssertExpectations(t)
+}
+
+func TestMembershipLatePaymentExtendsFromPeriodEnd(t *testing.T) {
+	repo := new(MockMembershipRepo)
+	clock := &fakeClock{t: membershipNow.AddDate(0, 1, 1)}
+	svc := newMembershipService(repo, payments.NewFakeProvider("secret"), clock)
+	sub := activeSubscription()
+	grace := sub.CurrentPeriodEnd.Add(DefaultMembershipGrace)
+	sub.Status, sub.GraceUntil = models.SubscriptionPastDue, &grace
+	repo.On("GetSubscriptionByID", uint(3)).Return(sub, nil).Once()
+	repo.On("SaveSubscription", mock.Anything).Return(nil).Once()
+
+	require.NoError(t, svc.PaymentSucceeded(context.Background(), &models.Payment{SubscriptionID: &sub.ID}))
+	assert.Equal(t, models.SubscriptionActive, sub.Status)
+	assert.Equal(t, membershipNow.AddDate(0, 1, 0), *sub.CurrentPeriodStart)
+	assert.Equal(t, membershipNow.AddDate(0, 2, 0), *sub.CurrentPeriodEnd)
+}
+
+func TestRenewCancelledAtPeriodEnd(t *testing.T) {
+	repo := new(MockMembershipRepo)
+	clock := &fakeClock{t: membershipNow.AddDate(0, 1, 0)}
+	svc := newMembershipService(repo, payments.NewFakeProvider("secret"), clock)
+	sub := activeSubscription()
+	sub.CancelAtPeriodEnd = true
+	repo.On("GetDueSubscriptions", clock.Now()).Return([]models.Subscription{*sub}, nil).Once()
+	repo.On("SaveSubscription", mock.MatchedBy(func(s *models.Subscription) bool { return s.Status == models.SubscriptionCancelled })).Return(nil).Once()
+
+	require.NoError(t, svc.Renew(context.Background()))
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "CreateMembershipPayment", mock.Anything)
+}
+
+func TestMembershipPaymentProviderDown(t *testing.T) {
+	repo := new(MockMembershipRepo)
+	provider := payments.NewFakeProvider("secret")
+	provider.Err = errors.New("timeout")
+	svc := newMembershipService(repo, provider, &fakeClock{t: membershipNow})
+	sub := activeSubscription()
+	sub.Status = models.SubscriptionPastDue
+	repo.On("GetCurrentSubscription", uint(4)).Return(sub, nil).Once()
+	repo.On("CreateMembershipPayment", mock.Anything).Return(nil, true, nil).Once()
+	repo.On("GetUserByID", uint(4)).Return(nil, errors.New("db down")).Once()
+	repo.On("SavePayment", mock.MatchedBy(func(p *models.Payment) bool { return p.Status == "failed" })).Return(nil).Once()
+
+	_, err := svc.Pay(context.Background(), 4)
+	assert.ErrorIs(t, err, ErrPaymentProvider)
+	repo.AssertExpectations(t)
+}
+
+func TestApplyMembership(t *testing.T) {
+	repo := new(MockMembershipRepo)
+	svc := newMembershipService(repo, payments.NewFakeProvider("secret"), &fakeClock{t: membershipNow})
+	repo.On("GetCurrentSubscription", uint(4)).Return(activeSubscription(), nil)
+	repo.On("GetServiceByID", "6").Return(&models.Service{Price: kzt(800000)}, nil)
+
+	t.Run("Unlimited Service", func(t *testing.T) {
+		b := &models.Booking{UserID: 4, ServiceID: 5, Date: "2026-03-10 12:00", Discount: kzt(5000)}
+		require.NoError(t, svc.Apply(b))
+		require.NotNil(t, b.SubscriptionID)
+		assert.Equal(t, uint(3), *b.SubscriptionID)
+		assert.True(t, b.Discount.IsZero())
+	})
+
+	t.Run("Limit Reached Falls Back To Discount", func(t *testing.T) {
+		repo.On("CountMembershipVisits", uint(3), uint(6), membershipNow).Return(int64(2), nil).Once()
+		b := &models.Booking{UserID: 4, ServiceID: 6, Date: "2026-03-10 12:00"}
+		require.NoError(t, svc.Apply(b))
+		assert.Nil(t, b.SubscriptionID)
+		assert.Equal(t, kzt(80000), b.Discount)
+	})
+
+	t.Run("Visit After Period", func(t *testing.T) {
+		b := &models.Booking{UserID: 4, ServiceID: 5, Date: "2026-04-05 12:00"}
+		require.NoError(t, svc.Apply(b))
+		assert.Nil(t, b.SubscriptionID)
+		assert.True(t, b.Discount.IsZero())
+	})
+
+	t.Run("No Membership", func(t *testing.T) {
+		repo.On("GetCurrentSubscription", uint(8)).Return(nil, gorm.ErrRecordNotFound).Once()
+		b := &models.Booking{UserID: 8, ServiceID: 5, Date: "2026-03-10 12:00"}
+		require.NoError(t, svc.Apply(b))
+		assert.Nil(t, b.SubscriptionID)
+	})
+}
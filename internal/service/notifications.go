@@ -0,0 +1,306 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/notify"
+	"beauty-salon/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidPreferences = errors.New("invalid notification preferences")
+
+const (
+	maxNotificationAttempts = 5
+	notificationLease       = 5 * time.Minute
+	bookingDateLayout       = "2006-01-02 15:04"
+)
+
+// DefaultReminderOffsets — за сколько до визита напоминать клиенту.
+var DefaultReminderOffsets = []time.Duration{24 * time.Hour, 2 * time.Hour}
+
+// BookingNotifier получает события жизненного цикла записи.
+type BookingNotifier interface {
+	BookingEvent(event string, b *models.Booking)
+}
+
+// BookingAttachments готовит вложения к уведомлению о записи, например приглашение .ics.
+type BookingAttachments interface {
+	BookingAttachments(event string, bookingID uint) ([]notify.Attachment, error)
+}
+
+// NotificationPreferencesInput перечисляет каналы и события, от которых клиент отписался.
+type NotificationPreferencesInput struct {
+	DisabledChannels []string `json:"disabled_channels"`
+	DisabledEvents   []string `json:"disabled_events"`
+}
+
+type Notifications interface {
+	GetNotifications(userID uint) ([]models.Notification, error)
+	GetPreferences(userID uint) (*models.NotificationPreference, error)
+	SavePreferences(userID uint, in NotificationPreferencesInput) (*models.NotificationPreference, error)
+}
+
+type NotificationService struct {
+	repo     repository.NotificationRepository
+	channels []notify.Channel
+	offsets  []time.Duration
+	attach   BookingAttachments
+	now      func() time.Time
+}
+
+func NewNotificationService(repo repository.NotificationRepository, offsets []time.Duration, channels ...notify.Channel) *NotificationService {
+	offsets = append([]time.Duration(nil), offsets...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return &NotificationService{repo: repo, channels: channels, offsets: offsets, now: time.Now}
+}
+
+// SetAttachments включает вложения в каналах, которые их поддерживают (email).
+func (s *NotificationService) SetAttachments(a BookingAttachments) { s.attach = a }
+
+// ParseReminderOffsets разбирает список вида "24h,2h".
+func ParseReminderOffsets(s string) ([]time.Duration, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultReminderOffsets, nil
+	}
+	var offsets []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid reminder offset %q", part)
+		}
+		offsets = append(offsets, d)
+	}
+	return offsets, nil
+}
+
+// RegisterExportSections добавляет уведомления и настройки в выгрузку персональных данных.
+func (s *NotificationService) RegisterExportSections(e *ExportService) {
+	e.AddSection("notifications", func(id uint) (interface{}, error) { return s.repo.GetNotificationsByUser(id) })
+	e.AddSection("notification_preferences", func(id uint) (interface{}, error) { return s.GetPreferences(id) })
+}
+
+// BookingEvent ставит уведомления в очередь. Ошибки не мешают самой записи и только логируются.
+func (s *NotificationService) BookingEvent(event string, b *models.Booking) {
+	if b.Service.ID == 0 || b.User.ID == 0 {
+		full, err := s.repo.GetBookingByID(strconv.FormatUint(uint64(b.ID), 10))
+		if err != nil {
+			log.Printf("notify: booking %d: %v", b.ID, err)
+			return
+		}
+		b = full
+	}
+	if err := s.enqueue(event, b, ""); err != nil {
+		log.Printf("notify: booking %d %s: %v", b.ID, event, err)
+	}
+}
+
+// enqueue создаёт по уведомлению на каждый доступный клиенту канал.
+// Непустой dedup делает уведомление уникальным в пределах канала.
+func (s *NotificationService) enqueue(event string, b *models.Booking, dedup string) error {
+	pref, err := s.GetPreferences(b.UserID)
+	if err != nil {
+		return err
+	}
+	// Язык берётся из профиля клиента; без него — шаблоны по умолчанию.
+	subject, body, err := notify.Render(b.User.Locale, event, notify.Data{
+		Name:    displayName(&b.User),
+		Service: b.Service.Localized(b.User.Locale).Title,
+		Staff:   b.Staff.FullName,
+		Date:    b.Date,
+	})
+	if err != nil {
+		return err
+	}
+	bookingID := b.ID
+	for _, ch := range s.channels {
+		to := ch.Address(&b.User)
+		if to == "" || !pref.Allows(ch.Name(), event) {
+			continue
+		}
+		n := &models.Notification{
+			UserID: b.UserID, BookingID: &bookingID, Event: event, Channel: ch.Name(), To: to,
+			Subject: subject, Body: body, Status: "pending", NextAttemptAt: s.now(),
+		}
+		if dedup != "" {
+			key := dedup + ":" + ch.Name()
+			n.DedupKey = &key
+		}
+		if err := s.repo.CreateNotification(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScheduleReminders ставит напоминания о ближайших записях. Для каждой записи
+// выбирается наименьший подходящий интервал, поэтому запись, созданная за час
+// до визита, получит одно напоминание, а не все сразу.
+func (s *NotificationService) ScheduleReminders() error {
+	if len(s.offsets) == 0 {
+		return nil
+	}
+	now := s.now()
+	bookings, err := s.repo.GetUpcomingBookings(now.Format(bookingDateLayout), now.Add(s.offsets[len(s.offsets)-1]).Format(bookingDateLayout))
+	if err != nil {
+		return err
+	}
+	for i := range bookings {
+		b := &bookings[i]
+		at, err := time.ParseInLocation(bookingDateLayout, b.Date, now.Location())
+		if err != nil {
+			continue
+		}
+		for _, offset := range s.offsets {
+			if at.Sub(now) > offset {
+				continue
+			}
+			// В ключе есть дата: после переноса записи напоминание придёт снова.
+			dedup := fmt.Sprintf("reminder:%d:%s:%s", b.ID, b.Date, offset)
+			if err := s.enqueue(notify.EventBookingReminder, b, dedup); err != nil {
+				log.Printf("notify: reminder for booking %d: %v", b.ID, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// Run планирует напоминания и отправляет очередь, пока не отменён ctx.
+func (s *NotificationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.ScheduleReminders(); err != nil {
+			log.Printf("notify: schedule reminders: %v", err)
+		}
+		for s.ProcessNext(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNext отправляет одно уведомление. Возвращает false, если отправлять нечего.
+func (s *NotificationService) ProcessNext(ctx context.Context) bool {
+	n, err := s.repo.ClaimDueNotification(s.now(), notificationLease)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("notify: claim failed: %v", err)
+		}
+		return false
+	}
+
+	n.Attempts++
+	if err := s.send(ctx, n); err != nil {
+		n.LastError = err.Error()
+		if n.Attempts >= maxNotificationAttempts {
+			n.Status = "failed"
+		} else {
+			// 1, 2, 4, 8 минут между попытками.
+			n.NextAttemptAt = s.now().Add(time.Minute << (n.Attempts - 1))
+		}
+	} else {
+		sentAt := s.now()
+		n.Status, n.SentAt, n.LastError = "sent", &sentAt, ""
+	}
+	if err := s.repo.SaveNotification(n); err != nil {
+		log.Printf("notify %d: save failed: %v", n.ID, err)
+	}
+	return true
+}
+
+func (s *NotificationService) send(ctx context.Context, n *models.Notification) error {
+	for _, ch := range s.channels {
+		if ch.Name() != n.Channel {
+			continue
+		}
+		// Вложения собираются при отправке, чтобы отразить текущее состояние записи.
+		if ac, ok := ch.(notify.AttachingChannel); ok && s.attach != nil && n.BookingID != nil {
+			files, err := s.attach.BookingAttachments(n.Event, *n.BookingID)
+			if err != nil {
+				return err
+			}
+			if len(files) > 0 {
+				return ac.SendWithAttachments(ctx, n.To, n.Subject, n.Body, files)
+			}
+		}
+		return ch.Send(ctx, n.To, n.Subject, n.Body)
+	}
+	return fmt.Errorf("channel %q is not configured", n.Channel)
+}
+
+func (s *NotificationService) GetNotifications(userID uint) ([]models.Notification, error) {
+	return s.repo.GetNotificationsByUser(userID)
+}
+
+func (s *NotificationService) GetPreferences(userID uint) (*models.NotificationPreference, error) {
+	p, err := s.repo.GetNotificationPreference(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.NotificationPreference{UserID: userID}, nil
+	}
+	return p, err
+}
+
+func (s *NotificationService) SavePreferences(userID uint, in NotificationPreferencesInput) (*models.NotificationPreference, error) {
+	var channels []string
+	for _, ch := range s.channels {
+		channels = append(channels, ch.Name())
+	}
+	disabledChannels, err := normalizeList(in.DisabledChannels, channels)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPreferences, err)
+	}
+	disabledEvents, err := normalizeList(in.DisabledEvents, notify.Events)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPreferences, err)
+	}
+	p := &models.NotificationPreference{UserID: userID, DisabledChannels: disabledChannels, DisabledEvents: disabledEvents}
+	if err := s.repo.SaveNotificationPreference(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// normalizeList проверяет значения по списку допустимых и склеивает их через запятую.
+func normalizeList(values, allowed []string) (string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if seen[v] {
+			continue
+		}
+		known := false
+		for _, a := range allowed {
+			if a == v {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return "", fmt.Errorf("unknown value %q", v)
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return strings.Join(out, ","), nil
+}
+
+func displayName(u *models.User) string {
+	if u.FullName != "" {
+		return u.FullName
+	}
+	return u.Username
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/notify"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockNotificationRepo struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepo) GetUserByID(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockNotificationRepo) GetBookingByID(id string) (*models.Booking, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+func (m *MockNotificationRepo) GetUpcomingBookings(from, to string) ([]models.Booking, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
+func (m *MockNotificationRepo) GetNotificationPreference(userID uint) (*models.NotificationPreference, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.NotificationPreference), args.Error(1)
+}
+func (m *MockNotificationRepo) SaveNotificationPreference(p *models.NotificationPreference) error {
+	return m.Called(p).Error(0)
+}
+func (m *MockNotificationRepo) CreateNotification(n *models.Notification) error {
+	return m.Called(n).Error(0)
+}
+func (m *MockNotificationRepo) GetNotificationsByUser(userID uint) ([]models.Notification, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Notification), args.Error(1)
+}
+func (m *MockNotificationRepo) ClaimDueNotification(now time.Time, lease time.Duration) (*models.Notification, error) {
+	args := m.Called(now, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Notification), args.Error(1)
+}
+func (m *MockNotificationRepo) SaveNotification(n *models.Notification) error {
+	return m.Called(n).Error(0)
+}
+
+type recordingNotifier struct {
+	events []string
+}
+
+func (r *recordingNotifier) BookingEvent(event string, _ *models.Booking) {
+	r.events = append(r.events, event)
+}
+
+func testBooking(date string) models.Booking {
+	phone := "+77010000000"
+	b := models.Booking{
+		UserID: 3, Date: date, Status: "confirmed",
+		User:    models.User{Username: "anna", FullName: "Анна", Phone: &phone},
+		Service: models.Service{Title: "Стрижка"},
+		Staff:   models.Staff{FullName: "Ольга"},
+	}
+	b.ID = 12
+	b.User.ID, b.Service.ID = 3, 1
+	return b
+}
+
+func TestBookingEventEnqueues(t *testing.T) {
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Renders And Respects Preferences", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := NewNotificationService(repo, nil, &notify.FakeChannel{ChannelName: "sms"}, &notify.FakeChannel{ChannelName: "email"})
+		svc.now = func() time.Time { return now }
+
+		b := testBooking("2025-05-02 12:00")
+		repo.On("GetNotificationPreference", uint(3)).Return(&models.NotificationPreference{DisabledChannels: "email"}, nil).Once()
+		repo.On("CreateNotification", mock.MatchedBy(func(n *models.Notification) bool {
+			return n.Channel == "sms" && n.To == "+77010000000" && n.Event == notify.EventBookingConfirmed &&
+				n.DedupKey == nil && n.NextAttemptAt.Equal(now) &&
+				assert.Contains(t, n.Body, "Стрижка") && assert.Contains(t, n.Body, "Анна")
+		})).Return(nil).Once()
+
+		svc.BookingEvent(notify.EventBookingConfirmed, &b)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Uses Client Locale", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := NewNotificationService(repo, nil, &notify.FakeChannel{ChannelName: "sms"})
+
+		b := testBooking("2025-05-02 12:00")
+		b.User.Locale = "kk"
+		b.Service.Translations = map[string]models.ServiceText{"kk": {Title: "Шаш қию"}}
+		repo.On("GetNotificationPreference", uint(3)).Return(nil, gorm.ErrRecordNotFound).Once()
+		repo.On("CreateNotification", mock.MatchedBy(func(n *models.Notification) bool {
+			return n.Subject == "Жазба расталды" && assert.Contains(t, n.Body, "Шаш қию")
+		})).Return(nil).Once()
+
+		svc.BookingEvent(notify.EventBookingConfirmed, &b)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Reloads Bare Booking", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := NewNotificationService(repo, nil, &notify.FakeChannel{ChannelName: "sms"})
+
+		full := testBooking("2025-05-02 12:00")
+		repo.On("GetBookingByID", "12").Return(&full, nil).Once()
+		repo.On("GetNotificationPreference", uint(3)).Return(nil, gorm.ErrRecordNotFound).Once()
+		repo.On("CreateNotification", mock.Anything).Return(nil).Once()
+
+		bare := &models.Booking{UserID: 3}
+		bare.ID = 12
+		svc.BookingEvent(notify.EventBookingCreated, bare)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Opted Out Of Event", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := NewNotificationService(repo, nil, &notify.FakeChannel{ChannelName: "sms"})
+
+		b := testBooking("2025-05-02 12:00")
+		repo.On("GetNotificationPreference", uint(3)).Return(&models.NotificationPreference{DisabledEvents: "booking_created"}, nil).Once()
+
+		svc.BookingEvent(notify.EventBookingCreated, &b)
+		repo.AssertNotCalled(t, "CreateNotification", mock.Anything)
+	})
+}
+
+func TestScheduleReminders(t *testing.T) {
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := new(MockNotificationRepo)
+	svc := NewNotificationService(repo, []time.Duration{2 * time.Hour, 24 * time.Hour}, &notify.FakeChannel{ChannelName: "sms"})
+	svc.now = func() time.Time { return now }
+
+	tomorrow := testBooking("2025-05-02 09:00") // через 23 часа — напоминание за сутки
+	soon := testBooking("2025-05-01 11:00")     // через час — только напоминание за 2 часа
+	soon.ID = 13
+	repo.On("GetUpcomingBookings", "2025-05-01 10:00", "2025-05-02 10:00").Return([]models.Booking{soon, tomorrow}, nil).Once()
+	repo.On("GetNotificationPreference", uint(3)).Return(nil, gorm.ErrRecordNotFound)
+
+	var keys []string
+	repo.On("CreateNotification", mock.Anything).Run(func(args mock.Arguments) {
+		n := args.Get(0).(*models.Notification)
+		assert.Equal(t, notify.EventBookingReminder, n.Event)
+		keys = append(keys, *n.DedupKey)
+	}).Return(nil)
+
+	require.NoError(t, svc.ScheduleReminders())
+	assert.Equal(t, []string{
+		"reminder:13:2025-05-01 11:00:2h0m0s:sms",
+		"reminder:12:2025-05-02 09:00:24h0m0s:sms",
+	}, keys)
+}
+
+type fakeAttachments struct{}
+
+func (fakeAttachments) BookingAttachments(_ string, bookingID uint) ([]notify.Attachment, error) {
+	return []notify.Attachment{{Name: fmt.Sprintf("booking-%d.ics", bookingID)}}, nil
+}
+
+func TestProcessNextNotification(t *testing.T) {
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	setup := func(ch *notify.FakeChannel) (*NotificationService, *MockNotificationRepo) {
+		repo := new(MockNotificationRepo)
+		svc := NewNotificationService(repo, nil, ch)
+		svc.now = func() time.Time { return now }
+		return svc, repo
+	}
+
+	t.Run("Sent", func(t *testing.T) {
+		ch := &notify.FakeChannel{ChannelName: "sms"}
+		svc, repo := setup(ch)
+		n := &models.Notification{Channel: "sms", To: "+77010000000", Body: "текст", Status: "pending"}
+		repo.On("ClaimDueNotification", now, notificationLease).Return(n, nil).Once()
+		repo.On("SaveNotification", n).Return(nil).Once()
+
+		assert.True(t, svc.ProcessNext(context.Background()))
+		assert.Equal(t, "sent", n.Status)
+		assert.Equal(t, 1, n.Attempts)
+		assert.Len(t, ch.Sent(), 1)
+	})
+
+	t.Run("Retries With Backoff", func(t *testing.T) {
+		svc, repo := setup(&notify.FakeChannel{ChannelName: "sms", Err: errors.New("gateway down")})
+		n := &models.Notification{Channel: "sms", Status: "pending", Attempts: 2}
+		repo.On("ClaimDueNotification", now, notificationLease).Return(n, nil).Once()
+		repo.On("SaveNotification", n).Return(nil).Once()
+
+		assert.True(t, svc.ProcessNext(context.Background()))
+		assert.Equal(t, "pending", n.Status)
+		assert.Equal(t, 3, n.Attempts)
+		assert.Equal(t, now.Add(4*time.Minute), n.NextAttemptAt)
+		assert.Equal(t, "gateway down", n.LastError)
+	})
+
+	t.Run("Gives Up", func(t *testing.T) {
+		svc, repo := setup(&notify.FakeChannel{ChannelName: "sms", Err: errors.New("gateway down")})
+		n := &models.Notification{Channel: "sms", Status: "pending", Attempts: maxNotificationAttempts - 1}
+		repo.On("ClaimDueNotification", now, notificationLease).Return(n, nil).Once()
+		repo.On("SaveNotification", n).Return(nil).Once()
+
+		assert.True(t, svc.ProcessNext(context.Background()))
+		assert.Equal(t, "failed", n.Status)
+	})
+
+	t.Run("With Attachments", func(t *testing.T) {
+		ch := &notify.FakeChannel{ChannelName: "email"}
+		svc, repo := setup(ch)
+		svc.SetAttachments(fakeAttachments{})
+		bookingID := uint(7)
+		n := &models.Notification{Channel: "email", Event: notify.EventBookingConfirmed, BookingID: &bookingID, Status: "pending"}
+		repo.On("ClaimDueNotification", now, notificationLease).Return(n, nil).Once()
+		repo.On("SaveNotification", n).Return(nil).Once()
+
+		assert.True(t, svc.ProcessNext(context.Background()))
+		assert.Equal(t, "sent", n.Status)
+		require.Len(t, ch.Sent(), 1)
+		assert.Equal(t, "booking-7.ics", ch.Sent()[0].Attachments[0].Name)
+	})
+
+	t.Run("Empty Queue", func(t *testing.T) {
+		svc, repo := setup(&notify.FakeChannel{ChannelName: "sms"})
+		repo.On("ClaimDueNotification", now, notificationLease).Return(nil, gorm.ErrRecordNotFound).Once()
+		assert.False(t, svc.ProcessNext(context.Background()))
+	})
+}
+
+func TestSaveNotificationPreferences(t *testing.T) {
+	repo := new(MockNotificationRepo)
+	svc := NewNotificationService(repo, nil, &notify.FakeChannel{ChannelName: "sms"}, &notify.FakeChannel{ChannelName: "email"})
+
+	repo.On("SaveNotificationPreference", mock.MatchedBy(func(p *models.NotificationPreference) bool {
+		return p.UserID == 3 && p.DisabledChannels == "email" && p.DisabledEvents == "booking_reminder"
+	})).Return(nil).Once()
+	_, err := svc.SavePreferences(3, NotificationPreferencesInput{
+		DisabledChannels: []string{"email", "email"},
+		DisabledEvents:   []string{"booking_reminder"},
+	})
+	assert.NoError(t, err)
+
+	_, err = svc.SavePreferences(3, NotificationPreferencesInput{DisabledChannels: []string{"pigeon"}})
+	assert.ErrorIs(t, err, ErrInvalidPreferences)
+}
+
+func TestParseReminderOffsets(t *testing.T) {
+	offsets, err := ParseReminderOffsets("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultReminderOffsets, offsets)
+
+	offsets, err = ParseReminderOffsets("48h, 30m")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{48 * time.Hour, 30 * time.Minute}, offsets)
+
+	_, err = ParseReminderOffsets("tomorrow")
+	assert.Error(t, err)
+}
+
+func TestSalonServiceBookingEvents(t *testing.T) {
+	setup := func() (*SalonService, *MockRepo, *recordingNotifier) {
+		repo := new(MockRepo)
+		svc := NewSalonService(repo, testTokens())
+		n := &recordingNotifier{}
+		svc.SetNotifier(n)
+		return svc, repo, n
+	}
+
+	t.Run("Created", func(t *testing.T) {
+		svc, repo, n := setup()
+		repo.On("CreateBooking", mock.Anything).Return(nil).Once()
+		assert.NoError(t, svc.CreateBooking(&models.Booking{}))
+		assert.Equal(t, []string{notify.EventBookingCreated}, n.events)
+	})
+
+	t.Run("Confirmed And Rescheduled", func(t *testing.T) {
+		svc, repo, n := setup()
+		before := &models.Booking{Status: "pending", Date: "2025-05-02 10:00"}
+		confirmed := &models.Booking{Status: "confirmed", Date: "2025-05-02 10:00"}
+		moved := &models.Booking{Status: "confirmed", Date: "2025-05-03 10:00"}
+		repo.On("UpdateBooking", mock.Anything, mock.Anything).Return(nil)
+
+		repo.On("GetBookingByID", "1").Return(before, nil).Once()
+		repo.On("GetBookingByID", "1").Return(confirmed, nil).Once()
+		_, err := svc.UpdateBooking("1", map[string]interface{}{"status": "confirmed"})
+		assert.NoError(t, err)
+
+		repo.On("GetBookingByID", "1").Return(confirmed, nil).Once()
+		repo.On("GetBookingByID", "1").Return(moved, nil).Once()
+		_, err = svc.UpdateBooking("1", map[string]interface{}{"date": "2025-05-03 10:00"})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{notify.EventBookingConfirmed, notify.EventBookingRescheduled}, n.events)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		svc, repo, n := setup()
+		repo.On("GetBookingByID", "1").Return(&models.Booking{Status: "confirmed"}, nil).Once()
+		repo.On("DeleteBooking", "1").Return(nil).Once()
+		assert.NoError(t, svc.CancelBooking("1"))
+		assert.Equal(t, []string{notify.EventBookingCancelled}, n.events)
+	})
+}
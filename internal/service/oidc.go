@@ -0,0 +1,169 @@
+package service
+
+import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("unknown identity provider")
+	ErrInvalidState     = errors.New("invalid or expired login state")
+	ErrIdentityTaken    = errors.New("identity is linked to another user")
+	usernameUnsafeChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+type OIDCClient interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (*auth.OIDCIdentity, error)
+}
+
+type OIDCLogin interface {
+	BeginLogin(ctx context.Context, provider string, linkUserID uint) (authURL, binding string, err error)
+	CompleteLogin(ctx context.Context, provider, state, binding, code string) (string, error)
+}
+
+// oidcState хранится в Redis между редиректом на провайдера и callback'ом.
+type oidcState struct {
+	Provider   string `json:"provider"`
+	Verifier   string `json:"verifier"`
+	Nonce      string `json:"nonce"`
+	LinkUserID uint   `json:"link_user_id,omitempty"`
+	Binding    string `json:"binding"` // хеш секрета из cookie браузера, начавшего вход
+}
+
+type OIDCService struct {
+	repo      repository.IdentityRepository
+	rdb       *redis.Client
+	tokens    auth.TokenIssuer
+	providers map[string]OIDCClient
+}
+
+func NewOIDCService(repo repository.IdentityRepository, rdb *redis.Client, tokens auth.TokenIssuer, providers ...OIDCClient) *OIDCService {
+	byName := make(map[string]OIDCClient, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &OIDCService{repo: repo, rdb: rdb, tokens: tokens, providers: byName}
+}
+
+// BeginLogin возвращает ссылку на провайдера и секрет binding, который
+// обработчик кладёт в HttpOnly-cookie: callback принимается только в том же
+// браузере, иначе чужой state позволил бы войти или привязать учётку жертве.
+// Если linkUserID != 0, после возврата внешняя учётка будет привязана к этому пользователю.
+func (s *OIDCService) BeginLogin(ctx context.Context, provider string, linkUserID uint) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+	state, binding := auth.RandomToken(24), auth.RandomToken(24)
+	st := oidcState{Provider: provider, Verifier: auth.NewPKCEVerifier(), Nonce: auth.RandomToken(16),
+		LinkUserID: linkUserID, Binding: hashBinding(binding)}
+	data, _ := json.Marshal(st)
+	if err := s.rdb.Set(ctx, "oidc_state:"+state, data, oidcStateTTL).Err(); err != nil {
+		return "", "", err
+	}
+	authURL, err := p.AuthCodeURL(ctx, state, st.Nonce, st.Verifier)
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, binding, nil
+}
+
+// CompleteLogin обрабатывает callback провайдера и возвращает наш токен доступа.
+// При первом входе пользователь создаётся автоматически.
+func (s *OIDCService) CompleteLogin(ctx context.Context, provider, state, binding, code string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+	// state одноразовый: GETDEL защищает от повторного использования.
+	raw, err := s.rdb.GetDel(ctx, "oidc_state:"+state).Bytes()
+	if err != nil {
+		return "", ErrInvalidState
+	}
+	var st oidcState
+	if err := json.Unmarshal(raw, &st); err != nil || st.Provider != provider ||
+		subtle.ConstantTimeCompare([]byte(st.Binding), []byte(hashBinding(binding))) != 1 {
+		return "", ErrInvalidState
+	}
+
+	id, err := p.Exchange(ctx, code, st.Verifier, st.Nonce)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.repo.GetUserByIdentity(provider, id.Subject)
+	switch {
+	case err == nil:
+		if st.LinkUserID != 0 && user.ID != st.LinkUserID {
+			return "", ErrIdentityTaken
+		}
+		return s.tokens.Issue(user.ID)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return "", err
+	}
+
+	identity := &models.UserIdentity{Provider: provider, Subject: id.Subject, Email: id.Email}
+	if st.LinkUserID != 0 {
+		identity.UserID = st.LinkUserID
+		if err := s.repo.CreateIdentity(identity); err != nil {
+			return "", err
+		}
+		return s.tokens.Issue(st.LinkUserID)
+	}
+
+	username, err := s.uniqueUsername(provider, id)
+	if err != nil {
+		return "", err
+	}
+	// Пароля нет: вход по логину/паролю для такого пользователя невозможен.
+	user = &models.User{Username: username}
+	if err := s.repo.CreateUserWithIdentity(user, identity); err != nil {
+		return "", err
+	}
+	return s.tokens.Issue(user.ID)
+}
+
+func hashBinding(binding string) string {
+	sum := sha256.Sum256([]byte(binding))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *OIDCService) uniqueUsername(provider string, id *auth.OIDCIdentity) (string, error) {
+	base := id.PreferredUsername
+	if base == "" && id.Email != "" {
+		base, _, _ = strings.Cut(id.Email, "@")
+	}
+	base = usernameUnsafeChars.ReplaceAllString(strings.ToLower(base), "")
+	if base == "" {
+		base = provider + "_user"
+	}
+	candidate := base
+	for i := 0; i < 5; i++ {
+		taken, err := s.repo.UsernameTaken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = base + "_" + strings.ToLower(auth.RandomToken(3))
+	}
+	return "", errors.New("could not generate unique username")
+}
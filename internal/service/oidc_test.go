@@ -0,0 +1,203 @@
+package service
+
+import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/auth/oidctest"
+	"beauty-salon/internal/models"
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockIdentityRepo struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepo) GetUserByIdentity(provider, subject string) (*models.User, error) {
+	args := m.Called(provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockIdentityRepo) CreateIdentity(i *models.UserIdentity) error { return m.Called(i).Error(0) }
+func (m *MockIdentityRepo) CreateUserWithIdentity(u *models.User, i *models.UserIdentity) error {
+	return m.Called(u, i).Error(0)
+}
+func (m *MockIdentityRepo) UsernameTaken(username string) (bool, error) {
+	args := m.Called(username)
+	return args.Bool(0), args.Error(1)
+}
+
+type oidcFixture struct {
+	provider *oidctest.Provider
+	repo     *MockIdentityRepo
+	redis    redismock.ClientMock
+	tokens   *auth.TokenManager
+	svc      *OIDCService
+	stored   map[string]string
+	binding  string // секрет из cookie браузера, начавшего вход
+}
+
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	p := oidctest.NewProvider("salon-app")
+	t.Cleanup(p.Close)
+	client := auth.NewOIDCProvider(auth.OIDCConfig{
+		Name: "mock", Issuer: p.Issuer(), ClientID: "salon-app", RedirectURL: "http://localhost/cb",
+	}, nil)
+
+	db, rmock := redismock.NewClientMock()
+	f := &oidcFixture{provider: p, repo: new(MockIdentityRepo), redis: rmock, tokens: testTokens(), stored: map[string]string{}}
+	f.svc = NewOIDCService(f.repo, db, f.tokens, client)
+	return f
+}
+
+// begin запускает вход и «логинит» пользователя у провайдера, возвращая code и state.
+func (f *oidcFixture) begin(t *testing.T, linkUserID uint, user oidctest.User) (string, string) {
+	f.redis.CustomMatch(func(expected, actual []interface{}) error {
+		f.stored[actual[1].(string)] = string(actual[2].([]byte))
+		return nil
+	}).ExpectSet("", "", oidcStateTTL).SetVal("OK")
+
+	authURL, binding, err := f.svc.BeginLogin(context.Background(), "mock", linkUserID)
+	require.NoError(t, err)
+	f.binding = binding
+	code, state, err := f.provider.Login(authURL, user)
+	require.NoError(t, err)
+
+	f.redis.ExpectGetDel("oidc_state:" + state).SetVal(f.stored["oidc_state:"+state])
+	return code, state
+}
+
+func TestOIDCLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("First Login Creates User", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		code, state := f.begin(t, 0, oidctest.User{Subject: "sub-1", Email: "Anna.K@example.com"})
+
+		f.repo.On("GetUserByIdentity", "mock", "sub-1").Return(nil, gorm.ErrRecordNotFound).Once()
+		f.repo.On("UsernameTaken", "anna.k").Return(false, nil).Once()
+		f.repo.On("CreateUserWithIdentity", mock.MatchedBy(func(u *models.User) bool {
+			return u.Username == "anna.k" && u.Password == ""
+		}), mock.MatchedBy(func(i *models.UserIdentity) bool {
+			return i.Provider == "mock" && i.Subject == "sub-1" && i.Email == "Anna.K@example.com"
+		})).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.User).ID = 5
+		}).Return(nil).Once()
+
+		token, err := f.svc.CompleteLogin(ctx, "mock", state, f.binding, code)
+		require.NoError(t, err)
+		claims, err := f.tokens.Verify(token)
+		require.NoError(t, err)
+		assert.Equal(t, uint(5), claims.UserID)
+		f.repo.AssertExpectations(t)
+		assert.NoError(t, f.redis.ExpectationsWereMet())
+	})
+
+	t.Run("Existing Identity", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		code, state := f.begin(t, 0, oidctest.User{Subject: "sub-2"})
+
+		user := &models.User{Username: "maria"}
+		user.ID = 9
+		f.repo.On("GetUserByIdentity", "mock", "sub-2").Return(user, nil).Once()
+
+		token, err := f.svc.CompleteLogin(ctx, "mock", state, f.binding, code)
+		require.NoError(t, err)
+		claims, _ := f.tokens.Verify(token)
+		assert.Equal(t, uint(9), claims.UserID)
+		f.repo.AssertNotCalled(t, "CreateUserWithIdentity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Username Collision", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		code, state := f.begin(t, 0, oidctest.User{Subject: "sub-3", PreferredUsername: "admin"})
+
+		f.repo.On("GetUserByIdentity", "mock", "sub-3").Return(nil, gorm.ErrRecordNotFound).Once()
+		f.repo.On("UsernameTaken", "admin").Return(true, nil).Once()
+		f.repo.On("UsernameTaken", mock.AnythingOfType("string")).Return(false, nil).Once()
+		f.repo.On("CreateUserWithIdentity", mock.MatchedBy(func(u *models.User) bool {
+			return u.Username != "admin" && len(u.Username) > len("admin_")
+		}), mock.Anything).Return(nil).Once()
+
+		_, err := f.svc.CompleteLogin(ctx, "mock", state, f.binding, code)
+		assert.NoError(t, err)
+		f.repo.AssertExpectations(t)
+	})
+
+	t.Run("Link To Current User", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		code, state := f.begin(t, 3, oidctest.User{Subject: "sub-4"})
+
+		f.repo.On("GetUserByIdentity", "mock", "sub-4").Return(nil, gorm.ErrRecordNotFound).Once()
+		f.repo.On("CreateIdentity", mock.MatchedBy(func(i *models.UserIdentity) bool {
+			return i.UserID == 3 && i.Subject == "sub-4"
+		})).Return(nil).Once()
+
+		_, err := f.svc.CompleteLogin(ctx, "mock", state, f.binding, code)
+		assert.NoError(t, err)
+		f.repo.AssertExpectations(t)
+	})
+
+	t.Run("Link Identity Of Another User", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		code, state := f.begin(t, 3, oidctest.User{Subject: "sub-5"})
+
+		other := &models.User{}
+		other.ID = 8
+		f.repo.On("GetUserByIdentity", "mock", "sub-5").Return(other, nil).Once()
+
+		_, err := f.svc.CompleteLogin(ctx, "mock", state, f.binding, code)
+		assert.ErrorIs(t, err, ErrIdentityTaken)
+	})
+
+	t.Run("Callback In Another Browser", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		code, state := f.begin(t, 3, oidctest.User{Subject: "sub-6"})
+
+		// Ссылку с чужим state подсунули жертве: cookie её браузера не совпадает.
+		_, err := f.svc.CompleteLogin(ctx, "mock", state, "", code)
+		assert.ErrorIs(t, err, ErrInvalidState)
+		f.repo.AssertNotCalled(t, "CreateIdentity", mock.Anything)
+	})
+
+	t.Run("Invalid State", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		f.redis.ExpectGetDel("oidc_state:forged").RedisNil()
+
+		_, err := f.svc.CompleteLogin(ctx, "mock", "forged", "", "code")
+		assert.ErrorIs(t, err, ErrInvalidState)
+	})
+
+	t.Run("Unknown Provider", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		_, _, err := f.svc.BeginLogin(ctx, "facebook", 0)
+		assert.ErrorIs(t, err, ErrUnknownProvider)
+	})
+}
+
+func TestOIDCBeginLoginURL(t *testing.T) {
+	f := newOIDCFixture(t)
+	f.redis.CustomMatch(func(expected, actual []interface{}) error { return nil }).
+		ExpectSet("", "", oidcStateTTL).SetErr(redis.ErrClosed)
+
+	_, _, err := f.svc.BeginLogin(context.Background(), "mock", 0)
+	assert.Error(t, err)
+
+	f.redis.CustomMatch(func(expected, actual []interface{}) error { return nil }).
+		ExpectSet("", "", oidcStateTTL).SetVal("OK")
+	authURL, binding, err := f.svc.BeginLogin(context.Background(), "mock", 0)
+	require.NoError(t, err)
+	assert.NotEmpty(t, binding)
+	u, _ := url.Parse(authURL)
+	assert.Equal(t, "salon-app", u.Query().Get("client_id"))
+	assert.NotEmpty(t, u.Query().Get("state"))
+}
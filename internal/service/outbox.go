@@ -0,0 +1,92 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	outboxLease      = time.Minute
+	outboxMaxBackoff = time.Hour
+)
+
+// OutboxRelay публикует события из outbox во все Sink'и. Событие считается
+// опубликованным, только когда его приняли все получатели; иначе оно
+// доставляется повторно всем, и получатели отбрасывают дубли по Event.ID.
+type OutboxRelay struct {
+	repo  repository.OutboxRepository
+	sinks []events.Sink
+	now   func() time.Time
+}
+
+func NewOutboxRelay(repo repository.OutboxRepository, sinks ...events.Sink) *OutboxRelay {
+	return &OutboxRelay{repo: repo, sinks: sinks, now: time.Now}
+}
+
+// Run публикует события, пока не отменён ctx.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for r.ProcessNext(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNext публикует одно событие. Возвращает false, если очередь пуста.
+func (r *OutboxRelay) ProcessNext(ctx context.Context) bool {
+	e, err := r.repo.ClaimOutboxEvent(r.now(), outboxLease)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("outbox: claim failed: %v", err)
+		}
+		return false
+	}
+
+	e.Attempts++
+	if err := r.publish(ctx, e); err != nil {
+		e.LastError = err.Error()
+		backoff := time.Second << (e.Attempts - 1)
+		if backoff > outboxMaxBackoff || backoff <= 0 {
+			backoff = outboxMaxBackoff
+		}
+		e.NextAttemptAt = r.now().Add(backoff)
+	} else {
+		publishedAt := r.now()
+		e.Status, e.PublishedAt, e.LastError = "published", &publishedAt, ""
+	}
+	if err := r.repo.SaveOutboxEvent(e); err != nil {
+		log.Printf("outbox %s: save failed: %v", e.EventID, err)
+	}
+	return true
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, e *models.OutboxEvent) error {
+	evt := events.Event{
+		ID:          e.EventID,
+		Type:        e.Type,
+		AggregateID: e.AggregateID,
+		OccurredAt:  e.CreatedAt,
+		Payload:     json.RawMessage(e.Payload),
+	}
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, evt); err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
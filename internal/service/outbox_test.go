@@ -0,0 +1,96 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepo) ClaimOutboxEvent(now time.Time, lease time.Duration) (*models.OutboxEvent, error) {
+	args := m.Called(now, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OutboxEvent), args.Error(1)
+}
+func (m *MockOutboxRepo) SaveOutboxEvent(e *models.OutboxEvent) error { return m.Called(e).Error(0) }
+
+type failingSink struct{ err error }
+
+func (failingSink) Name() string                                  { return "failing" }
+func (s failingSink) Publish(context.Context, events.Event) error { return s.err }
+
+func TestOutboxRelay(t *testing.T) {
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Publishes To Bus", func(t *testing.T) {
+		repo := new(MockOutboxRepo)
+		bus := events.NewBus(10)
+		var got []events.Event
+		bus.Subscribe(events.BookingCreated, func(_ context.Context, e events.Event) error {
+			got = append(got, e)
+			return nil
+		})
+		relay := NewOutboxRelay(repo, bus)
+		relay.now = func() time.Time { return now }
+
+		e := &models.OutboxEvent{EventID: "ev-1", Type: events.BookingCreated, AggregateID: 7, Payload: `{"booking_id":7}`}
+		repo.On("ClaimOutboxEvent", now, outboxLease).Return(e, nil).Once()
+		repo.On("SaveOutboxEvent", e).Return(nil).Once()
+
+		assert.True(t, relay.ProcessNext(context.Background()))
+		assert.Equal(t, "published", e.Status)
+		assert.Equal(t, now, *e.PublishedAt)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "ev-1", got[0].ID)
+		assert.JSONEq(t, `{"booking_id":7}`, string(got[0].Payload))
+	})
+
+	t.Run("Failed Sink Retries Later", func(t *testing.T) {
+		repo := new(MockOutboxRepo)
+		relay := NewOutboxRelay(repo, events.NewBus(10), failingSink{errors.New("timeout")})
+		relay.now = func() time.Time { return now }
+
+		e := &models.OutboxEvent{EventID: "ev-2", Type: events.UserRegistered, Payload: `{}`, Attempts: 3}
+		repo.On("ClaimOutboxEvent", now, outboxLease).Return(e, nil).Once()
+		repo.On("SaveOutboxEvent", e).Return(nil).Once()
+
+		assert.True(t, relay.ProcessNext(context.Background()))
+		assert.Empty(t, e.Status)
+		assert.Equal(t, 4, e.Attempts)
+		assert.Equal(t, now.Add(8*time.Second), e.NextAttemptAt)
+		assert.Equal(t, "failing: timeout", e.LastError)
+	})
+
+	t.Run("Backoff Is Capped", func(t *testing.T) {
+		repo := new(MockOutboxRepo)
+		relay := NewOutboxRelay(repo, failingSink{errors.New("down")})
+		relay.now = func() time.Time { return now }
+
+		e := &models.OutboxEvent{Attempts: 40}
+		repo.On("ClaimOutboxEvent", now, outboxLease).Return(e, nil).Once()
+		repo.On("SaveOutboxEvent", e).Return(nil).Once()
+
+		relay.ProcessNext(context.Background())
+		assert.Equal(t, now.Add(outboxMaxBackoff), e.NextAttemptAt)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		repo := new(MockOutboxRepo)
+		relay := NewOutboxRelay(repo)
+		relay.now = func() time.Time { return now }
+		repo.On("ClaimOutboxEvent", now, outboxLease).Return(nil, gorm.ErrRecordNotFound).Once()
+		assert.False(t, relay.ProcessNext(context.Background()))
+	})
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/repository"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPackage   = errors.New("invalid package")
+	ErrPackageNotFound  = errors.New("package not found")
+	ErrPackageExhausted = repository.ErrPackageExhausted
+)
+
+// PackageInput описывает пакет: услуги с количеством сеансов и общая цена.
+type PackageInput struct {
+	Name         string             `json:"name"`
+	Category     string             `json:"category"`
+	Price        money.Money        `json:"price"`
+	ValidityDays int                `json:"validity_days"` // 0 — бессрочно
+	Items        []PackageItemInput `json:"items"`
+}
+
+type PackageItemInput struct {
+	ServiceID uint `json:"service_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+type Packages interface {
+	CreatePackage(in PackageInput) (*models.Package, error)
+	GetPackages() ([]models.Package, error)
+	DeletePackage(id string) error
+	GetClientPackages(userID uint) ([]models.ClientPackage, error)
+}
+
+type PackageService struct {
+	repo repository.PackageRepository
+	now  func() time.Time
+}
+
+func NewPackageService(repo repository.PackageRepository) *PackageService {
+	return &PackageService{repo: repo, now: time.Now}
+}
+
+func (s *PackageService) CreatePackage(in PackageInput) (*models.Package, error) {
+	p := &models.Package{Name: strings.TrimSpace(in.Name), Category: strings.TrimSpace(in.Category), Price: in.Price, ValidityDays: in.ValidityDays}
+	if p.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidPackage)
+	}
+	if !in.Price.IsPositive() {
+		return nil, fmt.Errorf("%w: price must be positive", ErrInvalidPackage)
+	}
+	if p.Price.Currency == "" {
+		p.Price.Currency = money.DefaultCurrency
+	}
+	if in.ValidityDays < 0 {
+		return nil, fmt.Errorf("%w: validity_days must not be negative", ErrInvalidPackage)
+	}
+	if len(in.Items) == 0 {
+		return nil, fmt.Errorf("%w: package needs at least one service", ErrInvalidPackage)
+	}
+	seen := map[uint]bool{}
+	for _, it := range in.Items {
+		if it.Quantity <= 0 || seen[it.ServiceID] {
+			return nil, fmt.Errorf("%w: each service must be listed once with a positive quantity", ErrInvalidPackage)
+		}
+		seen[it.ServiceID] = true
+		if _, err := s.repo.GetServiceByID(strconv.FormatUint(uint64(it.ServiceID), 10)); errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: service %d not found", ErrInvalidPackage, it.ServiceID)
+		} else if err != nil {
+			return nil, err
+		}
+		p.Items = append(p.Items, models.PackageItem{ServiceID: it.ServiceID, Quantity: it.Quantity})
+	}
+	if err := s.repo.CreatePackage(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *PackageService) GetPackages() ([]models.Package, error) { return s.repo.GetPackages() }
+
+func (s *PackageService) DeletePackage(id string) error {
+	err := s.repo.DeletePackage(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPackageNotFound
+	}
+	return err
+}
+
+func (s *PackageService) GetClientPackages(userID uint) ([]models.ClientPackage, error) {
+	return s.repo.GetClientPackages(userID)
+}
+
+// Apply оплачивает запись сеансом из купленного пакета, если он есть.
+// Сеанс списывается вместе с созданием записи.
+func (s *PackageService) Apply(b *models.Booking) error {
+	b.PackageSessionID = nil
+	at, err := time.ParseInLocation(bookingDateLayout, b.Date, s.now().Location())
+	if err != nil {
+		at = s.now()
+	}
+	session, err := s.repo.FindPackageSession(b.UserID, b.ServiceID, at)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	b.PackageSessionID = &session.ID
+	return nil
+}
+
+// Sell готовит пакет packageID к продаже клиенту userID на кассе; сохраняется
+// он вместе с чеком.
+func (s *PackageService) Sell(packageID, userID uint) (*models.ClientPackage, *models.Package, error) {
+	p, err := s.repo.GetPackageByID(packageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrPackageNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	cp := &models.ClientPackage{UserID: userID, PackageID: p.ID, Name: p.Name}
+	if p.ValidityDays > 0 {
+		expires := s.now().AddDate(0, 0, p.ValidityDays)
+		cp.ExpiresAt = &expires
+	}
+	for _, it := range p.Items {
+		cp.Sessions = append(cp.Sessions, models.ClientPackageSession{ServiceID: it.ServiceID, Total: it.Quantity, Remaining: it.Quantity})
+	}
+	return cp, p, nil
+}
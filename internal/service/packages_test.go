@@ -0,0 +1,126 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockPackageRepo struct {
+	mock.Mock
+}
+
+func (m *MockPackageRepo) GetServiceByID(id string) (*models.Service, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Service), args.Error(1)
+}
+func (m *MockPackageRepo) CreatePackage(p *models.Package) error { return m.Called(p).Error(0) }
+func (m *MockPackageRepo) GetPackages() ([]models.Package, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Package), args.Error(1)
+}
+func (m *MockPackageRepo) GetPackageByID(id uint) (*models.Package, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Package), args.Error(1)
+}
+func (m *MockPackageRepo) DeletePackage(id string) error { return m.Called(id).Error(0) }
+func (m *MockPackageRepo) GetClientPackages(userID uint) ([]models.ClientPackage, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.ClientPackage), args.Error(1)
+}
+func (m *MockPackageRepo) FindPackageSession(userID, serviceID uint, at time.Time) (*models.ClientPackageSession, error) {
+	args := m.Called(userID, serviceID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientPackageSession), args.Error(1)
+}
+
+var packageNow = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func newPackageService(repo *MockPackageRepo) *PackageService {
+	svc := NewPackageService(repo)
+	svc.now = func() time.Time { return packageNow }
+	return svc
+}
+
+func laserPackage() *models.Package {
+	p := &models.Package{Name: "5 сеансов лазера", Category: "cosmetology", Price: kzt(10000000), ValidityDays: 180,
+		Items: []models.PackageItem{{ServiceID: 2, Quantity: 5}}}
+	p.ID = 3
+	return p
+}
+
+func TestCreatePackage(t *testing.T) {
+	repo := new(MockPackageRepo)
+	svc := newPackageService(repo)
+	repo.On("GetServiceByID", "2").Return(&models.Service{}, nil)
+	repo.On("GetServiceByID", "9").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("CreatePackage", mock.Anything).Return(nil).Once()
+
+	p, err := svc.CreatePackage(PackageInput{Name: " Лазер ", Price: money.New(10000000, ""), ValidityDays: 180,
+		Items: []PackageItemInput{{ServiceID: 2, Quantity: 5}}})
+	require.NoError(t, err)
+	assert.Equal(t, "Лазер", p.Name)
+	assert.Equal(t, money.DefaultCurrency, p.Price.Currency)
+	assert.Equal(t, []models.PackageItem{{ServiceID: 2, Quantity: 5}}, p.Items)
+
+	for _, in := range []PackageInput{
+		{Price: kzt(100), Items: []PackageItemInput{{ServiceID: 2, Quantity: 1}}},
+		{Name: "Без цены", Items: []PackageItemInput{{ServiceID: 2, Quantity: 1}}},
+		{Name: "Пустой", Price: kzt(100)},
+		{Name: "Дубль", Price: kzt(100), Items: []PackageItemInput{{ServiceID: 2, Quantity: 1}, {ServiceID: 2, Quantity: 2}}},
+		{Name: "Ноль", Price: kzt(100), Items: []PackageItemInput{{ServiceID: 2}}},
+		{Name: "Нет услуги", Price: kzt(100), Items: []PackageItemInput{{ServiceID: 9, Quantity: 1}}},
+	} {
+		_, err := svc.CreatePackage(in)
+		assert.ErrorIs(t, err, ErrInvalidPackage, in.Name)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestApplyPackageSession(t *testing.T) {
+	repo := new(MockPackageRepo)
+	svc := newPackageService(repo)
+	visit := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	repo.On("FindPackageSession", uint(4), uint(2), visit).Return(&models.ClientPackageSession{ID: 5}, nil).Once()
+	repo.On("FindPackageSession", uint(4), uint(7), visit).Return(nil, gorm.ErrRecordNotFound).Once()
+
+	b := &models.Booking{UserID: 4, ServiceID: 2, Date: mondayMorning}
+	require.NoError(t, svc.Apply(b))
+	require.NotNil(t, b.PackageSessionID)
+	assert.Equal(t, uint(5), *b.PackageSessionID)
+
+	b = &models.Booking{UserID: 4, ServiceID: 7, Date: mondayMorning}
+	require.NoError(t, svc.Apply(b))
+	assert.Nil(t, b.PackageSessionID)
+}
+
+func TestSellPackage(t *testing.T) {
+	repo := new(MockPackageRepo)
+	svc := newPackageService(repo)
+	repo.On("GetPackageByID", uint(3)).Return(laserPackage(), nil).Once()
+	repo.On("GetPackageByID", uint(8)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+	cp, p, err := svc.Sell(3, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "5 сеансов лазера", p.Name)
+	assert.Equal(t, uint(4), cp.UserID)
+	assert.Equal(t, packageNow.AddDate(0, 0, 180), *cp.ExpiresAt)
+	assert.Equal(t, []models.ClientPackageSession{{ServiceID: 2, Total: 5, Remaining: 5}}, cp.Sessions)
+
+	_, _, err = svc.Sell(8, 4)
+	assert.ErrorIs(t, err, ErrPackageNotFound)
+}
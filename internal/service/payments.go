@@ -0,0 +1,326 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/payments"
+	"beauty-salon/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrAlreadyPaid          = errors.New("booking is already paid")
+	ErrBookingNotPayable    = errors.New("booking cannot be paid")
+	ErrIdempotencyConflict  = errors.New("idempotency key is already used for another booking")
+	ErrPaymentNotRefundable = errors.New("payment cannot be refunded")
+	ErrInvalidRefundAmount  = errors.New("invalid refund amount")
+	ErrRefundTooLarge       = repository.ErrRefundExceedsPayment
+	ErrPaymentProvider      = errors.New("payment provider error")
+)
+
+type Payments interface {
+	CreatePayment(ctx context.Context, userID uint, bookingID, idempotencyKey string) (*models.Payment, error)
+	GetUserPayments(userID uint) ([]models.Payment, error)
+	GetBookingPayments(bookingID string) ([]models.Payment, error)
+	HandleNotification(ctx context.Context, header http.Header, body []byte) error
+	Refund(ctx context.Context, paymentID string, amount money.Money, reason string, by uint) (*models.PaymentRefund, error)
+}
+
+// CancellationPolicy решает, остаётся ли предоплата салону при отмене записи.
+type CancellationPolicy interface {
+	RetainsDeposit(date string, cancelledAt time.Time) bool
+}
+
+// BookingStatusUpdater меняет статус записи с уведомлением клиента — это SalonService.
+type BookingStatusUpdater interface {
+	UpdateBooking(id string, updates map[string]interface{}) (*models.Booking, error)
+	CancelBooking(id string) error
+}
+
+type PaymentService struct {
+	repo        repository.PaymentRepository
+	provider    payments.Provider
+	bookings    BookingStatusUpdater
+	policy      CancellationPolicy
+	memberships MembershipBilling
+	now         func() time.Time
+}
+
+// MembershipBilling продлевает абонемент после оплаты — это MembershipService.
+type MembershipBilling interface {
+	PaymentSucceeded(ctx context.Context, p *models.Payment) error
+}
+
+func NewPaymentService(repo repository.PaymentRepository, provider payments.Provider, bookings BookingStatusUpdater) *PaymentService {
+	return &PaymentService{repo: repo, provider: provider, bookings: bookings, now: time.Now}
+}
+
+// SetCancellationPolicy включает удержание предоплаты при поздней отмене.
+// Без политики при отмене возвращается всё.
+func (s *PaymentService) SetCancellationPolicy(p CancellationPolicy) { s.policy = p }
+
+// SetMemberships включает продление абонементов по оплаченным счетам.
+func (s *PaymentService) SetMemberships(m MembershipBilling) { s.memberships = m }
+
+// RegisterExportSections добавляет платежи в выгрузку персональных данных.
+func (s *PaymentService) RegisterExportSections(e *ExportService) {
+	e.AddSection("payments", func(id uint) (interface{}, error) { return s.repo.GetPaymentsByUser(id) })
+}
+
+// Subscribe подписывает сервис на события записей: при отмене записи оплата возвращается.
+func (s *PaymentService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.BookingStatusChanged, s.onBookingStatusChanged)
+}
+
+// CreatePayment выставляет счёт на оплату записи. Повтор с тем же ключом
+// идемпотентности возвращает уже созданный платёж, а не списывает деньги снова.
+func (s *PaymentService) CreatePayment(ctx context.Context, userID uint, bookingID, idempotencyKey string) (*models.Payment, error) {
+	b, err := s.repo.GetBookingByID(bookingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && b.UserID != userID) {
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var key string
+	if idempotencyKey != "" {
+		// Ключ выбирает клиент, поэтому он уникален только в пределах пользователя.
+		key = fmt.Sprintf("user:%d:%s", userID, idempotencyKey)
+		p, err := s.repo.GetPaymentByKey(key)
+		if err == nil {
+			if p.BookingID != b.ID {
+				return nil, ErrIdempotencyConflict
+			}
+			return p, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	} else if key, err = randomHex(16); err != nil {
+		return nil, err
+	}
+
+	// Визит по пакету или включённый в абонемент уже оплачен — второй раз
+	// платить нечего.
+	if b.Status == "cancelled" || b.Status == "completed" || b.Prepaid() {
+		return nil, ErrBookingNotPayable
+	}
+	// Запись ждёт только предоплату и только до срока. Полная оплата — со
+	// скидкой, посчитанной при записи.
+	kind, amount := "full", b.Service.Price.Sub(b.Discount)
+	if b.Status == "awaiting_payment" {
+		if b.PaymentDueAt != nil && !s.now().Before(*b.PaymentDueAt) {
+			return nil, ErrBookingNotPayable
+		}
+		kind, amount = "deposit", b.DepositAmount
+	}
+	existing, err := s.repo.GetPaymentsByBooking(b.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		switch existing[i].Status {
+		case "pending":
+			return &existing[i], nil
+		case "succeeded", "partially_refunded":
+			return nil, ErrAlreadyPaid
+		}
+	}
+
+	if !amount.IsPositive() {
+		return nil, ErrBookingNotPayable
+	}
+	p := &models.Payment{
+		BookingID: b.ID, UserID: userID, Amount: amount, RefundedAmount: money.Zero(amount.Currency), Kind: kind,
+		Provider: s.provider.Name(), Status: "pending", IdempotencyKey: key,
+	}
+	if err := s.repo.CreatePayment(p); err != nil {
+		if repository.IsUniqueViolation(err) {
+			// Тот же ключ пришёл в параллельном запросе.
+			return s.repo.GetPaymentByKey(key)
+		}
+		return nil, err
+	}
+
+	res, err := s.provider.CreatePayment(ctx, payments.CreateRequest{
+		IdempotencyKey: fmt.Sprintf("payment-%d", p.ID),
+		Amount:         p.Amount.Minor,
+		Currency:       p.Amount.Currency,
+		Description:    b.Service.Title,
+	})
+	if err != nil {
+		p.Status = "failed"
+		if serr := s.repo.SavePayment(p); serr != nil {
+			log.Printf("payments: payment %d: %v", p.ID, serr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPaymentProvider, err)
+	}
+	p.ProviderPaymentID, p.ConfirmationURL = res.ID, res.ConfirmationURL
+	if err := s.repo.SavePayment(p); err != nil {
+		return nil, err
+	}
+	if res.Status != payments.StatusPending {
+		if err := s.settle(ctx, p.ID, res.Status); err != nil {
+			return nil, err
+		}
+		return s.repo.GetPaymentByID(strconv.FormatUint(uint64(p.ID), 10))
+	}
+	return p, nil
+}
+
+func (s *PaymentService) GetUserPayments(userID uint) ([]models.Payment, error) {
+	return s.repo.GetPaymentsByUser(userID)
+}
+
+func (s *PaymentService) GetBookingPayments(bookingID string) ([]models.Payment, error) {
+	b, err := s.repo.GetBookingByID(bookingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPaymentsByBooking(b.ID)
+}
+
+// HandleNotification применяет уведомление провайдера о статусе платежа.
+// Провайдер повторяет уведомления, поэтому повтор ничего не меняет.
+func (s *PaymentService) HandleNotification(ctx context.Context, header http.Header, body []byte) error {
+	n, err := s.provider.ParseNotification(header, body)
+	if err != nil {
+		return err
+	}
+	p, err := s.repo.GetPaymentByProviderID(s.provider.Name(), n.PaymentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPaymentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.settle(ctx, p.ID, n.Status)
+}
+
+// settle фиксирует итог платежа. Оплаченная запись подтверждается; если её
+// успели отменить, деньги сразу возвращаются.
+func (s *PaymentService) settle(ctx context.Context, paymentID uint, status string) error {
+	if status != payments.StatusSucceeded && status != payments.StatusFailed {
+		return nil
+	}
+	p, changed, err := s.repo.SetPaymentStatus(paymentID, status, s.now())
+	if err != nil || !changed || status != payments.StatusSucceeded {
+		return err
+	}
+	if p.SubscriptionID != nil {
+		if s.memberships == nil {
+			return nil
+		}
+		return s.memberships.PaymentSucceeded(ctx, p)
+	}
+	id := strconv.FormatUint(uint64(p.BookingID), 10)
+	b, err := s.repo.GetBookingByID(id)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && b.Status == "cancelled"):
+		_, err = s.Refund(ctx, strconv.FormatUint(uint64(p.ID), 10), money.Money{}, "booking cancelled", 0)
+		return err
+	case err != nil:
+		return err
+	case b.Status == "pending" || b.Status == "awaiting_payment":
+		_, err = s.bookings.UpdateBooking(id, map[string]interface{}{"status": "confirmed"})
+		return err
+	}
+	return nil
+}
+
+// Refund возвращает amount (0 — весь остаток). Полный возврат отменяет
+// ещё не состоявшуюся запись.
+func (s *PaymentService) Refund(ctx context.Context, paymentID string, amount money.Money, reason string, by uint) (*models.PaymentRefund, error) {
+	if amount.IsNegative() {
+		return nil, ErrInvalidRefundAmount
+	}
+	p, err := s.repo.GetPaymentByID(paymentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Refundable().IsZero() {
+		return nil, ErrPaymentNotRefundable
+	}
+	if !amount.SameCurrency(p.Amount) {
+		return nil, ErrInvalidRefundAmount
+	}
+	amount.Currency = p.Amount.Currency
+
+	ref := &models.PaymentRefund{PaymentID: p.ID, Amount: amount, Reason: reason, CreatedBy: by}
+	if p, err = s.repo.ReserveRefund(ref); err != nil {
+		return nil, err
+	}
+	res, err := s.provider.Refund(ctx, payments.RefundRequest{
+		IdempotencyKey: fmt.Sprintf("refund-%d", ref.ID),
+		PaymentID:      p.ProviderPaymentID,
+		Amount:         ref.Amount.Minor,
+	})
+	if err != nil {
+		if ferr := s.repo.FailRefund(ref); ferr != nil {
+			log.Printf("payments: refund %d: %v", ref.ID, ferr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPaymentProvider, err)
+	}
+	ref.ProviderRefundID = res.ID
+	if err := s.repo.CompleteRefund(ref); err != nil {
+		return nil, err
+	}
+
+	if p.Status == "refunded" {
+		id := strconv.FormatUint(uint64(p.BookingID), 10)
+		if b, err := s.repo.GetBookingByID(id); err == nil && (b.Status == "pending" || b.Status == "confirmed") {
+			if err := s.bookings.CancelBooking(id); err != nil {
+				log.Printf("payments: cancel booking %d after refund: %v", p.BookingID, err)
+			}
+		}
+	}
+	return ref, nil
+}
+
+func (s *PaymentService) onBookingStatusChanged(ctx context.Context, e events.Event) error {
+	var change events.BookingStatusChangedPayload
+	if err := json.Unmarshal(e.Payload, &change); err != nil {
+		return err
+	}
+	if change.To != "cancelled" {
+		return nil
+	}
+	list, err := s.repo.GetPaymentsByBooking(change.BookingID)
+	if err != nil {
+		return err
+	}
+	for _, p := range list {
+		if p.Refundable().IsZero() || p.RetainedAt != nil {
+			continue
+		}
+		if p.Kind == "deposit" && s.policy != nil && s.policy.RetainsDeposit(change.Date, e.OccurredAt) {
+			if err := s.repo.RetainPayment(p.ID, s.now()); err != nil {
+				return err
+			}
+			continue
+		}
+		_, err := s.Refund(ctx, strconv.FormatUint(uint64(p.ID), 10), money.Money{}, "booking cancelled", 0)
+		if err != nil && !errors.Is(err, ErrPaymentNotRefundable) && !errors.Is(err, ErrRefundTooLarge) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/payments"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockPaymentRepo struct {
+	mock.Mock
+}
+
+func (m *MockPaymentRepo) GetBookingByID(id string) (*models.Booking, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+func (m *MockPaymentRepo) CreatePayment(p *models.Payment) error {
+	args := m.Called(p)
+	p.ID = 9
+	return args.Error(0)
+}
+func (m *MockPaymentRepo) SavePayment(p *models.Payment) error { return m.Called(p).Error(0) }
+func (m *MockPaymentRepo) GetPaymentByID(id string) (*models.Payment, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+func (m *MockPaymentRepo) GetPaymentByKey(key string) (*models.Payment, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+func (m *MockPaymentRepo) GetPaymentByProviderID(provider, providerID string) (*models.Payment, error) {
+	args := m.Called(provider, providerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+func (m *MockPaymentRepo) GetPaymentsByBooking(bookingID uint) ([]models.Payment, error) {
+	args := m.Called(bookingID)
+	return args.Get(0).([]models.Payment), args.Error(1)
+}
+func (m *MockPaymentRepo) GetPaymentsByUser(userID uint) ([]models.Payment, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Payment), args.Error(1)
+}
+func (m *MockPaymentRepo) SetPaymentStatus(id uint, status string, at time.Time) (*models.Payment, bool, error) {
+	args := m.Called(id, status, at)
+	if args.Get(0) == nil {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).(*models.Payment), args.Bool(1), args.Error(2)
+}
+func (m *MockPaymentRepo) ReserveRefund(r *models.PaymentRefund) (*models.Payment, error) {
+	args := m.Called(r)
+	r.ID = 3
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+func (m *MockPaymentRepo) CompleteRefund(r *models.PaymentRefund) error { return m.Called(r).Error(0) }
+func (m *MockPaymentRepo) FailRefund(r *models.PaymentRefund) error     { return m.Called(r).Error(0) }
+func (m *MockPaymentRepo) RetainPayment(id uint, at time.Time) error {
+	return m.Called(id, at).Error(0)
+}
+
+type fakeBookings struct {
+	updated   map[string]interface{}
+	cancelled []string
+}
+
+func (f *fakeBookings) UpdateBooking(id string, updates map[string]interface{}) (*models.Booking, error) {
+	f.updated = updates
+	return &models.Booking{}, nil
+}
+func (f *fakeBookings) CancelBooking(id string) error {
+	f.cancelled = append(f.cancelled, id)
+	return nil
+}
+
+var paymentNow = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func newTestPayments() (*PaymentService, *MockPaymentRepo, *payments.FakeProvider, *fakeBookings) {
+	repo := new(MockPaymentRepo)
+	provider := payments.NewFakeProvider("secret")
+	bookings := &fakeBookings{}
+	svc := NewPaymentService(repo, provider, bookings)
+	svc.now = func() time.Time { return paymentNow }
+	return svc, repo, provider, bookings
+}
+
+func payableBooking(status string) *models.Booking {
+	b := &models.Booking{UserID: 4, Status: status, Service: models.Service{Title: "Стрижка", Price: kzt(500050)}}
+	b.ID = 1
+	return b
+}
+
+// paidPayment проводит платёж у фейкового провайдера, чтобы по нему были возможны возвраты.
+func paidPayment(t *testing.T, provider *payments.FakeProvider, refunded money.Money) *models.Payment {
+	res, err := provider.CreatePayment(context.Background(), payments.CreateRequest{IdempotencyKey: "payment-9", Amount: 500050})
+	require.NoError(t, err)
+	_, _, err = provider.Settle(res.ID, payments.StatusSucceeded)
+	require.NoError(t, err)
+	p := &models.Payment{BookingID: 1, UserID: 4, Amount: kzt(500050), RefundedAmount: refunded, Status: "succeeded", ProviderPaymentID: res.ID}
+	if refunded.IsPositive() {
+		p.Status = "partially_refunded"
+	}
+	p.ID = 9
+	return p
+}
+
+func TestCreatePayment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Creates Payment", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		repo.On("GetBookingByID", "1").Return(payableBooking("pending"), nil).Once()
+		repo.On("GetPaymentByKey", "user:4:abc").Return(nil, gorm.ErrRecordNotFound).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{{Status: "failed"}}, nil).Once()
+		repo.On("CreatePayment", mock.MatchedBy(func(p *models.Payment) bool {
+			return p.Amount == kzt(500050) && p.Provider == "fake" && p.IdempotencyKey == "user:4:abc"
+		})).Return(nil).Once()
+		repo.On("SavePayment", mock.Anything).Return(nil).Once()
+
+		p, err := svc.CreatePayment(ctx, 4, "1", "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "pending", p.Status)
+		assert.NotEmpty(t, p.ProviderPaymentID)
+		assert.Contains(t, p.ConfirmationURL, p.ProviderPaymentID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Discounted Booking", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		b := payableBooking("pending")
+		b.Discount = kzt(50005)
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreatePayment", mock.MatchedBy(func(p *models.Payment) bool { return p.Amount == kzt(450045) })).Return(nil).Once()
+		repo.On("SavePayment", mock.Anything).Return(nil).Once()
+
+		_, err := svc.CreatePayment(ctx, 4, "1", "")
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Replays Idempotency Key", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		existing := &models.Payment{BookingID: 1, Status: "succeeded"}
+		repo.On("GetBookingByID", "1").Return(payableBooking("confirmed"), nil).Once()
+		repo.On("GetPaymentByKey", "user:4:abc").Return(existing, nil).Once()
+
+		p, err := svc.CreatePayment(ctx, 4, "1", "abc")
+		require.NoError(t, err)
+		assert.Same(t, existing, p)
+	})
+
+	t.Run("Key Used For Another Booking", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		repo.On("GetBookingByID", "1").Return(payableBooking("pending"), nil).Once()
+		repo.On("GetPaymentByKey", "user:4:abc").Return(&models.Payment{BookingID: 2}, nil).Once()
+
+		_, err := svc.CreatePayment(ctx, 4, "1", "abc")
+		assert.ErrorIs(t, err, ErrIdempotencyConflict)
+	})
+
+	t.Run("Already Paid", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		repo.On("GetBookingByID", "1").Return(payableBooking("confirmed"), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{{Status: "succeeded"}}, nil).Once()
+
+		_, err := svc.CreatePayment(ctx, 4, "1", "")
+		assert.ErrorIs(t, err, ErrAlreadyPaid)
+	})
+
+	t.Run("Someone Else's Booking", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		repo.On("GetBookingByID", "1").Return(payableBooking("pending"), nil).Once()
+
+		_, err := svc.CreatePayment(ctx, 5, "1", "abc")
+		assert.ErrorIs(t, err, ErrBookingNotFound)
+	})
+
+	t.Run("Cancelled Booking", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		repo.On("GetBookingByID", "1").Return(payableBooking("cancelled"), nil).Once()
+
+		_, err := svc.CreatePayment(ctx, 4, "1", "")
+		assert.ErrorIs(t, err, ErrBookingNotPayable)
+	})
+
+	t.Run("Package Session", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		b := payableBooking("pending")
+		session := uint(7)
+		b.PackageSessionID = &session
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+
+		_, err := svc.CreatePayment(ctx, 4, "1", "")
+		assert.ErrorIs(t, err, ErrBookingNotPayable)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Membership", func(t *testing.T) {
+		members := new(MockMembershipRepo)
+		memberships := newMembershipService(members, payments.NewFakeProvider("secret"), &fakeClock{t: membershipNow})
+		members.On("GetCurrentSubscription", uint(4)).Return(activeSubscription(), nil)
+		members.On("GetServiceByID", "6").Return(&models.Service{Price: kzt(800000)}, nil)
+
+		// Услуга входит в абонемент — онлайн-оплаты нет.
+		svc, repo, _, _ := newTestPayments()
+		b := payableBooking("pending")
+		b.ServiceID, b.Date = 5, "2026-03-10 12:00"
+		require.NoError(t, memberships.Apply(b))
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+		_, err := svc.CreatePayment(ctx, 4, "1", "")
+		assert.ErrorIs(t, err, ErrBookingNotPayable)
+
+		// Остальные услуги оплачиваются со скидкой абонемента.
+		b = payableBooking("pending")
+		b.ServiceID, b.Date, b.Service.Price = 6, "2026-03-10 12:00", kzt(800000)
+		members.On("CountMembershipVisits", uint(3), uint(6), membershipNow).Return(int64(2), nil).Once()
+		require.NoError(t, memberships.Apply(b))
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreatePayment", mock.MatchedBy(func(p *models.Payment) bool { return p.Amount == kzt(720000) })).Return(nil).Once()
+		repo.On("SavePayment", mock.Anything).Return(nil).Once()
+		_, err = svc.CreatePayment(ctx, 4, "1", "")
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Deposit", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		b := payableBooking("awaiting_payment")
+		due := paymentNow.Add(time.Minute)
+		b.DepositAmount, b.PaymentDueAt = kzt(150000), &due
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreatePayment", mock.MatchedBy(func(p *models.Payment) bool {
+			return p.Amount == kzt(150000) && p.Kind == "deposit"
+		})).Return(nil).Once()
+		repo.On("SavePayment", mock.Anything).Return(nil).Once()
+
+		_, err := svc.CreatePayment(ctx, 4, "1", "")
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Deposit Overdue", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		b := payableBooking("awaiting_payment")
+		due := paymentNow
+		b.DepositAmount, b.PaymentDueAt = kzt(150000), &due
+		repo.On("GetBookingByID", "1").Return(b, nil).Once()
+
+		_, err := svc.CreatePayment(ctx, 4, "1", "")
+		assert.ErrorIs(t, err, ErrBookingNotPayable)
+	})
+
+	t.Run("Provider Down", func(t *testing.T) {
+		svc, repo, provider, _ := newTestPayments()
+		provider.Err = errors.New("timeout")
+		repo.On("GetBookingByID", "1").Return(payableBooking("pending"), nil).Once()
+		repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{}, nil).Once()
+		repo.On("CreatePayment", mock.Anything).Return(nil).Once()
+		repo.On("SavePayment", mock.MatchedBy(func(p *models.Payment) bool { return p.Status == "failed" })).Return(nil).Once()
+
+		_, err := svc.CreatePayment(ctx, 4, "1", "")
+		assert.ErrorIs(t, err, ErrPaymentProvider)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestHandlePaymentNotification(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T) (*PaymentService, *MockPaymentRepo, *fakeBookings, http.Header, []byte) {
+		svc, repo, provider, bookings := newTestPayments()
+		res, err := provider.CreatePayment(ctx, payments.CreateRequest{IdempotencyKey: "payment-9", Amount: 500050})
+		require.NoError(t, err)
+		header, body, err := provider.Settle(res.ID, payments.StatusSucceeded)
+		require.NoError(t, err)
+		p := &models.Payment{BookingID: 1, ProviderPaymentID: res.ID, Status: "pending"}
+		p.ID = 9
+		repo.On("GetPaymentByProviderID", "fake", res.ID).Return(p, nil).Once()
+		return svc, repo, bookings, header, body
+	}
+
+	t.Run("Confirms Booking", func(t *testing.T) {
+		svc, repo, bookings, header, body := setup(t)
+		repo.On("SetPaymentStatus", uint(9), "succeeded", paymentNow).Return(&models.Payment{BookingID: 1, Status: "succeeded"}, true, nil).Once()
+		repo.On("GetBookingByID", "1").Return(payableBooking("pending"), nil).Once()
+
+		require.NoError(t, svc.HandleNotification(ctx, header, body))
+		assert.Equal(t, map[string]interface{}{"status": "confirmed"}, bookings.updated)
+	})
+
+	t.Run("Confirms Booking Awaiting Deposit", func(t *testing.T) {
+		svc, repo, bookings, header, body := setup(t)
+		repo.On("SetPaymentStatus", uint(9), "succeeded", paymentNow).Return(&models.Payment{BookingID: 1, Status: "succeeded"}, true, nil).Once()
+		repo.On("GetBookingByID", "1").Return(payableBooking("awaiting_payment"), nil).Once()
+
+		require.NoError(t, svc.HandleNotification(ctx, header, body))
+		assert.Equal(t, map[string]interface{}{"status": "confirmed"}, bookings.updated)
+	})
+
+	t.Run("Renews Membership", func(t *testing.T) {
+		svc, repo, bookings, header, body := setup(t)
+		memberships := &fakeMemberships{}
+		svc.SetMemberships(memberships)
+		sub := uint(3)
+		repo.On("SetPaymentStatus", uint(9), "succeeded", paymentNow).Return(&models.Payment{SubscriptionID: &sub, Status: "succeeded"}, true, nil).Once()
+
+		require.NoError(t, svc.HandleNotification(ctx, header, body))
+		assert.Equal(t, []uint{3}, memberships.paid)
+		assert.Nil(t, bookings.updated)
+	})
+
+	t.Run("Repeated Notification", func(t *testing.T) {
+		svc, repo, bookings, header, body := setup(t)
+		repo.On("SetPaymentStatus", uint(9), "succeeded", paymentNow).Return(&models.Payment{BookingID: 1, Status: "succeeded"}, false, nil).Once()
+
+		require.NoError(t, svc.HandleNotification(ctx, header, body))
+		assert.Nil(t, bookings.updated)
+		repo.AssertNotCalled(t, "GetBookingByID", "1")
+	})
+
+	t.Run("Bad Signature", func(t *testing.T) {
+		svc, _, _, header, body := setup(t)
+		header.Set(payments.SignatureHeader, payments.Sign("forged", body))
+		assert.ErrorIs(t, svc.HandleNotification(ctx, header, body), payments.ErrInvalidSignature)
+	})
+}
+
+func TestRefundPayment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Partial", func(t *testing.T) {
+		svc, repo, provider, bookings := newTestPayments()
+		p := paidPayment(t, provider, kzt(0))
+		after := *p
+		after.RefundedAmount, after.Status = kzt(100000), "partially_refunded"
+		repo.On("GetPaymentByID", "9").Return(p, nil).Once()
+		repo.On("ReserveRefund", mock.MatchedBy(func(r *models.PaymentRefund) bool { return r.Amount == kzt(100000) && r.CreatedBy == 1 })).Return(&after, nil).Once()
+		repo.On("CompleteRefund", mock.MatchedBy(func(r *models.PaymentRefund) bool { return r.ProviderRefundID != "" })).Return(nil).Once()
+
+		ref, err := svc.Refund(ctx, "9", kzt(100000), "скидка", 1)
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), ref.ID)
+		assert.Empty(t, bookings.cancelled)
+	})
+
+	t.Run("Full Cancels Booking", func(t *testing.T) {
+		svc, repo, provider, bookings := newTestPayments()
+		p := paidPayment(t, provider, kzt(0))
+		after := *p
+		after.RefundedAmount, after.Status = p.Amount, "refunded"
+		repo.On("GetPaymentByID", "9").Return(p, nil).Once()
+		repo.On("ReserveRefund", mock.Anything).Return(&after, nil).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.PaymentRefund).Amount = p.Amount
+		}).Once()
+		repo.On("CompleteRefund", mock.Anything).Return(nil).Once()
+		repo.On("GetBookingByID", "1").Return(payableBooking("confirmed"), nil).Once()
+
+		_, err := svc.Refund(ctx, "9", money.Money{}, "", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1"}, bookings.cancelled)
+	})
+
+	t.Run("Provider Rejects", func(t *testing.T) {
+		svc, repo, provider, _ := newTestPayments()
+		p := paidPayment(t, provider, kzt(0))
+		provider.Err = errors.New("declined")
+		repo.On("GetPaymentByID", "9").Return(p, nil).Once()
+		repo.On("ReserveRefund", mock.Anything).Return(p, nil).Once()
+		repo.On("FailRefund", mock.Anything).Return(nil).Once()
+
+		_, err := svc.Refund(ctx, "9", kzt(100), "", 1)
+		assert.ErrorIs(t, err, ErrPaymentProvider)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Not Paid", func(t *testing.T) {
+		svc, repo, _, _ := newTestPayments()
+		repo.On("GetPaymentByID", "9").Return(&models.Payment{Status: "pending", Amount: kzt(100)}, nil).Once()
+		_, err := svc.Refund(ctx, "9", money.Money{}, "", 1)
+		assert.ErrorIs(t, err, ErrPaymentNotRefundable)
+	})
+}
+
+func TestRefundOnBookingCancelled(t *testing.T) {
+	svc, repo, provider, _ := newTestPayments()
+	p := paidPayment(t, provider, kzt(100000))
+	after := *p
+	after.RefundedAmount, after.Status = p.Amount, "refunded"
+	repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{{Status: "failed"}, *p}, nil).Once()
+	repo.On("GetPaymentByID", "9").Return(p, nil).Once()
+	repo.On("ReserveRefund", mock.MatchedBy(func(r *models.PaymentRefund) bool { return r.Amount.IsZero() && r.CreatedBy == 0 })).
+		Run(func(args mock.Arguments) { args.Get(0).(*models.PaymentRefund).Amount = p.Refundable() }).
+		Return(&after, nil).Once()
+	repo.On("CompleteRefund", mock.Anything).Return(nil).Once()
+	repo.On("GetBookingByID", "1").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	bus := events.NewBus(10)
+	svc.Subscribe(bus)
+	payload, _ := json.Marshal(events.BookingStatusChangedPayload{BookingID: 1, From: "confirmed", To: "cancelled"})
+	require.NoError(t, bus.Publish(context.Background(), events.Event{ID: "ev-1", Type: events.BookingStatusChanged, Payload: payload}))
+	repo.AssertExpectations(t)
+}
+
+type fakeMemberships struct {
+	paid []uint
+}
+
+func (f *fakeMemberships) PaymentSucceeded(_ context.Context, p *models.Payment) error {
+	f.paid = append(f.paid, *p.SubscriptionID)
+	return nil
+}
+
+type fixedPolicy bool
+
+func (p fixedPolicy) RetainsDeposit(string, time.Time) bool { return bool(p) }
+
+func TestRetainDepositOnLateCancellation(t *testing.T) {
+	svc, repo, provider, _ := newTestPayments()
+	svc.SetCancellationPolicy(fixedPolicy(true))
+	deposit := paidPayment(t, provider, kzt(0))
+	deposit.Kind = "deposit"
+	retained := *deposit
+	retained.ID, retained.RetainedAt = 10, &paymentNow
+	repo.On("GetPaymentsByBooking", uint(1)).Return([]models.Payment{*deposit, retained}, nil).Once()
+	repo.On("RetainPayment", uint(9), paymentNow).Return(nil).Once()
+
+	bus := events.NewBus(10)
+	svc.Subscribe(bus)
+	payload, _ := json.Marshal(events.BookingStatusChangedPayload{BookingID: 1, Date: "2026-03-01 12:00", From: "confirmed", To: "cancelled"})
+	require.NoError(t, bus.Publish(context.Background(), events.Event{ID: "ev-1", Type: events.BookingStatusChanged, Payload: payload}))
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "ReserveRefund", mock.Anything)
+}
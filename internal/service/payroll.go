@@ -0,0 +1,300 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/repository"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCommission  = errors.New("invalid commission scheme")
+	ErrCommissionNotFound = errors.New("commission scheme not found")
+	ErrStaffNotFound      = errors.New("staff not found")
+)
+
+// CommissionSchemeInput — схема оплаты мастера: percent — percent процентов
+// от выручки за услуги, fixed — amount за каждую услугу, tiered — процент
+// ступени по выручке мастера за месяц.
+type CommissionSchemeInput struct {
+	Kind    string                `json:"kind"`
+	Percent int                   `json:"percent"`
+	Amount  money.Money           `json:"amount"`
+	Tiers   []CommissionTierInput `json:"tiers"`
+}
+
+type CommissionTierInput struct {
+	From    money.Money `json:"from"`
+	Percent int         `json:"percent"`
+}
+
+// PayrollRow — выплата мастеру за период в одной валюте: комиссия с услуг и чаевые.
+type PayrollRow struct {
+	StaffID    uint        `json:"staff_id"`
+	StaffName  string      `json:"staff_name"`
+	Scheme     string      `json:"scheme,omitempty"` // пусто — схема не задана, комиссии нет
+	Services   int         `json:"services"`
+	Revenue    money.Money `json:"revenue"` // за услуги, без скидок и НДС
+	Commission money.Money `json:"commission"`
+	Tips       money.Money `json:"tips"`
+	Total      money.Money `json:"total"`
+}
+
+// PayrollReport — строки по мастерам и итоги по каждой валюте. Мастер,
+// получавший оплату в нескольких валютах, занимает строку на каждую.
+type PayrollReport struct {
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	Rows   []PayrollRow  `json:"rows"`
+	Totals []money.Money `json:"totals"`
+}
+
+type Payroll interface {
+	SaveScheme(staffID string, in CommissionSchemeInput) (*models.CommissionScheme, error)
+	GetSchemes() ([]models.CommissionScheme, error)
+	DeleteScheme(staffID string) error
+	Report(from, to string) (*PayrollReport, error)
+}
+
+type PayrollService struct {
+	repo repository.PayrollRepository
+	now  func() time.Time
+}
+
+func NewPayrollService(repo repository.PayrollRepository) *PayrollService {
+	return &PayrollService{repo: repo, now: time.Now}
+}
+
+func (s *PayrollService) SaveScheme(staffID string, in CommissionSchemeInput) (*models.CommissionScheme, error) {
+	st, err := s.repo.GetStaffByID(staffID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrStaffNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	scheme := &models.CommissionScheme{StaffID: st.ID, Kind: in.Kind}
+	switch in.Kind {
+	case models.CommissionPercent:
+		if in.Percent <= 0 || in.Percent > 100 {
+			return nil, fmt.Errorf("%w: percent must be 1-100", ErrInvalidCommission)
+		}
+		scheme.Percent = in.Percent
+	case models.CommissionFixed:
+		if !in.Amount.IsPositive() {
+			return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidCommission)
+		}
+		scheme.Amount = withCurrency(in.Amount)
+	case models.CommissionTiered:
+		if len(in.Tiers) == 0 {
+			return nil, fmt.Errorf("%w: tiered scheme needs tiers", ErrInvalidCommission)
+		}
+		for i, t := range in.Tiers {
+			t.From = withCurrency(t.From)
+			if t.Percent < 0 || t.Percent > 100 || t.From.IsNegative() {
+				return nil, fmt.Errorf("%w: tier needs a non-negative from and percent 0-100", ErrInvalidCommission)
+			}
+			if i > 0 && (!t.From.SameCurrency(scheme.Tiers[i-1].From) || t.From.Cmp(scheme.Tiers[i-1].From) <= 0) {
+				return nil, fmt.Errorf("%w: tiers must go in ascending order of from", ErrInvalidCommission)
+			}
+			scheme.Tiers = append(scheme.Tiers, models.CommissionTier{From: t.From, Percent: t.Percent})
+		}
+	default:
+		return nil, fmt.Errorf("%w: kind must be percent, fixed or tiered", ErrInvalidCommission)
+	}
+	if err := s.repo.SaveCommissionScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+func (s *PayrollService) GetSchemes() ([]models.CommissionScheme, error) {
+	return s.repo.GetCommissionSchemes()
+}
+
+func (s *PayrollService) DeleteScheme(staffID string) error {
+	err := s.repo.DeleteCommissionScheme(staffID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCommissionNotFound
+	}
+	return err
+}
+
+// Report считает выплаты мастерам по чекам за дни from–to (YYYY-MM-DD,
+// включительно) по текущим схемам. Выручка мастера — его услуги и допуслуги
+// за вычетом скидок и НДС; ступень схемы tiered выбирается по выручке за
+// весь календарный месяц, даже если период захватывает только его часть.
+func (s *PayrollService) Report(from, to string) (*PayrollReport, error) {
+	loc := s.now().Location()
+	start, err := time.ParseInLocation("2006-01-02", from, loc)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, loc)
+	if err != nil || end.Before(start) {
+		return nil, ErrInvalidDate
+	}
+	end = end.AddDate(0, 0, 1)
+	// Чеки берутся за целые месяцы: остальные дни нужны только для ступени.
+	monthStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, loc)
+	monthEnd := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, loc)
+	if monthEnd.Before(end) {
+		monthEnd = monthEnd.AddDate(0, 1, 0)
+	}
+	receipts, err := s.repo.GetReceiptsBetween(monthStart, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+	staff, err := s.repo.GetAllStaff()
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repo.GetCommissionSchemes()
+	if err != nil {
+		return nil, err
+	}
+	schemes := map[uint]*models.CommissionScheme{}
+	for i := range list {
+		schemes[list[i].StaffID] = &list[i]
+	}
+
+	type rowKey struct {
+		staffID  uint
+		currency string
+	}
+	type monthKey struct {
+		rowKey
+		month string
+	}
+	// Выручка за весь месяц — база ступени, выручка и услуги за период — к оплате.
+	type monthTotal struct {
+		base     money.Money
+		revenue  money.Money
+		services int
+	}
+	rows := map[rowKey]*PayrollRow{}
+	months := map[monthKey]*monthTotal{}
+	for _, rec := range receipts {
+		inRange := !rec.CreatedAt.Before(start) && rec.CreatedAt.Before(end)
+		month := rec.CreatedAt.In(loc).Format("2006-01")
+		for _, it := range rec.Items {
+			if it.StaffID == nil {
+				continue
+			}
+			// Суммы строки в разных валютах сложить нельзя — строка пропускается.
+			if !it.Amount.SameCurrency(it.Discount) || !it.Amount.SameCurrency(it.Tax) || !it.Discount.SameCurrency(it.Tax) {
+				log.Printf("payroll: receipt %d item %d: currency mismatch, skipped", rec.ID, it.ID)
+				continue
+			}
+			cur := withCurrency(it.Amount.Add(it.Discount).Add(it.Tax)).Currency
+			key := rowKey{*it.StaffID, cur}
+			row := rows[key]
+			if row == nil && inRange {
+				zero := money.Zero(cur)
+				row = &PayrollRow{StaffID: *it.StaffID, Revenue: zero, Commission: zero, Tips: zero, Total: zero}
+				rows[key] = row
+			}
+			if it.Kind == "tip" {
+				if inRange {
+					row.Tips = row.Tips.Add(it.Amount)
+				}
+				continue
+			}
+			m := months[monthKey{key, month}]
+			if m == nil {
+				m = &monthTotal{base: money.Zero(cur), revenue: money.Zero(cur)}
+				months[monthKey{key, month}] = m
+			}
+			revenue := it.Amount.Sub(it.Discount)
+			if rec.TaxMode == TaxInclusive {
+				revenue = revenue.Sub(it.Tax)
+			}
+			m.base = m.base.Add(revenue)
+			if !inRange {
+				continue
+			}
+			m.revenue = m.revenue.Add(revenue)
+			row.Revenue = row.Revenue.Add(revenue)
+			if it.Kind == "service" {
+				m.services += it.Quantity
+				row.Services += it.Quantity
+			}
+		}
+	}
+	for key, m := range months {
+		row := rows[key.rowKey]
+		if sc := schemes[key.staffID]; sc != nil && row != nil {
+			row.Commission = row.Commission.Add(sc.Commission(m.revenue, m.services, m.base))
+		}
+	}
+
+	names := map[uint]string{}
+	for _, st := range staff {
+		names[st.ID] = st.FullName
+	}
+	report := &PayrollReport{From: from, To: to, Rows: []PayrollRow{}, Totals: []money.Money{}}
+	totals := map[string]money.Money{}
+	for key, row := range rows {
+		row.StaffName = names[key.staffID]
+		if sc := schemes[key.staffID]; sc != nil {
+			row.Scheme = sc.Kind
+		}
+		row.Total = row.Commission.Add(row.Tips)
+		totals[key.currency] = row.Total.Add(totals[key.currency])
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.StaffID != b.StaffID {
+			return a.StaffID < b.StaffID
+		}
+		return a.Total.Currency < b.Total.Currency
+	})
+	for _, t := range totals {
+		report.Totals = append(report.Totals, t)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool { return report.Totals[i].Currency < report.Totals[j].Currency })
+	if len(report.Totals) == 0 {
+		report.Totals = append(report.Totals, money.Zero(money.DefaultCurrency))
+	}
+	return report, nil
+}
+
+// PayrollCSV выгружает отчёт для бухгалтера. Разделитель — точка с запятой,
+// а в начале — BOM: так файл сразу открывается в Excel с кириллицей.
+func PayrollCSV(r *PayrollReport) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	w.Comma = ';'
+	records := [][]string{{"ID мастера", "Мастер", "Услуг", "Выручка", "Комиссия", "Чаевые", "К выплате", "Валюта"}}
+	for _, row := range r.Rows {
+		records = append(records, []string{strconv.FormatUint(uint64(row.StaffID), 10), row.StaffName,
+			strconv.Itoa(row.Services), row.Revenue.Decimal(), row.Commission.Decimal(), row.Tips.Decimal(),
+			row.Total.Decimal(), row.Total.Currency})
+	}
+	for _, t := range r.Totals {
+		records = append(records, []string{"", "Итого", "", "", "", "", t.Decimal(), t.Currency})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// withCurrency подставляет валюту салона, если в запросе её нет.
+func withCurrency(m money.Money) money.Money {
+	if m.Currency == "" {
+		m.Currency = money.DefaultCurrency
+	}
+	return m
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockPayrollRepo struct {
+	mock.Mock
+}
+
+func (m *MockPayrollRepo) GetAllStaff() ([]models.Staff, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Staff), args.Error(1)
+}
+func (m *MockPayrollRepo) GetStaffByID(id string) (*models.Staff, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Staff), args.Error(1)
+}
+func (m *MockPayrollRepo) SaveCommissionScheme(s *models.CommissionScheme) error {
+	return m.Called(s).Error(0)
+}
+func (m *MockPayrollRepo) GetCommissionSchemes() ([]models.CommissionScheme, error) {
+	args := m.Called()
+	return args.Get(0).([]models.CommissionScheme), args.Error(1)
+}
+func (m *MockPayrollRepo) DeleteCommissionScheme(staffID string) error {
+	return m.Called(staffID).Error(0)
+}
+func (m *MockPayrollRepo) GetReceiptsBetween(from, to time.Time) ([]models.Receipt, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]models.Receipt), args.Error(1)
+}
+
+var payrollNow = time.Date(2026, 4, 10, 12, 0, 0, 0, time.UTC)
+
+func newPayrollService(repo *MockPayrollRepo) *PayrollService {
+	svc := NewPayrollService(repo)
+	svc.now = func() time.Time { return payrollNow }
+	return svc
+}
+
+func payrollStaff(id uint, name string) models.Staff {
+	st := models.Staff{FullName: name}
+	st.ID = id
+	return st
+}
+
+func payrollItem(kind string, staffID uint, amount, discount, tax int64) models.ReceiptItem {
+	it := models.ReceiptItem{Kind: kind, Quantity: 1, Amount: kzt(amount), Discount: kzt(discount), Tax: kzt(tax)}
+	if staffID != 0 {
+		it.StaffID = &staffID
+	}
+	return it
+}
+
+func payrollReceipt(at time.Time, taxMode string, items ...models.ReceiptItem) models.Receipt {
+	rec := models.Receipt{TaxMode: taxMode, Items: items}
+	rec.CreatedAt = at
+	return rec
+}
+
+func TestSaveCommissionScheme(t *testing.T) {
+	repo := new(MockPayrollRepo)
+	svc := newPayrollService(repo)
+	olga := payrollStaff(2, "Ольга")
+	repo.On("GetStaffByID", "2").Return(&olga, nil)
+	repo.On("GetStaffByID", "9").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("SaveCommissionScheme", mock.Anything).Return(nil).Once()
+
+	scheme, err := svc.SaveScheme("2", CommissionSchemeInput{Kind: "tiered", Tiers: []CommissionTierInput{
+		{Percent: 30}, {From: money.New(50000000, ""), Percent: 40}}})
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), scheme.StaffID)
+	assert.Equal(t, []models.CommissionTier{{From: kzt(0), Percent: 30}, {From: kzt(50000000), Percent: 40}}, scheme.Tiers)
+
+	_, err = svc.SaveScheme("9", CommissionSchemeInput{Kind: "percent", Percent: 40})
+	assert.ErrorIs(t, err, ErrStaffNotFound)
+
+	for _, in := range []CommissionSchemeInput{
+		{Kind: "percent"},
+		{Kind: "percent", Percent: 101},
+		{Kind: "fixed"},
+		{Kind: "tiered"},
+		{Kind: "tiered", Tiers: []CommissionTierInput{{From: kzt(100), Percent: 30}, {From: kzt(100), Percent: 40}}},
+		{Kind: "tiered", Tiers: []CommissionTierInput{{Percent: 120}}},
+		{Kind: "salary"},
+	} {
+		_, err := svc.SaveScheme("2", in)
+		assert.ErrorIs(t, err, ErrInvalidCommission, "%+v", in)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestPayrollReport(t *testing.T) {
+	repo := new(MockPayrollRepo)
+	svc := newPayrollService(repo)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	repo.On("GetReceiptsBetween", from, time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)).Return([]models.Receipt{
+		// Цены с НДС: из выручки вычитаются скидка и налог.
+		payrollReceipt(time.Date(2026, 3, 5, 11, 0, 0, 0, time.UTC), TaxInclusive,
+			payrollItem("service", 2, 40000000, 2000000, 4000000),
+			payrollItem("product", 0, 300000, 0, 0),
+			payrollItem("tip", 2, 500000, 0, 0),
+			payrollItem("tip", 7, 100000, 0, 0)),
+		payrollReceipt(time.Date(2026, 3, 20, 15, 0, 0, 0, time.UTC), TaxExclusive,
+			payrollItem("service", 2, 20000000, 0, 2400000),
+			payrollItem("addon", 2, 5000000, 0, 600000)),
+		payrollReceipt(time.Date(2026, 4, 2, 10, 0, 0, 0, time.UTC), "",
+			payrollItem("service", 2, 10000000, 0, 0),
+			payrollItem("service", 5, 8000000, 0, 0)),
+	}, nil).Once()
+	repo.On("GetAllStaff").Return([]models.Staff{payrollStaff(2, "Ольга"), payrollStaff(5, "Айгерим"), payrollStaff(7, "Дана")}, nil).Once()
+	repo.On("GetCommissionSchemes").Return([]models.CommissionScheme{
+		{StaffID: 2, Kind: models.CommissionTiered, Tiers: []models.CommissionTier{{From: kzt(0), Percent: 30}, {From: kzt(50000000), Percent: 40}}},
+		{StaffID: 5, Kind: models.CommissionFixed, Amount: kzt(100000)},
+	}, nil).Once()
+
+	report, err := svc.Report("2026-03-01", "2026-04-30")
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 3)
+	// Март: 340 000 + 200 000 + 50 000 = 590 000 — ступень 40%; апрель: 100 000 — 30%.
+	assert.Equal(t, PayrollRow{StaffID: 2, StaffName: "Ольга", Scheme: "tiered", Services: 3, Revenue: kzt(69000000),
+		Commission: kzt(26600000), Tips: kzt(500000), Total: kzt(27100000)}, report.Rows[0])
+	assert.Equal(t, PayrollRow{StaffID: 5, StaffName: "Айгерим", Scheme: "fixed", Services: 1, Revenue: kzt(8000000),
+		Commission: kzt(100000), Tips: kzt(0), Total: kzt(100000)}, report.Rows[1])
+	assert.Equal(t, PayrollRow{StaffID: 7, StaffName: "Дана", Revenue: kzt(0), Commission: kzt(0), Tips: kzt(100000),
+		Total: kzt(100000)}, report.Rows[2])
+	assert.Equal(t, []money.Money{kzt(27300000)}, report.Totals)
+
+	out, err := PayrollCSV(report)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "\ufeffID мастера;Мастер;Услуг;Выручка;Комиссия;Чаевые;К выплате;Валюта", lines[0])
+	assert.Equal(t, "2;Ольга;3;690000.00;266000.00;5000.00;271000.00;KZT", lines[1])
+	assert.Equal(t, ";Итого;;;;;273000.00;KZT", lines[4])
+
+	_, err = svc.Report("2026-04-30", "2026-03-01")
+	assert.ErrorIs(t, err, ErrInvalidDate)
+	repo.AssertExpectations(t)
+}
+
+func TestPayrollReportPartialMonth(t *testing.T) {
+	repo := new(MockPayrollRepo)
+	svc := newPayrollService(repo)
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	mixed := payrollItem("service", 2, 1000000, 0, 0)
+	mixed.Discount = usd(100)
+	foreign := payrollItem("service", 2, 0, 0, 0)
+	foreign.Amount, foreign.Discount, foreign.Tax = usd(100000), usd(0), usd(0)
+	// Отчёт за 10–15 марта, но чеки берутся за весь март — ради ступени.
+	repo.On("GetReceiptsBetween", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)).Return([]models.Receipt{
+		payrollReceipt(time.Date(2026, 3, 5, 11, 0, 0, 0, time.UTC), "",
+			payrollItem("service", 2, 40000000, 0, 0),
+			payrollItem("tip", 2, 100000, 0, 0)),
+		payrollReceipt(time.Date(2026, 3, 12, 11, 0, 0, 0, time.UTC), "",
+			payrollItem("service", 2, 20000000, 0, 0),
+			foreign,
+			mixed),
+		payrollReceipt(time.Date(2026, 3, 20, 15, 0, 0, 0, time.UTC), "",
+			payrollItem("service", 5, 8000000, 0, 0)),
+	}, nil).Once()
+	repo.On("GetAllStaff").Return([]models.Staff{payrollStaff(2, "Ольга"), payrollStaff(5, "Айгерим")}, nil).Once()
+	repo.On("GetCommissionSchemes").Return([]models.CommissionScheme{
+		{StaffID: 2, Kind: models.CommissionTiered, Tiers: []models.CommissionTier{{From: kzt(0), Percent: 30}, {From: kzt(50000000), Percent: 40}}},
+	}, nil).Once()
+
+	report, err := svc.Report("2026-03-10", "2026-03-15")
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 2)
+	// За март 600 000 — ступень 40%, но платится только выручка 10–15 марта.
+	assert.Equal(t, PayrollRow{StaffID: 2, StaffName: "Ольга", Scheme: "tiered", Services: 1, Revenue: kzt(20000000),
+		Commission: kzt(8000000), Tips: kzt(0), Total: kzt(8000000)}, report.Rows[0])
+	// Выручка в другой валюте идёт отдельной строкой, ступени в тенге к ней не применяются.
+	assert.Equal(t, PayrollRow{StaffID: 2, StaffName: "Ольга", Scheme: "tiered", Services: 1, Revenue: usd(100000),
+		Commission: usd(0), Tips: usd(0), Total: usd(0)}, report.Rows[1])
+	assert.Equal(t, []money.Money{kzt(8000000), usd(0)}, report.Totals)
+
+	out, err := PayrollCSV(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), ";Итого;;;;;0.00;USD")
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteCommissionSchemeNotFound(t *testing.T) {
+	repo := new(MockPayrollRepo)
+	svc := newPayrollService(repo)
+	repo.On("DeleteCommissionScheme", "9").Return(gorm.ErrRecordNotFound).Once()
+
+	assert.ErrorIs(t, svc.DeleteScheme("9"), ErrCommissionNotFound)
+}
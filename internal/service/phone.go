@@ -0,0 +1,162 @@
+package service
+
+import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"beauty-salon/internal/sms"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPhone    = errors.New("invalid phone number")
+	ErrOTPCooldown     = errors.New("code was sent recently, try again later")
+	ErrOTPInvalid      = errors.New("invalid or expired code")
+	ErrOTPTooManyTries = errors.New("too many attempts, try again later")
+	e164               = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+)
+
+const (
+	otpTTL         = 5 * time.Minute
+	otpCooldown    = time.Minute
+	otpMaxAttempts = 5
+	// Счётчик попыток живёт своё окно и не сбрасывается новым кодом: иначе
+	// повторная отправка раз в минуту давала бы ещё otpMaxAttempts попыток.
+	otpAttemptWindow = 15 * time.Minute
+)
+
+type PhoneAuth interface {
+	RequestCode(ctx context.Context, phone string) error
+	VerifyCode(ctx context.Context, phone, code string) (string, error)
+}
+
+// PhoneAuthService — вход и регистрация по номеру телефона с одноразовым кодом.
+// В Redis хранится только HMAC кода, а не сам код.
+type PhoneAuthService struct {
+	repo   repository.PhoneRepository
+	rdb    *redis.Client
+	sms    sms.Sender
+	tokens auth.TokenIssuer
+	pepper []byte
+}
+
+func NewPhoneAuthService(repo repository.PhoneRepository, rdb *redis.Client, sender sms.Sender, tokens auth.TokenIssuer, pepper []byte) *PhoneAuthService {
+	return &PhoneAuthService{repo: repo, rdb: rdb, sms: sender, tokens: tokens, pepper: pepper}
+}
+
+// NormalizePhone приводит номер к E.164. Местный формат с ведущей 8
+// (8 701 123-45-67) трактуется как +7.
+func NormalizePhone(raw string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+	phone := b.String()
+	if strings.HasPrefix(phone, "8") && len(phone) == 11 {
+		phone = "+7" + phone[1:]
+	}
+	if !e164.MatchString(phone) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
+
+func (s *PhoneAuthService) RequestCode(ctx context.Context, phone string) error {
+	phone, err := NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	ok, err := s.rdb.SetNX(ctx, "otp_cooldown:"+phone, 1, otpCooldown).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOTPCooldown
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return err
+	}
+	if err := s.rdb.Set(ctx, "otp:"+phone, s.hash(phone, code), otpTTL).Err(); err != nil {
+		return err
+	}
+	return s.sms.Send(ctx, phone, fmt.Sprintf("Код для входа: %s", code))
+}
+
+// VerifyCode проверяет код и возвращает токен доступа. Если пользователя с
+// таким номером ещё нет, он регистрируется.
+func (s *PhoneAuthService) VerifyCode(ctx context.Context, phone, code string) (string, error) {
+	phone, err := NormalizePhone(phone)
+	if err != nil {
+		return "", err
+	}
+	attempts, err := s.rdb.Incr(ctx, "otp_attempts:"+phone).Result()
+	if err != nil {
+		return "", err
+	}
+	if attempts == 1 {
+		s.rdb.Expire(ctx, "otp_attempts:"+phone, otpAttemptWindow)
+	}
+	if attempts > otpMaxAttempts {
+		s.rdb.Del(ctx, "otp:"+phone)
+		return "", ErrOTPTooManyTries
+	}
+
+	stored, err := s.rdb.Get(ctx, "otp:"+phone).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrOTPInvalid
+	}
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal([]byte(stored), []byte(s.hash(phone, code))) {
+		return "", ErrOTPInvalid
+	}
+	s.rdb.Del(ctx, "otp:"+phone, "otp_attempts:"+phone)
+
+	user, err := s.repo.GetUserByPhone(phone)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user = &models.User{Username: phone, Phone: &phone}
+		err = s.repo.CreateUser(user)
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.tokens.Issue(user.ID)
+}
+
+func (s *PhoneAuthService) hash(phone, code string) string {
+	mac := hmac.New(sha256.New, s.pepper)
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/sms"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockPhoneRepo struct {
+	mock.Mock
+}
+
+func (m *MockPhoneRepo) GetUserByPhone(phone string) (*models.User, error) {
+	args := m.Called(phone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockPhoneRepo) CreateUser(u *models.User) error { return m.Called(u).Error(0) }
+
+func TestNormalizePhone(t *testing.T) {
+	cases := map[string]string{
+		"+7 (701) 123-45-67": "+77011234567",
+		"87011234567":        "+77011234567",
+		"+44 20 7946 0958":   "+442079460958",
+	}
+	for in, want := range cases {
+		got, err := NormalizePhone(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got)
+	}
+	for _, bad := range []string{"", "12345", "+7701abc4567", "7+7011234567", "+0123456789"} {
+		_, err := NormalizePhone(bad)
+		assert.ErrorIs(t, err, ErrInvalidPhone, bad)
+	}
+}
+
+func TestPhoneAuth(t *testing.T) {
+	ctx := context.Background()
+	phone := "+77011234567"
+
+	setup := func() (*PhoneAuthService, *MockPhoneRepo, redismock.ClientMock, *sms.FakeSender) {
+		db, rmock := redismock.NewClientMock()
+		repo := new(MockPhoneRepo)
+		sender := &sms.FakeSender{}
+		return NewPhoneAuthService(repo, db, sender, testTokens(), []byte("pepper")), repo, rmock, sender
+	}
+
+	// requestCode отправляет код и возвращает его вместе с сохранённым хэшем.
+	requestCode := func(t *testing.T, svc *PhoneAuthService, rmock redismock.ClientMock, sender *sms.FakeSender) (string, string) {
+		var stored string
+		rmock.ExpectSetNX("otp_cooldown:"+phone, 1, otpCooldown).SetVal(true)
+		rmock.CustomMatch(func(expected, actual []interface{}) error {
+			stored = actual[2].(string)
+			return nil
+		}).ExpectSet("otp:"+phone, "", otpTTL).SetVal("OK")
+
+		require.NoError(t, svc.RequestCode(ctx, "8 701 123 45 67"))
+		msg, ok := sender.Last()
+		require.True(t, ok)
+		assert.Equal(t, phone, msg.Phone)
+		code := msg.Text[strings.LastIndex(msg.Text, " ")+1:]
+		assert.Len(t, code, 6)
+		assert.NotContains(t, stored, code)
+		return code, stored
+	}
+
+	t.Run("Register New User", func(t *testing.T) {
+		svc, repo, rmock, sender := setup()
+		code, stored := requestCode(t, svc, rmock, sender)
+
+		rmock.ExpectIncr("otp_attempts:" + phone).SetVal(1)
+		rmock.ExpectExpire("otp_attempts:"+phone, otpAttemptWindow).SetVal(true)
+		rmock.ExpectGet("otp:" + phone).SetVal(stored)
+		rmock.ExpectDel("otp:"+phone, "otp_attempts:"+phone).SetVal(2)
+
+		repo.On("GetUserByPhone", phone).Return(nil, gorm.ErrRecordNotFound).Once()
+		repo.On("CreateUser", mock.MatchedBy(func(u *models.User) bool {
+			return u.Username == phone && *u.Phone == phone
+		})).Run(func(args mock.Arguments) { args.Get(0).(*models.User).ID = 11 }).Return(nil).Once()
+
+		token, err := svc.VerifyCode(ctx, phone, code)
+		require.NoError(t, err)
+		claims, err := svc.tokens.(*auth.TokenManager).Verify(token)
+		require.NoError(t, err)
+		assert.Equal(t, uint(11), claims.UserID)
+		repo.AssertExpectations(t)
+		assert.NoError(t, rmock.ExpectationsWereMet())
+	})
+
+	t.Run("Existing User", func(t *testing.T) {
+		svc, repo, rmock, sender := setup()
+		code, stored := requestCode(t, svc, rmock, sender)
+
+		rmock.ExpectIncr("otp_attempts:" + phone).SetVal(2)
+		rmock.ExpectGet("otp:" + phone).SetVal(stored)
+		rmock.ExpectDel("otp:"+phone, "otp_attempts:"+phone).SetVal(2)
+
+		user := &models.User{Username: "anna"}
+		user.ID = 4
+		repo.On("GetUserByPhone", phone).Return(user, nil).Once()
+
+		_, err := svc.VerifyCode(ctx, phone, code)
+		assert.NoError(t, err)
+		repo.AssertNotCalled(t, "CreateUser", mock.Anything)
+	})
+
+	t.Run("Wrong Code", func(t *testing.T) {
+		svc, _, rmock, _ := setup()
+		rmock.ExpectIncr("otp_attempts:" + phone).SetVal(1)
+		rmock.ExpectExpire("otp_attempts:"+phone, otpAttemptWindow).SetVal(true)
+		rmock.ExpectGet("otp:" + phone).SetVal(svc.hash(phone, "111111"))
+
+		_, err := svc.VerifyCode(ctx, phone, "222222")
+		assert.ErrorIs(t, err, ErrOTPInvalid)
+	})
+
+	t.Run("Expired Code", func(t *testing.T) {
+		svc, _, rmock, _ := setup()
+		rmock.ExpectIncr("otp_attempts:" + phone).SetVal(3)
+		rmock.ExpectGet("otp:" + phone).RedisNil()
+
+		_, err := svc.VerifyCode(ctx, phone, "123456")
+		assert.ErrorIs(t, err, ErrOTPInvalid)
+	})
+
+	t.Run("Too Many Attempts", func(t *testing.T) {
+		svc, _, rmock, _ := setup()
+		rmock.ExpectIncr("otp_attempts:" + phone).SetVal(otpMaxAttempts + 1)
+		rmock.ExpectDel("otp:" + phone).SetVal(1)
+
+		_, err := svc.VerifyCode(ctx, phone, "123456")
+		assert.ErrorIs(t, err, ErrOTPTooManyTries)
+		assert.NoError(t, rmock.ExpectationsWereMet())
+	})
+
+	t.Run("Resend Keeps Attempts", func(t *testing.T) {
+		svc, _, rmock, sender := setup()
+		code, _ := requestCode(t, svc, rmock, sender)
+		// Новый код не обнуляет счётчик: попытки кончились до конца окна.
+		rmock.ExpectIncr("otp_attempts:" + phone).SetVal(otpMaxAttempts + 1)
+		rmock.ExpectDel("otp:" + phone).SetVal(1)
+
+		_, err := svc.VerifyCode(ctx, phone, code)
+		assert.ErrorIs(t, err, ErrOTPTooManyTries)
+		assert.NoError(t, rmock.ExpectationsWereMet())
+	})
+
+	t.Run("Resend Cooldown", func(t *testing.T) {
+		svc, _, rmock, sender := setup()
+		rmock.ExpectSetNX("otp_cooldown:"+phone, 1, otpCooldown).SetVal(false)
+
+		err := svc.RequestCode(ctx, phone)
+		assert.ErrorIs(t, err, ErrOTPCooldown)
+		assert.Empty(t, sender.Sent())
+	})
+
+	t.Run("Invalid Phone", func(t *testing.T) {
+		svc, _, _, _ := setup()
+		assert.ErrorIs(t, svc.RequestCode(ctx, "call me"), ErrInvalidPhone)
+	})
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"beauty-salon/internal/i18n"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidProfile     = errors.New("invalid profile")
+	ErrProfileConflict    = errors.New("username or email is already taken")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrReauthRequired     = errors.New("sign in again to confirm this action")
+	usernamePattern       = regexp.MustCompile(`^[a-zA-Z0-9._-]{3,32}$`)
+)
+
+// passwordReauthWindow — насколько свежим должен быть вход, чтобы пользователь
+// без пароля (OIDC, телефон) мог задать первый пароль или удалить аккаунт.
+const passwordReauthWindow = 10 * time.Minute
+
+// ProfileUpdate — частичное обновление профиля: nil-поля не меняются,
+// пустая строка у email очищает значение. Телефона здесь нет: это логин для
+// входа по SMS, и привязывается он только подтверждённым кодом.
+type ProfileUpdate struct {
+	Username *string `json:"username"`
+	FullName *string `json:"full_name"`
+	Email    *string `json:"email"`
+	Locale   *string `json:"locale"`
+}
+
+func (p ProfileUpdate) validate() (map[string]interface{}, error) {
+	updates := map[string]interface{}{}
+	if p.Username != nil {
+		if !usernamePattern.MatchString(*p.Username) || strings.HasPrefix(*p.Username, "deleted_user_") {
+			return nil, fmt.Errorf("%w: username must be 3-32 characters of letters, digits, '.', '_' or '-'", ErrInvalidProfile)
+		}
+		updates["username"] = *p.Username
+	}
+	if p.FullName != nil {
+		name := strings.TrimSpace(*p.FullName)
+		if utf8.RuneCountInString(name) > 100 {
+			return nil, fmt.Errorf("%w: full_name is too long", ErrInvalidProfile)
+		}
+		updates["full_name"] = name
+	}
+	if p.Email != nil {
+		if *p.Email == "" {
+			updates["email"] = nil
+		} else {
+			addr, err := mail.ParseAddress(*p.Email)
+			if err != nil || addr.Address != *p.Email {
+				return nil, fmt.Errorf("%w: invalid email", ErrInvalidProfile)
+			}
+			updates["email"] = strings.ToLower(addr.Address)
+		}
+	}
+	if p.Locale != nil {
+		if *p.Locale == "" {
+			updates["locale"] = ""
+		} else {
+			locale := i18n.Normalize(*p.Locale)
+			if locale == "" {
+				return nil, fmt.Errorf("%w: invalid locale", ErrInvalidProfile)
+			}
+			updates["locale"] = locale
+		}
+	}
+	return updates, nil
+}
+
+func validatePassword(p string) error {
+	// bcrypt учитывает только первые 72 байта.
+	if len(p) < 8 || len(p) > 72 {
+		return fmt.Errorf("%w: password must be 8-72 bytes long", ErrInvalidProfile)
+	}
+	return nil
+}
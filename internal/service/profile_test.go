@@ -0,0 +1,175 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestProfileUpdateValidate(t *testing.T) {
+	updates, err := ProfileUpdate{
+		Username: strPtr("anna.k"),
+		FullName: strPtr("  Анна Ким "),
+		Email:    strPtr("Anna@Example.com"),
+		Locale:   strPtr("kk-KZ"),
+	}.validate()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"username":  "anna.k",
+		"full_name": "Анна Ким",
+		"email":     "anna@example.com",
+		"locale":    "kk",
+	}, updates)
+
+	updates, err = ProfileUpdate{Email: strPtr("")}.validate()
+	require.NoError(t, err)
+	assert.Contains(t, updates, "email")
+	assert.Nil(t, updates["email"])
+
+	// Телефон меняется только через подтверждение кодом, PATCH его игнорирует.
+	var p ProfileUpdate
+	require.NoError(t, json.Unmarshal([]byte(`{"phone":"+77011234567"}`), &p))
+	updates, err = p.validate()
+	require.NoError(t, err)
+	assert.Empty(t, updates)
+
+	for name, p := range map[string]ProfileUpdate{
+		"short username":    {Username: strPtr("ab")},
+		"username spaces":   {Username: strPtr("anna k")},
+		"reserved username": {Username: strPtr("deleted_user_5")},
+		"bad email":         {Email: strPtr("Anna <anna@example.com>")},
+		"bad locale":        {Locale: strPtr("de")},
+	} {
+		_, err := p.validate()
+		assert.ErrorIs(t, err, ErrInvalidProfile, name)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	user := &models.User{Username: "anna"}
+	user.ID = 1
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
+		mockRepo.On("GetUserByID", uint(1)).Return(user, nil).Twice()
+		mockRepo.On("UpdateUser", user, map[string]interface{}{"full_name": "Anna"}).Return(nil).Once()
+
+		res, err := svc.UpdateProfile(1, ProfileUpdate{FullName: strPtr("Anna")})
+		assert.NoError(t, err)
+		assert.NotNil(t, res)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
+		mockRepo.On("GetUserByID", uint(1)).Return(user, nil).Once()
+		mockRepo.On("UpdateUser", user, mock.Anything).Return(&pgconn.PgError{Code: "23505"}).Once()
+
+		_, err := svc.UpdateProfile(1, ProfileUpdate{Username: strPtr("maria")})
+		assert.ErrorIs(t, err, ErrProfileConflict)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		svc := NewSalonService(new(MockRepo), testTokens())
+		_, err := svc.UpdateProfile(1, ProfileUpdate{Username: strPtr("x")})
+		assert.ErrorIs(t, err, ErrInvalidProfile)
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("old-password"), 10)
+	user := &models.User{Password: string(hashed)}
+	user.ID = 1
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
+		mockRepo.On("GetUserByID", uint(1)).Return(user, nil).Once()
+		mockRepo.On("UpdateUser", user, mock.MatchedBy(func(u map[string]interface{}) bool {
+			return bcrypt.CompareHashAndPassword([]byte(u["password"].(string)), []byte("new-password")) == nil
+		})).Return(nil).Once()
+
+		assert.NoError(t, svc.ChangePassword(1, "old-password", "new-password", time.Time{}))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong Current Password", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
+		mockRepo.On("GetUserByID", uint(1)).Return(user, nil).Once()
+
+		err := svc.ChangePassword(1, "wrong", "new-password", time.Time{})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("First Password After Fresh Login", func(t *testing.T) {
+		now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+		phoneUser := &models.User{Phone: strPtr("+77010000000")}
+		phoneUser.ID = 2
+		mockRepo := new(MockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
+		svc.now = func() time.Time { return now }
+		mockRepo.On("GetUserByID", uint(2)).Return(phoneUser, nil).Twice()
+		mockRepo.On("UpdateUser", phoneUser, mock.Anything).Return(nil).Once()
+
+		// Токен выдан час назад — нужно войти заново.
+		assert.ErrorIs(t, svc.ChangePassword(2, "", "new-password", now.Add(-time.Hour)), ErrReauthRequired)
+		assert.NoError(t, svc.ChangePassword(2, "", "new-password", now.Add(-time.Minute)))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Weak Password", func(t *testing.T) {
+		svc := NewSalonService(new(MockRepo), testTokens())
+		assert.ErrorIs(t, svc.ChangePassword(1, "old-password", "short", time.Time{}), ErrInvalidProfile)
+	})
+}
+
+func TestDeleteAccount(t *testing.T) {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password"), 10)
+
+	t.Run("Password Confirmed", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
+		mockRepo.On("GetUserByID", uint(1)).Return(&models.User{Password: string(hashed)}, nil).Once()
+		mockRepo.On("AnonymizeUser", uint(1)).Return(nil).Once()
+
+		assert.NoError(t, svc.DeleteAccount(1, "password", time.Time{}))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
+		mockRepo.On("GetUserByID", uint(1)).Return(&models.User{Password: string(hashed)}, nil).Once()
+
+		assert.ErrorIs(t, svc.DeleteAccount(1, "nope", time.Time{}), ErrInvalidCredentials)
+		mockRepo.AssertNotCalled(t, "AnonymizeUser", mock.Anything)
+	})
+
+	t.Run("Passwordless User", func(t *testing.T) {
+		now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+		mockRepo := new(MockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
+		svc.now = func() time.Time { return now }
+		mockRepo.On("GetUserByID", uint(2)).Return(&models.User{}, nil).Twice()
+		mockRepo.On("AnonymizeUser", uint(2)).Return(errors.New("db error")).Once()
+
+		// Украденный старый токен не позволяет удалить аккаунт без свежего входа.
+		assert.ErrorIs(t, svc.DeleteAccount(2, "", now.Add(-time.Hour)), ErrReauthRequired)
+		assert.EqualError(t, svc.DeleteAccount(2, "", now.Add(-time.Minute)), "db error")
+		mockRepo.AssertExpectations(t)
+	})
+}
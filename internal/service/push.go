@@ -0,0 +1,57 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidPushDevice = errors.New("invalid push device")
+
+type PushDevices interface {
+	RegisterDevice(userID uint, platform, token string) error
+	UnregisterDevice(userID uint) error
+}
+
+// PushDeviceService хранит устройства клиентов для notify.PushChannel.
+type PushDeviceService struct {
+	repo repository.PushRepository
+}
+
+func NewPushDeviceService(repo repository.PushRepository) *PushDeviceService {
+	return &PushDeviceService{repo: repo}
+}
+
+func (s *PushDeviceService) RegisterDevice(userID uint, platform, token string) error {
+	token = strings.TrimSpace(token)
+	if token == "" || len(token) > 4096 {
+		return fmt.Errorf("%w: token is required", ErrInvalidPushDevice)
+	}
+	if !validPlatform(platform) {
+		return fmt.Errorf("%w: platform must be ios, android or web", ErrInvalidPushDevice)
+	}
+	return s.repo.ReplacePushDevice(&models.PushDevice{UserID: userID, Platform: platform, Token: token})
+}
+
+func (s *PushDeviceService) UnregisterDevice(userID uint) error {
+	return s.repo.DeletePushDevice(userID)
+}
+
+// PushToken — адрес для notify.PushChannel; "" — устройство не зарегистрировано.
+func (s *PushDeviceService) PushToken(userID uint) string {
+	d, err := s.repo.GetPushDevice(userID)
+	if err != nil {
+		return ""
+	}
+	return d.Token
+}
+
+func validPlatform(p string) bool {
+	switch p {
+	case "ios", "android", "web":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockPushRepo struct {
+	mock.Mock
+}
+
+func (m *MockPushRepo) ReplacePushDevice(d *models.PushDevice) error { return m.Called(d).Error(0) }
+func (m *MockPushRepo) DeletePushDevice(userID uint) error           { return m.Called(userID).Error(0) }
+func (m *MockPushRepo) GetPushDevice(userID uint) (*models.PushDevice, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PushDevice), args.Error(1)
+}
+
+func TestRegisterPushDevice(t *testing.T) {
+	repo := new(MockPushRepo)
+	svc := NewPushDeviceService(repo)
+	repo.On("ReplacePushDevice", &models.PushDevice{UserID: 4, Platform: "android", Token: "fcm-token"}).Return(nil).Once()
+
+	assert.NoError(t, svc.RegisterDevice(4, "android", " fcm-token "))
+	assert.ErrorIs(t, svc.RegisterDevice(4, "symbian", "fcm-token"), ErrInvalidPushDevice)
+	assert.ErrorIs(t, svc.RegisterDevice(4, "ios", "  "), ErrInvalidPushDevice)
+	repo.AssertExpectations(t)
+}
+
+func TestPushToken(t *testing.T) {
+	repo := new(MockPushRepo)
+	svc := NewPushDeviceService(repo)
+	repo.On("GetPushDevice", uint(4)).Return(&models.PushDevice{Token: "fcm-token"}, nil)
+	repo.On("GetPushDevice", uint(5)).Return(nil, gorm.ErrRecordNotFound)
+
+	assert.Equal(t, "fcm-token", svc.PushToken(4))
+	assert.Equal(t, "", svc.PushToken(5))
+}
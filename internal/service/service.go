@@ -1,14 +1,17 @@
 package service
 
 import (
+	"beauty-salon/internal/auth"
 	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/notify"
 	"beauty-salon/internal/repository"
 	"errors"
-	"os"
+	"fmt"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type Service interface {
@@ -16,6 +19,9 @@ type Service interface {
 	Login(username, password string) (string, error)
 	GetUserByID(id uint) (*models.User, error)
 	GetAllUsers() ([]models.User, error)
+	UpdateProfile(id uint, p ProfileUpdate) (*models.User, error)
+	ChangePassword(id uint, current, next string, authTime time.Time) error
+	DeleteAccount(id uint, password string, authTime time.Time) error
 	DeleteUser(id string) error
 
 	AddService(s *models.Service) error
@@ -31,16 +37,69 @@ type Service interface {
 	CreateBooking(b *models.Booking) error
 	GetBookings() ([]models.Booking, error)
 	GetBooking(id string) (*models.Booking, error)
-	UpdateBooking(id string, updates map[string]interface{}) (*models.Booking, error)
+	PatchBooking(id string, in BookingPatch, userID uint) (*models.Booking, error)
 	CancelBooking(id string) error
+
+	GetAvailableSlots(serviceID, staffID uint, day string) ([]Slot, error)
+	BookSlot(userID, serviceID, staffID uint, date string) (*models.Booking, error)
+	GetUserUpcomingBookings(userID uint) ([]models.Booking, error)
+	CancelUserBooking(userID uint, id string) error
 }
 
 type SalonService struct {
-	repo repository.Repository
+	repo        repository.Repository
+	tokens      auth.TokenIssuer
+	notifier    BookingNotifier
+	deposits    DepositPolicy
+	discounts   BookingDiscounts
+	packages    BookingPackages
+	memberships BookingMemberships
+	now         func() time.Time
+}
+
+// DepositPolicy назначает записи предоплату — это DepositService.
+type DepositPolicy interface {
+	Apply(b *models.Booking) error
+}
+
+// BookingDiscounts считает скидку записи по правилам и промокоду — это DiscountService.
+type BookingDiscounts interface {
+	Apply(b *models.Booking) error
+}
+
+// BookingPackages оплачивает запись сеансом купленного пакета — это PackageService.
+type BookingPackages interface {
+	Apply(b *models.Booking) error
+}
+
+// BookingMemberships применяет к записи абонемент клиента — это MembershipService.
+type BookingMemberships interface {
+	Apply(b *models.Booking) error
+}
+
+func NewSalonService(repo repository.Repository, tokens auth.TokenIssuer) *SalonService {
+	return &SalonService{repo: repo, tokens: tokens, now: time.Now}
 }
 
-func NewSalonService(repo repository.Repository) *SalonService {
-	return &SalonService{repo: repo}
+// SetNotifier подключает уведомления о записях. Без него события не отправляются.
+func (s *SalonService) SetNotifier(n BookingNotifier) { s.notifier = n }
+
+// SetDeposits включает правила предоплаты при записи.
+func (s *SalonService) SetDeposits(d DepositPolicy) { s.deposits = d }
+
+// SetDiscounts включает скидки и промокоды при записи.
+func (s *SalonService) SetDiscounts(d BookingDiscounts) { s.discounts = d }
+
+// SetPackages включает оплату записей сеансами купленных пакетов.
+func (s *SalonService) SetPackages(p BookingPackages) { s.packages = p }
+
+// SetMemberships включает услуги и скидки абонементов при записи.
+func (s *SalonService) SetMemberships(m BookingMemberships) { s.memberships = m }
+
+func (s *SalonService) notify(event string, b *models.Booking) {
+	if s.notifier != nil {
+		s.notifier.BookingEvent(event, b)
+	}
 }
 
 func (s *SalonService) Register(username, password string) error {
@@ -57,19 +116,78 @@ func (s *SalonService) Login(username, password string) (string, error) {
 		return "", errors.New("user not found")
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid credentials")
+		return "", ErrInvalidCredentials
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": u.ID,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
-	})
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return s.tokens.Issue(u.ID)
 }
 
 func (s *SalonService) GetUserByID(id uint) (*models.User, error) { return s.repo.GetUserByID(id) }
 func (s *SalonService) GetAllUsers() ([]models.User, error)       { return s.repo.GetAllUsers() }
 func (s *SalonService) DeleteUser(id string) error                { return s.repo.DeleteUser(id) }
 
+func (s *SalonService) UpdateProfile(id uint, p ProfileUpdate) (*models.User, error) {
+	updates, err := p.validate()
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return u, nil
+	}
+	if err := s.repo.UpdateUser(u, updates); err != nil {
+		if repository.IsUniqueViolation(err) {
+			return nil, ErrProfileConflict
+		}
+		return nil, err
+	}
+	return s.repo.GetUserByID(id)
+}
+
+// ChangePassword меняет пароль. Пользователь, вошедший через OIDC или по
+// телефону, пароля не имеет: первый пароль он задаёт без текущего, но только
+// сразу после входа (authTime — время выдачи токена).
+func (s *SalonService) ChangePassword(id uint, current, next string, authTime time.Time) error {
+	if err := validatePassword(next); err != nil {
+		return err
+	}
+	u, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	if u.Password == "" {
+		if s.now().Sub(authTime) > passwordReauthWindow {
+			return ErrReauthRequired
+		}
+	} else if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(current)) != nil {
+		return ErrInvalidCredentials
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(next), 10)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateUser(u, map[string]interface{}{"password": string(hashed)})
+}
+
+// DeleteAccount анонимизирует пользователя. Если у пользователя есть пароль,
+// его нужно подтвердить; без пароля (OIDC, телефон) — войти заново.
+func (s *SalonService) DeleteAccount(id uint, password string, authTime time.Time) error {
+	u, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	if u.Password == "" {
+		if s.now().Sub(authTime) > passwordReauthWindow {
+			return ErrReauthRequired
+		}
+	} else if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+		return ErrInvalidCredentials
+	}
+	return s.repo.AnonymizeUser(id)
+}
+
 func (s *SalonService) AddService(srv *models.Service) error   { return s.repo.CreateService(srv) }
 func (s *SalonService) GetServices() ([]models.Service, error) { return s.repo.GetAllServices() }
 func (s *SalonService) GetService(id string) (*models.Service, error) {
@@ -82,19 +200,162 @@ func (s *SalonService) GetStaffList() ([]models.Staff, error)     { return s.rep
 func (s *SalonService) GetStaff(id string) (*models.Staff, error) { return s.repo.GetStaffByID(id) }
 func (s *SalonService) DeleteStaff(id string) error               { return s.repo.DeleteStaff(id) }
 
-func (s *SalonService) CreateBooking(b *models.Booking) error  { return s.repo.CreateBooking(b) }
+func (s *SalonService) CreateBooking(b *models.Booking) error {
+	b.Discount, b.PackageSessionID, b.SubscriptionID = money.Money{}, nil, nil
+	if s.packages != nil {
+		if err := s.packages.Apply(b); err != nil {
+			return err
+		}
+	}
+	if s.discounts != nil {
+		if err := s.discounts.Apply(b); err != nil {
+			return err
+		}
+	}
+	if s.memberships != nil {
+		if err := s.memberships.Apply(b); err != nil {
+			return err
+		}
+	}
+	if s.deposits != nil {
+		if err := s.deposits.Apply(b); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.CreateBooking(b); err != nil {
+		return err
+	}
+	s.notify(notify.EventBookingCreated, b)
+	return nil
+}
 func (s *SalonService) GetBookings() ([]models.Booking, error) { return s.repo.GetAllBookings() }
 func (s *SalonService) GetBooking(id string) (*models.Booking, error) {
 	return s.repo.GetBookingByID(id)
 }
+
+var (
+	ErrBookingForbidden     = errors.New("only staff can change booking status")
+	ErrInvalidBookingUpdate = errors.New("invalid booking update")
+)
+
+// BookingPatch — поля записи, которые можно поменять через API. Остальное
+// (предоплата, скидка, пакет, абонемент) назначается только при записи.
+type BookingPatch struct {
+	Date    *string `json:"date"`
+	StaffID *uint   `json:"staff_id"`
+	Status  *string `json:"status"`
+}
+
+// bookingTransitions — разрешённые вручную смены статуса. Запись с предоплатой
+// подтверждает только оплата, а завершает — расчёт на кассе.
+var bookingTransitions = map[string][]string{
+	"pending":          {"confirmed", "cancelled"},
+	"awaiting_payment": {"cancelled"},
+	"confirmed":        {"cancelled"},
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range bookingTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PatchBooking переносит запись или меняет её статус. userID == 0 — запрос
+// по API-ключу. Клиент может менять только свои записи, статус — только
+// мастер или администратор.
+func (s *SalonService) PatchBooking(id string, in BookingPatch, userID uint) (*models.Booking, error) {
+	b, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	staff := false
+	if userID != 0 {
+		u, err := s.repo.GetUserByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		staff = u.Role == "staff" || u.Role == "admin"
+		if !staff && b.UserID != userID {
+			return nil, ErrBookingNotFound
+		}
+	}
+	updates := map[string]interface{}{}
+	if in.Date != nil && *in.Date != b.Date {
+		if _, err := time.Parse(bookingDateLayout, *in.Date); err != nil {
+			return nil, ErrInvalidDate
+		}
+		updates["date"] = *in.Date
+	}
+	if in.StaffID != nil && *in.StaffID != b.StaffID {
+		if *in.StaffID == 0 {
+			return nil, fmt.Errorf("%w: staff is required", ErrInvalidBookingUpdate)
+		}
+		updates["staff_id"] = *in.StaffID
+	}
+	if len(updates) > 0 && (b.Status == "cancelled" || b.Status == "completed") {
+		return nil, fmt.Errorf("%w: booking is %s", ErrInvalidBookingUpdate, b.Status)
+	}
+	if in.Status != nil && *in.Status != b.Status {
+		if !staff {
+			return nil, ErrBookingForbidden
+		}
+		if !canTransition(b.Status, *in.Status) {
+			return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidBookingUpdate, b.Status, *in.Status)
+		}
+		updates["status"] = *in.Status
+	}
+	if len(updates) == 0 {
+		return b, nil
+	}
+	b, err = s.UpdateBooking(id, updates)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: staff not found", ErrInvalidBookingUpdate)
+	}
+	return b, err
+}
+
+// UpdateBooking применяет изменения без проверок — для внутренних сценариев,
+// например подтверждения записи после оплаты.
 func (s *SalonService) UpdateBooking(id string, updates map[string]interface{}) (*models.Booking, error) {
 	b, err := s.repo.GetBookingByID(id)
 	if err != nil {
 		return nil, err
 	}
+	status, date := b.Status, b.Date
 	if err := s.repo.UpdateBooking(b, updates); err != nil {
 		return nil, err
 	}
-	return s.repo.GetBookingByID(id)
+	b, err = s.repo.GetBookingByID(id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case b.Status != status && b.Status == "cancelled":
+		s.notify(notify.EventBookingCancelled, b)
+	case b.Status != status && b.Status == "confirmed":
+		s.notify(notify.EventBookingConfirmed, b)
+	case b.Date != date:
+		s.notify(notify.EventBookingRescheduled, b)
+	}
+	return b, nil
+}
+func (s *SalonService) CancelBooking(id string) error {
+	if s.notifier == nil {
+		return s.repo.DeleteBooking(id)
+	}
+	// Запись читается до удаления: после него её уже не найти.
+	b, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteBooking(id); err != nil {
+		return err
+	}
+	if b.Status != "cancelled" {
+		s.notify(notify.EventBookingCancelled, b)
+	}
+	return nil
 }
-func (s *SalonService) CancelBooking(id string) error { return s.repo.DeleteBooking(id) }
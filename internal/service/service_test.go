@@ -1,10 +1,11 @@
 package service
 
 import (
+	"beauty-salon/internal/auth"
 	"beauty-salon/internal/models"
 	"errors"
-	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -35,6 +36,10 @@ func (m *MockRepo) GetAllUsers() ([]models.User, error) {
 	args := m.Called()
 	return args.Get(0).([]models.User), args.Error(1)
 }
+func (m *MockRepo) UpdateUser(u *models.User, updates map[string]interface{}) error {
+	return m.Called(u, updates).Error(0)
+}
+func (m *MockRepo) AnonymizeUser(id uint) error           { return m.Called(id).Error(0) }
 func (m *MockRepo) DeleteUser(id string) error            { return m.Called(id).Error(0) }
 func (m *MockRepo) CreateService(s *models.Service) error { return m.Called(s).Error(0) }
 func (m *MockRepo) GetAllServices() ([]models.Service, error) {
@@ -79,17 +84,31 @@ func (m *MockRepo) GetBookingByID(id string) (*models.Booking, error) {
 	}
 	return args.Get(0).(*models.Booking), args.Error(1)
 }
+func (m *MockRepo) GetBookingsByUser(userID uint) ([]models.Booking, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
+func (m *MockRepo) GetBookingsOnDay(day string) ([]models.Booking, error) {
+	args := m.Called(day)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
 func (m *MockRepo) UpdateBooking(b *models.Booking, updates map[string]interface{}) error {
 	return m.Called(b, updates).Error(0)
 }
 func (m *MockRepo) DeleteBooking(id string) error { return m.Called(id).Error(0) }
 
+func testTokens() *auth.TokenManager {
+	key, _ := auth.NewHMACKey("test", []byte("secret"))
+	tm, _ := auth.NewTokenManager("", time.Hour, key)
+	return tm
+}
+
 // --- ТЕСТЫ ---
 
 func TestRegister(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockRepo := new(MockRepo)
-		svc := NewSalonService(mockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
 
 		mockRepo.On("CreateUser", mock.AnythingOfType("*models.User")).Return(nil).Once()
 
@@ -100,7 +119,7 @@ func TestRegister(t *testing.T) {
 
 	t.Run("Repository Error", func(t *testing.T) {
 		mockRepo := new(MockRepo)
-		svc := NewSalonService(mockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
 
 		mockRepo.On("CreateUser", mock.AnythingOfType("*models.User")).
 			Return(errors.New("user already exists")).Once()
@@ -114,7 +133,7 @@ func TestRegister(t *testing.T) {
 
 	t.Run("Bcrypt Error", func(t *testing.T) {
 		mockRepo := new(MockRepo)
-		svc := NewSalonService(mockRepo)
+		svc := NewSalonService(mockRepo, testTokens())
 
 		longPass := make([]byte, 80)
 		_ = svc.Register("testuser", string(longPass))
@@ -122,9 +141,9 @@ func TestRegister(t *testing.T) {
 }
 
 func TestLogin(t *testing.T) {
-	os.Setenv("JWT_SECRET", "secret")
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	tokens := testTokens()
+	svc := NewSalonService(mockRepo, tokens)
 
 	hashed, _ := bcrypt.GenerateFromPassword([]byte("pass"), 10)
 	user := &models.User{Username: "admin", Password: string(hashed)}
@@ -134,7 +153,9 @@ func TestLogin(t *testing.T) {
 		mockRepo.On("GetUserByUsername", "admin").Return(user, nil).Once()
 		token, err := svc.Login("admin", "pass")
 		assert.NoError(t, err)
-		assert.NotEmpty(t, token)
+		claims, err := tokens.Verify(token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), claims.UserID)
 	})
 
 	t.Run("User Not Found", func(t *testing.T) {
@@ -155,7 +176,7 @@ func TestLogin(t *testing.T) {
 // Тесты пользователей
 func TestUserMethods(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	t.Run("GetUserByID", func(t *testing.T) {
 		mockRepo.On("GetUserByID", uint(1)).Return(&models.User{}, nil)
@@ -179,7 +200,7 @@ func TestUserMethods(t *testing.T) {
 // Тесты услуг (Services)
 func TestServiceMethods(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	t.Run("AddService", func(t *testing.T) {
 		mockRepo.On("CreateService", mock.Anything).Return(nil)
@@ -203,7 +224,7 @@ func TestServiceMethods(t *testing.T) {
 // Тесты сотрудников (Staff)
 func TestStaffMethods(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	t.Run("AddStaff", func(t *testing.T) {
 		mockRepo.On("CreateStaff", mock.Anything).Return(nil)
@@ -221,7 +242,7 @@ func TestStaffMethods(t *testing.T) {
 // Тесты бронирований (Bookings)
 func TestBookingMethods(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	t.Run("CreateBooking", func(t *testing.T) {
 		mockRepo.On("CreateBooking", mock.Anything).Return(nil)
@@ -253,9 +274,67 @@ func TestBookingMethods(t *testing.T) {
 	})
 }
 
+func TestPatchBooking(t *testing.T) {
+	strp := func(s string) *string { return &s }
+
+	t.Run("Client Cannot Change Status", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewSalonService(repo, testTokens())
+		repo.On("GetBookingByID", "1").Return(&models.Booking{UserID: 7, Status: "awaiting_payment"}, nil)
+		repo.On("GetUserByID", uint(7)).Return(&models.User{Role: "client"}, nil)
+
+		_, err := svc.PatchBooking("1", BookingPatch{Status: strp("confirmed")}, 7)
+		assert.ErrorIs(t, err, ErrBookingForbidden)
+		repo.AssertNotCalled(t, "UpdateBooking", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Client Cannot Touch Foreign Booking", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewSalonService(repo, testTokens())
+		repo.On("GetBookingByID", "1").Return(&models.Booking{UserID: 8, Status: "pending"}, nil)
+		repo.On("GetUserByID", uint(7)).Return(&models.User{Role: "client"}, nil)
+
+		_, err := svc.PatchBooking("1", BookingPatch{Date: strp("2025-05-03 10:00")}, 7)
+		assert.ErrorIs(t, err, ErrBookingNotFound)
+	})
+
+	t.Run("Staff Cannot Skip Deposit", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewSalonService(repo, testTokens())
+		repo.On("GetBookingByID", "1").Return(&models.Booking{UserID: 8, Status: "awaiting_payment"}, nil)
+		repo.On("GetUserByID", uint(2)).Return(&models.User{Role: "staff"}, nil)
+
+		_, err := svc.PatchBooking("1", BookingPatch{Status: strp("confirmed")}, 2)
+		assert.ErrorIs(t, err, ErrInvalidBookingUpdate)
+	})
+
+	t.Run("Client Reschedules", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewSalonService(repo, testTokens())
+		b := &models.Booking{UserID: 7, StaffID: 3, Status: "confirmed", Date: "2025-05-02 10:00"}
+		repo.On("GetBookingByID", "1").Return(b, nil)
+		repo.On("GetUserByID", uint(7)).Return(&models.User{Role: "client"}, nil)
+		repo.On("UpdateBooking", b, map[string]interface{}{"date": "2025-05-03 12:00"}).Return(nil).Once()
+
+		_, err := svc.PatchBooking("1", BookingPatch{Date: strp("2025-05-03 12:00")}, 7)
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Date", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewSalonService(repo, testTokens())
+		repo.On("GetBookingByID", "1").Return(&models.Booking{Status: "pending"}, nil)
+
+		// Запрос по API-ключу: userID == 0.
+		_, err := svc.PatchBooking("1", BookingPatch{Date: strp("tomorrow")}, 0)
+		assert.ErrorIs(t, err, ErrInvalidDate)
+	})
+}
+
 func TestDeleteService(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	t.Run("Success", func(t *testing.T) {
 		mockRepo.On("DeleteService", "1").Return(nil).Once()
@@ -279,7 +358,7 @@ func TestDeleteService(t *testing.T) {
 
 func TestGetStaff(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	t.Run("Success", func(t *testing.T) {
 		expectedStaff := &models.Staff{FullName: "Anna"}
@@ -307,7 +386,7 @@ func TestGetStaff(t *testing.T) {
 
 func TestDeleteStaff(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	t.Run("Success", func(t *testing.T) {
 		mockRepo.On("DeleteStaff", "1").Return(nil).Once()
@@ -331,7 +410,7 @@ func TestDeleteStaff(t *testing.T) {
 
 func TestGetBooking(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	t.Run("Success", func(t *testing.T) {
 		expectedBooking := &models.Booking{Status: "confirmed"}
@@ -359,7 +438,7 @@ func TestGetBooking(t *testing.T) {
 
 func TestGetBookings(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	t.Run("Success", func(t *testing.T) {
 		expectedBookings := []models.Booking{
@@ -391,7 +470,7 @@ func TestGetBookings(t *testing.T) {
 
 func TestUpdateBooking(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := NewSalonService(mockRepo)
+	svc := NewSalonService(mockRepo, testTokens())
 
 	id := "123"
 	updates := map[string]interface{}{"status": "confirmed"}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidDate     = errors.New("invalid date")
+	ErrSlotUnavailable = repository.ErrSlotUnavailable
+	ErrBookingNotFound = errors.New("booking not found")
+)
+
+// Рабочие часы салона и шаг сетки, по которой предлагаются слоты.
+const (
+	workdayStart = 10 * time.Hour
+	workdayEnd   = 20 * time.Hour
+	slotStep     = 30 * time.Minute
+)
+
+// Slot — время, на которое можно записаться к мастеру.
+type Slot struct {
+	StaffID   uint   `json:"staff_id"`
+	StaffName string `json:"staff_name"`
+	Date      string `json:"date"` // YYYY-MM-DD HH:MM
+}
+
+type interval struct{ from, to time.Time }
+
+// GetAvailableSlots возвращает свободные слоты на день YYYY-MM-DD для услуги.
+// staffID == 0 — у всех мастеров. Слот свободен, если процедура целиком
+// помещается в рабочий день и не пересекается с действующими записями мастера.
+func (s *SalonService) GetAvailableSlots(serviceID, staffID uint, day string) ([]Slot, error) {
+	now := s.now()
+	start, err := time.ParseInLocation("2006-01-02", day, now.Location())
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	srv, err := s.repo.GetServiceByID(strconv.FormatUint(uint64(serviceID), 10))
+	if err != nil {
+		return nil, err
+	}
+	var staff []models.Staff
+	if staffID != 0 {
+		st, err := s.repo.GetStaffByID(strconv.FormatUint(uint64(staffID), 10))
+		if err != nil {
+			return nil, err
+		}
+		staff = []models.Staff{*st}
+	} else if staff, err = s.repo.GetAllStaff(); err != nil {
+		return nil, err
+	}
+	bookings, err := s.repo.GetBookingsOnDay(day)
+	if err != nil {
+		return nil, err
+	}
+
+	busy := map[uint][]interval{}
+	for _, b := range bookings {
+		at, err := time.ParseInLocation(bookingDateLayout, b.Date, now.Location())
+		if err != nil {
+			continue
+		}
+		busy[b.StaffID] = append(busy[b.StaffID], interval{at, at.Add(b.Service.Duration())})
+	}
+
+	duration := srv.Duration()
+	closing := start.Add(workdayEnd)
+	var slots []Slot
+	for _, st := range staff {
+	next:
+		for t := start.Add(workdayStart); !t.Add(duration).After(closing); t = t.Add(slotStep) {
+			if t.Before(now) {
+				continue
+			}
+			for _, iv := range busy[st.ID] {
+				if t.Before(iv.to) && iv.from.Before(t.Add(duration)) {
+					continue next
+				}
+			}
+			slots = append(slots, Slot{StaffID: st.ID, StaffName: st.FullName, Date: t.Format(bookingDateLayout)})
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].Date < slots[j].Date })
+	return slots, nil
+}
+
+// BookSlot записывает клиента на свободный слот.
+func (s *SalonService) BookSlot(userID, serviceID, staffID uint, date string) (*models.Booking, error) {
+	if len(date) != len(bookingDateLayout) || staffID == 0 {
+		return nil, ErrSlotUnavailable
+	}
+	slots, err := s.GetAvailableSlots(serviceID, staffID, date[:10])
+	if err != nil {
+		return nil, err
+	}
+	for _, slot := range slots {
+		if slot.Date == date {
+			b := &models.Booking{UserID: userID, ServiceID: serviceID, StaffID: staffID, Date: date}
+			if err := s.CreateBooking(b); err != nil {
+				return nil, err
+			}
+			return b, nil
+		}
+	}
+	return nil, ErrSlotUnavailable
+}
+
+// GetUserUpcomingBookings возвращает предстоящие действующие записи клиента.
+func (s *SalonService) GetUserUpcomingBookings(userID uint) ([]models.Booking, error) {
+	bookings, err := s.repo.GetBookingsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().Format(bookingDateLayout)
+	var upcoming []models.Booking
+	for _, b := range bookings {
+		if b.Date >= now && b.Status != "cancelled" && b.Status != "completed" {
+			upcoming = append(upcoming, b)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return upcoming[i].Date < upcoming[j].Date })
+	return upcoming, nil
+}
+
+// CancelUserBooking отменяет запись от имени клиента: чужие записи для него не существуют.
+func (s *SalonService) CancelUserBooking(userID uint, id string) error {
+	b, err := s.repo.GetBookingByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && b.UserID != userID) {
+		return ErrBookingNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.CancelBooking(id)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func slotsFixture(now time.Time) (*SalonService, *MockRepo) {
+	repo := new(MockRepo)
+	svc := NewSalonService(repo, testTokens())
+	svc.now = func() time.Time { return now }
+
+	repo.On("GetServiceByID", "1").Return(&models.Service{DurationMin: 60}, nil)
+	anna := models.Staff{FullName: "Анна"}
+	anna.ID = 2
+	olga := models.Staff{FullName: "Ольга"}
+	olga.ID = 3
+	repo.On("GetStaffByID", "2").Return(&anna, nil)
+	repo.On("GetAllStaff").Return([]models.Staff{anna, olga}, nil)
+	repo.On("GetBookingsOnDay", "2025-05-02").Return([]models.Booking{
+		{StaffID: 2, Date: "2025-05-02 12:00", Service: models.Service{DurationMin: 90}},
+	}, nil)
+	return svc, repo
+}
+
+func TestGetAvailableSlots(t *testing.T) {
+	now := time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC)
+
+	t.Run("One Staff", func(t *testing.T) {
+		svc, _ := slotsFixture(now)
+		slots, err := svc.GetAvailableSlots(1, 2, "2025-05-02")
+		require.NoError(t, err)
+
+		var dates []string
+		for _, s := range slots {
+			dates = append(dates, s.Date[11:])
+		}
+		// 12:00-13:30 занято: часовая процедура не может начаться с 11:30 до 13:00.
+		assert.Equal(t, []string{"10:00", "10:30", "11:00", "13:30", "14:00", "14:30", "15:00", "15:30",
+			"16:00", "16:30", "17:00", "17:30", "18:00", "18:30", "19:00"}, dates)
+		assert.Equal(t, "Анна", slots[0].StaffName)
+	})
+
+	t.Run("All Staff Skips Past", func(t *testing.T) {
+		svc, _ := slotsFixture(time.Date(2025, 5, 2, 18, 10, 0, 0, time.UTC))
+		slots, err := svc.GetAvailableSlots(1, 0, "2025-05-02")
+		require.NoError(t, err)
+		assert.Equal(t, []Slot{
+			{StaffID: 2, StaffName: "Анна", Date: "2025-05-02 18:30"},
+			{StaffID: 3, StaffName: "Ольга", Date: "2025-05-02 18:30"},
+			{StaffID: 2, StaffName: "Анна", Date: "2025-05-02 19:00"},
+			{StaffID: 3, StaffName: "Ольга", Date: "2025-05-02 19:00"},
+		}, slots)
+	})
+
+	t.Run("Invalid Date", func(t *testing.T) {
+		svc, _ := slotsFixture(now)
+		_, err := svc.GetAvailableSlots(1, 0, "02.05.2025")
+		assert.ErrorIs(t, err, ErrInvalidDate)
+	})
+}
+
+func TestBookSlot(t *testing.T) {
+	now := time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC)
+
+	t.Run("Free Slot", func(t *testing.T) {
+		svc, repo := slotsFixture(now)
+		repo.On("CreateBooking", mock.MatchedBy(func(b *models.Booking) bool {
+			return b.UserID == 7 && b.ServiceID == 1 && b.StaffID == 2 && b.Date == "2025-05-02 14:00"
+		})).Return(nil).Once()
+
+		b, err := svc.BookSlot(7, 1, 2, "2025-05-02 14:00")
+		require.NoError(t, err)
+		assert.Equal(t, "2025-05-02 14:00", b.Date)
+	})
+
+	t.Run("Taken Slot", func(t *testing.T) {
+		svc, repo := slotsFixture(now)
+		_, err := svc.BookSlot(7, 1, 2, "2025-05-02 12:30")
+		assert.ErrorIs(t, err, ErrSlotUnavailable)
+		_, err = svc.BookSlot(7, 1, 2, "2025-05-02")
+		assert.ErrorIs(t, err, ErrSlotUnavailable)
+		repo.AssertNotCalled(t, "CreateBooking", mock.Anything)
+	})
+}
+
+func TestUserBookings(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewSalonService(repo, testTokens())
+	svc.now = func() time.Time { return time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC) }
+
+	repo.On("GetBookingsByUser", uint(7)).Return([]models.Booking{
+		{Date: "2025-05-03 10:00", Status: "pending"},
+		{Date: "2025-05-01 10:00", Status: "confirmed"},
+		{Date: "2025-05-02 15:00", Status: "confirmed"},
+		{Date: "2025-05-04 10:00", Status: "cancelled"},
+	}, nil)
+	upcoming, err := svc.GetUserUpcomingBookings(7)
+	require.NoError(t, err)
+	require.Len(t, upcoming, 2)
+	assert.Equal(t, "2025-05-02 15:00", upcoming[0].Date)
+
+	own := &models.Booking{UserID: 7}
+	repo.On("GetBookingByID", "5").Return(own, nil)
+	repo.On("GetBookingByID", "6").Return(&models.Booking{UserID: 8}, nil)
+	repo.On("GetBookingByID", "9").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("DeleteBooking", "5").Return(nil).Once()
+
+	assert.NoError(t, svc.CancelUserBooking(7, "5"))
+	assert.ErrorIs(t, svc.CancelUserBooking(7, "6"), ErrBookingNotFound)
+	assert.ErrorIs(t, svc.CancelUserBooking(7, "9"), ErrBookingNotFound)
+	repo.AssertNumberOfCalls(t, "DeleteBooking", 1)
+}
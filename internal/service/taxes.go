@@ -0,0 +1,115 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTaxRate  = errors.New("invalid tax rate")
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+)
+
+// Режимы цен: inclusive — цены каталога и кассы уже с НДС, налог выделяется
+// из них; exclusive — НДС начисляется сверху и входит в итог чека.
+const (
+	TaxInclusive = "inclusive"
+	TaxExclusive = "exclusive"
+)
+
+// ParseTaxMode разбирает TAX_MODE; пустое значение — цены с НДС.
+func ParseTaxMode(s string) (string, error) {
+	switch strings.TrimSpace(s) {
+	case "", TaxInclusive:
+		return TaxInclusive, nil
+	case TaxExclusive:
+		return TaxExclusive, nil
+	}
+	return "", fmt.Errorf("unknown tax mode %q", s)
+}
+
+// TaxRateInput — ставка для категории; пустая категория — ставка по умолчанию.
+// Rate — в сотых долях процента: 1200 — 12%, 0 — без НДС.
+type TaxRateInput struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Rate     int    `json:"rate"`
+}
+
+type Taxes interface {
+	SaveRate(in TaxRateInput) (*models.TaxRate, error)
+	GetRates() ([]models.TaxRate, error)
+	DeleteRate(id string) error
+}
+
+type TaxService struct {
+	repo repository.TaxRepository
+	mode string
+}
+
+func NewTaxService(repo repository.TaxRepository, mode string) *TaxService {
+	return &TaxService{repo: repo, mode: mode}
+}
+
+func (s *TaxService) SaveRate(in TaxRateInput) (*models.TaxRate, error) {
+	if in.Rate < 0 || in.Rate > 10000 {
+		return nil, fmt.Errorf("%w: rate must be 0-10000 hundredths of a percent", ErrInvalidTaxRate)
+	}
+	rate := &models.TaxRate{Category: strings.TrimSpace(in.Category), Name: strings.TrimSpace(in.Name), Rate: in.Rate}
+	if err := s.repo.SaveTaxRate(rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *TaxService) GetRates() ([]models.TaxRate, error) { return s.repo.GetTaxRates() }
+
+func (s *TaxService) DeleteRate(id string) error {
+	err := s.repo.DeleteTaxRate(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTaxRateNotFound
+	}
+	return err
+}
+
+// ApplyTax считает НДС по каждой строке услуг и товаров: с суммы строки за
+// вычетом её доли скидки, по ставке категории строки. Чаевые НДС не облагаются.
+func (s *TaxService) ApplyTax(rec *models.Receipt) error {
+	list, err := s.repo.GetTaxRates()
+	if err != nil {
+		return err
+	}
+	rates := make(map[string]int, len(list))
+	for _, r := range list {
+		rates[r.Category] = r.Rate
+	}
+
+	rec.TaxMode = s.mode
+	rec.Tax = money.Zero(rec.Subtotal.Currency)
+	for i := range rec.Items {
+		it := &rec.Items[i]
+		it.TaxRate, it.Tax = 0, money.Zero(it.Amount.Currency)
+		if !it.Taxable() {
+			continue
+		}
+		rate, ok := rates[it.Category]
+		if !ok {
+			rate = rates[""]
+		}
+		base := it.Amount.Sub(it.Discount)
+		if s.mode == TaxExclusive {
+			it.Tax = base.Ratio(int64(rate), 10000, money.TaxRounding)
+		} else {
+			it.Tax = base.Ratio(int64(rate), int64(10000+rate), money.TaxRounding)
+		}
+		it.TaxRate = rate
+		rec.Tax = rec.Tax.Add(it.Tax)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockTaxRepo struct {
+	mock.Mock
+}
+
+func (m *MockTaxRepo) GetTaxRates() ([]models.TaxRate, error) {
+	args := m.Called()
+	return args.Get(0).([]models.TaxRate), args.Error(1)
+}
+func (m *MockTaxRepo) SaveTaxRate(r *models.TaxRate) error { return m.Called(r).Error(0) }
+func (m *MockTaxRepo) DeleteTaxRate(id string) error       { return m.Called(id).Error(0) }
+
+// testRates: 12% по умолчанию, медицинские товары без НДС.
+var testRates = []models.TaxRate{{Category: "", Rate: 1200}, {Category: "medical", Rate: 0}}
+
+func taxedReceipt() *models.Receipt {
+	rec := &models.Receipt{Subtotal: kzt(800050), Discount: kzt(80005), Tips: kzt(50000)}
+	rec.Items = []models.ReceiptItem{
+		receiptLine("service", "Стрижка", "hair", 1, kzt(500050)),
+		receiptLine("product", "Пластырь", "medical", 2, kzt(150000)),
+		receiptLine("discount", "Скидка", "", 1, kzt(-80005)),
+		receiptLine("tip", "Ольга", "", 1, kzt(50000)),
+	}
+	allocateDiscount(rec)
+	return rec
+}
+
+func TestApplyTax(t *testing.T) {
+	t.Run("Inclusive", func(t *testing.T) {
+		repo := new(MockTaxRepo)
+		repo.On("GetTaxRates").Return(testRates, nil).Once()
+		rec := taxedReceipt()
+		require.NoError(t, NewTaxService(repo, TaxInclusive).ApplyTax(rec))
+
+		// Скидка 800.05 делится 5:3 — 500.05 на услугу и 300.00 на товар.
+		assert.Equal(t, kzt(50005), rec.Items[0].Discount)
+		assert.Equal(t, kzt(30000), rec.Items[1].Discount)
+		// НДС внутри 4500.45: 4500.45 × 12/112 = 482.19.
+		assert.Equal(t, 1200, rec.Items[0].TaxRate)
+		assert.Equal(t, kzt(48219), rec.Items[0].Tax)
+		assert.True(t, rec.Items[1].Tax.IsZero())
+		assert.True(t, rec.Items[3].Tax.IsZero(), "tips are not taxed")
+		assert.Equal(t, kzt(48219), rec.Tax)
+		assert.Equal(t, TaxInclusive, rec.TaxMode)
+	})
+
+	t.Run("Exclusive", func(t *testing.T) {
+		repo := new(MockTaxRepo)
+		repo.On("GetTaxRates").Return(testRates, nil).Once()
+		rec := taxedReceipt()
+		require.NoError(t, NewTaxService(repo, TaxExclusive).ApplyTax(rec))
+		// 4500.45 × 12% = 540.054.
+		assert.Equal(t, kzt(54005), rec.Tax)
+	})
+
+	t.Run("Half Even", func(t *testing.T) {
+		repo := new(MockTaxRepo)
+		repo.On("GetTaxRates").Return([]models.TaxRate{{Rate: 1000}}, nil).Twice()
+		svc := NewTaxService(repo, TaxExclusive)
+		for price, tax := range map[int64]int64{1125: 112, 1135: 114} {
+			rec := &models.Receipt{Subtotal: kzt(price), Items: []models.ReceiptItem{receiptLine("addon", "x", "", 1, kzt(price))}}
+			require.NoError(t, svc.ApplyTax(rec))
+			assert.Equal(t, kzt(tax), rec.Tax, "price %d", price)
+		}
+	})
+}
+
+func TestSaveTaxRate(t *testing.T) {
+	repo := new(MockTaxRepo)
+	svc := NewTaxService(repo, TaxInclusive)
+	for _, rate := range []int{-1, 10001} {
+		_, err := svc.SaveRate(TaxRateInput{Rate: rate})
+		assert.ErrorIs(t, err, ErrInvalidTaxRate)
+	}
+
+	repo.On("SaveTaxRate", &models.TaxRate{Category: "cosmetics", Name: "НДС", Rate: 1200}).Return(nil).Once()
+	_, err := svc.SaveRate(TaxRateInput{Category: " cosmetics ", Name: "НДС", Rate: 1200})
+	require.NoError(t, err)
+
+	repo.On("DeleteTaxRate", "9").Return(gorm.ErrRecordNotFound).Once()
+	assert.ErrorIs(t, svc.DeleteRate("9"), ErrTaxRateNotFound)
+}
+
+func TestParseTaxMode(t *testing.T) {
+	mode, err := ParseTaxMode("")
+	require.NoError(t, err)
+	assert.Equal(t, TaxInclusive, mode)
+	mode, err = ParseTaxMode("exclusive")
+	require.NoError(t, err)
+	assert.Equal(t, TaxExclusive, mode)
+	_, err = ParseTaxMode("gross")
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidLinkToken = errors.New("invalid or expired link token")
+
+const (
+	TelegramProvider = "telegram"
+	telegramLinkTTL  = 15 * time.Minute
+)
+
+type TelegramLinks interface {
+	CreateLink(ctx context.Context, userID uint) (string, error)
+	CompleteLink(ctx context.Context, token string, telegramUserID int64) (*models.User, error)
+	UserByTelegramID(telegramUserID int64) (*models.User, error)
+	Unlink(userID uint) error
+}
+
+// TelegramLinkService привязывает чат с ботом к учётке через deep link
+// t.me/<bot>?start=<token>. Токен одноразовый и живёт в Redis.
+type TelegramLinkService struct {
+	repo        repository.TelegramRepository
+	rdb         *redis.Client
+	botUsername string
+}
+
+func NewTelegramLinkService(repo repository.TelegramRepository, rdb *redis.Client, botUsername string) *TelegramLinkService {
+	return &TelegramLinkService{repo: repo, rdb: rdb, botUsername: botUsername}
+}
+
+// CreateLink возвращает ссылку, открыв которую пользователь привяжет Telegram.
+func (s *TelegramLinkService) CreateLink(ctx context.Context, userID uint) (string, error) {
+	token := auth.RandomToken(24)
+	if err := s.rdb.Set(ctx, "tg_link:"+token, userID, telegramLinkTTL).Err(); err != nil {
+		return "", err
+	}
+	return "https://t.me/" + s.botUsername + "?start=" + token, nil
+}
+
+// CompleteLink вызывается ботом на /start <token>. Прежняя привязка
+// пользователя заменяется: у одного клиента один чат.
+func (s *TelegramLinkService) CompleteLink(ctx context.Context, token string, telegramUserID int64) (*models.User, error) {
+	if token == "" {
+		return nil, ErrInvalidLinkToken
+	}
+	userID, err := s.rdb.GetDel(ctx, "tg_link:"+token).Uint64()
+	if err != nil {
+		return nil, ErrInvalidLinkToken
+	}
+	subject := strconv.FormatInt(telegramUserID, 10)
+	existing, err := s.repo.GetUserByIdentity(TelegramProvider, subject)
+	switch {
+	case err == nil && existing.ID != uint(userID):
+		return nil, ErrIdentityTaken
+	case err == nil:
+		return existing, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+	if err := s.repo.DeleteIdentity(uint(userID), TelegramProvider); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateIdentity(&models.UserIdentity{UserID: uint(userID), Provider: TelegramProvider, Subject: subject}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserByID(uint(userID))
+}
+
+func (s *TelegramLinkService) UserByTelegramID(telegramUserID int64) (*models.User, error) {
+	return s.repo.GetUserByIdentity(TelegramProvider, strconv.FormatInt(telegramUserID, 10))
+}
+
+func (s *TelegramLinkService) Unlink(userID uint) error {
+	return s.repo.DeleteIdentity(userID, TelegramProvider)
+}
+
+// TelegramChatID — адрес для notify.TelegramChannel. В личном чате id чата
+// совпадает с id пользователя Telegram.
+func (s *TelegramLinkService) TelegramChatID(userID uint) string {
+	i, err := s.repo.GetIdentityByUser(userID, TelegramProvider)
+	if err != nil {
+		return ""
+	}
+	return i.Subject
+}
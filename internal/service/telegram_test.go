@@ -0,0 +1,114 @@
+package service
+
+import (
+	"beauty-salon/internal/models"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockTelegramRepo struct {
+	MockIdentityRepo
+}
+
+func (m *MockTelegramRepo) GetUserByID(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockTelegramRepo) GetIdentityByUser(userID uint, provider string) (*models.UserIdentity, error) {
+	args := m.Called(userID, provider)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserIdentity), args.Error(1)
+}
+func (m *MockTelegramRepo) DeleteIdentity(userID uint, provider string) error {
+	return m.Called(userID, provider).Error(0)
+}
+
+func TestTelegramCreateLink(t *testing.T) {
+	db, rmock := redismock.NewClientMock()
+	svc := NewTelegramLinkService(new(MockTelegramRepo), db, "salon_bot")
+
+	var key string
+	rmock.CustomMatch(func(expected, actual []interface{}) error {
+		key = actual[1].(string)
+		return nil
+	}).ExpectSet("", 4, telegramLinkTTL).SetVal("OK")
+
+	link, err := svc.CreateLink(context.Background(), 4)
+	require.NoError(t, err)
+	token := strings.TrimPrefix(link, "https://t.me/salon_bot?start=")
+	assert.NotEqual(t, link, token)
+	assert.Equal(t, "tg_link:"+token, key)
+}
+
+func TestTelegramCompleteLink(t *testing.T) {
+	ctx := context.Background()
+	anna := &models.User{Username: "anna"}
+	anna.ID = 4
+
+	t.Run("Links Chat", func(t *testing.T) {
+		db, rmock := redismock.NewClientMock()
+		repo := new(MockTelegramRepo)
+		svc := NewTelegramLinkService(repo, db, "salon_bot")
+
+		rmock.ExpectGetDel("tg_link:tok").SetVal("4")
+		repo.On("GetUserByIdentity", "telegram", "100500").Return(nil, gorm.ErrRecordNotFound).Once()
+		repo.On("DeleteIdentity", uint(4), "telegram").Return(nil).Once()
+		repo.On("CreateIdentity", mock.MatchedBy(func(i *models.UserIdentity) bool {
+			return i.UserID == 4 && i.Provider == "telegram" && i.Subject == "100500"
+		})).Return(nil).Once()
+		repo.On("GetUserByID", uint(4)).Return(anna, nil).Once()
+
+		u, err := svc.CompleteLink(ctx, "tok", 100500)
+		require.NoError(t, err)
+		assert.Equal(t, "anna", u.Username)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Used Token", func(t *testing.T) {
+		db, rmock := redismock.NewClientMock()
+		svc := NewTelegramLinkService(new(MockTelegramRepo), db, "salon_bot")
+		rmock.ExpectGetDel("tg_link:tok").RedisNil()
+
+		_, err := svc.CompleteLink(ctx, "tok", 100500)
+		assert.ErrorIs(t, err, ErrInvalidLinkToken)
+		_, err = svc.CompleteLink(ctx, "", 100500)
+		assert.ErrorIs(t, err, ErrInvalidLinkToken)
+	})
+
+	t.Run("Chat Of Another User", func(t *testing.T) {
+		db, rmock := redismock.NewClientMock()
+		repo := new(MockTelegramRepo)
+		svc := NewTelegramLinkService(repo, db, "salon_bot")
+		other := &models.User{}
+		other.ID = 9
+
+		rmock.ExpectGetDel("tg_link:tok").SetVal("4")
+		repo.On("GetUserByIdentity", "telegram", "100500").Return(other, nil).Once()
+
+		_, err := svc.CompleteLink(ctx, "tok", 100500)
+		assert.ErrorIs(t, err, ErrIdentityTaken)
+		repo.AssertNotCalled(t, "CreateIdentity", mock.Anything)
+	})
+}
+
+func TestTelegramChatID(t *testing.T) {
+	repo := new(MockTelegramRepo)
+	svc := NewTelegramLinkService(repo, nil, "salon_bot")
+	repo.On("GetIdentityByUser", uint(4), "telegram").Return(&models.UserIdentity{Subject: "100500"}, nil)
+	repo.On("GetIdentityByUser", uint(5), "telegram").Return(nil, gorm.ErrRecordNotFound)
+
+	assert.Equal(t, "100500", svc.TelegramChatID(4))
+	assert.Equal(t, "", svc.TelegramChatID(5))
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"beauty-salon/internal/auth"
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/repository"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidWebhook   = errors.New("invalid webhook subscription")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+)
+
+const (
+	maxWebhookAttempts = 8
+	webhookLease       = 2 * time.Minute
+	webhookBaseBackoff = 30 * time.Second
+)
+
+// Заголовки исходящих вебхуков.
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// SignWebhook считает подпись "sha256=<hex>" от "<timestamp>.<body>".
+// Получатель проверяет её и отбрасывает запросы со старым timestamp.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type Webhooks interface {
+	CreateWebhook(rawURL string, eventTypes []string, createdBy uint) (secret string, w *models.WebhookSubscription, err error)
+	GetWebhooks() ([]models.WebhookSubscription, error)
+	DeleteWebhook(id string) error
+	GetDeliveries(subscriptionID string) ([]models.WebhookDelivery, error)
+	Redeliver(deliveryID string) (*models.WebhookDelivery, error)
+}
+
+type WebhookService struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookService(repo repository.WebhookRepository, client *http.Client) *WebhookService {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookService{repo: repo, client: client, now: time.Now}
+}
+
+func (s *WebhookService) CreateWebhook(rawURL string, eventTypes []string, createdBy uint) (string, *models.WebhookSubscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", nil, fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	types, err := normalizeList(eventTypes, append([]string{"*"}, events.Types...))
+	if err != nil || types == "" {
+		return "", nil, fmt.Errorf("%w: unknown or empty event types", ErrInvalidWebhook)
+	}
+	secret := "whsec_" + auth.RandomToken(24)
+	w := &models.WebhookSubscription{URL: rawURL, Secret: secret, EventTypes: types, Active: true, CreatedBy: createdBy}
+	if err := s.repo.CreateWebhook(w); err != nil {
+		return "", nil, err
+	}
+	return secret, w, nil
+}
+
+func (s *WebhookService) GetWebhooks() ([]models.WebhookSubscription, error) {
+	return s.repo.GetAllWebhooks()
+}
+func (s *WebhookService) DeleteWebhook(id string) error { return s.repo.DeleteWebhook(id) }
+func (s *WebhookService) GetDeliveries(subscriptionID string) ([]models.WebhookDelivery, error) {
+	return s.repo.GetDeliveries(subscriptionID)
+}
+
+// Redeliver ставит доставку в очередь заново, в том числе уже доставленную или проваленную.
+func (s *WebhookService) Redeliver(deliveryID string) (*models.WebhookDelivery, error) {
+	d, err := s.repo.GetDeliveryByID(deliveryID)
+	if err != nil {
+		return nil, ErrDeliveryNotFound
+	}
+	d.Status, d.Attempts, d.NextAttemptAt, d.LastError = "pending", 0, s.now(), ""
+	if err := s.repo.SaveDelivery(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *WebhookService) Name() string { return "webhooks" }
+
+// Publish реализует events.Sink: событие раскладывается по подходящим
+// подпискам в виде доставок, а отправляет их уже ProcessNext.
+func (s *WebhookService) Publish(_ context.Context, e events.Event) error {
+	hooks, err := s.repo.GetActiveWebhooks()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	for _, w := range hooks {
+		if !w.Accepts(e.Type) {
+			continue
+		}
+		d := &models.WebhookDelivery{
+			SubscriptionID: w.ID, EventID: e.ID, EventType: e.Type, Body: string(body),
+			Status: "pending", NextAttemptAt: s.now(),
+		}
+		if err := s.repo.CreateDelivery(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run отправляет доставки, пока не отменён ctx.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for s.ProcessNext(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNext выполняет одну доставку. Возвращает false, если очередь пуста.
+func (s *WebhookService) ProcessNext(ctx context.Context) bool {
+	d, err := s.repo.ClaimDueDelivery(s.now(), webhookLease)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("webhooks: claim failed: %v", err)
+		}
+		return false
+	}
+
+	d.Attempts++
+	w, err := s.repo.GetWebhookByID(d.SubscriptionID)
+	if err != nil {
+		// Подписку удалили — доставлять некуда.
+		d.Status, d.LastError = "failed", "subscription not found"
+	} else if code, err := s.deliver(ctx, w, d); err != nil {
+		d.ResponseCode, d.LastError = code, err.Error()
+		if d.Attempts >= maxWebhookAttempts {
+			d.Status = "failed"
+		} else {
+			// 30 с, 1 мин, 2 мин, ... около часа перед последней попыткой.
+			d.NextAttemptAt = s.now().Add(webhookBaseBackoff << (d.Attempts - 1))
+		}
+	} else {
+		deliveredAt := s.now()
+		d.Status, d.ResponseCode, d.LastError, d.DeliveredAt = "delivered", code, "", &deliveredAt
+	}
+	if err := s.repo.SaveDelivery(d); err != nil {
+		log.Printf("webhook delivery %d: save failed: %v", d.ID, err)
+	}
+	return true
+}
+
+func (s *WebhookService) deliver(ctx context.Context, w *models.WebhookSubscription, d *models.WebhookDelivery) (int, error) {
+	body := []byte(d.Body)
+	ts := s.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, d.EventID)
+	req.Header.Set(WebhookEventHeader, d.EventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(w.Secret, ts, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"beauty-salon/internal/events"
+	"beauty-salon/internal/models"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepo) CreateWebhook(w *models.WebhookSubscription) error {
+	return m.Called(w).Error(0)
+}
+func (m *MockWebhookRepo) GetAllWebhooks() ([]models.WebhookSubscription, error) {
+	args := m.Called()
+	return args.Get(0).([]models.WebhookSubscription), args.Error(1)
+}
+func (m *MockWebhookRepo) GetActiveWebhooks() ([]models.WebhookSubscription, error) {
+	args := m.Called()
+	return args.Get(0).([]models.WebhookSubscription), args.Error(1)
+}
+func (m *MockWebhookRepo) GetWebhookByID(id uint) (*models.WebhookSubscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+func (m *MockWebhookRepo) DeleteWebhook(id string) error { return m.Called(id).Error(0) }
+func (m *MockWebhookRepo) CreateDelivery(d *models.WebhookDelivery) error {
+	return m.Called(d).Error(0)
+}
+func (m *MockWebhookRepo) GetDeliveries(subscriptionID string) ([]models.WebhookDelivery, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+func (m *MockWebhookRepo) GetDeliveryByID(id string) (*models.WebhookDelivery, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+func (m *MockWebhookRepo) ClaimDueDelivery(now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	args := m.Called(now, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+func (m *MockWebhookRepo) SaveDelivery(d *models.WebhookDelivery) error { return m.Called(d).Error(0) }
+
+// receiver — тестовый получатель вебхуков, проверяющий подпись.
+type receiver struct {
+	mu     sync.Mutex
+	secret string
+	status int
+	got    []http.Header
+	bodies []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	ts, _ := strconv.ParseInt(req.Header.Get(WebhookTimestampHeader), 10, 64)
+	if req.Header.Get(WebhookSignatureHeader) != SignWebhook(r.secret, ts, body) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	r.mu.Lock()
+	r.got = append(r.got, req.Header.Clone())
+	r.bodies = append(r.bodies, string(body))
+	status := r.status
+	r.mu.Unlock()
+	w.WriteHeader(status)
+}
+
+func TestCreateWebhook(t *testing.T) {
+	repo := new(MockWebhookRepo)
+	svc := NewWebhookService(repo, nil)
+
+	repo.On("CreateWebhook", mock.MatchedBy(func(w *models.WebhookSubscription) bool {
+		return w.EventTypes == "user.registered,booking.completed" && strings.HasPrefix(w.Secret, "whsec_")
+	})).Return(nil).Once()
+	secret, w, err := svc.CreateWebhook("https://crm.example.com/hooks", []string{"user.registered", "booking.completed"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, w.Secret, secret)
+
+	_, _, err = svc.CreateWebhook("ftp://crm.example.com", []string{"*"}, 1)
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+	_, _, err = svc.CreateWebhook("https://crm.example.com", []string{"booking.exploded"}, 1)
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+	_, _, err = svc.CreateWebhook("https://crm.example.com", nil, 1)
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+}
+
+func TestWebhookPublishFansOut(t *testing.T) {
+	repo := new(MockWebhookRepo)
+	svc := NewWebhookService(repo, nil)
+
+	crm := models.WebhookSubscription{EventTypes: "user.registered"}
+	crm.ID = 1
+	all := models.WebhookSubscription{EventTypes: "*"}
+	all.ID = 2
+	repo.On("GetActiveWebhooks").Return([]models.WebhookSubscription{crm, all}, nil)
+
+	var created []uint
+	repo.On("CreateDelivery", mock.Anything).Run(func(args mock.Arguments) {
+		d := args.Get(0).(*models.WebhookDelivery)
+		assert.Equal(t, "ev-1", d.EventID)
+		created = append(created, d.SubscriptionID)
+	}).Return(nil)
+
+	err := svc.Publish(context.Background(), events.Event{ID: "ev-1", Type: events.BookingCompleted, Payload: json.RawMessage(`{}`)})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{2}, created)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	rcv := &receiver{secret: "whsec_test", status: http.StatusOK}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	sub := &models.WebhookSubscription{URL: srv.URL, Secret: "whsec_test"}
+	setup := func() (*WebhookService, *MockWebhookRepo) {
+		repo := new(MockWebhookRepo)
+		svc := NewWebhookService(repo, srv.Client())
+		svc.now = func() time.Time { return now }
+		repo.On("GetWebhookByID", uint(1)).Return(sub, nil)
+		return svc, repo
+	}
+
+	t.Run("Delivered With Signature", func(t *testing.T) {
+		svc, repo := setup()
+		d := &models.WebhookDelivery{SubscriptionID: 1, EventID: "ev-1", EventType: "user.registered", Body: `{"id":"ev-1"}`}
+		repo.On("ClaimDueDelivery", now, webhookLease).Return(d, nil).Once()
+		repo.On("SaveDelivery", d).Return(nil).Once()
+
+		assert.True(t, svc.ProcessNext(context.Background()))
+		assert.Equal(t, "delivered", d.Status)
+		assert.Equal(t, http.StatusOK, d.ResponseCode)
+
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+		require.Len(t, rcv.got, 1)
+		assert.Equal(t, "ev-1", rcv.got[0].Get(WebhookIDHeader))
+		assert.Equal(t, "user.registered", rcv.got[0].Get(WebhookEventHeader))
+		assert.Equal(t, strconv.FormatInt(now.Unix(), 10), rcv.got[0].Get(WebhookTimestampHeader))
+		assert.Equal(t, `{"id":"ev-1"}`, rcv.bodies[0])
+	})
+
+	t.Run("Server Error Backs Off", func(t *testing.T) {
+		rcv.mu.Lock()
+		rcv.status = http.StatusServiceUnavailable
+		rcv.mu.Unlock()
+		defer func() { rcv.status = http.StatusOK }()
+
+		svc, repo := setup()
+		d := &models.WebhookDelivery{SubscriptionID: 1, EventID: "ev-2", Body: `{}`, Attempts: 2}
+		repo.On("ClaimDueDelivery", now, webhookLease).Return(d, nil).Once()
+		repo.On("SaveDelivery", d).Return(nil).Once()
+
+		assert.True(t, svc.ProcessNext(context.Background()))
+		assert.Equal(t, "", d.Status)
+		assert.Equal(t, http.StatusServiceUnavailable, d.ResponseCode)
+		assert.Equal(t, now.Add(2*time.Minute), d.NextAttemptAt)
+	})
+
+	t.Run("Wrong Secret Eventually Fails", func(t *testing.T) {
+		svc, repo := setup()
+		repo.ExpectedCalls = nil
+		repo.On("GetWebhookByID", uint(1)).Return(&models.WebhookSubscription{URL: srv.URL, Secret: "other"}, nil)
+		d := &models.WebhookDelivery{SubscriptionID: 1, EventID: "ev-3", Body: `{}`, Attempts: maxWebhookAttempts - 1}
+		repo.On("ClaimDueDelivery", now, webhookLease).Return(d, nil).Once()
+		repo.On("SaveDelivery", d).Return(nil).Once()
+
+		assert.True(t, svc.ProcessNext(context.Background()))
+		assert.Equal(t, "failed", d.Status)
+		assert.Equal(t, http.StatusUnauthorized, d.ResponseCode)
+		assert.Contains(t, d.LastError, "bad signature")
+	})
+
+	t.Run("Empty Queue", func(t *testing.T) {
+		svc, repo := setup()
+		repo.On("ClaimDueDelivery", now, webhookLease).Return(nil, gorm.ErrRecordNotFound).Once()
+		assert.False(t, svc.ProcessNext(context.Background()))
+	})
+}
+
+func TestRedeliver(t *testing.T) {
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := new(MockWebhookRepo)
+	svc := NewWebhookService(repo, nil)
+	svc.now = func() time.Time { return now }
+
+	d := &models.WebhookDelivery{Status: "failed", Attempts: 8, LastError: "timeout"}
+	repo.On("GetDeliveryByID", "4").Return(d, nil).Once()
+	repo.On("SaveDelivery", d).Return(nil).Once()
+	res, err := svc.Redeliver("4")
+	require.NoError(t, err)
+	assert.Equal(t, "pending", res.Status)
+	assert.Equal(t, 0, res.Attempts)
+	assert.Equal(t, now, res.NextAttemptAt)
+
+	repo.On("GetDeliveryByID", "5").Return(nil, gorm.ErrRecordNotFound).Once()
+	_, err = svc.Redeliver("5")
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+}
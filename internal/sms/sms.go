@@ -0,0 +1,58 @@
+// Package sms — отправка SMS. Реальный шлюз подключается реализацией Sender.
+package sms
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+type Sender interface {
+	Send(ctx context.Context, phone, text string) error
+}
+
+// LogSender пишет в лог факт отправки вместо самой отправки — для разработки.
+// Текст не логируется: в нём бывают коды входа, а лог читают не только владельцы номеров.
+type LogSender struct{}
+
+func (LogSender) Send(_ context.Context, phone, text string) error {
+	log.Printf("[sms] to=%s len=%d", phone, len([]rune(text)))
+	return nil
+}
+
+type Message struct {
+	Phone string
+	Text  string
+}
+
+// FakeSender запоминает отправленные сообщения — для тестов.
+type FakeSender struct {
+	mu   sync.Mutex
+	sent []Message
+	Err  error
+}
+
+func (f *FakeSender) Send(_ context.Context, phone, text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.sent = append(f.sent, Message{Phone: phone, Text: text})
+	return nil
+}
+
+func (f *FakeSender) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.sent...)
+}
+
+func (f *FakeSender) Last() (Message, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.sent) == 0 {
+		return Message{}, false
+	}
+	return f.sent[len(f.sent)-1], true
+}
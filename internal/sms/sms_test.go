@@ -0,0 +1,40 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeSender(t *testing.T) {
+	f := &FakeSender{}
+	_, ok := f.Last()
+	assert.False(t, ok)
+
+	assert.NoError(t, f.Send(context.Background(), "+77010000000", "hi"))
+	assert.NoError(t, f.Send(context.Background(), "+77010000001", "bye"))
+
+	last, ok := f.Last()
+	assert.True(t, ok)
+	assert.Equal(t, Message{Phone: "+77010000001", Text: "bye"}, last)
+	assert.Len(t, f.Sent(), 2)
+
+	f.Err = errors.New("gateway down")
+	assert.Error(t, f.Send(context.Background(), "+77010000000", "x"))
+	assert.Len(t, f.Sent(), 2)
+}
+
+func TestLogSender(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	assert.NoError(t, LogSender{}.Send(context.Background(), "+77010000000", "Код для входа: 123456"))
+	assert.Contains(t, buf.String(), "+77010000000")
+	assert.NotContains(t, buf.String(), "123456")
+}
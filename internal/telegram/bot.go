@@ -0,0 +1,305 @@
+package telegram
+
+import (
+	"beauty-salon/internal/i18n"
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	pollTimeout  = 25 * time.Second
+	bookingDays  = 7 // на сколько дней вперёд предлагать запись
+	maxSlotRows  = 8 // не больше 24 кнопок со временем
+	slotsPerRow  = 3
+	dayLayout    = "2006-01-02"
+	buttonLayout = "02.01"
+)
+
+// Salon — часть service.Service, которой пользуется бот. Вся логика записи
+// (свободные слоты, проверки, уведомления) остаётся в SalonService.
+type Salon interface {
+	GetServices() ([]models.Service, error)
+	GetAvailableSlots(serviceID, staffID uint, day string) ([]service.Slot, error)
+	BookSlot(userID, serviceID, staffID uint, date string) (*models.Booking, error)
+	GetUserUpcomingBookings(userID uint) ([]models.Booking, error)
+	CancelUserBooking(userID uint, id string) error
+}
+
+// Payments выставляет счёт на предоплату записи — это service.PaymentService.
+type Payments interface {
+	CreatePayment(ctx context.Context, userID uint, bookingID, idempotencyKey string) (*models.Payment, error)
+}
+
+type Links interface {
+	CompleteLink(ctx context.Context, token string, telegramUserID int64) (*models.User, error)
+	UserByTelegramID(telegramUserID int64) (*models.User, error)
+}
+
+type Bot struct {
+	api      *Client
+	salon    Salon
+	links    Links
+	payments Payments
+	now      func() time.Time
+}
+
+func NewBot(api *Client, salon Salon, links Links) *Bot {
+	return &Bot{api: api, salon: salon, links: links, now: time.Now}
+}
+
+// SetPayments включает ссылку на оплату для записей с предоплатой.
+func (b *Bot) SetPayments(p Payments) { b.payments = p }
+
+// Run получает обновления long polling'ом, пока не отменён ctx.
+func (b *Bot) Run(ctx context.Context) {
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := b.api.GetUpdates(ctx, offset, pollTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("telegram: %v", err)
+				time.Sleep(5 * time.Second)
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			b.HandleUpdate(ctx, u)
+		}
+	}
+}
+
+// HandleUpdate обрабатывает команду или нажатие кнопки.
+func (b *Bot) HandleUpdate(ctx context.Context, u Update) {
+	var err error
+	switch {
+	case u.Message != nil && u.Message.From != nil:
+		err = b.handleMessage(ctx, u.Message)
+	case u.CallbackQuery != nil && u.CallbackQuery.Message != nil:
+		err = b.handleCallback(ctx, u.CallbackQuery)
+	}
+	if err != nil {
+		log.Printf("telegram: update %d: %v", u.UpdateID, err)
+	}
+}
+
+func (b *Bot) handleMessage(ctx context.Context, m *Message) error {
+	command, arg, _ := strings.Cut(strings.TrimSpace(m.Text), " ")
+	// В группах команды приходят как /book@salon_bot.
+	command, _, _ = strings.Cut(command, "@")
+
+	if command == "/start" && arg != "" {
+		user, err := b.links.CompleteLink(ctx, arg, m.From.ID)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidLinkToken) || errors.Is(err, service.ErrIdentityTaken) {
+				return b.reply(ctx, m.Chat.ID, "", err.Error(), nil)
+			}
+			return errors.Join(err, b.reply(ctx, m.Chat.ID, "", "Something went wrong, try again later", nil))
+		}
+		return b.reply(ctx, m.Chat.ID, user.Locale, "Account linked. Use /book to book a visit and /bookings to see your bookings.", nil)
+	}
+
+	user, err := b.links.UserByTelegramID(m.From.ID)
+	if err != nil {
+		return b.reply(ctx, m.Chat.ID, "", "To use the bot, link your account: open the Telegram link in your salon profile.", nil)
+	}
+	switch command {
+	case "/book":
+		return b.sendServices(ctx, m.Chat.ID, user)
+	case "/bookings":
+		return b.sendBookings(ctx, m.Chat.ID, user)
+	default:
+		return b.reply(ctx, m.Chat.ID, user.Locale, "Commands: /book — book a visit, /bookings — my bookings", nil)
+	}
+}
+
+func (b *Bot) handleCallback(ctx context.Context, q *CallbackQuery) error {
+	chatID := q.Message.Chat.ID
+	// Telegram ждёт ответа на каждое нажатие, иначе кнопка «висит».
+	ack := b.api.AnswerCallbackQuery(ctx, q.ID, "")
+
+	user, err := b.links.UserByTelegramID(q.From.ID)
+	if err != nil {
+		return errors.Join(ack, b.reply(ctx, chatID, "", "To use the bot, link your account: open the Telegram link in your salon profile.", nil))
+	}
+	parts := strings.SplitN(q.Data, ":", 4)
+	switch {
+	case parts[0] == "svc" && len(parts) == 2:
+		err = b.sendDays(ctx, chatID, user, parts[1])
+	case parts[0] == "day" && len(parts) == 3:
+		err = b.sendSlots(ctx, chatID, user, parts[1], parts[2])
+	case parts[0] == "slot" && len(parts) == 4:
+		err = b.book(ctx, chatID, user, parts[1], parts[2], parts[3])
+	case parts[0] == "cancel" && len(parts) == 2:
+		err = b.cancel(ctx, chatID, user, parts[1])
+	}
+	return errors.Join(ack, err)
+}
+
+func (b *Bot) sendServices(ctx context.Context, chatID int64, user *models.User) error {
+	services, err := b.salon.GetServices()
+	if err != nil {
+		return err
+	}
+	var rows [][]InlineKeyboardButton
+	for _, s := range services {
+		rows = append(rows, []InlineKeyboardButton{{
+			Text:         s.Localized(user.Locale).Title,
+			CallbackData: fmt.Sprintf("svc:%d", s.ID),
+		}})
+	}
+	return b.reply(ctx, chatID, user.Locale, "Choose a service:", rows)
+}
+
+func (b *Bot) sendDays(ctx context.Context, chatID int64, user *models.User, serviceID string) error {
+	var rows [][]InlineKeyboardButton
+	var row []InlineKeyboardButton
+	today := b.now()
+	for i := 0; i < bookingDays; i++ {
+		day := today.AddDate(0, 0, i)
+		row = append(row, InlineKeyboardButton{
+			Text:         day.Format(buttonLayout),
+			CallbackData: "day:" + serviceID + ":" + day.Format(dayLayout),
+		})
+		if len(row) == 4 {
+			rows, row = append(rows, row), nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return b.reply(ctx, chatID, user.Locale, "Choose a day:", rows)
+}
+
+func (b *Bot) sendSlots(ctx context.Context, chatID int64, user *models.User, serviceID, day string) error {
+	id, err := strconv.ParseUint(serviceID, 10, 64)
+	if err != nil {
+		return err
+	}
+	slots, err := b.salon.GetAvailableSlots(uint(id), 0, day)
+	if err != nil {
+		return b.replyErr(ctx, chatID, user, err)
+	}
+	if len(slots) == 0 {
+		return b.reply(ctx, chatID, user.Locale, "No free time on this day. Choose another day: /book", nil)
+	}
+	var rows [][]InlineKeyboardButton
+	for i := 0; i < len(slots) && len(rows) < maxSlotRows; i += slotsPerRow {
+		var row []InlineKeyboardButton
+		for _, s := range slots[i:min(i+slotsPerRow, len(slots))] {
+			row = append(row, InlineKeyboardButton{
+				Text:         s.Date[11:] + " " + s.StaffName,
+				CallbackData: fmt.Sprintf("slot:%s:%d:%s", serviceID, s.StaffID, s.Date),
+			})
+		}
+		rows = append(rows, row)
+	}
+	return b.reply(ctx, chatID, user.Locale, "Choose a time:", rows)
+}
+
+func (b *Bot) book(ctx context.Context, chatID int64, user *models.User, serviceID, staffID, date string) error {
+	srv, err := strconv.ParseUint(serviceID, 10, 64)
+	if err != nil {
+		return err
+	}
+	staff, err := strconv.ParseUint(staffID, 10, 64)
+	if err != nil {
+		return err
+	}
+	bk, err := b.salon.BookSlot(user.ID, uint(srv), uint(staff), date)
+	if err != nil {
+		return b.replyErr(ctx, chatID, user, err)
+	}
+	if bk.Status == "awaiting_payment" {
+		return b.askPrepayment(ctx, chatID, user, bk)
+	}
+	// Подробности придут отдельным уведомлением о создании записи.
+	return b.reply(ctx, chatID, user.Locale, "Done! See your bookings: /bookings", nil)
+}
+
+// askPrepayment сообщает, что запись подтвердится только после предоплаты,
+// и по возможности сразу даёт ссылку на оплату.
+func (b *Bot) askPrepayment(ctx context.Context, chatID int64, user *models.User, bk *models.Booking) error {
+	locale := user.Locale
+	if locale == "" {
+		locale = i18n.DefaultLocale
+	}
+	text := i18n.T(locale, "Prepayment is required to confirm the booking") + ": " + bk.DepositAmount.String()
+	if bk.PaymentDueAt != nil {
+		text += "\n" + i18n.T(locale, "Pay before") + " " + bk.PaymentDueAt.In(b.now().Location()).Format("02.01 15:04")
+	}
+	var rows [][]InlineKeyboardButton
+	if b.payments != nil {
+		// Ключ привязан к записи: повторное нажатие не выставит второй счёт.
+		p, err := b.payments.CreatePayment(ctx, user.ID, strconv.FormatUint(uint64(bk.ID), 10), fmt.Sprintf("telegram:booking:%d", bk.ID))
+		if err != nil {
+			log.Printf("telegram: payment for booking %d: %v", bk.ID, err)
+		} else if p.ConfirmationURL != "" {
+			rows = [][]InlineKeyboardButton{{{Text: i18n.T(locale, "Pay"), URL: p.ConfirmationURL}}}
+		}
+	}
+	if rows == nil {
+		text += "\n" + i18n.T(locale, "Pay in the app, otherwise the booking will be cancelled")
+	}
+	return b.send(ctx, chatID, text, rows)
+}
+
+func (b *Bot) sendBookings(ctx context.Context, chatID int64, user *models.User) error {
+	bookings, err := b.salon.GetUserUpcomingBookings(user.ID)
+	if err != nil {
+		return err
+	}
+	if len(bookings) == 0 {
+		return b.reply(ctx, chatID, user.Locale, "You have no upcoming bookings. Book a visit: /book", nil)
+	}
+	lines := []string{i18n.T(user.Locale, "Your upcoming bookings:")}
+	var rows [][]InlineKeyboardButton
+	for _, bk := range bookings {
+		lines = append(lines, fmt.Sprintf("• %s — %s, %s", bk.Date, bk.Service.Localized(user.Locale).Title, bk.Staff.FullName))
+		rows = append(rows, []InlineKeyboardButton{{
+			Text:         i18n.T(user.Locale, "Cancel") + " " + bk.Date,
+			CallbackData: fmt.Sprintf("cancel:%d", bk.ID),
+		}})
+	}
+	return b.send(ctx, chatID, strings.Join(lines, "\n"), rows)
+}
+
+func (b *Bot) cancel(ctx context.Context, chatID int64, user *models.User, bookingID string) error {
+	if err := b.salon.CancelUserBooking(user.ID, bookingID); err != nil {
+		return b.replyErr(ctx, chatID, user, err)
+	}
+	return b.reply(ctx, chatID, user.Locale, "Booking cancelled", nil)
+}
+
+// replyErr показывает клиенту понятные ошибки сервиса, остальные только логируются.
+func (b *Bot) replyErr(ctx context.Context, chatID int64, user *models.User, err error) error {
+	switch {
+	case errors.Is(err, service.ErrSlotUnavailable), errors.Is(err, service.ErrBookingNotFound), errors.Is(err, service.ErrInvalidDate):
+		return b.reply(ctx, chatID, user.Locale, err.Error(), nil)
+	default:
+		return errors.Join(err, b.reply(ctx, chatID, user.Locale, "Something went wrong, try again later", nil))
+	}
+}
+
+// reply переводит сообщение на язык клиента; без привязки — язык по умолчанию.
+func (b *Bot) reply(ctx context.Context, chatID int64, locale, msg string, rows [][]InlineKeyboardButton) error {
+	if locale == "" {
+		locale = i18n.DefaultLocale
+	}
+	return b.send(ctx, chatID, i18n.T(locale, msg), rows)
+}
+
+func (b *Bot) send(ctx context.Context, chatID int64, text string, rows [][]InlineKeyboardButton) error {
+	var markup *InlineKeyboardMarkup
+	if len(rows) > 0 {
+		markup = &InlineKeyboardMarkup{InlineKeyboard: rows}
+	}
+	return b.api.SendMessage(ctx, chatID, text, markup)
+}
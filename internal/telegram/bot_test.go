@@ -0,0 +1,239 @@
+package telegram
+
+import (
+	"beauty-salon/internal/models"
+	"beauty-salon/internal/money"
+	"beauty-salon/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockSalon struct {
+	mock.Mock
+}
+
+func (m *MockSalon) GetServices() ([]models.Service, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Service), args.Error(1)
+}
+func (m *MockSalon) GetAvailableSlots(serviceID, staffID uint, day string) ([]service.Slot, error) {
+	args := m.Called(serviceID, staffID, day)
+	return args.Get(0).([]service.Slot), args.Error(1)
+}
+func (m *MockSalon) BookSlot(userID, serviceID, staffID uint, date string) (*models.Booking, error) {
+	args := m.Called(userID, serviceID, staffID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+func (m *MockSalon) GetUserUpcomingBookings(userID uint) ([]models.Booking, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Booking), args.Error(1)
+}
+func (m *MockSalon) CancelUserBooking(userID uint, id string) error {
+	return m.Called(userID, id).Error(0)
+}
+
+type MockLinks struct {
+	mock.Mock
+}
+
+func (m *MockLinks) CompleteLink(ctx context.Context, token string, telegramUserID int64) (*models.User, error) {
+	args := m.Called(token, telegramUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockLinks) UserByTelegramID(telegramUserID int64) (*models.User, error) {
+	args := m.Called(telegramUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+const chatID = 100500
+
+func newTestBot(t *testing.T) (*Bot, *stubAPI, *MockSalon, *MockLinks) {
+	api := newStubAPI(t, "tok")
+	salon, links := new(MockSalon), new(MockLinks)
+	bot := NewBot(NewClient(api.URL, "tok", nil), salon, links)
+	bot.now = func() time.Time { return time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC) }
+	return bot, api, salon, links
+}
+
+func message(text string) Update {
+	return Update{Message: &Message{From: &User{ID: chatID}, Chat: Chat{ID: chatID}, Text: text}}
+}
+
+func callback(data string) Update {
+	return Update{CallbackQuery: &CallbackQuery{ID: "cb", From: User{ID: chatID}, Message: &Message{Chat: Chat{ID: chatID}}, Data: data}}
+}
+
+func buttons(c call) [][]interface{} {
+	markup, _ := c.Params["reply_markup"].(map[string]interface{})
+	var rows [][]interface{}
+	for _, r := range markup["inline_keyboard"].([]interface{}) {
+		rows = append(rows, r.([]interface{}))
+	}
+	return rows
+}
+
+func TestBotLinking(t *testing.T) {
+	bot, api, _, links := newTestBot(t)
+	ctx := context.Background()
+	anna := &models.User{Username: "anna", Locale: "en"}
+
+	links.On("CompleteLink", "good", int64(chatID)).Return(anna, nil).Once()
+	bot.HandleUpdate(ctx, message("/start good"))
+	sent := api.sent()
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Params["text"], "Account linked")
+
+	links.On("CompleteLink", "old", int64(chatID)).Return(nil, service.ErrInvalidLinkToken).Once()
+	bot.HandleUpdate(ctx, message("/start old"))
+	assert.Contains(t, api.sent()[0].Params["text"], "ссылка недействительна")
+
+	// Без привязки бот подсказывает, как её сделать.
+	links.On("UserByTelegramID", int64(chatID)).Return(nil, gorm.ErrRecordNotFound).Once()
+	bot.HandleUpdate(ctx, message("/book"))
+	assert.Contains(t, api.sent()[0].Params["text"], "привяжите аккаунт")
+}
+
+func TestBotBookingFlow(t *testing.T) {
+	bot, api, salon, links := newTestBot(t)
+	ctx := context.Background()
+	anna := &models.User{Username: "anna"}
+	anna.ID = 4
+	links.On("UserByTelegramID", int64(chatID)).Return(anna, nil)
+
+	haircut := models.Service{Title: "Стрижка"}
+	haircut.ID = 1
+	salon.On("GetServices").Return([]models.Service{haircut}, nil).Once()
+	bot.HandleUpdate(ctx, message("/book"))
+	sent := api.sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "Выберите услугу:", sent[0].Params["text"])
+	assert.Equal(t, "svc:1", buttons(sent[0])[0][0].(map[string]interface{})["callback_data"])
+
+	bot.HandleUpdate(ctx, callback("svc:1"))
+	sent = api.sent()
+	require.Len(t, sent, 1)
+	days := buttons(sent[0])
+	assert.Equal(t, "day:1:2025-05-02", days[0][0].(map[string]interface{})["callback_data"])
+	assert.Len(t, append(days[0], days[1]...), bookingDays)
+
+	salon.On("GetAvailableSlots", uint(1), uint(0), "2025-05-03").Return([]service.Slot{
+		{StaffID: 2, StaffName: "Ольга", Date: "2025-05-03 10:00"},
+		{StaffID: 2, StaffName: "Ольга", Date: "2025-05-03 10:30"},
+	}, nil).Once()
+	bot.HandleUpdate(ctx, callback("day:1:2025-05-03"))
+	sent = api.sent()
+	require.Len(t, sent, 1)
+	slot := buttons(sent[0])[0][1].(map[string]interface{})
+	assert.Equal(t, "10:30 Ольга", slot["text"])
+	assert.Equal(t, "slot:1:2:2025-05-03 10:30", slot["callback_data"])
+
+	salon.On("BookSlot", uint(4), uint(1), uint(2), "2025-05-03 10:30").Return(&models.Booking{}, nil).Once()
+	bot.HandleUpdate(ctx, callback("slot:1:2:2025-05-03 10:30"))
+	assert.Contains(t, api.sent()[0].Params["text"], "Готово")
+
+	salon.On("BookSlot", uint(4), uint(1), uint(2), "2025-05-03 10:00").Return(nil, service.ErrSlotUnavailable).Once()
+	bot.HandleUpdate(ctx, callback("slot:1:2:2025-05-03 10:00"))
+	assert.Equal(t, "это время уже занято", api.sent()[0].Params["text"])
+	salon.AssertExpectations(t)
+}
+
+type paymentsFunc func(userID uint, bookingID, key string) (*models.Payment, error)
+
+func (f paymentsFunc) CreatePayment(_ context.Context, userID uint, bookingID, key string) (*models.Payment, error) {
+	return f(userID, bookingID, key)
+}
+
+func TestBotBookingWithPrepayment(t *testing.T) {
+	bot, api, salon, links := newTestBot(t)
+	ctx := context.Background()
+	anna := &models.User{Username: "anna"}
+	anna.ID = 4
+	links.On("UserByTelegramID", int64(chatID)).Return(anna, nil)
+
+	due := time.Date(2025, 5, 2, 10, 0, 0, 0, time.UTC)
+	pending := &models.Booking{Status: "awaiting_payment", DepositAmount: money.New(300000, "KZT"), PaymentDueAt: &due}
+	pending.ID = 12
+	salon.On("BookSlot", uint(4), uint(1), uint(2), "2025-05-03 10:30").Return(pending, nil).Twice()
+
+	// Без платежей бот не обещает, что запись готова.
+	bot.HandleUpdate(ctx, callback("slot:1:2:2025-05-03 10:30"))
+	text := api.sent()[0].Params["text"].(string)
+	assert.NotContains(t, text, "Готово")
+	assert.Contains(t, text, "Запись подтвердится после предоплаты: 3000.00 KZT")
+	assert.Contains(t, text, "02.05 10:00")
+
+	bot.SetPayments(paymentsFunc(func(userID uint, bookingID, key string) (*models.Payment, error) {
+		assert.Equal(t, uint(4), userID)
+		assert.Equal(t, "12", bookingID)
+		assert.Equal(t, "telegram:booking:12", key)
+		return &models.Payment{ConfirmationURL: "https://pay.example/12"}, nil
+	}))
+	bot.HandleUpdate(ctx, callback("slot:1:2:2025-05-03 10:30"))
+	pay := buttons(api.sent()[0])[0][0].(map[string]interface{})
+	assert.Equal(t, "Оплатить", pay["text"])
+	assert.Equal(t, "https://pay.example/12", pay["url"])
+	assert.NotContains(t, pay, "callback_data")
+}
+
+func TestBotBookingsAndCancel(t *testing.T) {
+	bot, api, salon, links := newTestBot(t)
+	ctx := context.Background()
+	anna := &models.User{Username: "anna", Locale: "kk"}
+	anna.ID = 4
+	links.On("UserByTelegramID", int64(chatID)).Return(anna, nil)
+
+	b := models.Booking{Date: "2025-05-03 10:00", Service: models.Service{Title: "Стрижка",
+		Translations: map[string]models.ServiceText{"kk": {Title: "Шаш қию"}}}, Staff: models.Staff{FullName: "Ольга"}}
+	b.ID = 12
+	salon.On("GetUserUpcomingBookings", uint(4)).Return([]models.Booking{b}, nil).Once()
+	bot.HandleUpdate(ctx, message("/bookings"))
+	sent := api.sent()
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Params["text"], "2025-05-03 10:00 — Шаш қию, Ольга")
+	assert.Equal(t, "cancel:12", buttons(sent[0])[0][0].(map[string]interface{})["callback_data"])
+
+	salon.On("CancelUserBooking", uint(4), "12").Return(nil).Once()
+	bot.HandleUpdate(ctx, callback("cancel:12"))
+	assert.Equal(t, "Жазба болдырылмады", api.sent()[0].Params["text"])
+
+	salon.On("CancelUserBooking", uint(4), "13").Return(service.ErrBookingNotFound).Once()
+	bot.HandleUpdate(ctx, callback("cancel:13"))
+	assert.Equal(t, "жазба табылмады", api.sent()[0].Params["text"])
+}
+
+func TestBotRun(t *testing.T) {
+	bot, api, _, links := newTestBot(t)
+	links.On("UserByTelegramID", int64(chatID)).Return(&models.User{}, nil)
+	api.updates = []Update{func() Update { u := message("/help"); u.UpdateID = 10; return u }()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	bot.Run(ctx)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	var offsets []float64
+	for _, c := range api.calls {
+		if c.Method == "getUpdates" {
+			offsets = append(offsets, c.Params["offset"].(float64))
+		}
+	}
+	require.GreaterOrEqual(t, len(offsets), 2)
+	assert.Equal(t, float64(0), offsets[0])
+	assert.Equal(t, float64(11), offsets[1])
+}
@@ -0,0 +1,140 @@
+// Package telegram — клиент Bot API и бот для записи в салон.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL — адрес Bot API. В тестах вместо него подставляется локальная заглушка.
+const DefaultBaseURL = "https://api.telegram.org"
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from,omitempty"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message,omitempty"`
+	Data    string   `json:"data"`
+}
+
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+// InlineKeyboardButton — кнопка под сообщением: либо callback, либо ссылка.
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient создаёт клиента Bot API. Пустой baseURL — api.telegram.org.
+// Таймаут HTTP должен быть больше таймаута long polling в GetUpdates.
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Minute}
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), token: token, http: httpClient}
+}
+
+// call вызывает метод Bot API и раскладывает поле result в out.
+func (c *Client) call(ctx context.Context, method string, params, out interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/bot"+c.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var r struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("telegram %s: %s: %w", method, resp.Status, err)
+	}
+	if !r.OK {
+		return fmt.Errorf("telegram %s: %s", method, r.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(r.Result, out)
+	}
+	return nil
+}
+
+// GetUpdates ждёт новые события не дольше timeout (long polling).
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := c.call(ctx, "getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message", "callback_query"},
+	}, &updates)
+	return updates, err
+}
+
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string, markup *InlineKeyboardMarkup) error {
+	params := map[string]interface{}{"chat_id": chatID, "text": text}
+	if markup != nil {
+		params["reply_markup"] = markup
+	}
+	return c.call(ctx, "sendMessage", params, nil)
+}
+
+func (c *Client) AnswerCallbackQuery(ctx context.Context, id, text string) error {
+	return c.call(ctx, "answerCallbackQuery", map[string]interface{}{"callback_query_id": id, "text": text}, nil)
+}
+
+// SendText отправляет уведомление в чат; chatID хранится строкой в привязке учётки.
+func (c *Client) SendText(ctx context.Context, chatID, text string) error {
+	id, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("telegram: invalid chat id %q", chatID)
+	}
+	return c.SendMessage(ctx, id, text, nil)
+}
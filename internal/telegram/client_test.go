@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type call struct {
+	Method string
+	Params map[string]interface{}
+}
+
+// stubAPI — локальная заглушка Bot API: запоминает вызовы и отдаёт заранее заданные обновления.
+type stubAPI struct {
+	*httptest.Server
+	mu      sync.Mutex
+	calls   []call
+	updates []Update
+}
+
+func newStubAPI(t *testing.T, token string) *stubAPI {
+	s := &stubAPI{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, ok := strings.CutPrefix(r.URL.Path, "/bot"+token+"/")
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "description": "Unauthorized"})
+			return
+		}
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+
+		s.mu.Lock()
+		s.calls = append(s.calls, call{Method: method, Params: params})
+		var result interface{} = true
+		if method == "getUpdates" {
+			result, s.updates = s.updates, nil
+		}
+		s.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// sent возвращает тексты отправленных сообщений и сбрасывает журнал.
+func (s *stubAPI) sent() []call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []call
+	for _, c := range s.calls {
+		if c.Method == "sendMessage" {
+			out = append(out, c)
+		}
+	}
+	s.calls = nil
+	return out
+}
+
+func TestClient(t *testing.T) {
+	api := newStubAPI(t, "123:abc")
+	c := NewClient(api.URL+"/", "123:abc", nil)
+	ctx := context.Background()
+
+	api.updates = []Update{{UpdateID: 7, Message: &Message{Chat: Chat{ID: 42}, Text: "/book"}}}
+	updates, err := c.GetUpdates(ctx, 5, time.Second)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Equal(t, "/book", updates[0].Message.Text)
+	assert.Equal(t, float64(5), api.calls[0].Params["offset"])
+
+	markup := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "A", CallbackData: "a"}}}}
+	require.NoError(t, c.SendMessage(ctx, 42, "hi", markup))
+	require.NoError(t, c.SendText(ctx, "42", "reminder"))
+	sent := api.sent()
+	require.Len(t, sent, 2)
+	assert.Equal(t, float64(42), sent[0].Params["chat_id"])
+	assert.NotNil(t, sent[0].Params["reply_markup"])
+	assert.Equal(t, "reminder", sent[1].Params["text"])
+
+	assert.Error(t, c.SendText(ctx, "not-a-chat", "x"))
+
+	bad := NewClient(api.URL, "wrong", nil)
+	err = bad.SendMessage(ctx, 42, "hi", nil)
+	assert.ErrorContains(t, err, "Unauthorized")
+}